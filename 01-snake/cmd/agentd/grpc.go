@@ -0,0 +1,160 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// The gRPC protocol is HTTP/2 with length prefixed messages and the status
+// in the trailers, which net/http handles fine on its own for the single
+// streaming method we serve.
+
+// gRPC status codes
+const (
+	codeOK                = 0
+	codeCanceled          = 1
+	codeInvalidArgument   = 3
+	codeResourceExhausted = 8
+	codeUnimplemented     = 12
+	codeInternal          = 13
+)
+
+const maxMessageSize = 64 << 10
+
+type status struct {
+	code int
+	msg  string
+}
+
+func (s *status) Error() string {
+	return fmt.Sprintf("grpc status %d: %s", s.code, s.msg)
+}
+
+func errorf(code int, format string, args ...any) error {
+	return &status{code, fmt.Sprintf(format, args...)}
+}
+
+// stream is one call of a streaming method.
+type stream struct {
+	r  io.Reader
+	w  http.ResponseWriter
+	rc *http.ResponseController
+
+	hdr [5]byte
+}
+
+// recv reads the next message into m, io.EOF once the client is done.
+func (s *stream) recv(m encoding.BinaryUnmarshaler) error {
+	if _, err := io.ReadFull(s.r, s.hdr[:]); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return errorf(codeInvalidArgument, "truncated message")
+		}
+		return err
+	}
+
+	if s.hdr[0] != 0 {
+		return errorf(codeUnimplemented, "compressed messages are not supported")
+	}
+	n := binary.BigEndian.Uint32(s.hdr[1:])
+	if n > maxMessageSize {
+		return errorf(codeResourceExhausted, "message of %d bytes is too large", n)
+	}
+
+	buf := make([]byte, n)
+	if _, err := io.ReadFull(s.r, buf); err != nil {
+		return errorf(codeInvalidArgument, "truncated message")
+	}
+
+	if err := m.UnmarshalBinary(buf); err != nil {
+		return errorf(codeInvalidArgument, "%v", err)
+	}
+	return nil
+}
+
+// send writes m and flushes it to the client.
+func (s *stream) send(m encoding.BinaryMarshaler) error {
+	data, err := m.MarshalBinary()
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 5, 5+len(data))
+	binary.BigEndian.PutUint32(buf[1:], uint32(len(data)))
+	buf = append(buf, data...)
+
+	if _, err := s.w.Write(buf); err != nil {
+		return err
+	}
+	return s.rc.Flush()
+}
+
+// grpcHandler serves gRPC streaming methods by their full name, like
+// "/snake.agent.v1.Agent/Play".
+type grpcHandler map[string]func(*stream) error
+
+func (h grpcHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.ProtoMajor != 2 || r.Method != http.MethodPost ||
+		!strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "gRPC only", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/grpc")
+
+	method, ok := h[r.URL.Path]
+	if !ok {
+		// trailers only response
+		writeStatus(w.Header(), errorf(codeUnimplemented, "unknown method %s", r.URL.Path))
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+
+	// HTTP/2 lets the client keep sending while we answer
+	rc := http.NewResponseController(w)
+	rc.Flush()
+
+	err := method(&stream{r: r.Body, w: w, rc: rc})
+	if err != nil && r.Context().Err() != nil {
+		err = errorf(codeCanceled, "canceled")
+	}
+	writeStatus(w.Header(), err)
+}
+
+func writeStatus(h http.Header, err error) {
+	var st *status
+	switch {
+	case err == nil:
+		st = &status{code: codeOK}
+	case errors.As(err, &st):
+	default:
+		st = &status{codeInternal, err.Error()}
+	}
+
+	h.Set("Grpc-Status", strconv.Itoa(st.code))
+	if st.msg != "" {
+		// percent encoded as per the spec
+		h.Set("Grpc-Message", url.PathEscape(st.msg))
+	}
+}
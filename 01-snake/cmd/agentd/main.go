@@ -0,0 +1,159 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command agentd serves the headless snake simulation over gRPC, so bots
+// written in any language can play against the real rules. The service is
+// described in pkg/agent/agent.proto.
+//
+// gRPC is served over TLS. Unless given a certificate, agentd creates a
+// self-signed one on the first start; clients have to trust that file:
+//
+//	go run ./cmd/agentd -addr :50051
+//
+//	# Python
+//	creds = grpc.ssl_channel_credentials(open("agentd.crt", "rb").read())
+//	channel = grpc.secure_channel("localhost:50051", creds)
+//
+// With -spectate, the ongoing sessions can be watched read-only in a browser:
+//
+//	go run ./cmd/agentd -spectate :8082
+//	# open http://localhost:8082
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/agent"
+)
+
+var (
+	addr        = flag.String("addr", ":50051", "address to listen on")
+	certPath    = flag.String("cert", "agentd.crt", "TLS certificate, a self-signed one is created if missing")
+	keyPath     = flag.String("key", "agentd.key", "TLS private key")
+	hosts       = flag.String("hosts", "localhost,127.0.0.1,::1", "names and addresses the self-signed certificate is valid for")
+	maxSessions = flag.Int("max-sessions", 64, "maximum number of concurrent sessions")
+	spectate    = flag.String("spectate", "", "address to serve the browser view of the sessions on (plain HTTP), off if empty")
+)
+
+type agentServer struct {
+	sessions atomic.Int64
+	max      int64
+
+	// arena publishes the sessions to spectators, nil if off
+	arena *arena
+}
+
+// play serves /snake.agent.v1.Agent/Play, answering each action with an
+// observation.
+func (s *agentServer) play(st *stream) error {
+	if s.sessions.Add(1) > s.max {
+		s.sessions.Add(-1)
+		return errorf(codeResourceExhausted, "too many sessions")
+	}
+	defer s.sessions.Add(-1)
+
+	var m *match
+	if s.arena != nil {
+		m = s.arena.join()
+		defer s.arena.leave(m)
+	}
+
+	sess := agent.NewSession()
+	for {
+		var a agent.Action
+		if err := st.recv(&a); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+
+		o := sess.Apply(a)
+		if m != nil {
+			s.arena.publish(m, o)
+		}
+		if err := st.send(&o); err != nil {
+			return err
+		}
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	log.SetPrefix("agentd: ")
+
+	cert, err := loadCert(*certPath, *keyPath, *hosts)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s := &agentServer{max: int64(*maxSessions)}
+
+	srv := &http.Server{
+		Addr: *addr,
+		Handler: grpcHandler{
+			"/snake.agent.v1.Agent/Play": s.play,
+		},
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			NextProtos:   []string{"h2"},
+		},
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *spectate != "" {
+		s.arena = newArena()
+		spectators := &http.Server{
+			Addr:              *spectate,
+			Handler:           s.arena.handler(),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+		go func() {
+			log.Printf("spectators on %s", *spectate)
+			if err := spectators.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				log.Fatal(err)
+			}
+		}()
+		go func() {
+			<-ctx.Done()
+			spectators.Close()
+		}()
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("listening on %s", *addr)
+	if err := srv.ListenAndServeTLS("", ""); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
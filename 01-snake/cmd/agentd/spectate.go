@@ -0,0 +1,187 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/agent"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// bots step far faster than anyone can watch, spectators get at most this
+// many frames per second
+const spectateFPS = 30
+
+//go:embed spectate.html
+var spectatePage []byte
+
+// frame is the state of a match sent to spectators.
+type frame struct {
+	Width   int           `json:"width"`
+	Height  int           `json:"height"`
+	Snake   []snake.Point `json:"snake"`
+	Food    snake.Point   `json:"food"`
+	Score   int           `json:"score"`
+	Step    uint64        `json:"step"`
+	Episode uint64        `json:"episode"`
+	Done    bool          `json:"done"`
+}
+
+type match struct {
+	id      int
+	started time.Time
+	frame   frame
+	// version counts the frames, spectators send the ones they haven't seen
+	version uint64
+	ended   bool
+}
+
+// arena keeps the latest frame of each ongoing session for spectators.
+type arena struct {
+	mu      sync.Mutex
+	nextID  int
+	matches map[int]*match
+}
+
+func newArena() *arena {
+	return &arena{matches: make(map[int]*match)}
+}
+
+// join registers a new match.
+func (a *arena) join() *match {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.nextID++
+	m := &match{id: a.nextID, started: time.Now()}
+	a.matches[m.id] = m
+	return m
+}
+
+// publish records the observation as the latest frame of the match.
+func (a *arena) publish(m *match, o agent.Observation) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	m.frame = frame{
+		Width:   o.Width,
+		Height:  o.Height,
+		Snake:   slices.Clone(o.Snake),
+		Food:    o.Food,
+		Score:   o.Score,
+		Step:    o.Step,
+		Episode: o.Episode,
+		Done:    o.Done,
+	}
+	m.version++
+}
+
+func (a *arena) leave(m *match) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	m.ended = true
+	delete(a.matches, m.id)
+}
+
+// handler serves the read-only spectator view:
+//
+//	GET /                     page watching the matches
+//	GET /api/matches          ongoing matches
+//	GET /api/matches/{id}     frames of a match as server-sent events
+func (a *arena) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Write(spectatePage)
+	})
+	mux.HandleFunc("GET /api/matches", a.list)
+	mux.HandleFunc("GET /api/matches/{id}", a.watch)
+	return mux
+}
+
+func (a *arena) list(w http.ResponseWriter, r *http.Request) {
+	type summary struct {
+		ID      int       `json:"id"`
+		Started time.Time `json:"started"`
+		Score   int       `json:"score"`
+		Episode uint64    `json:"episode"`
+	}
+
+	a.mu.Lock()
+	list := make([]summary, 0, len(a.matches))
+	for _, m := range a.matches {
+		list = append(list, summary{m.id, m.started, m.frame.Score, m.frame.Episode})
+	}
+	a.mu.Unlock()
+
+	slices.SortFunc(list, func(a, b summary) int { return a.ID - b.ID })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func (a *arena) watch(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	a.mu.Lock()
+	m := a.matches[id]
+	a.mu.Unlock()
+	if err != nil || m == nil {
+		http.Error(w, "no such match", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	rc := http.NewResponseController(w)
+
+	ticker := time.NewTicker(time.Second / spectateFPS)
+	defer ticker.Stop()
+
+	var seen uint64
+	for {
+		a.mu.Lock()
+		f, version, ended := m.frame, m.version, m.ended
+		a.mu.Unlock()
+
+		if ended {
+			fmt.Fprint(w, "event: end\ndata: {}\n\n")
+			rc.Flush()
+			return
+		}
+		if version != seen {
+			seen = version
+			data, _ := json.Marshal(f)
+			if _, err := fmt.Fprintf(w, "data: %s\n\n", data); err != nil {
+				return
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+
+		select {
+		case <-ticker.C:
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,107 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command arcade is a single binary with all the examples: a menu lists
+// them and runs the one picked in the same window, Esc returns to the menu.
+//
+// Run it from the module root:
+//
+//	go run ./cmd/arcade
+package main
+
+import (
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"jhartman.pl/gamedev/pkg/bot"
+	"jhartman.pl/gamedev/pkg/display"
+	"jhartman.pl/gamedev/pkg/scene"
+	"jhartman.pl/gamedev/pkg/snake"
+	"jhartman.pl/gamedev/pkg/snakegame"
+)
+
+// entry is a game of the arcade.
+type entry struct {
+	name        string
+	description string
+	// preview returns a state of the game to show as the thumbnail
+	preview func() *snake.Game
+	// start returns the game, ready to run
+	start func() ebiten.Game
+}
+
+// botPreview is a game the bot has been playing for a while.
+func botPreview(name string, seed uint64, steps int) func() *snake.Game {
+	return func() *snake.Game {
+		b, err := bot.ByName(name)
+		if err != nil {
+			panic(err)
+		}
+		return bot.Play(b, seed, steps)
+	}
+}
+
+func snakeGame() *snakegame.Game {
+	g := snakegame.NewGame()
+	g.ApplyPreset(snakegame.DetectPreset())
+	return g
+}
+
+// watch is the snake played by one of the bots.
+func watch(name string) func() ebiten.Game {
+	return func() ebiten.Game {
+		b, err := bot.ByName(name)
+		if err != nil {
+			panic(err)
+		}
+		g := snakeGame()
+		g.SetController(bot.Drive(b, g.Core))
+		return g
+	}
+}
+
+var entries = []entry{
+	{
+		name:        "Snake",
+		description: "The classic: eat, grow and don't bite your tail.",
+		preview:     botPreview("greedy", 1, 120),
+		start:       func() ebiten.Game { return snakeGame() },
+	},
+	{
+		name:        "Snake: A* bot",
+		description: "Watch the path finding bot chase the food.",
+		preview:     botPreview("astar", 2, 400),
+		start:       watch("astar"),
+	},
+	{
+		name:        "Snake: cycle bot",
+		description: "A bot following a cycle through every cell. Slow but it never loses.",
+		preview:     botPreview("hamiltonian", 3, 2000),
+		start:       watch("hamiltonian"),
+	},
+}
+
+func main() {
+	m := &menu{entries: entries}
+	mgr := scene.New(m)
+	m.launch = mgr.Push
+
+	err := display.Run(mgr, display.Options{
+		Title:         "Arcade",
+		LogicalWidth:  menuWidth,
+		LogicalHeight: menuHeight,
+		Vsync:         true,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+}
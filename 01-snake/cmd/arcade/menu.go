@@ -0,0 +1,187 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"log"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/examples/resources/fonts"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+const (
+	menuWidth  = 640
+	menuHeight = 480
+
+	columns = 3
+	margin  = 16
+	cardW   = (menuWidth - margin*(columns+1)) / columns
+	thumbW  = cardW - 8
+	thumbH  = thumbW * 3 / 4
+	cardH   = thumbH + 84
+	top     = 56
+)
+
+var (
+	titleFace *text.GoTextFace
+	nameFace  *text.GoTextFace
+	descFace  *text.GoTextFace
+)
+
+func init() {
+	s, err := text.NewGoTextFaceSource(bytes.NewReader(fonts.MPlus1pRegular_ttf))
+	if err != nil {
+		log.Fatal(err)
+	}
+	titleFace = &text.GoTextFace{Source: s, Size: 28}
+	nameFace = &text.GoTextFace{Source: s, Size: 16}
+	descFace = &text.GoTextFace{Source: s, Size: 12}
+}
+
+// menu is the grid of games.
+type menu struct {
+	entries  []entry
+	selected int
+	thumbs   []*ebiten.Image
+	// launch runs the picked game
+	launch func(ebiten.Game)
+}
+
+// card returns where entry i is drawn.
+func card(i int) image.Rectangle {
+	x := margin + (i%columns)*(cardW+margin)
+	y := top + (i/columns)*(cardH+margin)
+	return image.Rect(x, y, x+cardW, y+cardH)
+}
+
+func (m *menu) Update() error {
+	n := len(m.entries)
+
+	switch {
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowRight):
+		m.selected = (m.selected + 1) % n
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowLeft):
+		m.selected = (m.selected + n - 1) % n
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowDown) && m.selected+columns < n:
+		m.selected += columns
+	case inpututil.IsKeyJustPressed(ebiten.KeyArrowUp) && m.selected >= columns:
+		m.selected -= columns
+	case inpututil.IsKeyJustPressed(ebiten.KeyEnter), inpututil.IsKeyJustPressed(ebiten.KeySpace):
+		m.launch(m.entries[m.selected].start())
+	case inpututil.IsKeyJustPressed(ebiten.KeyEscape):
+		return ebiten.Termination
+	}
+
+	x, y := ebiten.CursorPosition()
+	for i := range m.entries {
+		if !image.Pt(x, y).In(card(i)) {
+			continue
+		}
+		if inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+			m.selected = i
+			m.launch(m.entries[i].start())
+		}
+	}
+
+	return nil
+}
+
+// thumbnail renders a state of the snake board.
+func thumbnail(g *snake.Game) *ebiten.Image {
+	img := ebiten.NewImage(thumbW, thumbH)
+	img.Fill(color.Gray{24})
+
+	cw := float32(thumbW) / snake.BoardWidth
+	ch := float32(thumbH) / snake.BoardHeight
+	cell := func(p snake.Point, c color.Color) {
+		vector.DrawFilledRect(img, float32(p.X)*cw, float32(p.Y)*ch, cw-1, ch-1, c, false)
+	}
+
+	for i, p := range g.Snake.All() {
+		v := uint8(255 - min(i, 150))
+		cell(p, color.Gray{v})
+	}
+	cell(g.Food, color.RGBA{255, 0, 0, 255})
+	return img
+}
+
+func (m *menu) Draw(screen *ebiten.Image) {
+	if m.thumbs == nil {
+		for _, e := range m.entries {
+			m.thumbs = append(m.thumbs, thumbnail(e.preview()))
+		}
+	}
+
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(margin, 12)
+	text.Draw(screen, "Arcade", titleFace, op)
+
+	for i, e := range m.entries {
+		r := card(i)
+
+		border := color.Gray{80}
+		if i == m.selected {
+			border = color.Gray{230}
+		}
+		vector.StrokeRect(screen, float32(r.Min.X), float32(r.Min.Y), cardW, cardH, 2, border, false)
+
+		dop := &ebiten.DrawImageOptions{}
+		dop.GeoM.Translate(float64(r.Min.X+4), float64(r.Min.Y+4))
+		screen.DrawImage(m.thumbs[i], dop)
+
+		op := &text.DrawOptions{}
+		op.GeoM.Translate(float64(r.Min.X+6), float64(r.Min.Y+thumbH+8))
+		text.Draw(screen, e.name, nameFace, op)
+
+		op = &text.DrawOptions{}
+		op.GeoM.Translate(float64(r.Min.X+6), float64(r.Min.Y+thumbH+30))
+		op.LineSpacing = descFace.Size * 1.3
+		text.Draw(screen, wrap(e.description, descFace, thumbW-4), descFace, op)
+	}
+
+	msg := "Arrows and Enter or click to play, Esc to come back"
+	w, _ := text.Measure(msg, descFace, 0)
+	op = &text.DrawOptions{}
+	op.GeoM.Translate((menuWidth-w)/2, menuHeight-margin-descFace.Size)
+	text.Draw(screen, msg, descFace, op)
+}
+
+func (m *menu) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return menuWidth, menuHeight
+}
+
+// wrap breaks s into lines no wider than width.
+func wrap(s string, face text.Face, width int) string {
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		next := word
+		if line != "" {
+			next = line + " " + word
+		}
+		if w, _ := text.Measure(next, face, 0); w > float64(width) && line != "" {
+			lines = append(lines, line)
+			next = word
+		}
+		line = next
+	}
+	return strings.Join(append(lines, line), "\n")
+}
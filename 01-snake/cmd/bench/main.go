@@ -0,0 +1,81 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command bench compares the results of go test -bench, read from the
+// standard input, with a stored baseline. It exits with 1 when a benchmark
+// allocates more than before, in number or in bytes, or one of the baseline
+// didn't run.
+//
+//	go test ./pkg/snake -run '^$' -bench . -benchmem | go run ./cmd/bench
+//	go test ./pkg/snake -run '^$' -bench . -benchmem | go run ./cmd/bench -update
+//	go test ./pkg/snakegame -run '^$' -bench . -benchmem | go run ./cmd/bench -baseline pkg/snakegame/testdata/bench.json
+//
+// Times depend on the machine, so they're only compared with -time, the
+// share a benchmark may take longer, against a baseline stored with -update
+// on the same machine. With -count the fastest run of each benchmark counts.
+//
+//	go test ./pkg/snake -run '^$' -bench . -benchmem -count 3 | go run ./cmd/bench -time 0.25
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"jhartman.pl/gamedev/pkg/bench"
+)
+
+var (
+	baseline = flag.String("baseline", "pkg/snake/testdata/bench.json", "file of the stored results")
+	update   = flag.Bool("update", false, "store the results as the new baseline")
+	timeTol  = flag.Float64("time", 0, "how much longer a benchmark may take, 0.25 for 25%, times aren't compared with 0")
+)
+
+func main() {
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("bench: ")
+
+	results, err := bench.Parse(os.Stdin)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if len(results) == 0 {
+		log.Fatal("no benchmark results on the standard input, see go test -bench")
+	}
+	for _, r := range results {
+		fmt.Println(r)
+	}
+
+	if *update {
+		if err := bench.Save(*baseline, results); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("baseline written to %s\n", *baseline)
+		return
+	}
+
+	base, err := bench.Load(*baseline)
+	if err != nil {
+		log.Fatalf("%v (run with -update to create it)", err)
+	}
+	regs := bench.Compare(base, results, *timeTol)
+	for _, r := range regs {
+		fmt.Println(r)
+	}
+	if len(regs) > 0 {
+		os.Exit(1)
+	}
+	fmt.Println("no regressions")
+}
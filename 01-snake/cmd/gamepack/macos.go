@@ -0,0 +1,107 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path"
+	"text/template"
+)
+
+var infoPlist = template.Must(template.New("Info.plist").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>CFBundleName</key>
+	<string>{{.Title}}</string>
+	<key>CFBundleExecutable</key>
+	<string>{{.Name}}</string>
+	<key>CFBundleIdentifier</key>
+	<string>{{.BundleID}}</string>
+	<key>CFBundleShortVersionString</key>
+	<string>{{.Version}}</string>
+	<key>CFBundleVersion</key>
+	<string>{{.Version}}</string>
+	<key>CFBundleIconFile</key>
+	<string>icon.icns</string>
+	<key>CFBundlePackageType</key>
+	<string>APPL</string>
+	<key>NSHighResolutionCapable</key>
+	<true/>
+</dict>
+</plist>
+`))
+
+// icnsTypes maps the icon size to the icns element type holding a PNG of
+// that size
+var icnsTypes = map[int]string{
+	16:   "icp4",
+	32:   "icp5",
+	64:   "icp6",
+	128:  "ic07",
+	256:  "ic08",
+	512:  "ic09",
+	1024: "ic10",
+}
+
+// icns wraps a square PNG into an Apple icon image.
+func icns(png []byte, size int) ([]byte, error) {
+	typ, ok := icnsTypes[size]
+	if !ok || len(png) == 0 {
+		return nil, fmt.Errorf("unsupported icon size %d, use a power of two between 16 and 1024", size)
+	}
+
+	var b bytes.Buffer
+	b.WriteString("icns")
+	binary.Write(&b, binary.BigEndian, uint32(8+8+len(png)))
+	b.WriteString(typ)
+	binary.Write(&b, binary.BigEndian, uint32(8+len(png)))
+	b.Write(png)
+
+	return b.Bytes(), nil
+}
+
+// bundle returns the files of a macOS application bundle for bin.
+func (p *packer) bundle(bin string) ([]file, error) {
+	if p.iconW != p.iconH {
+		return nil, fmt.Errorf("icon must be square, got %dx%d", p.iconW, p.iconH)
+	}
+
+	icon, err := icns(p.icon, p.iconW)
+	if err != nil {
+		return nil, err
+	}
+
+	var plist bytes.Buffer
+	err = infoPlist.Execute(&plist, struct {
+		Title, Name, BundleID, Version string
+	}{*title, *name, *bundleID, p.version})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(bin)
+	if err != nil {
+		return nil, err
+	}
+
+	contents := path.Join(*title+".app", "Contents")
+	return []file{
+		{name: path.Join(contents, "Info.plist"), data: plist.Bytes(), mode: 0o644},
+		{name: path.Join(contents, "MacOS", *name), data: data, mode: 0o755},
+		{name: path.Join(contents, "Resources", "icon.icns"), data: icon, mode: 0o644},
+	}, nil
+}
@@ -0,0 +1,207 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command gamepack cross-compiles one of the examples and packages it for
+// distribution: one zip per target with the binary (a .app bundle on macOS,
+// an index.html loading the wasm for js/wasm), the embedded icon and
+// version, and the licenses of everything linked in.
+//
+// Run it from the module root, e.g.:
+//
+//	go run ./cmd/gamepack -example . -name snake -title Snake
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	_ "image/png"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"jhartman.pl/gamedev/internal/release"
+)
+
+var (
+	example    = flag.String("example", ".", "package of the example to build")
+	name       = flag.String("name", "snake", "name of the binary")
+	title      = flag.String("title", "Snake", "human readable name, used for the macOS bundle")
+	appVersion = flag.String("version", "", "version to embed (default: git describe)")
+	icon       = flag.String("icon", "assets/icon.png", "PNG icon to embed")
+	bundleID   = flag.String("bundle-id", "", "macOS bundle identifier (default: pl.jhartman.<name>)")
+	targets    = flag.String("targets", "windows/amd64,darwin/amd64,darwin/arm64,linux/amd64", "comma separated list of GOOS/GOARCH pairs")
+	out        = flag.String("o", "dist", "output directory")
+)
+
+type target struct {
+	goos   string
+	goarch string
+}
+
+func (t target) String() string {
+	return t.goos + "/" + t.goarch
+}
+
+func parseTargets(s string) ([]target, error) {
+	var ts []target
+
+	for _, v := range strings.Split(s, ",") {
+		goos, goarch, ok := strings.Cut(strings.TrimSpace(v), "/")
+		if !ok || goos == "" || goarch == "" {
+			return nil, fmt.Errorf("invalid target %q, expected GOOS/GOARCH", v)
+		}
+		ts = append(ts, target{goos, goarch})
+	}
+
+	return ts, nil
+}
+
+type packer struct {
+	version string
+	icon    []byte
+	iconW   int
+	iconH   int
+}
+
+func main() {
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("gamepack: ")
+
+	ts, err := parseTargets(*targets)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	p := &packer{version: *appVersion}
+	if p.version == "" {
+		p.version = release.GitVersion()
+	}
+	if *bundleID == "" {
+		*bundleID = "pl.jhartman." + *name
+	}
+
+	p.icon, err = os.ReadFile(*icon)
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(p.icon))
+	if err != nil {
+		log.Fatalf("icon %s: %v", *icon, err)
+	}
+	p.iconW, p.iconH = cfg.Width, cfg.Height
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatal(err)
+	}
+
+	failed := false
+	for _, t := range ts {
+		zip, err := p.pack(t)
+		if err != nil {
+			log.Printf("%s: %v", t, err)
+			failed = true
+			continue
+		}
+		log.Printf("%s: %s", t, zip)
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+// pack builds the example for t and zips it together with its licenses.
+func (p *packer) pack(t target) (string, error) {
+	tmp, err := os.MkdirTemp("", "gamepack")
+	if err != nil {
+		return "", err
+	}
+	defer os.RemoveAll(tmp)
+
+	bin := filepath.Join(tmp, *name)
+	switch t.goos {
+	case "windows":
+		bin += ".exe"
+	case "js":
+		bin += ".wasm"
+	}
+
+	if err := p.build(t, bin); err != nil {
+		return "", err
+	}
+
+	files, err := collectLicenses(t)
+	if err != nil {
+		return "", err
+	}
+
+	switch t.goos {
+	case "darwin":
+		app, err := p.bundle(bin)
+		if err != nil {
+			return "", err
+		}
+		files = append(files, app...)
+	case "js":
+		web, err := p.web(bin)
+		if err != nil {
+			return "", err
+		}
+		files = append(files, web...)
+	default:
+		data, err := os.ReadFile(bin)
+		if err != nil {
+			return "", err
+		}
+		files = append(files, file{name: filepath.Base(bin), data: data, mode: 0o755})
+	}
+
+	zip := filepath.Join(*out, fmt.Sprintf("%s-%s-%s-%s.zip", *name, p.version, t.goos, t.goarch))
+	return zip, writeZip(zip, files)
+}
+
+func (p *packer) build(t target, bin string) error {
+	ldflags := "-s -w -X main.version=" + p.version
+
+	cgo := "0"
+	switch t.goos {
+	case "linux":
+		// ebiten needs cgo (GLFW) on linux, which can't be cross-compiled
+		// without a C toolchain for the target
+		if runtime.GOOS != "linux" {
+			return fmt.Errorf("linux builds need cgo, build them on a linux host")
+		}
+		cgo = "1"
+	case "windows":
+		ldflags += " -H windowsgui"
+
+		syso := filepath.Join(*example, "zz_gamepack_windows_"+t.goarch+".syso")
+		if err := writeSyso(syso, t.goarch, p.icon, p.iconW, p.iconH); err != nil {
+			return err
+		}
+		defer os.Remove(syso)
+	}
+
+	cmd := exec.Command("go", "build", "-trimpath", "-ldflags", ldflags, "-o", bin, *example)
+	cmd.Env = append(os.Environ(), "GOOS="+t.goos, "GOARCH="+t.goarch, "CGO_ENABLED="+cgo)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
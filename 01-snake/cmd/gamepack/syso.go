@@ -0,0 +1,230 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+)
+
+// The windows icon is linked in as a resource: writeSyso produces a COFF
+// object with a .rsrc section holding RT_ICON and RT_GROUP_ICON entries, and
+// the go linker picks up *.syso files found next to the main package.
+
+const (
+	rtIcon      = 3
+	rtGroupIcon = 14
+	langEnUS    = 0x409
+
+	// IMAGE_SCN_CNT_INITIALIZED_DATA | IMAGE_SCN_MEM_READ
+	rsrcCharacteristics = 0x40000040
+	// IMAGE_SYM_CLASS_STATIC
+	symClassStatic = 3
+)
+
+var machines = map[string]struct {
+	machine uint16
+	reloc   uint16 // relocation type for image relative addresses
+}{
+	"amd64": {0x8664, 3}, // IMAGE_REL_AMD64_ADDR32NB
+	"386":   {0x14c, 7},  // IMAGE_REL_I386_DIR32NB
+	"arm64": {0xaa64, 2}, // IMAGE_REL_ARM64_ADDR32NB
+}
+
+type coffHeader struct {
+	Machine              uint16
+	NumberOfSections     uint16
+	TimeDateStamp        uint32
+	PointerToSymbolTable uint32
+	NumberOfSymbols      uint32
+	SizeOfOptionalHeader uint16
+	Characteristics      uint16
+}
+
+type sectionHeader struct {
+	Name                 [8]byte
+	VirtualSize          uint32
+	VirtualAddress       uint32
+	SizeOfRawData        uint32
+	PointerToRawData     uint32
+	PointerToRelocations uint32
+	PointerToLinenumbers uint32
+	NumberOfRelocations  uint16
+	NumberOfLinenumbers  uint16
+	Characteristics      uint32
+}
+
+type relocation struct {
+	VirtualAddress   uint32
+	SymbolTableIndex uint32
+	Type             uint16
+}
+
+type symbol struct {
+	Name               [8]byte
+	Value              uint32
+	SectionNumber      int16
+	Type               uint16
+	StorageClass       uint8
+	NumberOfAuxSymbols uint8
+}
+
+type resourceDirectory struct {
+	Characteristics      uint32
+	TimeDateStamp        uint32
+	MajorVersion         uint16
+	MinorVersion         uint16
+	NumberOfNamedEntries uint16
+	NumberOfIDEntries    uint16
+}
+
+type resourceDirectoryEntry struct {
+	ID     uint32
+	Offset uint32
+}
+
+type resourceDataEntry struct {
+	OffsetToData uint32
+	Size         uint32
+	CodePage     uint32
+	Reserved     uint32
+}
+
+type resource struct {
+	typ  uint32
+	id   uint32
+	data []byte
+}
+
+// rsrc lays out the resource tree (type -> id -> language -> data) for res,
+// which must be sorted by type. It returns the section data and the offsets
+// of the data entries, whose addresses need to be relocated.
+func rsrc(res []resource) ([]byte, []uint32) {
+	const (
+		dirSize   = 16
+		entrySize = 8
+		dataSize  = 16
+		tableSize = dirSize + entrySize
+	)
+
+	n := len(res)
+	idsOff := dirSize + n*entrySize
+	langsOff := idsOff + n*tableSize
+	dataEntriesOff := langsOff + n*tableSize
+	blobsOff := dataEntriesOff + n*dataSize
+
+	var b bytes.Buffer
+	w := func(v any) {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+	const subdir = 0x80000000
+
+	w(resourceDirectory{NumberOfIDEntries: uint16(n)})
+	for i, r := range res {
+		w(resourceDirectoryEntry{r.typ, subdir | uint32(idsOff+i*tableSize)})
+	}
+	for i, r := range res {
+		w(resourceDirectory{NumberOfIDEntries: 1})
+		w(resourceDirectoryEntry{r.id, subdir | uint32(langsOff+i*tableSize)})
+	}
+	for i := range res {
+		w(resourceDirectory{NumberOfIDEntries: 1})
+		w(resourceDirectoryEntry{langEnUS, uint32(dataEntriesOff + i*dataSize)})
+	}
+
+	var relocs []uint32
+	off := blobsOff
+	for _, r := range res {
+		relocs = append(relocs, uint32(b.Len()))
+		w(resourceDataEntry{OffsetToData: uint32(off), Size: uint32(len(r.data))})
+		off += (len(r.data) + 7) &^ 7
+	}
+	for _, r := range res {
+		b.Write(r.data)
+		b.Write(make([]byte, (8-len(r.data)%8)%8))
+	}
+
+	return b.Bytes(), relocs
+}
+
+// writeSyso writes a COFF object file for goarch embedding png as the
+// application icon.
+func writeSyso(name, goarch string, png []byte, width, height int) error {
+	m, ok := machines[goarch]
+	if !ok {
+		return fmt.Errorf("windows icon: unsupported architecture %s", goarch)
+	}
+
+	// 0 means 256 in icon directories
+	dim := func(v int) uint8 {
+		if v >= 256 {
+			return 0
+		}
+		return uint8(v)
+	}
+
+	var group bytes.Buffer
+	binary.Write(&group, binary.LittleEndian, struct {
+		Reserved, Type, Count uint16
+	}{0, 1, 1})
+	binary.Write(&group, binary.LittleEndian, struct {
+		Width, Height, ColorCount, Reserved uint8
+		Planes, BitCount                    uint16
+		BytesInRes                          uint32
+		ID                                  uint16
+	}{dim(width), dim(height), 0, 0, 1, 32, uint32(len(png)), 1})
+
+	data, relocs := rsrc([]resource{
+		{rtIcon, 1, png},
+		{rtGroupIcon, 1, group.Bytes()},
+	})
+
+	const headersSize = 20 + 40
+	relocsOff := headersSize + len(data)
+	symbolsOff := relocsOff + len(relocs)*10
+
+	var b bytes.Buffer
+	w := func(v any) {
+		binary.Write(&b, binary.LittleEndian, v)
+	}
+
+	w(coffHeader{
+		Machine:              m.machine,
+		NumberOfSections:     1,
+		PointerToSymbolTable: uint32(symbolsOff),
+		NumberOfSymbols:      1,
+	})
+	w(sectionHeader{
+		Name:                 [8]byte{'.', 'r', 's', 'r', 'c'},
+		SizeOfRawData:        uint32(len(data)),
+		PointerToRawData:     headersSize,
+		PointerToRelocations: uint32(relocsOff),
+		NumberOfRelocations:  uint16(len(relocs)),
+		Characteristics:      rsrcCharacteristics,
+	})
+	b.Write(data)
+	for _, r := range relocs {
+		w(relocation{VirtualAddress: r, Type: m.reloc})
+	}
+	w(symbol{
+		Name:          [8]byte{'.', 'r', 's', 'r', 'c'},
+		SectionNumber: 1,
+		StorageClass:  symClassStatic,
+	})
+	// empty string table
+	w(uint32(4))
+
+	return os.WriteFile(name, b.Bytes(), 0o644)
+}
@@ -0,0 +1,96 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"html/template"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+var indexHTML = template.Must(template.New("index.html").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+html, body { margin: 0; height: 100%; background: #000; overflow: hidden; }
+</style>
+</head>
+<body>
+<script src="wasm_exec.js"></script>
+<script>
+const go = new Go();
+// not every host serves .wasm as application/wasm, which streaming needs
+fetch("{{.Wasm}}")
+	.then(resp => resp.arrayBuffer())
+	.then(buf => WebAssembly.instantiate(buf, go.importObject))
+	.then(result => go.run(result.instance))
+	.catch(err => {
+		document.body.style.color = "#fff";
+		document.body.textContent = "Failed to start: " + err;
+	});
+</script>
+</body>
+</html>
+`))
+
+// wasmExec returns the JavaScript support file of the Go distribution the
+// wasm is built with.
+func wasmExec() ([]byte, error) {
+	goroot, err := exec.Command("go", "env", "GOROOT").Output()
+	if err != nil {
+		return nil, err
+	}
+	root := strings.TrimSpace(string(goroot))
+
+	// moved to lib/wasm in Go 1.24
+	data, err := os.ReadFile(filepath.Join(root, "lib", "wasm", "wasm_exec.js"))
+	if err != nil {
+		data, err = os.ReadFile(filepath.Join(root, "misc", "wasm", "wasm_exec.js"))
+	}
+	return data, err
+}
+
+// web lays out the wasm build for the browser: an index.html loading it,
+// at the root of the zip as itch.io and most static hosts expect.
+func (p *packer) web(wasm string) ([]file, error) {
+	data, err := os.ReadFile(wasm)
+	if err != nil {
+		return nil, err
+	}
+
+	js, err := wasmExec()
+	if err != nil {
+		return nil, err
+	}
+
+	var index bytes.Buffer
+	err = indexHTML.Execute(&index, struct {
+		Title string
+		Wasm  string
+	}{*title, filepath.Base(wasm)})
+	if err != nil {
+		return nil, err
+	}
+
+	return []file{
+		{name: "index.html", data: index.Bytes(), mode: 0o644},
+		{name: "wasm_exec.js", data: js, mode: 0o644},
+		{name: filepath.Base(wasm), data: data, mode: 0o644},
+	}, nil
+}
@@ -0,0 +1,138 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+type file struct {
+	name string
+	data []byte
+	mode fs.FileMode
+}
+
+func writeZip(name string, files []file) error {
+	f, err := os.Create(name)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw := zip.NewWriter(f)
+	for _, v := range files {
+		h := &zip.FileHeader{
+			Name:   v.name,
+			Method: zip.Deflate,
+		}
+		h.SetMode(v.mode)
+
+		w, err := zw.CreateHeader(h)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(v.data); err != nil {
+			return err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+func isLicense(name string) bool {
+	name = strings.ToUpper(name)
+	for _, prefix := range []string{"LICENSE", "LICENCE", "COPYING", "NOTICE"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// collectLicenses returns the license files of the Go distribution and of
+// every module linked into the example when built for t.
+func collectLicenses(t target) ([]file, error) {
+	cmd := exec.Command("go", "list", "-deps", "-json=Module", *example)
+	cmd.Env = append(os.Environ(), "GOOS="+t.goos, "GOARCH="+t.goarch)
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	goroot, err := exec.Command("go", "env", "GOROOT").Output()
+	if err != nil {
+		return nil, err
+	}
+
+	// module path -> directory
+	dirs := map[string]string{
+		"go": strings.TrimSpace(string(goroot)),
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(out))
+	for dec.More() {
+		var pkg struct {
+			Module *struct {
+				Path string
+				Dir  string
+			}
+		}
+		if err := dec.Decode(&pkg); err != nil {
+			return nil, err
+		}
+		if pkg.Module != nil && pkg.Module.Dir != "" {
+			dirs[pkg.Module.Path] = pkg.Module.Dir
+		}
+	}
+
+	var files []file
+	for _, mod := range slices.Sorted(maps.Keys(dirs)) {
+		dir := dirs[mod]
+
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, e := range entries {
+			if e.IsDir() || !isLicense(e.Name()) {
+				continue
+			}
+
+			data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, file{
+				name: path.Join("licenses", mod, e.Name()),
+				data: data,
+				mode: 0o644,
+			})
+		}
+	}
+
+	return files, nil
+}
@@ -0,0 +1,83 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command mapsd is the maps server, where players share the snake levels
+// they made: it keeps the uploaded maps in a JSON file and serves the index,
+// the downloads and the ratings, see pkg/maps for the client.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"jhartman.pl/gamedev/internal/httpapi"
+)
+
+var (
+	addr        = flag.String("addr", ":8081", "address to listen on")
+	dbPath      = flag.String("db", "maps.json", "file the maps are kept in")
+	uploadRate  = flag.Float64("upload-rate", 2, "uploads allowed per minute and client")
+	rateRate    = flag.Float64("rating-rate", 20, "ratings allowed per minute and client")
+	queryRate   = flag.Float64("query-rate", 120, "index queries and downloads allowed per minute and client")
+	behindProxy = flag.Bool("behind-proxy", false, "take the client address from X-Forwarded-For")
+)
+
+func main() {
+	flag.Parse()
+
+	log.SetPrefix("mapsd: ")
+
+	st, err := openStore(*dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	s := &server{
+		store:   st,
+		uploads: httpapi.NewLimiter(*uploadRate),
+		ratings: httpapi.NewLimiter(*rateRate),
+		queries: httpapi.NewLimiter(*queryRate),
+		clients: httpapi.Clients{BehindProxy: *behindProxy},
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           s.routes(),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       time.Minute,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	log.Printf("listening on %s", *addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
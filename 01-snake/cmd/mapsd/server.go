@@ -0,0 +1,153 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"jhartman.pl/gamedev/internal/httpapi"
+	"jhartman.pl/gamedev/pkg/maps"
+)
+
+const (
+	maxBodySize = 64 << 10
+
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type server struct {
+	store   *store
+	uploads *httpapi.Limiter
+	ratings *httpapi.Limiter
+	queries *httpapi.Limiter
+	clients httpapi.Clients
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /api/maps", s.clients.Limit(s.queries, s.handleList))
+	mux.HandleFunc("GET /api/maps/{id}", s.clients.Limit(s.queries, s.handleGet))
+	mux.HandleFunc("POST /api/maps", s.clients.Limit(s.uploads, s.handleUpload))
+	mux.HandleFunc("POST /api/maps/{id}/ratings", s.clients.Limit(s.ratings, s.handleRate))
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	return mux
+}
+
+// decode reads a JSON body, rejecting unknown fields.
+func decode(w http.ResponseWriter, r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+
+func (s *server) handleList(w http.ResponseWriter, r *http.Request) {
+	q := maps.Query{
+		Sort:   r.URL.Query().Get("sort"),
+		Search: r.URL.Query().Get("q"),
+	}
+	switch q.Sort {
+	case "":
+		q.Sort = maps.SortTop
+	case maps.SortTop, maps.SortNew:
+	default:
+		httpapi.WriteError(w, http.StatusBadRequest, "sort must be top or new")
+		return
+	}
+
+	var err error
+	if q.Offset, err = httpapi.IntParam(r, "offset", 0); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if q.Limit, err = httpapi.LimitParam(r, defaultLimit, maxLimit); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	httpapi.WriteJSON(w, http.StatusOK, s.store.list(q))
+}
+
+func (s *server) handleGet(w http.ResponseWriter, r *http.Request) {
+	m, err := s.store.download(r.PathValue("id"))
+	if !s.check(w, err) {
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, m)
+}
+
+func (s *server) handleUpload(w http.ResponseWriter, r *http.Request) {
+	var m maps.Map
+	if err := decode(w, r, &m); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := m.Validate(); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	m, err := s.store.add(m, time.Now().UTC())
+	if !s.check(w, err) {
+		return
+	}
+	log.Printf("%s uploaded %q by %s", m.ID, m.Name, m.Author)
+	httpapi.WriteJSON(w, http.StatusCreated, m)
+}
+
+func (s *server) handleRate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Stars int `json:"stars"`
+	}
+	if err := decode(w, r, &body); err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if body.Stars < 1 || body.Stars > 5 {
+		httpapi.WriteError(w, http.StatusBadRequest, "stars must be 1 to 5")
+		return
+	}
+
+	// one vote per address, not kept in the clear
+	sum := sha256.Sum256([]byte(s.clients.Addr(r)))
+	voter := hex.EncodeToString(sum[:8])
+
+	m, err := s.store.rate(r.PathValue("id"), voter, body.Stars)
+	if !s.check(w, err) {
+		return
+	}
+	httpapi.WriteJSON(w, http.StatusOK, m)
+}
+
+// check writes the response for a store error, returning whether there was
+// none.
+func (s *server) check(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errNotFound):
+		httpapi.WriteError(w, http.StatusNotFound, err.Error())
+	default:
+		log.Printf("store: %v", err)
+		httpapi.WriteError(w, http.StatusInternalServerError, "internal error")
+	}
+	return false
+}
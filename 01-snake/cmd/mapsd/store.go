@@ -0,0 +1,202 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"cmp"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/maps"
+)
+
+var errNotFound = errors.New("map not found")
+
+// record is a stored map with the ratings behind its average.
+type record struct {
+	maps.Map
+	// Ratings are the stars per voter, a hash of their address
+	Ratings map[string]int `json:"ratings,omitempty"`
+}
+
+// store keeps the maps in memory and in a JSON file, rewritten on every
+// change. The maps are small and few enough for that.
+type store struct {
+	path string
+
+	mu   sync.Mutex
+	maps map[string]*record
+}
+
+func openStore(path string) (*store, error) {
+	s := &store{path: path, maps: map[string]*record{}}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var rs []*record
+	if err := json.Unmarshal(data, &rs); err != nil {
+		return nil, err
+	}
+	for _, r := range rs {
+		s.maps[r.ID] = r
+	}
+	return s, nil
+}
+
+// save writes the file atomically, must be called with mu held.
+func (s *store) save() error {
+	rs := make([]*record, 0, len(s.maps))
+	for _, r := range s.maps {
+		rs = append(rs, r)
+	}
+	slices.SortFunc(rs, func(a, b *record) int { return a.Created.Compare(b.Created) })
+
+	data, err := json.Marshal(rs)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (s *store) add(m maps.Map, now time.Time) (maps.Map, error) {
+	id, err := newID()
+	if err != nil {
+		return m, err
+	}
+
+	m.ID = id
+	m.Created = now
+	m.Rating, m.Votes, m.Downloads = 0, 0, 0
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maps[id] = &record{Map: m}
+	return m, s.save()
+}
+
+// list returns a page of the index, without the walls.
+func (s *store) list(q maps.Query) maps.Page {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	search := strings.ToLower(q.Search)
+	var ms []maps.Map
+	for _, r := range s.maps {
+		if search != "" &&
+			!strings.Contains(strings.ToLower(r.Name), search) &&
+			!strings.Contains(strings.ToLower(r.Author), search) {
+			continue
+		}
+		m := r.Map
+		m.Walls, m.Portals, m.Obstacles, m.Ice, m.Mud = nil, nil, nil, nil, nil
+		ms = append(ms, m)
+	}
+
+	slices.SortFunc(ms, func(a, b maps.Map) int {
+		if q.Sort == maps.SortNew {
+			return b.Created.Compare(a.Created)
+		}
+		return cmp.Or(
+			cmp.Compare(b.Rating, a.Rating),
+			cmp.Compare(b.Votes, a.Votes),
+			b.Created.Compare(a.Created),
+		)
+	})
+
+	page := maps.Page{Total: len(ms)}
+	if q.Offset < len(ms) {
+		end := min(q.Offset+q.Limit, len(ms))
+		page.Maps = ms[q.Offset:end]
+		if end < len(ms) {
+			page.Next = end
+		}
+	}
+	return page
+}
+
+// download returns a map, counting the download.
+func (s *store) download(id string) (maps.Map, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.maps[id]
+	if !ok {
+		return maps.Map{}, errNotFound
+	}
+	r.Downloads++
+	return r.Map, s.save()
+}
+
+// rate records the voter's stars, replacing their earlier ones.
+func (s *store) rate(id, voter string, stars int) (maps.Map, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r, ok := s.maps[id]
+	if !ok {
+		return maps.Map{}, errNotFound
+	}
+
+	if r.Ratings == nil {
+		r.Ratings = map[string]int{}
+	}
+	r.Ratings[voter] = stars
+
+	sum := 0
+	for _, v := range r.Ratings {
+		sum += v
+	}
+	r.Votes = len(r.Ratings)
+	r.Rating = float64(sum) / float64(r.Votes)
+
+	m := r.Map
+	m.Walls, m.Portals, m.Obstacles, m.Ice, m.Mud = nil, nil, nil, nil, nil
+	return m, s.save()
+}
@@ -0,0 +1,149 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command publish releases one of the examples on itch.io: it packages the
+// desktop and web builds with cmd/gamepack and, given the itch.io project,
+// uploads each zip to its channel with butler
+// (https://itch.io/docs/butler/).
+//
+// Run it from the module root, e.g.:
+//
+//	go run ./cmd/publish -example . -name snake -title Snake -itch jh/snake
+//
+// Without -itch the zips are only built, ready for a manual upload. The web
+// channel has to be marked as "played in the browser" once on the project
+// page.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"jhartman.pl/gamedev/internal/release"
+)
+
+var (
+	example    = flag.String("example", ".", "package of the example to build")
+	name       = flag.String("name", "snake", "name of the binary")
+	title      = flag.String("title", "Snake", "human readable name")
+	appVersion = flag.String("version", "", "version to release (default: git describe)")
+	targets    = flag.String("targets", "windows/amd64,darwin/amd64,darwin/arm64,linux/amd64,js/wasm", "comma separated list of GOOS/GOARCH pairs")
+	out        = flag.String("o", "dist", "output directory")
+	itch       = flag.String("itch", "", "itch.io project to push to, as user/game")
+	butler     = flag.String("butler", "butler", "butler executable")
+	dryRun     = flag.Bool("n", false, "print the butler commands instead of running them")
+)
+
+// channels are the itch.io channel names per GOOS; itch.io tags the
+// uploads by platform from them.
+var channels = map[string]string{
+	"windows": "windows",
+	"darwin":  "mac",
+	"linux":   "linux",
+	"js":      "web",
+}
+
+type upload struct {
+	zip     string
+	channel string
+}
+
+// uploads maps the targets to their zips and channels. The architecture is
+// only added to the channel when an OS has more than one.
+func uploads(targets []string, version string) ([]upload, error) {
+	arches := map[string]int{}
+	for _, t := range targets {
+		goos, _, _ := strings.Cut(t, "/")
+		arches[goos]++
+	}
+
+	var ups []upload
+	for _, t := range targets {
+		goos, goarch, ok := strings.Cut(t, "/")
+		if !ok || goos == "" || goarch == "" {
+			return nil, fmt.Errorf("invalid target %q, expected GOOS/GOARCH", t)
+		}
+
+		channel, ok := channels[goos]
+		if !ok {
+			return nil, fmt.Errorf("%s: no itch.io channel for %s", t, goos)
+		}
+		if arches[goos] > 1 {
+			channel += "-" + goarch
+		}
+
+		zip := filepath.Join(*out, fmt.Sprintf("%s-%s-%s-%s.zip", *name, version, goos, goarch))
+		ups = append(ups, upload{zip, channel})
+	}
+	return ups, nil
+}
+
+func run(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func main() {
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("publish: ")
+
+	version := *appVersion
+	if version == "" {
+		version = release.GitVersion()
+	}
+
+	var ts []string
+	for _, t := range strings.Split(*targets, ",") {
+		ts = append(ts, strings.TrimSpace(t))
+	}
+	ups, err := uploads(ts, version)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	err = run("go", "run", "./cmd/gamepack",
+		"-example", *example,
+		"-name", *name,
+		"-title", *title,
+		"-version", version,
+		"-targets", strings.Join(ts, ","),
+		"-o", *out)
+	if err != nil {
+		log.Fatalf("gamepack: %v", err)
+	}
+
+	if *itch == "" {
+		log.Printf("built %d zips in %s, pass -itch user/game to push them", len(ups), *out)
+		return
+	}
+
+	for _, u := range ups {
+		args := []string{"push", u.zip, *itch + ":" + u.channel, "--userversion", version}
+		if *dryRun {
+			fmt.Println(*butler, strings.Join(args, " "))
+			continue
+		}
+		if err := run(*butler, args...); err != nil {
+			log.Fatalf("%s: %v", u.channel, err)
+		}
+		log.Printf("%s: pushed %s", u.channel, filepath.Base(u.zip))
+	}
+}
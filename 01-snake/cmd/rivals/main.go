@@ -0,0 +1,95 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command rivals plays matches headlessly between two bot.Rival snakes of
+// different lookahead depths and reports how they fared: the wins of each,
+// the draws, the average scores. Useful to see what a depth is worth before
+// setting it with -rival-depth.
+//
+//	go run ./cmd/rivals -depths 2,20 -n 1000
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"jhartman.pl/gamedev/pkg/bot"
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+var (
+	depths   = flag.String("depths", "2,20", "lookahead depths of the two rivals")
+	n        = flag.Int("n", 1000, "number of matches")
+	seed     = flag.Uint64("seed", 1, "seed of the first match, the others follow")
+	maxSteps = flag.Int("max-steps", 10_000, "steps after which a match is a draw")
+)
+
+func main() {
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("rivals: ")
+
+	var rivals [2]bot.Rival
+	parts := strings.Split(*depths, ",")
+	if len(parts) != 2 {
+		log.Fatalf("-depths takes two depths, got %q", *depths)
+	}
+	for i, s := range parts {
+		d, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil || d < 0 {
+			log.Fatalf("invalid depth %q", s)
+		}
+		rivals[i].Depth = d
+	}
+	if *n <= 0 || *maxSteps <= 0 {
+		log.Fatal("-n and -max-steps must be positive")
+	}
+
+	var wins [2]int
+	var scores [2]int
+	draws, steps := 0, 0
+	for i := range *n {
+		m := snake.NewMatch(*seed+uint64(i), 2)
+		step := 0
+		for ; step < *maxSteps && !m.Over; step++ {
+			for j, r := range rivals {
+				if d := r.Observe(m, j); d != input.None {
+					m.Players[j].Turn(d.Delta())
+				}
+			}
+			m.Step()
+		}
+		steps += step
+
+		one, two := m.Players[0], m.Players[1]
+		switch {
+		case one.Crashed == two.Crashed:
+			draws++
+		case two.Crashed:
+			wins[0]++
+		default:
+			wins[1]++
+		}
+		scores[0] += one.Score
+		scores[1] += two.Score
+	}
+
+	for i, r := range rivals {
+		fmt.Printf("depth %-3d won %5.1f%%, scoring %.1f on average\n", r.Depth, 100*float64(wins[i])/float64(*n), float64(scores[i])/float64(*n))
+	}
+	fmt.Printf("draws     %5.1f%%, matches of %.0f steps on average\n", 100*float64(draws)/float64(*n), float64(steps)/float64(*n))
+}
@@ -0,0 +1,140 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"sync"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/leaderboard"
+)
+
+// how far the client's clock may be off, signatures older than that are
+// rejected
+const signatureWindow = 5 * time.Minute
+
+var deviceRe = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// authenticator issues anonymous devices and checks the signatures of their
+// submissions. Device secrets are derived from the device ID with the
+// server key, so nothing has to be stored per device.
+type authenticator struct {
+	key []byte
+
+	mu        sync.Mutex
+	seen      map[string]time.Time // signatures used within the window
+	lastSweep time.Time
+}
+
+// loadKey reads the server key, generating it on first start.
+func loadKey(name string) ([]byte, error) {
+	key, err := os.ReadFile(name)
+	if err == nil {
+		if len(key) < 32 {
+			return nil, errors.New("server key too short")
+		}
+		return key, nil
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	return key, os.WriteFile(name, key, 0o600)
+}
+
+func newAuthenticator(key []byte) *authenticator {
+	return &authenticator{
+		key:  key,
+		seen: make(map[string]time.Time),
+	}
+}
+
+func (a *authenticator) secret(id string) string {
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte("device:" + id))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (a *authenticator) newDevice() (leaderboard.Device, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return leaderboard.Device{}, err
+	}
+
+	d := leaderboard.Device{ID: hex.EncodeToString(id)}
+	d.Secret = a.secret(d.ID)
+	return d, nil
+}
+
+// verify checks the signature of a request to path with the given body and
+// that it isn't replayed. It returns the device that signed it.
+func (a *authenticator) verify(r *http.Request, path string, body []byte, now time.Time) (string, error) {
+	id := r.Header.Get(leaderboard.HeaderDevice)
+	if !deviceRe.MatchString(id) {
+		return "", errors.New("missing or invalid device")
+	}
+
+	ts, err := strconv.ParseInt(r.Header.Get(leaderboard.HeaderTimestamp), 10, 64)
+	if err != nil {
+		return "", errors.New("missing or invalid timestamp")
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > signatureWindow || d < -signatureWindow {
+		return "", errors.New("timestamp out of range, check the clock")
+	}
+
+	sig := r.Header.Get(leaderboard.HeaderSignature)
+	want := leaderboard.Sign(a.secret(id), r.Method, path, ts, body)
+	if !hmac.Equal([]byte(sig), []byte(want)) {
+		return "", errors.New("invalid signature")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.sweep(now)
+	if _, ok := a.seen[sig]; ok {
+		return "", errors.New("request already submitted")
+	}
+	a.seen[sig] = now
+
+	return id, nil
+}
+
+// sweep forgets signatures that are too old to be accepted anyway. Must be
+// called with mu held.
+func (a *authenticator) sweep(now time.Time) {
+	if now.Sub(a.lastSweep) < time.Minute {
+		return
+	}
+	a.lastSweep = now
+
+	for sig, t := range a.seen {
+		if now.Sub(t) > 2*signatureWindow {
+			delete(a.seen, sig)
+		}
+	}
+}
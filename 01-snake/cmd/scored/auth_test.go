@@ -0,0 +1,113 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/leaderboard"
+)
+
+func TestVerify(t *testing.T) {
+	const path = "/api/scores"
+	now := time.Unix(1_700_000_000, 0)
+	body := []byte(`{"game":"snake","mode":"classic","name":"jh","score":42}`)
+
+	// signed returns a request of d to path at ts, signed with secret
+	signed := func(d leaderboard.Device, secret string, ts time.Time) *http.Request {
+		r := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+		r.Header.Set(leaderboard.HeaderDevice, d.ID)
+		r.Header.Set(leaderboard.HeaderTimestamp, strconv.FormatInt(ts.Unix(), 10))
+		r.Header.Set(leaderboard.HeaderSignature, leaderboard.Sign(secret, r.Method, path, ts.Unix(), body))
+		return r
+	}
+
+	for _, tc := range []struct {
+		name string
+		// request makes the request verified, with a for the server and d
+		// for the device registered there
+		request func(t *testing.T, a *authenticator, d leaderboard.Device) *http.Request
+		// wantErr is part of the error, "" for a request passing
+		wantErr string
+	}{
+		{"valid", func(t *testing.T, a *authenticator, d leaderboard.Device) *http.Request {
+			return signed(d, d.Secret, now)
+		}, ""},
+		{"clock a little off", func(t *testing.T, a *authenticator, d leaderboard.Device) *http.Request {
+			return signed(d, d.Secret, now.Add(signatureWindow-time.Second))
+		}, ""},
+		{"bad signature", func(t *testing.T, a *authenticator, d leaderboard.Device) *http.Request {
+			return signed(d, strings.Repeat("0", 64), now)
+		}, "invalid signature"},
+		{"other device's signature", func(t *testing.T, a *authenticator, d leaderboard.Device) *http.Request {
+			other, err := a.newDevice()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := signed(other, other.Secret, now)
+			r.Header.Set(leaderboard.HeaderDevice, d.ID)
+			return r
+		}, "invalid signature"},
+		{"reused signature", func(t *testing.T, a *authenticator, d leaderboard.Device) *http.Request {
+			if _, err := a.verify(signed(d, d.Secret, now), path, body, now); err != nil {
+				t.Fatal(err)
+			}
+			return signed(d, d.Secret, now)
+		}, "already submitted"},
+		{"timestamp in the past", func(t *testing.T, a *authenticator, d leaderboard.Device) *http.Request {
+			return signed(d, d.Secret, now.Add(-signatureWindow-time.Second))
+		}, "timestamp out of range"},
+		{"timestamp in the future", func(t *testing.T, a *authenticator, d leaderboard.Device) *http.Request {
+			return signed(d, d.Secret, now.Add(signatureWindow+time.Second))
+		}, "timestamp out of range"},
+		{"missing device", func(t *testing.T, a *authenticator, d leaderboard.Device) *http.Request {
+			r := signed(d, d.Secret, now)
+			r.Header.Del(leaderboard.HeaderDevice)
+			return r
+		}, "missing or invalid device"},
+		{"missing timestamp", func(t *testing.T, a *authenticator, d leaderboard.Device) *http.Request {
+			r := signed(d, d.Secret, now)
+			r.Header.Del(leaderboard.HeaderTimestamp)
+			return r
+		}, "missing or invalid timestamp"},
+		{"missing signature", func(t *testing.T, a *authenticator, d leaderboard.Device) *http.Request {
+			r := signed(d, d.Secret, now)
+			r.Header.Del(leaderboard.HeaderSignature)
+			return r
+		}, "invalid signature"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			a := newAuthenticator(bytes.Repeat([]byte{7}, 32))
+			d, err := a.newDevice()
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			id, err := a.verify(tc.request(t, a, d), path, body, now)
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Fatalf("got %v, want no error", err)
+			case tc.wantErr == "" && id != d.ID:
+				t.Errorf("signed by %q, want %q", id, d.ID)
+			case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+				t.Errorf("got %v, want an error with %q", err, tc.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command scored is the leaderboard server. It stores the scores submitted
+// by the games in SQLite and serves the boards per game, mode and day, see
+// pkg/leaderboard for the client.
+//
+// The SQLite driver is only compiled in with the sqlite build tag:
+//
+//	go get modernc.org/sqlite@v1.34.5
+//	go build -tags sqlite ./cmd/scored
+//
+// Without it the scores are kept in memory, which is enough for development.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"jhartman.pl/gamedev/internal/httpapi"
+)
+
+var (
+	addr          = flag.String("addr", ":8080", "address to listen on")
+	dbPath        = flag.String("db", "scores.db", "SQLite database file")
+	keyPath       = flag.String("key", "scored.key", "file with the key device secrets are derived from, created if missing")
+	submitRate    = flag.Float64("submit-rate", 6, "score submissions allowed per minute and client")
+	queryRate     = flag.Float64("query-rate", 120, "board queries allowed per minute and client")
+	registerRate  = flag.Float64("register-rate", 3, "device registrations allowed per minute and client")
+	behindProxy   = flag.Bool("behind-proxy", false, "take the client address from X-Forwarded-For")
+	requireReplay = flag.Bool("require-replay", false, "reject submissions without a replay")
+	metricsAddr   = flag.String("metrics-addr", "", "serve /metrics on this address instead of next to the API")
+)
+
+func main() {
+	flag.Parse()
+
+	log.SetPrefix("scored: ")
+
+	key, err := loadKey(*keyPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	st, err := openStore(*dbPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer st.Close()
+
+	m := newServerMetrics()
+	s := &server{
+		store:     st,
+		auth:      newAuthenticator(key),
+		submits:   httpapi.NewLimiter(*submitRate),
+		queries:   httpapi.NewLimiter(*queryRate),
+		registers: httpapi.NewLimiter(*registerRate),
+		clients: httpapi.Clients{
+			BehindProxy: *behindProxy,
+			Limited:     func(r *http.Request) { m.limited.Inc(r.Pattern) },
+		},
+		requireReplay: *requireReplay,
+		metrics:       m,
+		serveMetrics:  *metricsAddr == "",
+	}
+
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           s.routes(),
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		IdleTimeout:       time.Minute,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	go func() {
+		<-ctx.Done()
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		srv.Shutdown(shutdownCtx)
+	}()
+
+	if *metricsAddr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("GET /metrics", s.metrics.registry)
+
+		go func() {
+			log.Printf("serving metrics on %s", *metricsAddr)
+			if err := http.ListenAndServe(*metricsAddr, mux); err != nil {
+				log.Fatal(err)
+			}
+		}()
+	}
+
+	log.Printf("listening on %s", *addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.Fatal(err)
+	}
+}
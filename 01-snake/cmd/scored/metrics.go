@@ -0,0 +1,91 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/metrics"
+)
+
+// submission results counted by scored_submissions_total
+const (
+	resultAccepted     = "accepted"
+	resultInvalid      = "invalid"
+	resultUnauthorized = "unauthorized"
+	resultBadReplay    = "bad_replay"
+	resultError        = "error"
+)
+
+type serverMetrics struct {
+	registry *metrics.Registry
+
+	requests      *metrics.Counter
+	duration      *metrics.Histogram
+	limited       *metrics.Counter
+	devices       *metrics.Counter
+	submissions   *metrics.Counter
+	replayLatency *metrics.Histogram
+}
+
+func newServerMetrics() *serverMetrics {
+	r := metrics.NewRegistry()
+
+	return &serverMetrics{
+		registry: r,
+		requests: r.NewCounter("scored_http_requests_total",
+			"HTTP requests by route and status code.", "route", "code"),
+		duration: r.NewHistogram("scored_http_request_duration_seconds",
+			"Time taken to handle HTTP requests.", nil),
+		limited: r.NewCounter("scored_rate_limited_total",
+			"Requests refused by the rate limiter, by route.", "route"),
+		devices: r.NewCounter("scored_devices_registered_total",
+			"Anonymous devices registered."),
+		submissions: r.NewCounter("scored_submissions_total",
+			"Score submissions by result.", "result"),
+		replayLatency: r.NewHistogram("scored_replay_verification_seconds",
+			"Time taken to verify submitted replays.",
+			[]float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5}),
+	}
+}
+
+// statusRecorder remembers the status code written by a handler.
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// instrument counts and times the requests handled by h. Requests are
+// labeled with the matched mux pattern to keep the number of series bounded.
+func (m *serverMetrics) instrument(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		h.ServeHTTP(rec, r)
+
+		route := r.Pattern
+		if route == "" {
+			route = "unmatched"
+		}
+		m.requests.Inc(route, strconv.Itoa(rec.status))
+		m.duration.Since(start)
+	})
+}
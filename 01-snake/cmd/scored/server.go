@@ -0,0 +1,229 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"jhartman.pl/gamedev/internal/httpapi"
+	"jhartman.pl/gamedev/pkg/leaderboard"
+	"jhartman.pl/gamedev/pkg/replay"
+)
+
+const (
+	maxBodySize = 256 << 10
+	maxNameLen  = 16
+	maxScore    = 1_000_000
+
+	defaultLimit = 10
+	maxLimit     = 100
+)
+
+// game and mode identifiers
+var idRe = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,31}$`)
+
+type server struct {
+	store         store
+	auth          *authenticator
+	requireReplay bool
+	submits       *httpapi.Limiter
+	queries       *httpapi.Limiter
+	registers     *httpapi.Limiter
+	clients       httpapi.Clients
+	metrics       *serverMetrics
+	serveMetrics  bool // serve /metrics next to the API
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/devices", s.clients.Limit(s.registers, s.handleRegister))
+	mux.HandleFunc("POST /api/scores", s.clients.Limit(s.submits, s.handleSubmit))
+	mux.HandleFunc("GET /api/scores", s.clients.Limit(s.queries, s.handleList))
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	if s.serveMetrics {
+		mux.Handle("GET /metrics", s.metrics.registry)
+	}
+	return s.metrics.instrument(mux)
+}
+
+func validateBoard(game, mode string) error {
+	if !idRe.MatchString(game) {
+		return errors.New("invalid game")
+	}
+	if !idRe.MatchString(mode) {
+		return errors.New("invalid mode")
+	}
+	return nil
+}
+
+func validateScore(sc *leaderboard.Score) error {
+	if err := validateBoard(sc.Game, sc.Mode); err != nil {
+		return err
+	}
+
+	sc.Name = strings.TrimSpace(sc.Name)
+	if sc.Name == "" || utf8.RuneCountInString(sc.Name) > maxNameLen {
+		return errors.New("name must be 1 to 16 characters")
+	}
+	for _, r := range sc.Name {
+		if !unicode.IsPrint(r) {
+			return errors.New("invalid character in name")
+		}
+	}
+
+	if sc.Score < 0 || sc.Score > maxScore {
+		return errors.New("invalid score")
+	}
+	return nil
+}
+
+func (s *server) handleRegister(w http.ResponseWriter, r *http.Request) {
+	d, err := s.auth.newDevice()
+	if err != nil {
+		log.Printf("register: %v", err)
+		httpapi.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	s.metrics.devices.Inc()
+	httpapi.WriteJSON(w, http.StatusCreated, d)
+}
+
+func (s *server) handleSubmit(w http.ResponseWriter, r *http.Request) {
+	var sc leaderboard.Score
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+	if err != nil {
+		s.metrics.submissions.Inc(resultInvalid)
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	if _, err := s.auth.verify(r, "/api/scores", body, time.Now()); err != nil {
+		s.metrics.submissions.Inc(resultUnauthorized)
+		httpapi.WriteError(w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sc); err != nil {
+		s.metrics.submissions.Inc(resultInvalid)
+		httpapi.WriteError(w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+	if err := validateScore(&sc); err != nil {
+		s.metrics.submissions.Inc(resultInvalid)
+		httpapi.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	verified := false
+	if len(sc.Replay) > 0 {
+		start := time.Now()
+		err := verifyReplay(sc)
+		s.metrics.replayLatency.Since(start)
+		if err != nil {
+			s.metrics.submissions.Inc(resultBadReplay)
+			httpapi.WriteError(w, http.StatusUnprocessableEntity, err.Error())
+			return
+		}
+		verified = true
+	} else if s.requireReplay {
+		s.metrics.submissions.Inc(resultInvalid)
+		httpapi.WriteError(w, http.StatusBadRequest, "replay required")
+		return
+	}
+
+	res, err := s.store.Add(r.Context(), sc, time.Now().UTC(), verified)
+	if err != nil {
+		log.Printf("submit: %v", err)
+		s.metrics.submissions.Inc(resultError)
+		httpapi.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+
+	s.metrics.submissions.Inc(resultAccepted)
+	httpapi.WriteJSON(w, http.StatusCreated, res)
+}
+
+// verifyReplay plays the attached replay through the simulation, it has to
+// reproduce the claimed score.
+func verifyReplay(sc leaderboard.Score) error {
+	if sc.Game != "snake" {
+		return errors.New("replays are only supported for snake")
+	}
+
+	var r replay.Replay
+	if err := r.UnmarshalBinary(sc.Replay); err != nil {
+		return err
+	}
+	// more lives or a target would make any score easy
+	if !r.Rules.Default() {
+		return errors.New("replays are only verified under the default rules")
+	}
+
+	return replay.Verify(&r, sc.Score)
+}
+
+func (s *server) handleList(w http.ResponseWriter, r *http.Request) {
+	q := leaderboard.Query{
+		Game: r.URL.Query().Get("game"),
+		Mode: r.URL.Query().Get("mode"),
+		Day:  r.URL.Query().Get("day"),
+	}
+
+	err := validateBoard(q.Game, q.Mode)
+	if err == nil && q.Day != "" {
+		if _, perr := time.Parse(leaderboard.DayFormat, q.Day); perr != nil {
+			err = errors.New("invalid day, expected YYYY-MM-DD")
+		}
+	}
+	if err == nil {
+		q.Offset, err = httpapi.IntParam(r, "offset", 0)
+	}
+	if err == nil {
+		q.Limit, err = httpapi.LimitParam(r, defaultLimit, maxLimit)
+	}
+	if err != nil {
+		httpapi.WriteError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	page, err := s.store.List(r.Context(), q)
+	if err != nil {
+		log.Printf("list: %v", err)
+		httpapi.WriteError(w, http.StatusInternalServerError, "internal error")
+		return
+	}
+	if page.Entries == nil {
+		page.Entries = []leaderboard.Entry{}
+	}
+	if q.Offset+len(page.Entries) < page.Total {
+		page.Next = q.Offset + len(page.Entries)
+	}
+
+	httpapi.WriteJSON(w, http.StatusOK, page)
+}
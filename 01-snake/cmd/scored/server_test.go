@@ -0,0 +1,99 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"jhartman.pl/gamedev/pkg/bot"
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/leaderboard"
+	"jhartman.pl/gamedev/pkg/replay"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// recorded plays a run under the default rules, the A* bot eating until
+// the snake is 6 long, then it turns left every step until it bites
+// itself, and returns its replay.
+func recorded(t *testing.T) *replay.Replay {
+	t.Helper()
+	const seed = 7
+	rec := replay.NewRecorder(seed)
+	g := snake.New(seed)
+	var b bot.AStar
+	for g.State == snake.RUNNING && rec.Steps() < replay.MaxSteps {
+		d := input.DirOf(g.Direction.Y, -g.Direction.X)
+		if g.Snake.Len() < 6 {
+			d = b.Observe(g)
+		}
+		if d != input.None {
+			g.Turn(d.Delta())
+			rec.Turn(input.DirOf(g.Direction.X, g.Direction.Y))
+		}
+		g.Step()
+		rec.Step(g)
+	}
+	if g.State != snake.CRASHED {
+		t.Fatalf("the run ended in state %d", g.State)
+	}
+	return rec.Replay()
+}
+
+func TestVerifyReplay(t *testing.T) {
+	r := recorded(t)
+	marshal := func(r *replay.Replay) []byte {
+		data, err := r.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+	data := marshal(r)
+
+	tampered := append([]byte(nil), data...)
+	tampered[len(tampered)/2] ^= 0xff
+	livesR := *r
+	livesR.Rules.Lives = 3
+	// claims 10 more points, the hashes proving the run being thrown away
+	boosted := *r
+	boosted.Score += 10
+	boosted.Hashes = nil
+
+	for _, tc := range []struct {
+		name    string
+		game    string
+		score   int
+		replay  []byte
+		wantErr string
+	}{
+		{"valid", "snake", r.Score, data, ""},
+		{"score not matching", "snake", r.Score + 1, data, "doesn't match the claimed"},
+		{"score of the replay boosted", "snake", boosted.Score, marshal(&boosted), "doesn't match the claimed"},
+		{"tampered", "snake", r.Score, tampered, "replay:"},
+		{"truncated", "snake", r.Score, data[:len(data)/2], "replay:"},
+		{"empty", "snake", r.Score, nil, "not a replay"},
+		{"more lives", "snake", r.Score, marshal(&livesR), "default rules"},
+		{"other game", "tetris", r.Score, data, "only supported for snake"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			err := verifyReplay(leaderboard.Score{Game: tc.game, Mode: "classic", Name: "jh", Score: tc.score, Replay: tc.replay})
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Fatalf("got %v, want no error", err)
+			case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+				t.Errorf("got %v, want an error with %q", err, tc.wantErr)
+			}
+		})
+	}
+}
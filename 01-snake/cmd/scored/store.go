@@ -0,0 +1,111 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"cmp"
+	"context"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/leaderboard"
+)
+
+// store keeps the submitted scores.
+type store interface {
+	Add(ctx context.Context, s leaderboard.Score, at time.Time, verified bool) (leaderboard.Submitted, error)
+	List(ctx context.Context, q leaderboard.Query) (leaderboard.Page, error)
+	Close() error
+}
+
+// sqlDriver is the database/sql driver used for the database, set when one
+// is compiled in (see sqlite.go).
+var sqlDriver string
+
+func openStore(path string) (store, error) {
+	if sqlDriver == "" {
+		log.Print("built without the sqlite tag, scores are kept in memory")
+		return &memStore{}, nil
+	}
+	return openSQLStore(sqlDriver, path)
+}
+
+type record struct {
+	leaderboard.Entry
+	game string
+	mode string
+	day  string
+}
+
+// memStore keeps the scores in memory, they are lost on restart.
+type memStore struct {
+	mu      sync.Mutex
+	records []record
+}
+
+func (m *memStore) Add(ctx context.Context, s leaderboard.Score, at time.Time, verified bool) (leaderboard.Submitted, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := int64(len(m.records) + 1)
+	m.records = append(m.records, record{
+		Entry: leaderboard.Entry{
+			ID:        id,
+			Name:      s.Name,
+			Score:     s.Score,
+			CreatedAt: at,
+			Verified:  verified,
+		},
+		game: s.Game,
+		mode: s.Mode,
+		day:  at.Format(leaderboard.DayFormat),
+	})
+
+	rank := 1
+	for _, r := range m.records {
+		if r.game == s.Game && r.mode == s.Mode && r.Score > s.Score {
+			rank++
+		}
+	}
+
+	return leaderboard.Submitted{ID: id, Rank: rank}, nil
+}
+
+func (m *memStore) List(ctx context.Context, q leaderboard.Query) (leaderboard.Page, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var board []leaderboard.Entry
+	for _, r := range m.records {
+		if r.game == q.Game && r.mode == q.Mode && (q.Day == "" || r.day == q.Day) {
+			board = append(board, r.Entry)
+		}
+	}
+
+	// best first, older first among equal scores
+	slices.SortFunc(board, func(a, b leaderboard.Entry) int {
+		return cmp.Or(cmp.Compare(b.Score, a.Score), cmp.Compare(a.ID, b.ID))
+	})
+
+	page := leaderboard.Page{Total: len(board)}
+	if q.Offset < len(board) {
+		page.Entries = board[q.Offset:min(q.Offset+q.Limit, len(board))]
+	}
+	return page, nil
+}
+
+func (m *memStore) Close() error {
+	return nil
+}
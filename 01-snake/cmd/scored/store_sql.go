@@ -0,0 +1,151 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/leaderboard"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS scores (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	game       TEXT    NOT NULL,
+	mode       TEXT    NOT NULL,
+	name       TEXT    NOT NULL,
+	score      INTEGER NOT NULL,
+	day        TEXT    NOT NULL,
+	created_at INTEGER NOT NULL,
+	verified   INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX IF NOT EXISTS scores_board ON scores (game, mode, score DESC);
+CREATE INDEX IF NOT EXISTS scores_daily ON scores (game, mode, day, score DESC);
+`
+
+// sqlStore keeps the scores in an SQLite database.
+type sqlStore struct {
+	db *sql.DB
+}
+
+func openSQLStore(driver, path string) (*sqlStore, error) {
+	db, err := sql.Open(driver, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows a single writer anyway
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &sqlStore{db: db}, nil
+}
+
+// migrate adds the columns databases created by older versions lack.
+func migrate(db *sql.DB) error {
+	rows, err := db.Query(`SELECT name FROM pragma_table_info('scores')`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	columns := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return err
+		}
+		columns[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if !columns["verified"] {
+		_, err = db.Exec(`ALTER TABLE scores ADD COLUMN verified INTEGER NOT NULL DEFAULT 0`)
+	}
+	return err
+}
+
+func (s *sqlStore) Add(ctx context.Context, sc leaderboard.Score, at time.Time, verified bool) (leaderboard.Submitted, error) {
+	var res leaderboard.Submitted
+
+	r, err := s.db.ExecContext(ctx,
+		`INSERT INTO scores (game, mode, name, score, day, created_at, verified) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+		sc.Game, sc.Mode, sc.Name, sc.Score, at.Format(leaderboard.DayFormat), at.UnixMilli(), verified)
+	if err != nil {
+		return res, err
+	}
+
+	if res.ID, err = r.LastInsertId(); err != nil {
+		return res, err
+	}
+
+	err = s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) + 1 FROM scores WHERE game = ? AND mode = ? AND score > ?`,
+		sc.Game, sc.Mode, sc.Score).Scan(&res.Rank)
+
+	return res, err
+}
+
+func (s *sqlStore) List(ctx context.Context, q leaderboard.Query) (leaderboard.Page, error) {
+	var page leaderboard.Page
+
+	where := `WHERE game = ? AND mode = ?`
+	args := []any{q.Game, q.Mode}
+	if q.Day != "" {
+		where += ` AND day = ?`
+		args = append(args, q.Day)
+	}
+
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM scores `+where, args...).Scan(&page.Total)
+	if err != nil {
+		return page, err
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, name, score, created_at, verified FROM scores `+where+` ORDER BY score DESC, id ASC LIMIT ? OFFSET ?`,
+		append(args, q.Limit, q.Offset)...)
+	if err != nil {
+		return page, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			e  leaderboard.Entry
+			ms int64
+		)
+		if err := rows.Scan(&e.ID, &e.Name, &e.Score, &ms, &e.Verified); err != nil {
+			return page, err
+		}
+		e.CreatedAt = time.UnixMilli(ms).UTC()
+		page.Entries = append(page.Entries, e)
+	}
+
+	return page, rows.Err()
+}
+
+func (s *sqlStore) Close() error {
+	return s.db.Close()
+}
@@ -0,0 +1,210 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command simulate plays many games headlessly with one of the bots, on all
+// CPU cores, and reports how they went: the score distribution, the length
+// of the snake and why the games ended. Useful to see what a rule change
+// does to the game.
+//
+//	go run ./cmd/simulate -bot astar -n 10000
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/bot"
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+var (
+	botName  = flag.String("bot", "astar", "bot playing the games: "+fmt.Sprint(bot.Names()))
+	n        = flag.Int("n", 1000, "number of games")
+	seed     = flag.Uint64("seed", 1, "seed of the first game, the others follow")
+	maxSteps = flag.Int("max-steps", 100_000, "steps after which a game is stopped")
+	workers  = flag.Int("workers", runtime.NumCPU(), "games played in parallel")
+)
+
+// why a game ended
+const (
+	causeTrapped = iota // crashed without a safe move left
+	causeBlunder        // crashed although there was a safe move
+	causeTimeout        // reached -max-steps
+	causeWon            // filled the board
+	numCauses
+)
+
+var causeNames = [numCauses]string{"trapped", "blunder", "timeout", "won"}
+
+type result struct {
+	score  int
+	length int
+	steps  int
+	cause  int
+}
+
+func play(b bot.Controller, seed uint64) result {
+	g := snake.New(seed)
+
+	for step := range *maxSteps {
+		trapped := !hasSafeMove(g)
+
+		if d := b.Observe(g); d != input.None {
+			g.Turn(d.Delta())
+		}
+		g.Step()
+
+		if g.State != snake.RUNNING {
+			r := result{score: g.Score, length: g.Snake.Len(), steps: step + 1, cause: causeBlunder}
+			if trapped {
+				r.cause = causeTrapped
+			}
+			if g.State == snake.WON {
+				r.cause = causeWon
+			}
+			return r
+		}
+	}
+
+	return result{score: g.Score, length: g.Snake.Len(), steps: *maxSteps, cause: causeTimeout}
+}
+
+func hasSafeMove(g *snake.Game) bool {
+	for _, d := range input.Dirs {
+		x, y := d.Delta()
+		if g.Reverses(x, y) {
+			continue
+		}
+		if !g.Blocked(g.Ahead(x, y)) {
+			return true
+		}
+	}
+	return false
+}
+
+func main() {
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("simulate: ")
+
+	if _, err := bot.ByName(*botName); err != nil {
+		log.Fatal(err)
+	}
+	if *n <= 0 || *workers <= 0 || *maxSteps <= 0 {
+		log.Fatal("-n, -workers and -max-steps must be positive")
+	}
+
+	start := time.Now()
+
+	results := make([]result, *n)
+	seeds := make(chan int)
+
+	var wg sync.WaitGroup
+	for range *workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			// bots may keep state, one per worker
+			b, _ := bot.ByName(*botName)
+			for i := range seeds {
+				results[i] = play(b, *seed+uint64(i))
+			}
+		}()
+	}
+	for i := range *n {
+		seeds <- i
+	}
+	close(seeds)
+	wg.Wait()
+
+	report(results, time.Since(start))
+}
+
+func report(results []result, took time.Duration) {
+	scores := make([]int, len(results))
+	var length, steps, score int
+	var causes [numCauses]int
+	for i, r := range results {
+		scores[i] = r.score
+		score += r.score
+		length += r.length
+		steps += r.steps
+		causes[r.cause]++
+	}
+	slices.Sort(scores)
+
+	n := float64(len(results))
+	fmt.Printf("%d games by %s in %v (%.0f steps/s)\n\n", len(results), *botName,
+		took.Round(time.Millisecond), float64(steps)/took.Seconds())
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "score\tmean %.2f\tmin %d\tp10 %d\tp50 %d\tp90 %d\tmax %d\n",
+		float64(score)/n, scores[0], percentile(scores, 10), percentile(scores, 50),
+		percentile(scores, 90), scores[len(scores)-1])
+	fmt.Fprintf(w, "length\tmean %.2f\n", float64(length)/n)
+	fmt.Fprintf(w, "steps\tmean %.1f\n", float64(steps)/n)
+	w.Flush()
+
+	fmt.Println("\nscore distribution")
+	histogram(scores)
+
+	fmt.Println("\nend of the games")
+	w = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for c, count := range causes {
+		fmt.Fprintf(w, "%s\t%d\t%.1f%%\t\n", causeNames[c], count, float64(count)/n*100)
+	}
+	w.Flush()
+}
+
+// percentile of sorted values, nearest rank.
+func percentile(sorted []int, p int) int {
+	i := (len(sorted)*p + 99) / 100
+	return sorted[max(i-1, 0)]
+}
+
+// histogram prints the sorted scores in ten buckets.
+func histogram(sorted []int) {
+	const buckets, width = 10, 50
+
+	lo, hi := sorted[0], sorted[len(sorted)-1]
+	size := max((hi-lo+buckets)/buckets, 1)
+
+	counts := make([]int, buckets)
+	for _, s := range sorted {
+		counts[min((s-lo)/size, buckets-1)]++
+	}
+	most := slices.Max(counts)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight)
+	for i, c := range counts {
+		from := lo + i*size
+		if from > hi {
+			break
+		}
+		bar := make([]rune, c*width/most)
+		for j := range bar {
+			bar[j] = '█'
+		}
+		fmt.Fprintf(w, "%d-%d\t%d\t %s\n", from, from+size-1, c, string(bar))
+	}
+	w.Flush()
+}
@@ -0,0 +1,183 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command stack runs the self-hosted server stack with Docker Compose: the
+// leaderboard server (cmd/scored) with its database on a persistent volume,
+// behind a Caddy reverse proxy terminating TLS.
+//
+// The Dockerfile, compose file and proxy config are embedded in the binary
+// and written out before every command, so they always match the source.
+// Run it from the module root, e.g.:
+//
+//	go run ./cmd/stack up
+//	go run ./cmd/stack -domain scores.example.com up
+//	go run ./cmd/stack logs scored
+//	go run ./cmd/stack down
+package main
+
+import (
+	"bytes"
+	"embed"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"text/template"
+)
+
+//go:embed deploy
+var deploy embed.FS
+
+var (
+	dir    = flag.String("dir", "dist/stack", "directory the configuration is written to")
+	domain = flag.String("domain", "", "public domain of the stack (default: localhost)")
+)
+
+// commands maps the stack commands to docker compose arguments, extra
+// arguments are appended.
+var commands = map[string][]string{
+	"build": {"build"},
+	"up":    {"up", "--build", "--detach"},
+	"down":  {"down"},
+	"logs":  {"logs", "--follow"},
+	"ps":    {"ps"},
+}
+
+func usage() {
+	fmt.Fprintf(flag.CommandLine.Output(), `usage: stack [flags] command [args]
+
+commands:
+  config  only write the configuration
+  build   build the images
+  up      build and start the stack in the background
+  down    stop the stack, volumes are kept
+  logs    follow the logs, optionally of the given services
+  ps      list the running services
+
+flags:
+`)
+	flag.PrintDefaults()
+}
+
+// params fill the compose file template.
+type params struct {
+	Context    string // module root, relative to the compose file
+	Dockerfile string // relative to Context
+}
+
+// writeConfig writes the embedded configuration to dir.
+func writeConfig(dir string) error {
+	root, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(filepath.Join(root, "go.mod")); err != nil {
+		return fmt.Errorf("run stack from the module root: %w", err)
+	}
+
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	context, err := filepath.Rel(abs, root)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(root, abs)
+	if err != nil {
+		return err
+	}
+	p := params{
+		Context:    filepath.ToSlash(context),
+		Dockerfile: path.Join(filepath.ToSlash(rel), "scored.Dockerfile"),
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	return fs.WalkDir(deploy, "deploy", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := deploy.ReadFile(name)
+		if err != nil {
+			return err
+		}
+
+		if path.Base(name) == "compose.yaml" {
+			t, err := template.New(name).Parse(string(data))
+			if err != nil {
+				return err
+			}
+			var buf bytes.Buffer
+			if err := t.Execute(&buf, p); err != nil {
+				return err
+			}
+			data = buf.Bytes()
+		}
+
+		return os.WriteFile(filepath.Join(dir, path.Base(name)), data, 0o644)
+	})
+}
+
+func main() {
+	flag.Usage = usage
+	flag.Parse()
+
+	log.SetFlags(0)
+	log.SetPrefix("stack: ")
+
+	if flag.NArg() < 1 {
+		usage()
+		os.Exit(2)
+	}
+
+	if err := writeConfig(*dir); err != nil {
+		log.Fatal(err)
+	}
+
+	cmd := flag.Arg(0)
+	if cmd == "config" {
+		log.Printf("configuration written to %s", *dir)
+		return
+	}
+
+	args, ok := commands[cmd]
+	if !ok {
+		log.Printf("unknown command %q", cmd)
+		usage()
+		os.Exit(2)
+	}
+
+	args = append([]string{"compose", "--file", filepath.Join(*dir, "compose.yaml")}, args...)
+	args = append(args, flag.Args()[1:]...)
+
+	c := exec.Command("docker", args...)
+	c.Stdin, c.Stdout, c.Stderr = os.Stdin, os.Stdout, os.Stderr
+	c.Env = os.Environ()
+	if *domain != "" {
+		c.Env = append(c.Env, "DOMAIN="+*domain)
+	}
+
+	if err := c.Run(); err != nil {
+		if exit, ok := err.(*exec.ExitError); ok {
+			os.Exit(exit.ExitCode())
+		}
+		log.Fatal(err)
+	}
+}
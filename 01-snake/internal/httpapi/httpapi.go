@@ -0,0 +1,169 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package httpapi holds what the game servers, cmd/scored and cmd/mapsd,
+// share: telling the clients apart and limiting their rate, the parsing of
+// the queries and the JSON answers.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Clients tells the clients of a server apart, to limit their requests.
+type Clients struct {
+	// BehindProxy takes the address of the client from X-Forwarded-For, as
+	// set by a reverse proxy.
+	BehindProxy bool
+	// Limited, unless nil, is called with each request refused by Limit.
+	Limited func(r *http.Request)
+}
+
+// Addr identifies the client making r.
+func (c Clients) Addr(r *http.Request) string {
+	if c.BehindProxy {
+		// the proxy appends the address it saw last
+		if v := r.Header.Get("X-Forwarded-For"); v != "" {
+			parts := strings.Split(v, ",")
+			return strings.TrimSpace(parts[len(parts)-1])
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Limit serves h to the clients l allows, telling the others to retry in a
+// minute.
+func (c Clients) Limit(l *Limiter, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !l.Allow(c.Addr(r), time.Now()) {
+			if c.Limited != nil {
+				c.Limited(r)
+			}
+			w.Header().Set("Retry-After", "60")
+			WriteError(w, http.StatusTooManyRequests, "too many requests")
+			return
+		}
+		h(w, r)
+	}
+}
+
+// Limiter is a token bucket per client: each one can make burst requests at
+// once, refilled at the configured rate.
+type Limiter struct {
+	rate  float64 // tokens per second
+	burst float64
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter allows perMinute requests per minute, all of them at once at
+// most.
+func NewLimiter(perMinute float64) *Limiter {
+	return &Limiter{
+		rate:    perMinute / 60,
+		burst:   max(perMinute, 1),
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token of the client key at now, false if it has none left.
+func (l *Limiter) Allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// sweep forgets clients whose bucket has been refilled completely, which is
+// the same as not knowing them. Must be called with mu held.
+func (l *Limiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for k, b := range l.buckets {
+		if b.tokens+now.Sub(b.last).Seconds()*l.rate >= l.burst {
+			delete(l.buckets, k)
+		}
+	}
+}
+
+// WriteJSON answers with v encoded as JSON.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// WriteError answers with msg as {"error": msg}.
+func WriteError(w http.ResponseWriter, status int, msg string) {
+	WriteJSON(w, status, map[string]string{"error": msg})
+}
+
+// LimitParam parses the optional limit query parameter, the size of a page:
+// def if missing, max at most. 0 is invalid, as a page of nothing.
+func LimitParam(r *http.Request, def, max int) (int, error) {
+	n, err := IntParam(r, "limit", def)
+	if err != nil || n == 0 {
+		return 0, errors.New("invalid limit")
+	}
+	return min(n, max), nil
+}
+
+// IntParam parses an optional non-negative integer query parameter.
+func IntParam(r *http.Request, name string, def int) (int, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		return 0, errors.New("invalid " + name)
+	}
+	return n, nil
+}
@@ -14,272 +14,446 @@ package main
 
 import (
 	"bytes"
+	"context"
+	"embed"
+	"errors"
+	"flag"
 	"fmt"
-	"image/color"
+	"image"
+	_ "image/png"
 	"log"
-	"math"
 	"math/rand/v2"
-	"slices"
+	"os"
+	"time"
 
 	"github.com/hajimehoshi/ebiten/v2"
-	"github.com/hajimehoshi/ebiten/v2/examples/resources/fonts"
-	"github.com/hajimehoshi/ebiten/v2/text/v2"
-	"github.com/hajimehoshi/ebiten/v2/vector"
+	"jhartman.pl/gamedev/pkg/bot"
+	"jhartman.pl/gamedev/pkg/cloudsync"
+	"jhartman.pl/gamedev/pkg/display"
+	"jhartman.pl/gamedev/pkg/maps"
+	"jhartman.pl/gamedev/pkg/presence"
+	"jhartman.pl/gamedev/pkg/scene"
+	"jhartman.pl/gamedev/pkg/settings"
+	"jhartman.pl/gamedev/pkg/snake"
+	"jhartman.pl/gamedev/pkg/snakegame"
+	"jhartman.pl/gamedev/pkg/stats"
+	"jhartman.pl/gamedev/pkg/twitch"
 )
 
-const (
-	screenWidth  = 320
-	screenHeight = 240
-	boardWidth   = screenWidth/boxSize - 2
-	boardHeight  = screenHeight/boxSize - 2
-	boxSize      = 8
-	speed        = math.MaxUint8 / 8
-)
-
-const (
-	RUNNING = iota
-	CRASHED
-	CRASHING
-)
+// version is set at build time, see cmd/gamepack
+var version = "dev"
 
-type Point struct {
-	x int
-	y int
-}
+//go:embed assets/icon.png
+var icon []byte
 
-type Game struct {
-	snake     []*Point
-	food      *Point
-	offscreen *ebiten.Image
-	direction *Point
-	color     float32
-	score     int
-	state     int
-	frame     uint32
-}
+// the mazes played with -mazes, one after the other
+//
+//go:embed levels
+var mazeFiles embed.FS
 
-var (
-	mplusFaceSource *text.GoTextFaceSource
-	mplusNormalFace *text.GoTextFace
-	mplusBigFace    *text.GoTextFace
-)
+func main() {
+	// settings are only the defaults, flags override them for this run
+	s, err := settings.Load()
+	if err != nil {
+		log.Printf("settings: %v", err)
+	}
 
-func init() {
-	s, err := text.NewGoTextFaceSource(bytes.NewReader(fonts.MPlus1pRegular_ttf))
+	showVersion := flag.Bool("version", false, "print version and exit")
+	flag.BoolVar(&s.DiscordPresence, "presence", s.DiscordPresence, "show the game status in Discord")
+	flag.StringVar(&s.DiscordAppID, "discord-app-id", s.DiscordAppID, "Discord application ID used for the presence")
+	flag.StringVar(&s.Monitor, "monitor", s.Monitor, "name or number of the monitor to open on, see -monitors")
+	flag.BoolVar(&s.RememberWindow, "remember-window", s.RememberWindow, "open the window where it was left the last time")
+	listMonitors := flag.Bool("monitors", false, "list the monitors and exit")
+	flag.StringVar(&s.DisplayMode, "display", s.DisplayMode, "display mode: windowed, fullscreen or borderless (default: from the preset)")
+	flag.IntVar(&s.TPS, "tps", s.TPS, "game updates per second, higher lowers the input latency")
+	flag.StringVar(&s.Difficulty, "difficulty", s.Difficulty, "pace of the snake: easy, normal or hard (default: normal)")
+	flag.Float64Var(&s.Speed, "speed", s.Speed, "cells per second the snake moves, e.g. 7.5, instead of the difficulty's")
+	flag.BoolVar(&s.SpeedUp, "speed-up", s.SpeedUp, "make the snake faster every few points")
+	level := flag.String("level", "", "start on this level: Box, Pillars, Cross, Corridors or a downloaded map (default: no walls)")
+	playMazes := flag.Bool("mazes", false, "play the mazes in levels/ one after the other")
+	playCampaign := flag.Bool("campaign", false, "play the campaign, levels of growing quotas and speeds")
+	mazeTarget := flag.Int("maze-target", 10, "score moving on to the next maze with -mazes")
+	seed := flag.Uint64("seed", 0, "seed the boards follow from, the same runs for the same moves; 0 for a random one, logged to play it again")
+	boardWidth := flag.Int("width", snake.BoardWidth+1, "columns of the board, the window growing to fit")
+	boardHeight := flag.Int("height", snake.BoardHeight+1, "rows of the board, the window growing to fit")
+	cellSize := flag.Int("cell", snakegame.DefaultCellSize, "size of a cell of the board in pixels, 4 to 32")
+	winLength := flag.Int("win-length", 0, "length winning a run, 0 to win only filling the board")
+	lives := flag.Int("lives", 1, "crashes a run takes, the snake coming back at the start until the last")
+	growth := flag.Int("growth", 1, "segments each food adds, one a step")
+	chase := flag.Bool("chaser", false, "bring an enemy hunting the head once the score reaches 30")
+	mirror := flag.Bool("mirror", false, "swap the controls, left for right and up for down")
+	hardcore := flag.Bool("hardcore", false, "a single life and no rewind, the high scores only taking a new best")
+	arenaName := flag.String("arena", "", "shape of the board, the cells out of it being solid: rect, circle, cross or donut (default: rect)")
+	combos := flag.Bool("combo", false, "multiply the points of the food eaten quickly one after the other")
+	modeName := flag.String("mode", "", "rules of the runs: classic (solid border, steady pace), endless (wrapping border, faster and faster), time-attack (2 minutes to score), zen (no crashes, no score), daily (the day's board, the same for everyone) or fog (classic, seeing only near the head); without it, picked on the start screen of a single player run, or none for a level, the mazes, the campaign, a bot, Twitch or a replay")
+	flag.StringVar(&s.Border, "border", s.Border, "what the edge of the board does to the snake: turn, solid (crash) or wrap (default: turn)")
+	flag.BoolVar(&s.Sprites, "sprites", s.Sprites, "draw the snake and the food with sprites")
+	flag.BoolVar(&s.Smooth, "smooth", s.Smooth, "slide the snake from cell to cell rather than jumping")
+	flag.StringVar(&s.Skin, "skin", s.Skin, "how the snake and the food are drawn: flat, sprites or a PNG sprite sheet of 4 by 4 tiles laid out like pkg/assets/sprites.png (default: flat, sprites with -sprites)")
+	flag.BoolVar(&s.Vsync, "vsync", s.Vsync, "sync drawing with the display's refresh rate")
+	flag.StringVar(&s.KeyboardLayout, "keyboard", s.KeyboardLayout, "keyboard layout: qwerty, azerty or qwertz (default: detected)")
+	flag.StringVar(&s.MapsServer, "maps-server", s.MapsServer, "URL of the server sharing community maps, see cmd/mapsd")
+	flag.StringVar(&s.LeaderboardServer, "leaderboard", s.LeaderboardServer, "URL of the score server to submit the final scores to and show the global top 10 of, see cmd/scored")
+	uploadMap := flag.String("upload-map", "", "share the map in this text file on the maps server and exit")
+	twitchChannel := flag.String("twitch", "", "let the chat of this Twitch channel steer the snake")
+	twitchMode := flag.String("twitch-mode", "vote", "how chat commands are applied: vote (majority per step) or queue")
+	presetName := flag.String("preset", "", "device preset: desktop or deck (default: detected)")
+	botName := flag.String("bot", "", "let a bot play: greedy, astar or hamiltonian")
+	players := flag.Int("players", 1, "players on the keyboard, 2 to race a friend steering with WASD")
+	rival := flag.Bool("rival", false, "race a computer snake for the food")
+	royale := flag.Int("royale", 0, "battle royale against this many computer snakes, 3 to 6, on a larger board")
+	rivalDepth := flag.Int("rival-depth", bot.DefaultRivalDepth, "moves the computer snakes look ahead with -rival and -royale, lower is easier")
+	showLatency := flag.Bool("latency", false, "show the input latency overlay (toggle with F3)")
+	speedrun := flag.Bool("speedrun", false, "show a timer of the run split every 10 foods, the final time going to the statistics")
+	rewind := flag.Bool("rewind", true, "let the player hold Backspace after a crash to go back a few seconds, 3 times a run")
+	showGhost := flag.Bool("ghost", true, "show the best run on the same board and rules as a ghost snake going along")
+	replayFile := flag.String("replay", "", "play back the run in this .replay file, see the replays directory next to the settings")
+	exportDir := flag.String("export-stats", "", "export the statistics to this directory and exit")
+	sync := flag.Bool("sync", true, "sync the save, settings and statistics with the cloud storage configured in the settings")
+	flag.Parse()
+
+	if *players != 1 && *players != 2 {
+		log.Fatalf("-players must be 1 or 2, got %d", *players)
+	}
+	if *players == 2 && *rival {
+		log.Fatal("-rival takes the place of the second player, it can't be combined with -players 2")
+	}
+	if *royale != 0 && (*royale < snakegame.MinRoyaleRivals || *royale > snakegame.MaxRoyaleRivals) {
+		log.Fatalf("-royale must be %d to %d, got %d", snakegame.MinRoyaleRivals, snakegame.MaxRoyaleRivals, *royale)
+	}
+	if *royale != 0 && (*players == 2 || *rival) {
+		log.Fatal("-royale can't be combined with -players 2 or -rival")
+	}
+	if (*players == 2 || *rival || *royale != 0) && (*botName != "" || *twitchChannel != "" || *level != "" || *playMazes || *playCampaign) {
+		log.Fatal("-players 2, -rival and -royale can't be combined with -bot, -twitch, -level, -mazes or -campaign")
+	}
+	if *replayFile != "" && (*players == 2 || *rival || *royale != 0 || *botName != "" || *twitchChannel != "" || *level != "" || *playMazes || *playCampaign) {
+		log.Fatal("-replay can't be combined with -players 2, -rival, -royale, -bot, -twitch, -level, -mazes or -campaign")
+	}
+	if min(*boardWidth, *boardHeight)-1 < snake.MinBoardSize || max(*boardWidth, *boardHeight)-1 > snake.MaxBoardSize {
+		log.Fatalf("-width and -height must be %d to %d, got %d and %d", snake.MinBoardSize+1, snake.MaxBoardSize+1, *boardWidth, *boardHeight)
+	}
+	if *cellSize < 4 || *cellSize > 32 {
+		log.Fatalf("-cell must be 4 to 32, got %d", *cellSize)
+	}
+	if w, h := *boardWidth**cellSize+8, *boardHeight**cellSize+8; w < snakegame.ScreenWidth || h < snakegame.ScreenHeight {
+		log.Fatalf("-width and -height by -cell must make a screen of at least %dx%d pixels for the HUD, got %dx%d", snakegame.ScreenWidth, snakegame.ScreenHeight, w, h)
+	}
+	resized := *boardWidth != snake.BoardWidth+1 || *boardHeight != snake.BoardHeight+1
+	if resized && (*players == 2 || *rival || *royale != 0 || *botName != "" || *level != "" || *playMazes || *playCampaign || *replayFile != "") {
+		log.Fatal("-width and -height can't be combined with -players 2, -rival, -royale, -bot, -level, -mazes, -campaign or -replay")
+	}
+	if *playCampaign && (*level != "" || *playMazes) {
+		log.Fatal("-campaign can't be combined with -level or -mazes")
+	}
+	gameMode, err := snakegame.GameModeByName(*modeName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if gameMode != snakegame.FreeMode && gameMode != snakegame.ClassicMode && (*players == 2 || *rival || *royale != 0 || *playCampaign) {
+		log.Fatal("-mode can't be combined with -players 2, -rival, -royale or -campaign")
+	}
+	if gameMode == snakegame.DailyMode && (resized || *level != "" || *playMazes || *arenaName != "") {
+		log.Fatal("-mode daily plays the classic board, it can't be combined with -width, -height, -level, -mazes or -arena")
+	}
+	if *rivalDepth < 0 {
+		log.Fatalf("-rival-depth can't be negative, got %d", *rivalDepth)
+	}
+	if *winLength < 0 {
+		log.Fatalf("-win-length can't be negative, got %d", *winLength)
+	}
+	if *lives < 1 {
+		log.Fatalf("-lives must be at least 1, got %d", *lives)
+	}
+	if *growth < 1 || *growth > 10 {
+		log.Fatalf("-growth must be 1 to 10, got %d", *growth)
+	}
+	if s.TPS <= 0 {
+		log.Fatalf("-tps must be positive, got %d", s.TPS)
+	}
+	if s.Speed < 0 || s.Speed > float64(s.TPS) {
+		log.Fatalf("-speed must be between 0 and the TPS, got %g", s.Speed)
+	}
+	border, err := snake.BorderByName(s.Border)
+	if err != nil {
+		log.Fatal(err)
+	}
+	arena, err := snake.ArenaByName(*arenaName)
+	if err != nil {
+		log.Fatal(err)
+	}
+	difficulty, err := snakegame.DifficultyByName(s.Difficulty)
 	if err != nil {
 		log.Fatal(err)
 	}
-	mplusFaceSource = s
 
-	mplusNormalFace = &text.GoTextFace{
-		Source: mplusFaceSource,
-		Size:   24,
+	if *showVersion {
+		fmt.Println(version)
+		return
 	}
-	mplusBigFace = &text.GoTextFace{
-		Source: mplusFaceSource,
-		Size:   32,
+
+	if *listMonitors {
+		for _, m := range display.Monitors() {
+			fmt.Println(m)
+		}
+		return
 	}
-}
 
-func (g *Game) handleKeyboard() {
-	switch {
-	case ebiten.IsKeyPressed(ebiten.KeyArrowUp) && g.direction.y != 1:
-		g.direction.x = 0
-		g.direction.y = -1
-	case ebiten.IsKeyPressed(ebiten.KeyArrowRight) && g.direction.x != -1:
-		g.direction.x = 1
-		g.direction.y = 0
-	case ebiten.IsKeyPressed(ebiten.KeyArrowDown) && g.direction.y != -1:
-		g.direction.x = 0
-		g.direction.y = 1
-	case ebiten.IsKeyPressed(ebiten.KeyArrowLeft) && g.direction.x != 1:
-		g.direction.x = -1
-		g.direction.y = 0
+	if *exportDir != "" {
+		st, err := stats.Load()
+		if err != nil {
+			log.Fatal(err)
+		}
+		files, err := st.Export(*exportDir)
+		for _, f := range files {
+			fmt.Println(f)
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
 	}
-}
 
-func (p *Point) String() string {
-	return fmt.Sprintf("[%d,%d]", p.x, p.y)
-}
+	if *uploadMap != "" {
+		if err := shareMap(s.MapsServer, *uploadMap); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
 
-func (g *Game) detectBorder(p *Point) {
-	if p.x+g.direction.x < 0 {
-		g.direction.x = 0
-		g.direction.y = -1
-	} else if p.x+g.direction.x > boardWidth {
-		g.direction.x = 0
-		g.direction.y = 1
+	if *sync && s.CloudSync != nil {
+		// before the game loads the files
+		syncFiles(s.CloudSync)
 	}
 
-	if p.y+g.direction.y < 0 {
-		g.direction.x = 1
-		g.direction.y = 0
-	} else if p.y+g.direction.y > boardHeight {
-		g.direction.x = -1
-		g.direction.y = 0
+	preset, err := snakegame.PresetByName(*presetName)
+	if err != nil {
+		log.Fatal(err)
 	}
-}
 
-func (g *Game) detectCollision(h *Point) bool {
-	for i := 1; i < len(g.snake); i++ {
-		p := g.snake[i]
-		if p.x == h.x && p.y == h.y {
-			return true
+	mode := display.Windowed
+	if preset.Fullscreen {
+		mode = display.Fullscreen
+	}
+	if s.DisplayMode != "" {
+		if mode, err = display.ParseMode(s.DisplayMode); err != nil {
+			log.Fatal(err)
 		}
 	}
 
-	return false
-}
+	img, _, err := image.Decode(bytes.NewReader(icon))
+	if err != nil {
+		log.Fatal(err)
+	}
 
-func (g *Game) Update() error {
-	g.handleKeyboard()
-
-	// new segment will be the last snake's tail
-	tail := &Point{
-		g.snake[len(g.snake)-1].x,
-		g.snake[len(g.snake)-1].y,
-	}
-
-	if uint16(g.color)+speed >= math.MaxUint8 {
-		switch g.state {
-		case RUNNING:
-			// update color (= sync)
-			// Snake
-			//
-			// Iterate backward (i.e. tail -> head) as the new segment
-			// position should be in the point where the predecesor (still) is
-			for i, v := range slices.Backward(g.snake) {
-				// head update
-				if i == 0 {
-					g.detectBorder(v)
-
-					v.x += g.direction.x
-					v.y += g.direction.y
-
-					// Grabbing the food? If so:
-					// - set a new peiece
-					// - append the new segment where the last tail was
-					if v.x == g.food.x && v.y == g.food.y {
-						g.setFood()
-						g.snake = append(g.snake, tail)
-						g.score += 1
-					}
-				} else {
-					v.x = g.snake[i-1].x
-					v.y = g.snake[i-1].y
-				}
-			}
-
-			// check for collision and reinit if needed
-			if g.detectCollision(g.snake[0]) {
-				g.state = CRASHED
-			}
-		case CRASHED:
-			g.score = 0
-			g.state = CRASHING
-
-		case CRASHING:
-			if len(g.snake) > 1 {
-				g.snake = g.snake[0 : len(g.snake)-1]
-			} else {
-				g.state = RUNNING
-			}
+	ebiten.SetWindowIcon([]image.Image{img})
+
+	// closed once the game is over, log.Fatal would skip deferred calls
+	var closers []func()
+
+	if *seed == 0 {
+		// from the OS entropy
+		*seed = rand.Uint64()
+		log.Printf("seed %d, run with -seed %[1]d to play the same boards again", *seed)
+	}
+	g := snakegame.NewGame(snakegame.WithSeed(*seed), snakegame.WithBoardSize(*boardWidth, *boardHeight), snakegame.WithCellSize(*cellSize))
+	g.ApplyPreset(preset)
+	if err := g.SetKeyboardLayout(s.KeyboardLayout); err != nil {
+		log.Fatal(err)
+	}
+	g.ShowLatency(*showLatency)
+	g.SetGhost(*showGhost)
+	g.SetRewind(*rewind)
+	g.SetSpeedrun(*speedrun)
+	g.SetDifficulty(difficulty)
+	if s.Speed > 0 {
+		g.SetSpeed(s.Speed)
+	}
+	g.SetBorder(border)
+	g.SetWinLength(*winLength)
+	g.SetLives(*lives)
+	g.SetCombos(*combos)
+	g.SetGrowth(*growth)
+	g.SetChase(*chase)
+	g.SetMirror(*mirror)
+	g.SetHardcore(*hardcore)
+	g.SetArena(arena)
+	if *modeName != "" {
+		g.SetMode(gameMode)
+		if s.Border != "" {
+			// a border asked for wins over the mode's
+			g.SetBorder(border)
+		}
+	} else if *players == 1 && !*rival && *royale == 0 && *level == "" && !*playMazes && !*playCampaign && *botName == "" && *twitchChannel == "" && *replayFile == "" {
+		g.ShowStartScreen()
+	}
+	if !s.SpeedUp {
+		g.SetSpeedUp(snakegame.SpeedUp{})
+	}
+	skin := s.Skin
+	if skin == "" && s.Sprites {
+		skin = snakegame.SpritesSkin
+	}
+	if err := g.SetSkin(skin); err != nil {
+		log.Fatal(err)
+	}
+	g.SetSmooth(s.Smooth)
+	if s.MapsServer != "" {
+		g.SetMapsServer(s.MapsServer)
+	}
+	if s.LeaderboardServer != "" {
+		g.SetLeaderboard(s.LeaderboardServer)
+	}
+	if *level != "" {
+		if err := g.SetLevel(*level); err != nil {
+			log.Fatal(err)
 		}
+	}
+	if *playMazes {
+		ms, err := maps.Load(mazeFiles, "levels")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := g.PlayMazes(ms, *mazeTarget); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *playCampaign {
+		if err := g.PlayCampaign(snakegame.Campaign()); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if *replayFile != "" {
+		r, err := snakegame.ReadReplay(*replayFile)
+		if err != nil {
+			log.Fatal(err)
+		}
+		g.PlayReplay(r)
+	}
 
-		g.color -= math.MaxUint8
+	if *players == 2 {
+		g.PlayVersus()
+	}
+	if *rival {
+		g.PlayRival(bot.Rival{Depth: *rivalDepth})
+	}
+	if *royale != 0 {
+		g.PlayRoyale(*royale, bot.Rival{Depth: *rivalDepth})
 	}
 
-	if g.state == CRASHING {
-		g.color += speed * 3
-	} else {
-		g.color += speed
+	if s.DiscordPresence && s.DiscordAppID != "" {
+		p := presence.New(s.DiscordAppID)
+		closers = append(closers, p.Close)
+
+		start := time.Now()
+		g.SetStatusFunc(func(status string) {
+			p.Set(presence.Activity{
+				Details: "Playing Snake",
+				State:   status,
+				Start:   start,
+			})
+		})
 	}
-	return nil
-}
 
-func (g *Game) Draw(screen *ebiten.Image) {
-	g.offscreen.Clear()
-
-	// board
-	vector.StrokeRect(g.offscreen, 2, 2, screenWidth-4, screenHeight-4, 2, color.Gray{200}, true)
-
-	// snake
-	for i, v := range slices.Backward(g.snake) {
-		var c color.Color
-
-		if i == 0 {
-			// head update
-			c = color.Gray{uint8(g.color)}
-		} else if i == len(g.snake)-1 && len(g.snake) > 1 {
-			// last tail section
-			c = color.Gray{math.MaxUint8 - uint8(g.color)}
-		} else {
-			// middle sections
-			c = color.Gray{uint8(math.Sin(float64(i+int(g.frame/30)))*64 + 128)}
+	if *twitchChannel != "" {
+		mode, err := twitch.ParseMode(*twitchMode)
+		if err != nil {
+			log.Fatal(err)
 		}
 
-		vector.DrawFilledRect(g.offscreen,
-			float32(5+v.x*boxSize),
-			float32(5+v.y*boxSize),
-			float32(boxSize-1),
-			float32(boxSize-1),
-			c,
-			true)
+		chat := twitch.New(*twitchChannel, mode)
+		closers = append(closers, chat.Close)
+
+		g.SetController(chat)
 	}
 
-	// food
-	vector.DrawFilledRect(g.offscreen,
-		float32(5+g.food.x*boxSize),
-		float32(5+g.food.y*boxSize),
-		float32(boxSize-1),
-		float32(boxSize-1),
-		color.RGBA{255, 0, 0, 0},
-		true)
+	if *botName != "" {
+		b, err := bot.ByName(*botName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		g.SetController(bot.Drive(b, g.Core))
+	}
 
-	// score
+	// a failing update shows what went wrong, then ends the game here
+	width, height := g.ScreenSize()
+	err = display.Run(scene.New(g), display.Options{
+		Title:         "Snake game",
+		Width:         preset.WindowWidth,
+		Height:        preset.WindowHeight,
+		LogicalWidth:  width,
+		LogicalHeight: height,
+		Monitor:       s.Monitor,
+		Mode:          mode,
+		TPS:           s.TPS,
+		Vsync:         s.Vsync,
+		Remember:      s.RememberWindow,
+	})
+
+	if err := g.Close(); err != nil {
+		log.Print(err)
+	}
+	for _, c := range closers {
+		c()
+	}
 
-	op := &text.DrawOptions{}
-	op.GeoM.Translate(5, 3)
+	if *sync && s.CloudSync != nil {
+		syncFiles(s.CloudSync)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
 
-	text.Draw(g.offscreen, fmt.Sprintf("Score: %d", g.score),
-		&text.GoTextFace{Source: mplusFaceSource, Size: 16},
-		op,
-	)
+// shareMap uploads the map in the text file to the maps server.
+func shareMap(server, path string) error {
+	if server == "" {
+		return errors.New("-upload-map needs -maps-server")
+	}
 
-	screen.DrawImage(g.offscreen, nil)
-	g.frame += 1
-}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	m, err := maps.ParseText(data)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
 
-func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
-	return screenWidth, screenHeight
-}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 
-func (g *Game) setFood() {
-	g.food.x = rand.IntN(boardWidth)
-	g.food.y = rand.IntN(boardHeight)
+	m, err = maps.New(server).Upload(ctx, m)
+	if err != nil {
+		return err
+	}
+	fmt.Println(m.ID)
+	return nil
 }
 
-func NewGame() ebiten.Game {
-	g := &Game{
-		offscreen: ebiten.NewImage(screenWidth, screenHeight),
-		snake: []*Point{
-			{boardHeight / 2, boardWidth / 2},
-		},
-		direction: &Point{1, 0},
-		food:      &Point{},
-		state:     RUNNING,
-		frame:     0,
+// syncFiles syncs the per user files, failures only mean playing with the
+// local ones.
+func syncFiles(cfg *settings.CloudSync) {
+	store, err := cloudsync.New(*cfg)
+	if err != nil {
+		log.Printf("sync: %v", err)
+		return
 	}
 
-	g.setFood()
-
-	return g
-}
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
 
-func main() {
-	ebiten.SetWindowSize(screenWidth*2, screenHeight*2)
-	ebiten.SetWindowTitle("Snake game")
-	if err := ebiten.RunGame(NewGame()); err != nil {
-		log.Fatal(err)
+	syncer := &cloudsync.Syncer{Store: store, Files: cloudsync.Files}
+	results, err := syncer.Sync(ctx)
+	for _, r := range results {
+		if r.Action != cloudsync.UpToDate {
+			log.Printf("sync: %v", r)
+		}
+		if r.Name == "settings.json" && r.Action == cloudsync.Pulled {
+			log.Printf("sync: the new settings apply from the next start")
+		}
+	}
+	if err != nil {
+		log.Printf("sync: %v", err)
 	}
 }
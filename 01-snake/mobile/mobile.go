@@ -0,0 +1,44 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mobile is the entry point for ebitenmobile bind. See README.md
+// for how to build the Android and iOS libraries.
+package mobile
+
+import (
+	"github.com/hajimehoshi/ebiten/v2/mobile"
+	"jhartman.pl/gamedev/pkg/snakegame"
+)
+
+var game = snakegame.NewGame()
+
+func init() {
+	mobile.SetGame(game)
+}
+
+// SetSafeArea is called by the host app whenever the safe area insets
+// (notches, rounded corners, system bars) change. Values are in
+// device-independent pixels (dp on Android, points on iOS).
+func SetSafeArea(top, right, bottom, left int) {
+	game.SetSafeArea(snakegame.Insets{
+		Top:    top,
+		Right:  right,
+		Bottom: bottom,
+		Left:   left,
+	})
+}
+
+// Dummy is a dummy exported function.
+//
+// gomobile doesn't compile a package that doesn't include any exported
+// function. Dummy forces gomobile to compile this package.
+func Dummy() {}
@@ -0,0 +1,145 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package achievements unlocks the goals a player reaches, from definitions
+// given as data so any game can bring its own. The game reports its
+// statistics as they change, the tracker tells which goals they reach and
+// keeps those unlocked on disk, next to the settings.
+//
+//	defs, err := achievements.Parse(data)
+//	t, err := achievements.Load("snake-achievements.json", defs)
+//	for _, a := range t.Report("length", 50, time.Now()) {
+//		show("Achievement unlocked: " + a.Name)
+//		t.Save()
+//	}
+package achievements
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/settings"
+)
+
+// Achievement is a goal: a statistic of the game reaching a value.
+type Achievement struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Stat is the statistic the game reports, Goal the value unlocking the
+	// achievement once reached.
+	Stat string  `json:"stat"`
+	Goal float64 `json:"goal"`
+}
+
+// Parse reads definitions from a JSON array, each with a unique ID, a name
+// and a statistic.
+func Parse(data []byte) ([]Achievement, error) {
+	var defs []Achievement
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return nil, fmt.Errorf("achievements: %w", err)
+	}
+
+	ids := make(map[string]bool, len(defs))
+	for i, a := range defs {
+		if a.ID == "" || a.Name == "" || a.Stat == "" {
+			return nil, fmt.Errorf("achievements: %d: the ID, name and statistic are required", i+1)
+		}
+		if ids[a.ID] {
+			return nil, fmt.Errorf("achievements: %s is defined twice", a.ID)
+		}
+		ids[a.ID] = true
+	}
+	return defs, nil
+}
+
+// Tracker unlocks the achievements as the statistics are reported.
+type Tracker struct {
+	defs []Achievement
+	// Unlocked are when the achievements were unlocked, by ID.
+	Unlocked map[string]time.Time
+	name     string
+}
+
+// Load reads the achievements unlocked from the file called name in
+// settings.Dir, none if there's no file yet. A damaged file is reported,
+// the tracker starting over without it.
+func Load(name string, defs []Achievement) (*Tracker, error) {
+	t := &Tracker{defs: defs, Unlocked: map[string]time.Time{}, name: name}
+
+	path, err := t.path()
+	if err != nil {
+		return t, err
+	}
+	data, err := settings.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return t, nil
+	} else if err != nil {
+		return t, err
+	}
+
+	var unlocked map[string]time.Time
+	if err := json.Unmarshal(data, &unlocked); err != nil {
+		return t, fmt.Errorf("achievements: %w", err)
+	}
+	for id, at := range unlocked {
+		t.Unlocked[id] = at
+	}
+	return t, nil
+}
+
+func (t *Tracker) path() (string, error) {
+	dir, err := settings.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, t.name), nil
+}
+
+// Save writes the achievements unlocked atomically.
+func (t *Tracker) Save() error {
+	path, err := t.path()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(t.Unlocked)
+	if err != nil {
+		return err
+	}
+	return settings.WriteFile(path, data)
+}
+
+// All returns the definitions, in their order.
+func (t *Tracker) All() []Achievement {
+	return t.defs
+}
+
+// Report tells the tracker the statistic stat is at value, returning the
+// achievements it unlocked at now, if any.
+func (t *Tracker) Report(stat string, value float64, now time.Time) []Achievement {
+	var unlocked []Achievement
+	for _, a := range t.defs {
+		if a.Stat != stat || value < a.Goal {
+			continue
+		}
+		if _, ok := t.Unlocked[a.ID]; ok {
+			continue
+		}
+		t.Unlocked[a.ID] = now
+		unlocked = append(unlocked, a)
+	}
+	return unlocked
+}
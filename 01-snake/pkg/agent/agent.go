@@ -0,0 +1,118 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package agent lets programs play the headless snake simulation: they send
+// actions and get observations of the board back. The messages are the ones
+// of agent.proto, with their protobuf encoding, so cmd/agentd can serve them
+// over gRPC to bots written in any language.
+package agent
+
+import (
+	"math/rand/v2"
+
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// Action is what the agent does next.
+type Action struct {
+	// Reset starts a new episode, without stepping.
+	Reset bool
+	// Seed of the new episode, random unless HasSeed.
+	Seed    uint64
+	HasSeed bool
+	// Dir to turn to before the next step, None keeps going.
+	Dir input.Dir
+}
+
+// Observation is the state of the episode after an action.
+type Observation struct {
+	Width, Height int
+	Snake         []snake.Point // head first
+	Food          snake.Point
+	Dir           input.Dir
+	Score         int
+	Step          uint64
+	Done          bool
+	Episode       uint64
+	Seed          uint64
+}
+
+// Session plays episodes one after the other.
+type Session struct {
+	game    *snake.Game
+	seed    uint64
+	step    uint64
+	episode uint64
+}
+
+// NewSession returns a session, the first episode starts with the first
+// action.
+func NewSession() *Session {
+	return &Session{}
+}
+
+func (s *Session) reset(seed uint64) {
+	s.game = snake.New(seed)
+	s.seed = seed
+	s.step = 0
+	s.episode++
+}
+
+// Apply performs a and observes the result. Steps once the episode is over
+// don't change anything, the agent has to reset.
+func (s *Session) Apply(a Action) Observation {
+	switch {
+	case a.Reset:
+		seed := a.Seed
+		if !a.HasSeed {
+			seed = rand.Uint64()
+		}
+		s.reset(seed)
+		return s.Observe()
+	case s.game == nil:
+		s.reset(rand.Uint64())
+	}
+
+	if s.game.State == snake.RUNNING {
+		if a.Dir != input.None {
+			s.game.Turn(a.Dir.Delta())
+		}
+		s.game.Step()
+		s.step++
+	}
+
+	return s.Observe()
+}
+
+// Observe returns the current state without acting.
+func (s *Session) Observe() Observation {
+	if s.game == nil {
+		s.reset(rand.Uint64())
+	}
+	g := s.game
+
+	o := Observation{
+		Width:   snake.BoardWidth,
+		Height:  snake.BoardHeight,
+		Snake:   g.Snake.Points(),
+		Food:    g.Food,
+		Dir:     input.DirOf(g.Direction.X, g.Direction.Y),
+		Score:   g.Score,
+		Step:    s.step,
+		Done:    g.State != snake.RUNNING,
+		Episode: s.episode,
+		Seed:    s.seed,
+	}
+
+	return o
+}
@@ -0,0 +1,249 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package agent
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// The messages are few and small, so they're encoded by hand instead of
+// pulling in protobuf and generated code. Field numbers follow agent.proto.
+
+// protobuf wire types
+const (
+	wireVarint = 0
+	wireI64    = 1
+	wireLen    = 2
+	wireI32    = 5
+)
+
+var errTruncated = errors.New("agent: truncated message")
+
+type encoder []byte
+
+func (e *encoder) tag(field, wire int) {
+	*e = binary.AppendUvarint(*e, uint64(field<<3|wire))
+}
+
+// uint writes a varint field, zero values are omitted as in proto3.
+func (e *encoder) uint(field int, v uint64) {
+	if v == 0 {
+		return
+	}
+	e.tag(field, wireVarint)
+	*e = binary.AppendUvarint(*e, v)
+}
+
+// int writes an int32 field, negative values take ten bytes.
+func (e *encoder) int(field int, v int) {
+	e.uint(field, uint64(int64(v)))
+}
+
+func (e *encoder) bool(field int, v bool) {
+	if v {
+		e.uint(field, 1)
+	}
+}
+
+func (e *encoder) bytes(field int, b []byte) {
+	e.tag(field, wireLen)
+	*e = binary.AppendUvarint(*e, uint64(len(b)))
+	*e = append(*e, b...)
+}
+
+// decode calls f for every field of data, with the value of varint fields
+// or the contents of length delimited ones. Other fields are skipped.
+func decode(data []byte, f func(field, wire int, v uint64, b []byte) error) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errTruncated
+		}
+		data = data[n:]
+
+		field, wire := int(key>>3), int(key&7)
+		if field == 0 {
+			return errors.New("agent: invalid field number 0")
+		}
+
+		var v uint64
+		var b []byte
+		switch wire {
+		case wireVarint:
+			v, n = binary.Uvarint(data)
+			if n <= 0 {
+				return errTruncated
+			}
+			data = data[n:]
+		case wireLen:
+			l, n := binary.Uvarint(data)
+			if n <= 0 || l > uint64(len(data)-n) {
+				return errTruncated
+			}
+			b = data[n : n+int(l)]
+			data = data[n+int(l):]
+		case wireI64, wireI32:
+			size := 8
+			if wire == wireI32 {
+				size = 4
+			}
+			if len(data) < size {
+				return errTruncated
+			}
+			data = data[size:]
+			continue
+		default:
+			return fmt.Errorf("agent: unsupported wire type %d", wire)
+		}
+
+		if err := f(field, wire, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func wantWire(field, wire, want int) error {
+	if wire != want {
+		return fmt.Errorf("agent: field %d has wire type %d, want %d", field, wire, want)
+	}
+	return nil
+}
+
+func (a *Action) MarshalBinary() ([]byte, error) {
+	var e encoder
+
+	e.bool(1, a.Reset)
+	if a.HasSeed {
+		// explicit presence, written even when zero
+		e.tag(2, wireVarint)
+		e = binary.AppendUvarint(e, a.Seed)
+	}
+	e.uint(3, uint64(a.Dir))
+
+	return e, nil
+}
+
+func (a *Action) UnmarshalBinary(data []byte) error {
+	*a = Action{}
+
+	return decode(data, func(field, wire int, v uint64, b []byte) error {
+		switch field {
+		case 1:
+			a.Reset = v != 0
+		case 2:
+			a.Seed, a.HasSeed = v, true
+		case 3:
+			// unknown enum values are kept by proto3, but we can't do
+			// anything with them
+			if v > uint64(input.Left) {
+				return fmt.Errorf("agent: invalid direction %d", v)
+			}
+			a.Dir = input.Dir(v)
+		default:
+			return nil
+		}
+		return wantWire(field, wire, wireVarint)
+	})
+}
+
+func marshalPoint(p snake.Point) []byte {
+	var e encoder
+	e.int(1, p.X)
+	e.int(2, p.Y)
+	return e
+}
+
+func unmarshalPoint(data []byte) (snake.Point, error) {
+	var p snake.Point
+
+	err := decode(data, func(field, wire int, v uint64, b []byte) error {
+		switch field {
+		case 1:
+			p.X = int(int32(v))
+		case 2:
+			p.Y = int(int32(v))
+		default:
+			return nil
+		}
+		return wantWire(field, wire, wireVarint)
+	})
+
+	return p, err
+}
+
+func (o *Observation) MarshalBinary() ([]byte, error) {
+	var e encoder
+
+	e.int(1, o.Width)
+	e.int(2, o.Height)
+	for _, p := range o.Snake {
+		e.bytes(3, marshalPoint(p))
+	}
+	e.bytes(4, marshalPoint(o.Food))
+	e.uint(5, uint64(o.Dir))
+	e.int(6, o.Score)
+	e.uint(7, o.Step)
+	e.bool(8, o.Done)
+	e.uint(9, o.Episode)
+	e.uint(10, o.Seed)
+
+	return e, nil
+}
+
+func (o *Observation) UnmarshalBinary(data []byte) error {
+	*o = Observation{}
+
+	return decode(data, func(field, wire int, v uint64, b []byte) error {
+		switch field {
+		case 3, 4:
+			if err := wantWire(field, wire, wireLen); err != nil {
+				return err
+			}
+			p, err := unmarshalPoint(b)
+			if err != nil {
+				return err
+			}
+			if field == 3 {
+				o.Snake = append(o.Snake, p)
+			} else {
+				o.Food = p
+			}
+			return nil
+		case 1:
+			o.Width = int(int32(v))
+		case 2:
+			o.Height = int(int32(v))
+		case 5:
+			o.Dir = input.Dir(v)
+		case 6:
+			o.Score = int(int32(v))
+		case 7:
+			o.Step = v
+		case 8:
+			o.Done = v != 0
+		case 9:
+			o.Episode = v
+		case 10:
+			o.Seed = v
+		default:
+			return nil
+		}
+		return wantWire(field, wire, wireVarint)
+	})
+}
@@ -0,0 +1,72 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package assets holds the images bundled with the games. Each is decoded
+// on first use and shared after that.
+//
+// sprites.png is a sheet of 8x8 tiles for the snake, see pkg/snakegame.
+package assets
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"image"
+	_ "image/png"
+	"sync"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+//go:embed *.png
+var files embed.FS
+
+var (
+	mu     sync.Mutex
+	images = map[string]*ebiten.Image{}
+)
+
+// Image returns the bundled image name.
+func Image(name string) (*ebiten.Image, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if img, ok := images[name]; ok {
+		return img, nil
+	}
+
+	data, err := files.ReadFile(name)
+	if err != nil {
+		return nil, fmt.Errorf("assets: %w", err)
+	}
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("assets: %s: %w", name, err)
+	}
+
+	img := ebiten.NewImageFromImage(src)
+	images[name] = img
+	return img, nil
+}
+
+// Tiles cuts img into size x size tiles, left to right and top to bottom.
+func Tiles(img *ebiten.Image, size int) []*ebiten.Image {
+	b := img.Bounds()
+
+	var tiles []*ebiten.Image
+	for y := b.Min.Y; y+size <= b.Max.Y; y += size {
+		for x := b.Min.X; x+size <= b.Max.X; x += size {
+			tiles = append(tiles, img.SubImage(image.Rect(x, y, x+size, y+size)).(*ebiten.Image))
+		}
+	}
+	return tiles
+}
@@ -0,0 +1,179 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bench compares the results of go test -bench with a stored
+// baseline, so a change making the game slower or allocate more doesn't go
+// unnoticed, and has the Track the benchmarks of the simulation and the
+// game play on. See cmd/bench.
+package bench
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Result is how a benchmark did. NsPerOp is 0 in a baseline without the
+// times, see Compare.
+type Result struct {
+	Name        string  `json:"name"`
+	NsPerOp     float64 `json:"ns_per_op,omitempty"`
+	AllocsPerOp int64   `json:"allocs_per_op"`
+	BytesPerOp  int64   `json:"bytes_per_op"`
+}
+
+func (r Result) String() string {
+	return fmt.Sprintf("%-24s %12.1f ns/op %6d allocs/op %8d B/op", r.Name, r.NsPerOp, r.AllocsPerOp, r.BytesPerOp)
+}
+
+// Parse reads the output of go test -bench, -benchmem for the allocations,
+// and returns the results in the order the benchmarks first ran. A benchmark
+// run more than once, with -count, keeps its fastest time, so that noise
+// from the rest of the machine matters less, and its most allocations. The
+// names lose the Benchmark prefix and the GOMAXPROCS suffix: Tick/10 for
+// BenchmarkTick/10-8.
+func Parse(r io.Reader) ([]Result, error) {
+	var rs []Result
+	index := make(map[string]int)
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		f := strings.Fields(sc.Text())
+		if len(f) < 4 || !strings.HasPrefix(f[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.Atoi(f[1]); err != nil {
+			// a benchmark's log, not its result
+			continue
+		}
+
+		res := Result{Name: name(f[0])}
+		for i := 2; i+1 < len(f); i += 2 {
+			v, err := strconv.ParseFloat(f[i], 64)
+			if err != nil {
+				return nil, fmt.Errorf("bench: %s: %q is not a number", f[0], f[i])
+			}
+			switch f[i+1] {
+			case "ns/op":
+				res.NsPerOp = v
+			case "allocs/op":
+				res.AllocsPerOp = int64(v)
+			case "B/op":
+				res.BytesPerOp = int64(v)
+			}
+		}
+
+		i, ok := index[res.Name]
+		if !ok {
+			index[res.Name] = len(rs)
+			rs = append(rs, res)
+			continue
+		}
+		best := &rs[i]
+		best.NsPerOp = min(best.NsPerOp, res.NsPerOp)
+		best.AllocsPerOp = max(best.AllocsPerOp, res.AllocsPerOp)
+		best.BytesPerOp = max(best.BytesPerOp, res.BytesPerOp)
+	}
+	return rs, sc.Err()
+}
+
+// name strips the Benchmark prefix and the GOMAXPROCS suffix off the name
+// of a benchmark.
+func name(s string) string {
+	s = strings.TrimPrefix(s, "Benchmark")
+	if i := strings.LastIndexByte(s, '-'); i >= 0 {
+		if _, err := strconv.Atoi(s[i+1:]); err == nil {
+			s = s[:i]
+		}
+	}
+	return s
+}
+
+// Regression is a benchmark doing worse than its baseline, or missing from
+// the results.
+type Regression struct {
+	Name string
+	// What got worse: allocs, bytes or time, "missing" when it didn't run.
+	What      string
+	Base, Got float64
+}
+
+func (r Regression) String() string {
+	if r.What == "missing" {
+		return fmt.Sprintf("%s: in the baseline, not in the results", r.Name)
+	}
+	return fmt.Sprintf("%s: %s went from %.1f to %.1f per op", r.Name, r.What, r.Base, r.Got)
+}
+
+// Compare returns where got does worse than base: allocating more at all,
+// in number or in bytes, and, with timeTol above 0, taking more than timeTol
+// (0.25 for 25%) longer. The allocations are the same on every machine, the
+// times aren't, so they're only compared when asked for and where base has
+// them. A benchmark of base missing from got is a regression too, one
+// missing from base is new and can't regress.
+func Compare(base, got []Result, timeTol float64) []Regression {
+	byName := make(map[string]Result, len(got))
+	for _, r := range got {
+		byName[r.Name] = r
+	}
+
+	var regs []Regression
+	for _, b := range base {
+		g, ok := byName[b.Name]
+		if !ok {
+			regs = append(regs, Regression{Name: b.Name, What: "missing"})
+			continue
+		}
+		if g.AllocsPerOp > b.AllocsPerOp {
+			regs = append(regs, Regression{g.Name, "allocs", float64(b.AllocsPerOp), float64(g.AllocsPerOp)})
+		}
+		if g.BytesPerOp > b.BytesPerOp {
+			regs = append(regs, Regression{g.Name, "bytes", float64(b.BytesPerOp), float64(g.BytesPerOp)})
+		}
+		if timeTol > 0 && b.NsPerOp > 0 && g.NsPerOp > b.NsPerOp*(1+timeTol) {
+			regs = append(regs, Regression{g.Name, "time (ns)", b.NsPerOp, g.NsPerOp})
+		}
+	}
+	return regs
+}
+
+// Load reads a baseline written by Save.
+func Load(path string) ([]Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rs []Result
+	if err := json.Unmarshal(data, &rs); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return rs, nil
+}
+
+// Save writes rs as the baseline at path, creating the directory.
+func Save(path string, rs []Result) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(rs, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
@@ -0,0 +1,85 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bench
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	out := `goos: linux
+goarch: amd64
+pkg: jhartman.pl/gamedev/pkg/snake
+BenchmarkTick/10-8     	14000000	        85.7 ns/op	       0 B/op	       0 allocs/op
+BenchmarkTick/10-8     	14000000	        80.1 ns/op	       0 B/op	       0 allocs/op
+BenchmarkFood/full-8   	   80000	     14260 ns/op	       2 B/op	       1 allocs/op
+BenchmarkFood/full-8   	   80000	     15010 ns/op	       0 B/op	       0 allocs/op
+BenchmarkStep          	20000000	        64.4 ns/op
+    bench_test.go:42: BenchmarkStep logging
+PASS
+ok  	jhartman.pl/gamedev/pkg/snake	12.345s
+`
+	got, err := Parse(strings.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Result{
+		{Name: "Tick/10", NsPerOp: 80.1},
+		{Name: "Food/full", NsPerOp: 14260, AllocsPerOp: 1, BytesPerOp: 2},
+		{Name: "Step", NsPerOp: 64.4},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	base := []Result{
+		{Name: "Tick", NsPerOp: 100},
+		{Name: "Food", NsPerOp: 100, AllocsPerOp: 1, BytesPerOp: 8},
+		{Name: "Draw"},
+		{Name: "Gone", NsPerOp: 100},
+	}
+	got := []Result{
+		{Name: "Tick", NsPerOp: 200},
+		{Name: "Food", NsPerOp: 90, AllocsPerOp: 1, BytesPerOp: 16},
+		{Name: "Draw", NsPerOp: 1e6, AllocsPerOp: 2},
+		{Name: "New", NsPerOp: 100, AllocsPerOp: 5},
+	}
+
+	for _, tc := range []struct {
+		name    string
+		timeTol float64
+		want    []Regression
+	}{
+		{"allocations", 0, []Regression{
+			{"Food", "bytes", 8, 16},
+			{"Draw", "allocs", 0, 2},
+			{Name: "Gone", What: "missing"},
+		}},
+		{"times", 0.25, []Regression{
+			{"Tick", "time (ns)", 100, 200},
+			{"Food", "bytes", 8, 16},
+			{"Draw", "allocs", 0, 2},
+			{Name: "Gone", What: "missing"},
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := Compare(base, got, tc.timeTol); !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
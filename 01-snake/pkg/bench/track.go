@@ -0,0 +1,98 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bench
+
+import (
+	"math"
+
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// the snake goes round a cycle through all the columns but the last, where
+// the food stays out of its way
+const (
+	cycleWidth  = snake.BoardWidth
+	cycleHeight = snake.BoardHeight + 1
+)
+
+// CycleLength is the length of the longest snake on a Track, which fills
+// all the board but a column.
+const CycleLength = cycleHeight + (cycleWidth-1)*(cycleHeight-1) + cycleWidth - 1
+
+// cycle returns the cells of the cycle in order: down the first column, up
+// and down the others from the second row, and back along the first row.
+func cycle() []snake.Point {
+	c := make([]snake.Point, 0, CycleLength)
+	for y := range cycleHeight {
+		c = append(c, snake.Point{X: 0, Y: y})
+	}
+	for x := 1; x < cycleWidth; x++ {
+		for i := range cycleHeight - 1 {
+			y := 1 + i
+			if x%2 == 1 {
+				y = cycleHeight - 1 - i
+			}
+			c = append(c, snake.Point{X: x, Y: y})
+		}
+	}
+	for x := cycleWidth - 1; x > 0; x-- {
+		c = append(c, snake.Point{X: x, Y: 0})
+	}
+	return c
+}
+
+// Track is a game with a snake of a given length going round a cycle
+// forever, never eating or crashing, to benchmark any length of snake.
+type Track struct {
+	Game *snake.Game
+	next map[snake.Point]input.Dir
+}
+
+// NewTrack returns a track with a snake of length 1 to CycleLength.
+func NewTrack(length int) *Track {
+	c := cycle()
+	t := &Track{Game: snake.New(1), next: make(map[snake.Point]input.Dir, len(c))}
+	for i, p := range c {
+		q := c[(i+1)%len(c)]
+		t.next[p] = input.DirOf(q.X-p.X, q.Y-p.Y)
+	}
+
+	// the head at length-1, the tail at 0
+	body := make([]snake.Point, length)
+	for i := range body {
+		body[i] = c[length-1-i]
+	}
+	g := t.Game
+	g.Snake = snake.NewBody(body...)
+	// the way it came into the head
+	x, y := t.next[c[(length-2+len(c))%len(c)]].Delta()
+	g.Direction = snake.Point{X: x, Y: y}
+	g.Food = snake.Point{X: snake.BoardWidth, Y: snake.BoardHeight}
+	// nor a timed food or a power-up, the length and pace stay
+	g.TimedWait = math.MaxInt
+	g.PowerUpWait = math.MaxInt
+	return t
+}
+
+// Next returns the way along the cycle, steering the snake as an
+// input.Controller.
+func (t *Track) Next() input.Dir {
+	return t.next[t.Game.Snake.Head()]
+}
+
+// Tick turns the snake along the cycle and steps.
+func (t *Track) Tick() {
+	t.Game.Turn(t.Next().Delta())
+	t.Game.Step()
+}
@@ -0,0 +1,214 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bot
+
+import (
+	"container/heap"
+	"slices"
+
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+const (
+	cols  = snake.BoardWidth + 1
+	rows  = snake.BoardHeight + 1
+	cells = cols * rows
+)
+
+func index(p snake.Point) int {
+	return p.Y*cols + p.X
+}
+
+func inside(p snake.Point) bool {
+	return p.X >= 0 && p.X < cols && p.Y >= 0 && p.Y < rows
+}
+
+// AStar follows the shortest path to the food, but only if the snake can
+// still reach its tail once there, so it doesn't box itself in. Otherwise it
+// chases its tail, or as a last resort goes where there's the most room.
+type AStar struct{}
+
+func (AStar) Observe(g *snake.Game) input.Dir {
+	body := g.Snake.Points()
+
+	if path := findPath(body, g.Food, g.Direction); path != nil {
+		if tailReachable(grown(body, path)) && safeStep(g, path[0]) {
+			return path[0]
+		}
+	}
+
+	// the tail moves away as fast as the head follows it
+	if len(body) > 1 {
+		if path := findPath(body, body[len(body)-1], g.Direction); path != nil && safeStep(g, path[0]) {
+			return path[0]
+		}
+	}
+
+	return roomiest(g, body)
+}
+
+// safeStep checks the first step of a path against the actual rules, the
+// paths don't know that the tail stays put when the food lies under it.
+func safeStep(g *snake.Game, d input.Dir) bool {
+	return !g.Blocked(g.Ahead(d.Delta()))
+}
+
+// freeAt returns, per cell, the number of steps after which the body leaves
+// it: the head may enter a cell in step k if k >= freeAt.
+func freeAt(body []snake.Point) []int {
+	free := make([]int, cells)
+	for i, p := range body {
+		if inside(p) {
+			free[index(p)] = max(free[index(p)], len(body)-i)
+		}
+	}
+	return free
+}
+
+type node struct {
+	p     snake.Point
+	steps int
+	cost  int // steps plus the estimate to the goal
+}
+
+type queue []node
+
+func (q queue) Len() int           { return len(q) }
+func (q queue) Less(i, j int) bool { return q[i].cost < q[j].cost }
+func (q queue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *queue) Push(x any)        { *q = append(*q, x.(node)) }
+func (q *queue) Pop() any {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
+
+// findPath returns the directions leading the head of body to goal, taking
+// into account that the body moves along. nil if there's no path.
+func findPath(body []snake.Point, goal snake.Point, dir snake.Point) []input.Dir {
+	head := body[0]
+	if head == goal {
+		return nil
+	}
+
+	free := freeAt(body)
+	from := make([]input.Dir, cells)
+	seen := make([]bool, cells)
+	seen[index(head)] = true
+
+	q := &queue{{p: head, cost: distance(head, goal)}}
+	for q.Len() > 0 {
+		n := heap.Pop(q).(node)
+
+		for _, d := range input.Dirs {
+			dx, dy := d.Delta()
+			// the first step can't reverse, unless there is only the head
+			if n.p == head && len(body) > 1 && dx == -dir.X && dy == -dir.Y {
+				continue
+			}
+
+			p := snake.Point{X: n.p.X + dx, Y: n.p.Y + dy}
+			if !inside(p) || seen[index(p)] || n.steps+1 < free[index(p)] {
+				continue
+			}
+			seen[index(p)] = true
+			from[index(p)] = d
+
+			if p == goal {
+				return backtrack(from, head, goal)
+			}
+			heap.Push(q, node{p, n.steps + 1, n.steps + 1 + distance(p, goal)})
+		}
+	}
+
+	return nil
+}
+
+func backtrack(from []input.Dir, start, goal snake.Point) []input.Dir {
+	var path []input.Dir
+	for p := goal; p != start; {
+		d := from[index(p)]
+		path = append(path, d)
+
+		dx, dy := d.Delta()
+		p = snake.Point{X: p.X - dx, Y: p.Y - dy}
+	}
+	slices.Reverse(path)
+	return path
+}
+
+// grown returns the body after following path and eating at its end.
+func grown(body []snake.Point, path []input.Dir) []snake.Point {
+	trail := slices.Clone(body)
+	slices.Reverse(trail)
+
+	p := body[0]
+	for _, d := range path {
+		dx, dy := d.Delta()
+		p = snake.Point{X: p.X + dx, Y: p.Y + dy}
+		trail = append(trail, p)
+	}
+
+	trail = trail[max(0, len(trail)-len(body)-1):]
+	slices.Reverse(trail)
+	return trail
+}
+
+// tailReachable reports whether the head of body has a way to its tail.
+func tailReachable(body []snake.Point) bool {
+	tail := body[len(body)-1]
+	// no direction to avoid reversing to, the body already blocks that
+	return findPath(body, tail, snake.Point{}) != nil
+}
+
+// roomiest picks the safe direction leading to the largest free area.
+func roomiest(g *snake.Game, body []snake.Point) input.Dir {
+	best, bestRoom := input.None, -1
+	for _, d := range safe(g) {
+		if room := flood(body, g.Ahead(d.Delta())); room > bestRoom {
+			best, bestRoom = d, room
+		}
+	}
+	return best
+}
+
+// flood counts the cells reachable from p without crossing the body.
+func flood(body []snake.Point, p snake.Point) int {
+	blocked := make([]bool, cells)
+	for _, b := range body[:len(body)-1] {
+		if inside(b) {
+			blocked[index(b)] = true
+		}
+	}
+
+	stack := []snake.Point{p}
+	blocked[index(p)] = true
+	n := 0
+	for len(stack) > 0 {
+		p := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		n++
+
+		for _, d := range input.Dirs {
+			dx, dy := d.Delta()
+			next := snake.Point{X: p.X + dx, Y: p.Y + dy}
+			if inside(next) && !blocked[index(next)] {
+				blocked[index(next)] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	return n
+}
@@ -0,0 +1,119 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bot has computer players for the snake game. They look at the
+// simulation and pick the next direction, which makes them usable to steer
+// the game on screen (see Drive), to play headlessly and as baselines for
+// the agents trained with pkg/rlenv.
+package bot
+
+import (
+	"fmt"
+	"sort"
+
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// Controller is a computer player.
+type Controller interface {
+	// Observe returns the direction to turn to before the next step, g
+	// must not be modified.
+	Observe(g *snake.Game) input.Dir
+}
+
+// the reference bots by name
+var bots = map[string]func() Controller{
+	"greedy":      func() Controller { return Greedy{} },
+	"astar":       func() Controller { return AStar{} },
+	"hamiltonian": func() Controller { return NewHamiltonian() },
+}
+
+// Names lists the reference bots.
+func Names() []string {
+	names := make([]string, 0, len(bots))
+	for n := range bots {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ByName returns a new reference bot: greedy, astar or hamiltonian.
+func ByName(name string) (Controller, error) {
+	f, ok := bots[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown bot %q, expected one of %v", name, Names())
+	}
+	return f(), nil
+}
+
+type driver struct {
+	c     Controller
+	state func() *snake.Game
+}
+
+// Drive adapts c to an input.Controller steering the game returned by
+// state, e.g. for snakegame.Game.SetController.
+func Drive(c Controller, state func() *snake.Game) input.Controller {
+	return &driver{c, state}
+}
+
+func (d *driver) Next() input.Dir {
+	return d.c.Observe(d.state())
+}
+
+// Play runs a game headlessly with c until it crashes or maxSteps steps,
+// returning the game.
+func Play(c Controller, seed uint64, maxSteps int) *snake.Game {
+	g := snake.New(seed)
+	for range maxSteps {
+		if d := c.Observe(g); d != input.None {
+			g.Turn(d.Delta())
+		}
+		if g.Step(); g.State != snake.RUNNING {
+			break
+		}
+	}
+	return g
+}
+
+// safe lists the directions that don't crash the snake in the next step.
+func safe(g *snake.Game) []input.Dir {
+	var dirs []input.Dir
+	for _, d := range input.Dirs {
+		if reverses(g, d) {
+			continue
+		}
+		if !g.Blocked(g.Ahead(d.Delta())) {
+			dirs = append(dirs, d)
+		}
+	}
+	return dirs
+}
+
+// reverses reports whether turning to d would send the snake back into its
+// neck, which the game ignores.
+func reverses(g *snake.Game, d input.Dir) bool {
+	return g.Reverses(d.Delta())
+}
+
+func distance(a, b snake.Point) int {
+	return abs(a.X-b.X) + abs(a.Y-b.Y)
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
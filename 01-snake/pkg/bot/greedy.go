@@ -0,0 +1,38 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bot
+
+import (
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// Greedy heads straight for the food, only avoiding crashing in the very
+// next step. It traps itself as soon as the snake gets long.
+type Greedy struct{}
+
+func (Greedy) Observe(g *snake.Game) input.Dir {
+	dirs := safe(g)
+	if len(dirs) == 0 {
+		return input.None
+	}
+
+	best, bestDist := dirs[0], -1
+	for _, d := range dirs {
+		dist := distance(g.Ahead(d.Delta()), g.Food)
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = d, dist
+		}
+	}
+	return best
+}
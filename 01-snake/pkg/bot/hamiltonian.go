@@ -0,0 +1,78 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bot
+
+import (
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// The food only ever appears in 0..BoardWidth-1 x 0..BoardHeight-1, an even
+// number of columns, so there's a cycle through all of these cells: snaking
+// down and up the columns below the first row, and back along the first row.
+const (
+	cycleW = snake.BoardWidth
+	cycleH = snake.BoardHeight
+)
+
+// Hamiltonian follows a fixed cycle visiting every cell the food can be in.
+// It never crashes, but takes the long way to every piece of food.
+type Hamiltonian struct {
+	next []input.Dir // per cell of the cycle
+}
+
+func NewHamiltonian() *Hamiltonian {
+	h := &Hamiltonian{next: make([]input.Dir, cycleW*cycleH)}
+
+	for y := range cycleH {
+		for x := range cycleW {
+			var d input.Dir
+			switch {
+			case y == 0 && x > 0:
+				// back along the first row
+				d = input.Left
+			case x%2 == 0:
+				// even columns go down, over to the right at the bottom
+				d = input.Down
+				if y == cycleH-1 {
+					d = input.Right
+				}
+			default:
+				// odd ones up, over to the right at the second row, but
+				// the last one goes on to the first row
+				d = input.Up
+				if y == 1 && x < cycleW-1 {
+					d = input.Right
+				}
+			}
+			h.next[y*cycleW+x] = d
+		}
+	}
+
+	return h
+}
+
+func (h *Hamiltonian) Observe(g *snake.Game) input.Dir {
+	head := g.Snake.Head()
+	if head.X < 0 || head.X >= cycleW || head.Y < 0 || head.Y >= cycleH {
+		// outside of the cycle, get back in
+		return AStar{}.Observe(g)
+	}
+
+	d := h.next[head.Y*cycleW+head.X]
+	if reverses(g, d) || g.Blocked(g.Ahead(d.Delta())) {
+		// only happens while joining the cycle
+		return AStar{}.Observe(g)
+	}
+	return d
+}
@@ -0,0 +1,195 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bot
+
+import (
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// DefaultRivalDepth is the lookahead of a Rival making a fair opponent.
+const DefaultRivalDepth = 20
+
+// Rival plays a snake of a match against the others: it takes the shortest
+// way to the food around all the snakes, but only moves where it can still
+// make Depth moves after, counting on the tails moving on, so it doesn't box
+// itself in. The lower Depth, the easier it is to beat; 0 only avoids
+// crashing in the next step. It steers clear of the cells the other heads
+// can move into, as meeting them head on crashes both.
+type Rival struct {
+	Depth int
+}
+
+// rivalBudget caps the moves a Rival looks at per direction, as in a tight
+// spot the ways to go grow exponentially with the depth
+const rivalBudget = 20_000
+
+// Observe returns the direction to turn player i of m to before the next
+// step, m must not be modified.
+func (r Rival) Observe(m *snake.Match, i int) input.Dir {
+	me := m.Players[i]
+	a := area{m.Width + 1, m.Height + 1}
+
+	// per cell, the step after which the snake on it has moved on, the
+	// tails first
+	free := make([]int, a.cells())
+	blocked := make([]bool, a.cells())
+	for _, p := range m.Players {
+		if p.Out {
+			continue
+		}
+		n := p.Snake.Len()
+		for j, v := range p.Snake.All() {
+			free[a.index(v)] = max(free[a.index(v)], n-j)
+			if j < n-1 {
+				blocked[a.index(v)] = true
+			}
+		}
+	}
+
+	// cells another head can move into, only taken when there's no other way
+	contested := make([]bool, a.cells())
+	for _, p := range m.Players {
+		if p == me || p.Crashed {
+			continue
+		}
+		for _, d := range input.Dirs {
+			x, y := d.Delta()
+			if q := m.Ahead(p, x, y); a.inside(q) {
+				contested[a.index(q)] = true
+			}
+		}
+	}
+
+	toFood := distances(m, a, blocked, append([]snake.Point{m.Food}, m.Pellets...))
+	look := lookahead{m: m, area: a, free: free, seen: make([]bool, a.cells())}
+
+	best, bestScore := input.None, 0
+	for _, d := range input.Dirs {
+		x, y := d.Delta()
+		if me.Snake.Len() > 1 && x == -me.Direction.X && y == -me.Direction.Y {
+			continue
+		}
+		p := m.Ahead(me, x, y)
+		if m.Blocked(p) {
+			continue
+		}
+
+		// rank the moves: by how far the snake can go from there up to the
+		// depth, away from the other heads, then the closer to the food
+		look.budget = rivalBudget
+		score := look.moves(p, 1, r.Depth) << 20
+		if !contested[a.index(p)] {
+			score += 1 << 19
+		}
+		if dist := toFood[a.index(p)]; dist >= 0 {
+			score += a.cells() - dist
+		}
+
+		if best == input.None || score > bestScore {
+			best, bestScore = d, score
+		}
+	}
+	return best
+}
+
+// lookahead searches the moves of a snake, the others staying where they
+// are.
+type lookahead struct {
+	m    *snake.Match
+	area area
+	// free is the step from which each cell is free, see Observe
+	free []int
+	// seen are the cells of the moves being looked at
+	seen   []bool
+	budget int
+}
+
+// moves returns how many moves, up to depth, the snake can make after moving
+// to p in step, looking deeper until it finds a way to make them all.
+func (l *lookahead) moves(p snake.Point, step, depth int) int {
+	if depth == 0 || l.budget <= 0 {
+		return depth
+	}
+	l.budget--
+
+	l.seen[l.area.index(p)] = true
+	defer func() { l.seen[l.area.index(p)] = false }()
+
+	most := 0
+	neighbours(l.m, l.area, p, func(q snake.Point) {
+		if most == depth || l.seen[l.area.index(q)] || step+1 < l.free[l.area.index(q)] {
+			return
+		}
+		most = max(most, 1+l.moves(q, step+1, depth-1))
+	})
+	return most
+}
+
+// neighbours yields the cells next to p on the board, through the edges
+// when they wrap.
+func neighbours(m *snake.Match, a area, p snake.Point, yield func(snake.Point)) {
+	for _, d := range input.Dirs {
+		dx, dy := d.Delta()
+		q := snake.Point{X: p.X + dx, Y: p.Y + dy}
+		if m.Border == snake.BorderWrap {
+			q = snake.Point{X: (q.X + a.cols) % a.cols, Y: (q.Y + a.rows) % a.rows}
+		}
+		if a.inside(q) {
+			yield(q)
+		}
+	}
+}
+
+// distances returns, per cell, the number of moves to the nearest of from,
+// -1 where none can be reached around the blocked cells.
+func distances(m *snake.Match, a area, blocked []bool, from []snake.Point) []int {
+	dist := make([]int, a.cells())
+	for i := range dist {
+		dist[i] = -1
+	}
+	for _, p := range from {
+		dist[a.index(p)] = 0
+	}
+
+	queue := from
+	for len(queue) > 0 {
+		p := queue[0]
+		queue = queue[1:]
+		neighbours(m, a, p, func(q snake.Point) {
+			if blocked[a.index(q)] || dist[a.index(q)] >= 0 {
+				return
+			}
+			dist[a.index(q)] = dist[a.index(p)] + 1
+			queue = append(queue, q)
+		})
+	}
+	return dist
+}
+
+// area is the board of a match, which may be larger than the one of Game.
+type area struct {
+	cols, rows int
+}
+
+func (a area) cells() int {
+	return a.cols * a.rows
+}
+
+func (a area) index(p snake.Point) int {
+	return p.Y*a.cols + p.X
+}
+
+func (a area) inside(p snake.Point) bool {
+	return p.X >= 0 && p.X < a.cols && p.Y >= 0 && p.Y < a.rows
+}
@@ -0,0 +1,290 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package cloudsync keeps the per user files (settings, save, statistics,
+// ...) in sync across machines through a WebDAV or S3 compatible storage
+// the player provides.
+//
+// Next to the files the storage holds a manifest with the hash and
+// modification time of each. Locally the hashes from the last sync are kept,
+// which tells which side changed a file since. When both did, the most
+// recently modified version wins; local files are always backed up before
+// being overwritten.
+package cloudsync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/settings"
+)
+
+const (
+	manifestName = "manifest.json"
+	stateName    = "sync-state.json"
+	backupDir    = "backups"
+)
+
+// ErrNotFound is returned by stores for missing objects.
+var ErrNotFound = errors.New("cloudsync: not found")
+
+// Store is the remote storage.
+type Store interface {
+	Get(ctx context.Context, name string) ([]byte, error)
+	Put(ctx context.Context, name string, data []byte) error
+}
+
+// New returns the store configured in the settings.
+func New(cfg settings.CloudSync) (Store, error) {
+	switch cfg.Kind {
+	case "webdav":
+		return &WebDAV{URL: cfg.URL, Username: cfg.Username, Password: cfg.Password}, nil
+	case "s3":
+		return &S3{URL: cfg.URL, Region: cfg.Region, AccessKey: cfg.AccessKey, SecretKey: cfg.SecretKey}, nil
+	}
+	return nil, fmt.Errorf("cloudsync: unknown storage kind %q, expected webdav or s3", cfg.Kind)
+}
+
+// entry describes a version of a file, an empty hash meaning it's deleted.
+type entry struct {
+	Hash     string    `json:"hash"`
+	Modified time.Time `json:"modified"`
+}
+
+// Action is what happened to a file.
+type Action string
+
+const (
+	UpToDate Action = "up to date"
+	Pushed   Action = "pushed"
+	Pulled   Action = "pulled"
+)
+
+// Result of syncing a file.
+type Result struct {
+	Name     string
+	Action   Action
+	Conflict bool // both sides had changed, the newer one won
+}
+
+func (r Result) String() string {
+	if r.Conflict {
+		return fmt.Sprintf("%s: %s (conflict, the newer version won)", r.Name, r.Action)
+	}
+	return fmt.Sprintf("%s: %s", r.Name, r.Action)
+}
+
+// Syncer syncs files of a local directory with a store.
+type Syncer struct {
+	Store Store
+	// Dir is the local directory, settings.Dir() if empty.
+	Dir string
+	// Files are the names of the files to sync.
+	Files []string
+}
+
+// Files are the files synced by default.
+var Files = []string{"settings.json", "snake-autosave.json", "snake-stats.json", "snake-achievements.json"}
+
+type local struct {
+	data []byte
+	entry
+}
+
+func hash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (s *Syncer) dir() (string, error) {
+	if s.Dir != "" {
+		return s.Dir, nil
+	}
+	return settings.Dir()
+}
+
+func readLocal(name string) (local, error) {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return local{}, nil
+	} else if err != nil {
+		return local{}, err
+	}
+
+	fi, err := os.Stat(name)
+	if err != nil {
+		return local{}, err
+	}
+	return local{data, entry{hash(data), fi.ModTime().UTC()}}, nil
+}
+
+// readJSON decodes the file name into v, leaving v as is if it's missing.
+func readJSON(name string, v any) error {
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// Sync brings the local files and the store in line.
+func (s *Syncer) Sync(ctx context.Context) ([]Result, error) {
+	dir, err := s.dir()
+	if err != nil {
+		return nil, err
+	}
+
+	manifest := map[string]entry{}
+	data, err := s.Store.Get(ctx, manifestName)
+	switch {
+	case errors.Is(err, ErrNotFound):
+	case err != nil:
+		return nil, err
+	default:
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return nil, fmt.Errorf("cloudsync: manifest: %w", err)
+		}
+	}
+
+	// hashes of the last sync
+	state := map[string]string{}
+	if err := readJSON(filepath.Join(dir, stateName), &state); err != nil {
+		return nil, fmt.Errorf("cloudsync: state: %w", err)
+	}
+
+	backup := filepath.Join(dir, backupDir, time.Now().Format("2006-01-02-150405"))
+	manifestChanged := false
+
+	var results []Result
+	for _, name := range s.Files {
+		path := filepath.Join(dir, name)
+
+		l, err := readLocal(path)
+		if err != nil {
+			return results, err
+		}
+		remote := manifest[name]
+		base := state[name]
+
+		r := Result{Name: name, Action: UpToDate}
+
+		localChanged := l.Hash != base
+		remoteChanged := remote.Hash != base
+		switch {
+		case l.Hash == remote.Hash:
+			// same on both sides, maybe changed the same way
+		case localChanged && remoteChanged:
+			r.Conflict = true
+			if l.Modified.After(remote.Modified) {
+				r.Action = Pushed
+			} else {
+				r.Action = Pulled
+			}
+		case localChanged:
+			r.Action = Pushed
+		case remoteChanged:
+			r.Action = Pulled
+		}
+
+		switch r.Action {
+		case Pushed:
+			if l.Hash != "" {
+				if err := s.Store.Put(ctx, name, l.data); err != nil {
+					return results, err
+				}
+			} else {
+				l.Modified = time.Now().UTC()
+			}
+			manifest[name] = l.entry
+			manifestChanged = true
+		case Pulled:
+			if err := pull(ctx, s.Store, name, path, remote, backup); err != nil {
+				return results, err
+			}
+		}
+
+		state[name] = manifest[name].Hash
+		if r.Action == UpToDate {
+			state[name] = l.Hash
+		}
+		results = append(results, r)
+	}
+
+	if manifestChanged {
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return results, err
+		}
+		if err := s.Store.Put(ctx, manifestName, data); err != nil {
+			return results, err
+		}
+	}
+
+	data, err = json.Marshal(state)
+	if err != nil {
+		return results, err
+	}
+	return results, settings.WriteFile(filepath.Join(dir, stateName), data)
+}
+
+// pull replaces the local file with the remote version, backing it up
+// first.
+func pull(ctx context.Context, st Store, name, path string, remote entry, backup string) error {
+	var data []byte
+	if remote.Hash != "" {
+		var err error
+		if data, err = st.Get(ctx, name); err != nil {
+			return err
+		}
+		if hash(data) != remote.Hash {
+			return fmt.Errorf("cloudsync: %s doesn't match the manifest, still uploading?", name)
+		}
+	}
+
+	old, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	default:
+		if err := os.MkdirAll(backup, 0o755); err != nil {
+			return err
+		}
+		if err := os.WriteFile(filepath.Join(backup, name), old, 0o644); err != nil {
+			return err
+		}
+	}
+
+	if remote.Hash == "" {
+		err := os.Remove(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if err := settings.WriteFile(path, data); err != nil {
+		return err
+	}
+	// keep the time of the change, it decides conflicts
+	return os.Chtimes(path, time.Time{}, remote.Modified)
+}
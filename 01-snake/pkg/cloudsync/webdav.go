@@ -0,0 +1,106 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cloudsync
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxObjectSize bounds what is downloaded, the synced files are small.
+const maxObjectSize = 16 << 20
+
+// WebDAV stores the files in a WebDAV collection, e.g. Nextcloud's
+// https://cloud.example.com/remote.php/dav/files/USER/gamedev/.
+type WebDAV struct {
+	URL                string
+	Username, Password string
+	// HTTPClient defaults to http.DefaultClient.
+	HTTPClient *http.Client
+}
+
+func (w *WebDAV) url(name string) string {
+	return strings.TrimSuffix(w.URL, "/") + "/" + url.PathEscape(name)
+}
+
+func (w *WebDAV) do(ctx context.Context, method, u string, body []byte) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if w.Username != "" {
+		req.SetBasicAuth(w.Username, w.Password)
+	}
+
+	c := w.HTTPClient
+	if c == nil {
+		c = http.DefaultClient
+	}
+	return c.Do(req)
+}
+
+func (w *WebDAV) Get(ctx context.Context, name string) ([]byte, error) {
+	resp, err := w.do(ctx, http.MethodGet, w.url(name), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, ErrNotFound
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("cloudsync: GET %s: %s", name, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, maxObjectSize))
+}
+
+func (w *WebDAV) Put(ctx context.Context, name string, data []byte) error {
+	for created := false; ; created = true {
+		resp, err := w.do(ctx, http.MethodPut, w.url(name), data)
+		if err != nil {
+			return err
+		}
+		resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusConflict && !created:
+			// the collection doesn't exist yet
+			if err := w.mkcol(ctx); err != nil {
+				return err
+			}
+		case resp.StatusCode >= 300:
+			return fmt.Errorf("cloudsync: PUT %s: %s", name, resp.Status)
+		default:
+			return nil
+		}
+	}
+}
+
+func (w *WebDAV) mkcol(ctx context.Context) error {
+	resp, err := w.do(ctx, "MKCOL", strings.TrimSuffix(w.URL, "/")+"/", nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("cloudsync: MKCOL: %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package display sets up the window of the desktop examples: the monitor
+// it opens on, fullscreen, and where it was left the last time.
+package display
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Options describe how the window is opened.
+type Options struct {
+	// Title of the window.
+	Title string
+	// Width and Height are the default window size, used unless a
+	// remembered one is restored. If zero, it is fitted to the monitor.
+	Width, Height int
+	// LogicalWidth and LogicalHeight are the resolution the game renders
+	// at, which a fitted window is a whole multiple of.
+	LogicalWidth, LogicalHeight int
+	// Monitor is the name or the number (from 1) of the monitor to open on,
+	// the remembered or the primary one if empty or not connected.
+	Monitor string
+	// Mode is how the window is shown.
+	Mode Mode
+	// TPS is the number of updates per second, 60 if zero. The game is
+	// expected to keep its pace whatever the rate.
+	TPS int
+	// Vsync syncs drawing with the display's refresh rate.
+	Vsync bool
+	// Remember restores the window where it was left and saves it on exit.
+	// Its size and position only are remembered in windowed mode.
+	Remember bool
+}
+
+// Monitors describes the connected monitors, one per line, numbered as
+// Options.Monitor expects them.
+func Monitors() []string {
+	var lines []string
+	for i, m := range ebiten.AppendMonitors(nil) {
+		w, h := m.Size()
+		lines = append(lines, fmt.Sprintf("%d: %s %dx%d scale %.2f", i+1, m.Name(), w, h, m.DeviceScaleFactor()))
+	}
+	return lines
+}
+
+// monitor finds a monitor by name or number, returning its number.
+func monitor(name string) (*ebiten.MonitorType, int, error) {
+	ms := ebiten.AppendMonitors(nil)
+
+	if n, err := strconv.Atoi(name); err == nil {
+		if n < 1 || n > len(ms) {
+			return nil, 0, fmt.Errorf("display: no monitor %d, there are %d", n, len(ms))
+		}
+		return ms[n-1], n, nil
+	}
+
+	for i, m := range ms {
+		if strings.EqualFold(m.Name(), name) {
+			return m, i + 1, nil
+		}
+	}
+	return nil, 0, fmt.Errorf("display: no monitor named %q", name)
+}
+
+// Run opens the window as described by opts and runs the game in it.
+func Run(game ebiten.Game, opts Options) error {
+	var w *window
+	if opts.Remember {
+		var err error
+		if w, err = loadWindow(); err != nil {
+			log.Printf("display: %v", err)
+		}
+	}
+
+	// the settings are synced, the monitor may be one of another machine
+	num := 0
+	if opts.Monitor != "" {
+		if m, n, err := monitor(opts.Monitor); err != nil {
+			log.Printf("%v, using the default one", err)
+		} else {
+			ebiten.SetMonitor(m)
+			num = n
+		}
+	}
+	if num == 0 && w != nil {
+		num = w.restoreMonitor()
+	}
+
+	ebiten.SetWindowTitle(opts.Title)
+	if opts.Width > 0 && opts.Height > 0 {
+		ebiten.SetWindowSize(opts.Width, opts.Height)
+	} else {
+		ebiten.SetWindowSize(fit(ebiten.Monitor(), opts.LogicalWidth, opts.LogicalHeight))
+	}
+	if w != nil && opts.Mode == Windowed {
+		w.restore(num)
+	}
+	opts.Mode.apply()
+
+	if opts.TPS > 0 {
+		ebiten.SetTPS(opts.TPS)
+	}
+	ebiten.SetVsyncEnabled(opts.Vsync)
+
+	if !opts.Remember {
+		return ebiten.RunGame(game)
+	}
+
+	t := &tracker{Game: game, last: w, mode: opts.Mode}
+	err := ebiten.RunGame(t)
+	if t.last != nil {
+		if err := t.last.save(); err != nil {
+			log.Printf("display: %v", err)
+		}
+	}
+	return err
+}
+
+// share of the monitor a fitted window may take
+const fitShare = 0.75
+
+// fit returns the window size, in device-independent pixels, showing the
+// logical resolution scaled by the largest whole factor within most of the
+// monitor. The factor applies to physical pixels, so the game stays sharp
+// with fractional OS scaling (125%, 150%, ...) and grows on 4K monitors
+// whatever scale they are run at.
+func fit(m *ebiten.MonitorType, lw, lh int) (int, int) {
+	if m == nil {
+		return 2 * lw, 2 * lh
+	}
+	scale := m.DeviceScaleFactor()
+	sw, sh := m.Size()
+
+	n := int(min(float64(sw)*scale*fitShare/float64(lw), float64(sh)*scale*fitShare/float64(lh)))
+	n = max(n, 1)
+
+	return int(math.Round(float64(n*lw) / scale)), int(math.Round(float64(n*lh) / scale))
+}
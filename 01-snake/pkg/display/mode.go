@@ -0,0 +1,76 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package display
+
+import (
+	"fmt"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Mode is how the window is shown.
+type Mode int
+
+const (
+	// Windowed is a regular, resizable window.
+	Windowed Mode = iota
+	// Fullscreen is ebiten's fullscreen, a native fullscreen space on
+	// macOS.
+	Fullscreen
+	// Borderless is a window without decorations covering the monitor. It
+	// looks like fullscreen but stays a window on the desktop, so switching
+	// to other applications is instant.
+	Borderless
+)
+
+// ParseMode parses "windowed", "fullscreen" or "borderless".
+func ParseMode(s string) (Mode, error) {
+	switch s {
+	case "windowed":
+		return Windowed, nil
+	case "fullscreen":
+		return Fullscreen, nil
+	case "borderless":
+		return Borderless, nil
+	}
+	return Windowed, fmt.Errorf("display: unknown mode %q, expected windowed, fullscreen or borderless", s)
+}
+
+func (m Mode) String() string {
+	switch m {
+	case Fullscreen:
+		return "fullscreen"
+	case Borderless:
+		return "borderless"
+	}
+	return "windowed"
+}
+
+// apply shows the window in the mode, on the current monitor. The game keeps
+// its logical resolution, ebiten scales it to fit the window.
+func (m Mode) apply() {
+	switch m {
+	case Windowed:
+		ebiten.SetFullscreen(false)
+		ebiten.SetWindowDecorated(true)
+		ebiten.SetWindowResizingMode(ebiten.WindowResizingModeEnabled)
+	case Fullscreen:
+		ebiten.SetFullscreen(true)
+	case Borderless:
+		ebiten.SetFullscreen(false)
+		ebiten.SetWindowDecorated(false)
+		ebiten.SetWindowResizingMode(ebiten.WindowResizingModeDisabled)
+		ebiten.SetWindowSize(ebiten.Monitor().Size())
+		ebiten.SetWindowPosition(0, 0)
+	}
+}
@@ -0,0 +1,150 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package display
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"jhartman.pl/gamedev/pkg/settings"
+)
+
+// the placement is per machine, so it isn't part of the (synced) settings
+const fileName = "window.json"
+
+// how often (in ticks) the window placement is sampled
+const trackTicks = 30
+
+// window is where the window was left, the position being relative to the
+// monitor in device-independent pixels.
+type window struct {
+	Monitor     int    `json:"monitor"`
+	MonitorName string `json:"monitor_name"`
+	X           int    `json:"x"`
+	Y           int    `json:"y"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+func path() (string, error) {
+	dir, err := settings.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fileName), nil
+}
+
+// loadWindow returns the remembered placement, nil if there is none.
+func loadWindow() (*window, error) {
+	name, err := path()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := settings.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	w := &window{}
+	if err := json.Unmarshal(data, w); err != nil {
+		return nil, err
+	}
+	return w, nil
+}
+
+func (w *window) save() error {
+	name, err := path()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(w)
+	if err != nil {
+		return err
+	}
+	return settings.WriteFile(name, data)
+}
+
+// restoreMonitor moves to the remembered monitor if it's still connected,
+// returning its number or 0.
+func (w *window) restoreMonitor() int {
+	ms := ebiten.AppendMonitors(nil)
+	if w.Monitor < 1 || w.Monitor > len(ms) || ms[w.Monitor-1].Name() != w.MonitorName {
+		return 0
+	}
+	ebiten.SetMonitor(ms[w.Monitor-1])
+	return w.Monitor
+}
+
+// restore sets the remembered size, and the position too when on the same
+// monitor and still on screen.
+func (w *window) restore(monitor int) {
+	if w.Width <= 0 || w.Height <= 0 {
+		return
+	}
+	ebiten.SetWindowSize(w.Width, w.Height)
+
+	if monitor != w.Monitor {
+		return
+	}
+	sw, sh := ebiten.Monitor().Size()
+	if w.X < 0 || w.Y < 0 || w.X >= sw || w.Y >= sh {
+		return
+	}
+	ebiten.SetWindowPosition(w.X, w.Y)
+}
+
+// tracker samples the window placement while the game runs, it can't be
+// read anymore once the window is closed.
+type tracker struct {
+	ebiten.Game
+	ticks int
+	last  *window
+	mode  Mode
+}
+
+func (t *tracker) Update() error {
+	if t.ticks%trackTicks == 0 {
+		t.sample()
+	}
+	t.ticks++
+
+	return t.Game.Update()
+}
+
+func (t *tracker) sample() {
+	// a window covering the monitor would be restored as such
+	if t.mode != Windowed {
+		return
+	}
+
+	m := ebiten.Monitor()
+	n := slices.Index(ebiten.AppendMonitors(nil), m)
+	if m == nil || n < 0 {
+		return
+	}
+
+	w := &window{Monitor: n + 1, MonitorName: m.Name()}
+	w.X, w.Y = ebiten.WindowPosition()
+	w.Width, w.Height = ebiten.WindowSize()
+	if w.Width > 0 && w.Height > 0 {
+		t.last = w
+	}
+}
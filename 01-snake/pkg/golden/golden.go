@@ -0,0 +1,107 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package golden compares rendered frames with stored reference images, so
+// changes to the drawing code can't alter what's on screen unnoticed. See
+// the golden test of pkg/snakegame.
+package golden
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+)
+
+// Result is the outcome of comparing two images.
+type Result struct {
+	// Differing is the number of pixels off by more than the tolerance.
+	Differing int
+	Total     int
+	// Diff shows the differing pixels in red over a faded copy of the
+	// reference.
+	Diff *image.RGBA
+}
+
+// Fraction is the share of the pixels that differ.
+func (r Result) Fraction() float64 {
+	if r.Total == 0 {
+		return 0
+	}
+	return float64(r.Differing) / float64(r.Total)
+}
+
+// Compare compares got with want pixel by pixel. A pixel differs when any of
+// its channels is off by more than tol (0-255), which absorbs the small
+// differences between GPUs in antialiasing and text rendering.
+func Compare(got, want image.Image, tol uint8) (Result, error) {
+	gb, wb := got.Bounds(), want.Bounds()
+	if gb.Dx() != wb.Dx() || gb.Dy() != wb.Dy() {
+		return Result{}, fmt.Errorf("golden: size %dx%d, want %dx%d", gb.Dx(), gb.Dy(), wb.Dx(), wb.Dy())
+	}
+
+	r := Result{
+		Total: wb.Dx() * wb.Dy(),
+		Diff:  image.NewRGBA(image.Rect(0, 0, wb.Dx(), wb.Dy())),
+	}
+	for y := range wb.Dy() {
+		for x := range wb.Dx() {
+			g := color.RGBAModel.Convert(got.At(gb.Min.X+x, gb.Min.Y+y)).(color.RGBA)
+			w := color.RGBAModel.Convert(want.At(wb.Min.X+x, wb.Min.Y+y)).(color.RGBA)
+
+			if differ(g.R, w.R, tol) || differ(g.G, w.G, tol) || differ(g.B, w.B, tol) || differ(g.A, w.A, tol) {
+				r.Differing++
+				r.Diff.SetRGBA(x, y, color.RGBA{255, 0, 0, 255})
+				continue
+			}
+			r.Diff.SetRGBA(x, y, color.RGBA{w.R / 4, w.G / 4, w.B / 4, 255})
+		}
+	}
+	return r, nil
+}
+
+func differ(a, b, tol uint8) bool {
+	if a > b {
+		return a-b > tol
+	}
+	return b-a > tol
+}
+
+// Load reads a PNG.
+func Load(path string) (image.Image, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	return png.Decode(f)
+}
+
+// Save writes img as a PNG, creating the directory if needed.
+func Save(path string, img image.Image) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := png.Encode(f, img); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,114 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package input abstracts where direction changes come from, so the snake can
+// be steered by something else than the local keyboard (a chat, a bot, ...).
+package input
+
+import "strings"
+
+// Dir is a movement direction.
+type Dir int
+
+const (
+	None Dir = iota
+	Up
+	Right
+	Down
+	Left
+)
+
+// Dirs lists the four directions clockwise from Up.
+var Dirs = [...]Dir{Up, Right, Down, Left}
+
+// Delta returns the board offset of a single step in the direction.
+func (d Dir) Delta() (x, y int) {
+	switch d {
+	case Up:
+		return 0, -1
+	case Right:
+		return 1, 0
+	case Down:
+		return 0, 1
+	case Left:
+		return -1, 0
+	}
+	return 0, 0
+}
+
+// DirOf returns the direction of a single step board offset, None if there
+// isn't one.
+func DirOf(x, y int) Dir {
+	for _, d := range Dirs {
+		if dx, dy := d.Delta(); dx == x && dy == y {
+			return d
+		}
+	}
+	return None
+}
+
+// Opposite returns the direction the other way, None for None.
+func (d Dir) Opposite() Dir {
+	x, y := d.Delta()
+	return DirOf(-x, -y)
+}
+
+// Arrow returns the direction as an arrow symbol.
+func (d Dir) Arrow() string {
+	switch d {
+	case Up:
+		return "↑"
+	case Right:
+		return "→"
+	case Down:
+		return "↓"
+	case Left:
+		return "←"
+	}
+	return "·"
+}
+
+func (d Dir) String() string {
+	switch d {
+	case Up:
+		return "up"
+	case Right:
+		return "right"
+	case Down:
+		return "down"
+	case Left:
+		return "left"
+	}
+	return "none"
+}
+
+// ParseDir understands direction names, compass points and WASD, case
+// insensitive.
+func ParseDir(s string) (Dir, bool) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "up", "north", "w":
+		return Up, true
+	case "right", "east", "d":
+		return Right, true
+	case "down", "south", "s":
+		return Down, true
+	case "left", "west", "a":
+		return Left, true
+	}
+	return None, false
+}
+
+// Controller supplies direction changes to the game. Next is called once per
+// movement step and returns None when the direction should be kept.
+type Controller interface {
+	Next() Dir
+}
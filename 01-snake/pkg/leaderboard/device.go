@@ -0,0 +1,71 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderboard
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"strconv"
+)
+
+// Headers carrying a signed request.
+const (
+	HeaderDevice    = "X-Device-ID"
+	HeaderTimestamp = "X-Timestamp"
+	HeaderSignature = "X-Signature"
+)
+
+// Device is the anonymous identity the server hands out on first contact.
+// Submissions are signed with its secret, which is enough to stop casual
+// spoofing without any user accounts.
+type Device struct {
+	ID     string `json:"device_id"`
+	Secret string `json:"secret"`
+}
+
+// Sign computes the signature of a request made at timestamp (unix seconds)
+// as the hex encoded HMAC-SHA256 over method, path, timestamp and the hash
+// of the body.
+func Sign(secret, method, path string, timestamp int64, body []byte) string {
+	sum := sha256.Sum256(body)
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(method + "\n" + path + "\n" + strconv.FormatInt(timestamp, 10) + "\n"))
+	mac.Write([]byte(hex.EncodeToString(sum[:])))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// ReadDevice loads a device saved with WriteDevice.
+func ReadDevice(name string) (Device, error) {
+	var d Device
+
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return d, err
+	}
+
+	return d, json.Unmarshal(data, &d)
+}
+
+// WriteDevice saves d, readable by the user only as it holds the secret.
+func WriteDevice(name string, d Device) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0o600)
+}
@@ -0,0 +1,208 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package leaderboard is the client of the score server (cmd/scored) and
+// holds the types both sides exchange.
+package leaderboard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// DayFormat is how days are written in queries, days are in UTC.
+const DayFormat = "2006-01-02"
+
+// Score is a submission.
+type Score struct {
+	Game  string `json:"game"`
+	Mode  string `json:"mode"`
+	Name  string `json:"name"`
+	Score int    `json:"score"`
+	// Replay optionally proves the score, it's the run encoded by
+	// replay.Replay.MarshalBinary.
+	Replay []byte `json:"replay,omitempty"`
+}
+
+// Submitted is the server's answer to a submission.
+type Submitted struct {
+	ID int64 `json:"id"`
+	// Rank is the position of the score on its all-time board, from 1.
+	Rank int `json:"rank"`
+}
+
+// Entry is a score on a board.
+type Entry struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Score     int       `json:"score"`
+	CreatedAt time.Time `json:"created_at"`
+	// Verified is set when the score was proven by its replay.
+	Verified bool `json:"verified"`
+}
+
+// Query selects a board and a page of it.
+type Query struct {
+	Game string
+	Mode string
+	// Day restricts the board to scores submitted that day (see DayFormat),
+	// empty means all time.
+	Day string
+
+	Offset int
+	Limit  int
+}
+
+// Page is a part of a board, best scores first.
+type Page struct {
+	Entries []Entry `json:"entries"`
+	// Total is the number of scores on the whole board.
+	Total int `json:"total"`
+	// Next is the offset of the next page, 0 on the last one.
+	Next int `json:"next,omitempty"`
+}
+
+// Error is returned for requests the server rejected.
+type Error struct {
+	Status  int
+	Message string `json:"error"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("leaderboard: %s (%d)", e.Message, e.Status)
+}
+
+// Client talks to a score server.
+type Client struct {
+	// BaseURL is where the server is, e.g. https://scores.example.com.
+	BaseURL string
+	// HTTPClient is used for requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+	// Device signs the submissions. When empty, Submit registers a new one
+	// first; callers should persist it (see WriteDevice) for later runs.
+	Device Device
+}
+
+// New returns a client for the server at baseURL.
+func New(baseURL string) *Client {
+	return &Client{BaseURL: baseURL}
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// Register asks the server for a new device identity and uses it from now
+// on.
+func (c *Client) Register(ctx context.Context) (Device, error) {
+	var d Device
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+"/api/devices", nil)
+	if err != nil {
+		return d, err
+	}
+
+	if err := c.do(req, &d); err != nil {
+		return d, err
+	}
+
+	c.Device = d
+	return d, nil
+}
+
+// Submit sends s to the server, signed with the client's device.
+func (c *Client) Submit(ctx context.Context, s Score) (Submitted, error) {
+	var res Submitted
+
+	if c.Device.ID == "" {
+		if _, err := c.Register(ctx); err != nil {
+			return res, err
+		}
+	}
+
+	body, err := json.Marshal(s)
+	if err != nil {
+		return res, err
+	}
+
+	const path = "/api/scores"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return res, err
+	}
+
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderDevice, c.Device.ID)
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderSignature, Sign(c.Device.Secret, http.MethodPost, path, ts, body))
+
+	return res, c.do(req, &res)
+}
+
+// Top fetches a page of a board.
+func (c *Client) Top(ctx context.Context, q Query) (Page, error) {
+	var page Page
+
+	v := url.Values{}
+	v.Set("game", q.Game)
+	v.Set("mode", q.Mode)
+	if q.Day != "" {
+		v.Set("day", q.Day)
+	}
+	if q.Offset > 0 {
+		v.Set("offset", strconv.Itoa(q.Offset))
+	}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api/scores?"+v.Encode(), nil)
+	if err != nil {
+		return page, err
+	}
+
+	return page, c.do(req, &page)
+}
+
+func (c *Client) do(req *http.Request, v any) error {
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		e := &Error{Status: resp.StatusCode}
+		if json.Unmarshal(data, e) != nil || e.Message == "" {
+			e.Message = http.StatusText(resp.StatusCode)
+		}
+		return e
+	}
+
+	return json.Unmarshal(data, v)
+}
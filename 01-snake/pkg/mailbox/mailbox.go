@@ -0,0 +1,112 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mailbox passes messages from background goroutines (network
+// connections, requests, the host's UI thread) to the game loop.
+//
+// The game state belongs to the goroutine running Update. Others never touch
+// it: they Post a message describing what happened, and the game loop takes
+// them all out with Receive once per tick and applies them itself. Posting
+// never blocks, so a slow frame doesn't hold up a connection, and the game
+// loop never waits on the network.
+//
+//	box := mailbox.New[Message]()
+//
+//	go func() {
+//		for msg := range conn.Messages() {
+//			box.Post(msg)
+//		}
+//	}()
+//
+//	func (g *Game) Update() error {
+//		for _, msg := range g.box.Receive() {
+//			g.apply(msg)
+//		}
+//		...
+//	}
+//
+// Messages are values: a message must not share memory the sender keeps
+// changing after posting it.
+package mailbox
+
+import "sync"
+
+// Mailbox is an unbounded queue of messages, posted from any goroutine and
+// received by one. The zero value is an empty mailbox.
+type Mailbox[T any] struct {
+	mu    sync.Mutex
+	queue []T
+	// spare is the slice handed out by the last Receive, reused for the
+	// next messages once it's taken back
+	spare []T
+
+	ready chan struct{}
+}
+
+// New returns an empty mailbox.
+func New[T any]() *Mailbox[T] {
+	return &Mailbox[T]{}
+}
+
+// Post queues msg. It never blocks and is safe to call from any goroutine.
+func (m *Mailbox[T]) Post(msg T) {
+	m.mu.Lock()
+	m.queue = append(m.queue, msg)
+	ready := m.readyLocked()
+	m.mu.Unlock()
+
+	select {
+	case ready <- struct{}{}:
+	default:
+	}
+}
+
+// Receive takes out the messages posted since the last call, oldest first,
+// without waiting. The slice is only valid until the next call, so that
+// receiving every tick doesn't allocate.
+func (m *Mailbox[T]) Receive() []T {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	msgs := m.queue
+	clear(m.spare)
+	m.queue = m.spare[:0]
+	m.spare = msgs
+	return msgs
+}
+
+// Last takes out the messages posted since the last call and returns the
+// newest one, for messages that replace each other like a position or a
+// size. ok is false if nothing was posted.
+func (m *Mailbox[T]) Last() (msg T, ok bool) {
+	msgs := m.Receive()
+	if len(msgs) == 0 {
+		return msg, false
+	}
+	return msgs[len(msgs)-1], true
+}
+
+// Ready is signaled after a Post, for a goroutine waiting on messages
+// instead of polling every tick. Several posts may be signaled once.
+func (m *Mailbox[T]) Ready() <-chan struct{} {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.readyLocked()
+}
+
+func (m *Mailbox[T]) readyLocked() chan struct{} {
+	if m.ready == nil {
+		m.ready = make(chan struct{}, 1)
+	}
+	return m.ready
+}
@@ -0,0 +1,118 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mailbox_test
+
+import (
+	"sync"
+	"testing"
+
+	"jhartman.pl/gamedev/pkg/mailbox"
+)
+
+type msg struct {
+	sender, seq int
+}
+
+// TestConcurrent posts from several goroutines while one receives, waiting
+// on Ready, run it with -race. Every message has to arrive once, each
+// sender's in the order posted.
+func TestConcurrent(t *testing.T) {
+	const senders, posts = 8, 1000
+	box := mailbox.New[msg]()
+
+	var wg sync.WaitGroup
+	for s := range senders {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range posts {
+				box.Post(msg{s, i})
+			}
+		}()
+	}
+
+	next := make([]int, senders)
+	for got := 0; got < senders*posts; {
+		<-box.Ready()
+		for _, m := range box.Receive() {
+			if m.seq != next[m.sender] {
+				t.Fatalf("sender %d: got message %d, want %d", m.sender, m.seq, next[m.sender])
+			}
+			next[m.sender]++
+			got++
+		}
+	}
+	wg.Wait()
+
+	if msgs := box.Receive(); len(msgs) != 0 {
+		t.Errorf("%d messages left over", len(msgs))
+	}
+}
+
+// TestLastConcurrent has the receiver keep only the newest message of a
+// sender posting increasing values.
+func TestLastConcurrent(t *testing.T) {
+	const posts = 1000
+	var box mailbox.Mailbox[int]
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 1; i <= posts; i++ {
+			box.Post(i)
+		}
+	}()
+
+	last := 0
+	for last < posts {
+		<-box.Ready()
+		if v, ok := box.Last(); ok {
+			if v <= last {
+				t.Fatalf("got %d after %d", v, last)
+			}
+			last = v
+		}
+	}
+	<-done
+
+	if v, ok := box.Last(); ok {
+		t.Errorf("got %d, want nothing", v)
+	}
+}
+
+func TestReceive(t *testing.T) {
+	var box mailbox.Mailbox[int]
+	if msgs := box.Receive(); len(msgs) != 0 {
+		t.Fatalf("empty mailbox: got %v", msgs)
+	}
+
+	box.Post(1)
+	box.Post(2)
+	if msgs := box.Receive(); len(msgs) != 2 || msgs[0] != 1 || msgs[1] != 2 {
+		t.Errorf("got %v, want [1 2]", msgs)
+	}
+	if msgs := box.Receive(); len(msgs) != 0 {
+		t.Errorf("received twice: got %v", msgs)
+	}
+
+	// once the slices are grown, posting and receiving every tick doesn't
+	// allocate
+	allocs := testing.AllocsPerRun(100, func() {
+		box.Post(3)
+		box.Post(4)
+		box.Receive()
+	})
+	if allocs != 0 {
+		t.Errorf("%v allocations per tick, want 0", allocs)
+	}
+}
@@ -0,0 +1,90 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import "jhartman.pl/gamedev/pkg/snake"
+
+const (
+	maxX = snake.BoardWidth
+	maxY = snake.BoardHeight
+	midX = snake.BoardWidth / 2
+	midY = snake.BoardHeight / 2
+)
+
+// Builtin returns the levels shipped with the game, for a start before any
+// are downloaded. They have no ID.
+func Builtin() []Map {
+	return []Map{
+		builtin("Box", func(x, y int) bool {
+			// a frame with a gap in the middle of each side
+			onFrame := (x == 5 || x == maxX-5) && y >= 5 && y <= maxY-5 ||
+				(y == 5 || y == maxY-5) && x >= 5 && x <= maxX-5
+			return onFrame && abs(x-midX) > 2 && abs(y-midY) > 2
+		}),
+		builtin("Pillars", func(x, y int) bool {
+			// 2x2 blocks every 8 cells
+			return x%8 >= 6 && x < maxX-2 && y%8 >= 5 && y%8 <= 6
+		}),
+		builtin("Cross", func(x, y int) bool {
+			// open in the middle and where the snake starts
+			vertical := x == midX && y >= 4 && y <= maxY-4 && abs(y-snake.Start.Y) > 2
+			horizontal := y == midY && x >= 4 && x <= maxX-4
+			return (vertical || horizontal) && (abs(x-midX) > 2 || abs(y-midY) > 2)
+		}),
+		builtin("Corridors", func(x, y int) bool {
+			// bars from alternating sides
+			return y == 7 && x <= maxX-8 || y == maxY-7 && x >= 8
+		}),
+		patrol(),
+	}
+}
+
+// patrol is the level of obstacles going up and down both sides and across
+// the top.
+func patrol() Map {
+	m := Map{Name: "Patrol", Author: "jh"}
+	line := func(every int, from, to [2]int) {
+		o := Obstacle{Every: every}
+		for p := from; ; p[0], p[1] = p[0]+sign(to[0]-p[0]), p[1]+sign(to[1]-p[1]) {
+			o.Path = append(o.Path, p)
+			if p == to {
+				break
+			}
+		}
+		m.Obstacles = append(m.Obstacles, o)
+	}
+	line(2, [2]int{8, 3}, [2]int{8, maxY - 3})
+	line(2, [2]int{maxX - 8, maxY - 3}, [2]int{maxX - 8, 3})
+	line(3, [2]int{12, 6}, [2]int{maxX - 12, 6})
+	return m
+}
+
+// builtin makes the map with walls where wall is true.
+func builtin(name string, wall func(x, y int) bool) Map {
+	m := Map{Name: name, Author: "jh"}
+	for y := range maxY + 1 {
+		for x := range maxX + 1 {
+			if wall(x, y) {
+				m.Walls = append(m.Walls, [2]int{x, y})
+			}
+		}
+	}
+	return m
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
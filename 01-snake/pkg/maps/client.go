@@ -0,0 +1,181 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// Sort orders of the index.
+const (
+	SortTop = "top" // best rated first
+	SortNew = "new" // most recent first
+)
+
+// Query selects a page of the index.
+type Query struct {
+	// Sort is SortTop or SortNew, SortTop if empty.
+	Sort string
+	// Search matches the name or the author.
+	Search string
+
+	Offset int
+	Limit  int
+}
+
+// Page is a part of the index. The maps come without their walls, Get
+// downloads them.
+type Page struct {
+	Maps []Map `json:"maps"`
+	// Total is the number of maps matching the query.
+	Total int `json:"total"`
+	// Next is the offset of the next page, 0 on the last one.
+	Next int `json:"next,omitempty"`
+}
+
+// Error is returned for requests the server rejected.
+type Error struct {
+	Status  int
+	Message string `json:"error"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("maps: %s (%d)", e.Message, e.Status)
+}
+
+// Client talks to a maps server.
+type Client struct {
+	// BaseURL is where the server is, e.g. https://maps.example.com.
+	BaseURL string
+	// HTTPClient is used for requests, http.DefaultClient if nil.
+	HTTPClient *http.Client
+}
+
+// New returns a client for the server at baseURL.
+func New(baseURL string) *Client {
+	return &Client{BaseURL: baseURL}
+}
+
+func (c *Client) httpClient() *http.Client {
+	if c.HTTPClient != nil {
+		return c.HTTPClient
+	}
+	return http.DefaultClient
+}
+
+// List fetches a page of the index.
+func (c *Client) List(ctx context.Context, q Query) (Page, error) {
+	var page Page
+
+	v := url.Values{}
+	if q.Sort != "" {
+		v.Set("sort", q.Sort)
+	}
+	if q.Search != "" {
+		v.Set("q", q.Search)
+	}
+	if q.Offset > 0 {
+		v.Set("offset", strconv.Itoa(q.Offset))
+	}
+	if q.Limit > 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api/maps?"+v.Encode(), nil)
+	if err != nil {
+		return page, err
+	}
+
+	return page, c.do(req, &page)
+}
+
+// Get downloads a map, walls included.
+func (c *Client) Get(ctx context.Context, id string) (Map, error) {
+	var m Map
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api/maps/"+url.PathEscape(id), nil)
+	if err != nil {
+		return m, err
+	}
+
+	return m, c.do(req, &m)
+}
+
+// Rate gives the map 1 to 5 stars, replacing an earlier rating from the
+// same player. It returns the map with its new rating.
+func (c *Client) Rate(ctx context.Context, id string, stars int) (Map, error) {
+	var m Map
+
+	body, err := json.Marshal(map[string]int{"stars": stars})
+	if err != nil {
+		return m, err
+	}
+
+	return m, c.post(ctx, "/api/maps/"+url.PathEscape(id)+"/ratings", body, &m)
+}
+
+// Upload publishes a map, returning it as stored by the server.
+func (c *Client) Upload(ctx context.Context, m Map) (Map, error) {
+	var res Map
+
+	if err := m.Validate(); err != nil {
+		return res, err
+	}
+
+	body, err := json.Marshal(Map{Name: m.Name, Author: m.Author, Walls: m.Walls, Portals: m.Portals, Obstacles: m.Obstacles, Ice: m.Ice, Mud: m.Mud})
+	if err != nil {
+		return res, err
+	}
+
+	return res, c.post(ctx, "/api/maps", body, &res)
+}
+
+func (c *Client) post(ctx context.Context, path string, body []byte, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.BaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	return c.do(req, v)
+}
+
+func (c *Client) do(req *http.Request, v any) error {
+	resp, err := c.httpClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return err
+	}
+
+	if resp.StatusCode/100 != 2 {
+		e := &Error{Status: resp.StatusCode}
+		if json.Unmarshal(data, e) != nil || e.Message == "" {
+			e.Message = http.StatusText(resp.StatusCode)
+		}
+		return e
+	}
+
+	return json.Unmarshal(data, v)
+}
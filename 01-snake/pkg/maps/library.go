@@ -0,0 +1,103 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"jhartman.pl/gamedev/pkg/settings"
+)
+
+// IDs are made by the server, they become file names here
+var idRe = regexp.MustCompile(`^[a-z0-9]{1,32}$`)
+
+// ValidID reports whether id is a well formed map ID.
+func ValidID(id string) bool {
+	return idRe.MatchString(id)
+}
+
+// Dir returns where the downloaded maps are kept.
+func Dir() (string, error) {
+	dir, err := settings.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "maps"), nil
+}
+
+// Install keeps a downloaded map, replacing an older copy.
+func Install(m Map) error {
+	if !ValidID(m.ID) {
+		return fmt.Errorf("maps: invalid id %q", m.ID)
+	}
+	if err := m.Validate(); err != nil {
+		return err
+	}
+
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return settings.WriteFile(filepath.Join(dir, m.ID+".json"), data)
+}
+
+// Installed returns the downloaded maps by name. Broken files are skipped.
+func Installed() ([]Map, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var ms []Map
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".json" {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return ms, err
+		}
+
+		var m Map
+		if json.Unmarshal(data, &m) != nil || m.Validate() != nil {
+			continue
+		}
+		ms = append(ms, m)
+	}
+
+	slices.SortFunc(ms, func(a, b Map) int {
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	})
+	return ms, nil
+}
@@ -0,0 +1,219 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package maps shares user made snake levels, walls on the board: it is the
+// client of the maps server (cmd/mapsd), where they are browsed, downloaded
+// and rated, and keeps the downloaded ones next to the settings.
+package maps
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+const (
+	maxNameLen   = 32
+	maxAuthorLen = 16
+	// MaxWalls bounds the size of a map, a third of the board
+	MaxWalls = (snake.BoardWidth + 1) * (snake.BoardHeight + 1) / 3
+	// MaxPortals bounds the pairs of portals, one per digit in the text
+	// format
+	MaxPortals = 10
+	// MaxObstacles bounds the obstacles, and maxPatrol the cells each
+	// patrols
+	MaxObstacles = 8
+	maxPatrol    = snake.BoardWidth + 1
+)
+
+// Map is a level.
+type Map struct {
+	// ID is given by the server.
+	ID     string   `json:"id,omitempty"`
+	Name   string   `json:"name"`
+	Author string   `json:"author"`
+	Walls  [][2]int `json:"walls,omitempty"`
+	// Portals are pairs of cells, the snake going into either comes out
+	// of the other.
+	Portals [][2][2]int `json:"portals,omitempty"`
+	// Obstacles patrol the board.
+	Obstacles []Obstacle `json:"obstacles,omitempty"`
+	// Ice and Mud are the tiles, see snake.Tiles.
+	Ice [][2]int `json:"ice,omitempty"`
+	Mud [][2]int `json:"mud,omitempty"`
+
+	// set by the server
+	Rating    float64   `json:"rating"` // average stars, 1 to 5
+	Votes     int       `json:"votes"`
+	Downloads int       `json:"downloads"`
+	Created   time.Time `json:"created"`
+}
+
+// Points returns the walls as board points.
+func (m *Map) Points() []snake.Point {
+	ps := make([]snake.Point, 0, len(m.Walls))
+	for _, w := range m.Walls {
+		ps = append(ps, snake.Point{X: w[0], Y: w[1]})
+	}
+	return ps
+}
+
+// Obstacle moves along Path, a cell every Every steps, and back.
+type Obstacle struct {
+	Path  [][2]int `json:"path"`
+	Every int      `json:"every,omitempty"`
+}
+
+// Patrols returns the obstacles as the board's.
+func (m *Map) Patrols() []snake.Obstacle {
+	os := make([]snake.Obstacle, 0, len(m.Obstacles))
+	for _, o := range m.Obstacles {
+		so := snake.Obstacle{Every: o.Every}
+		for _, p := range o.Path {
+			so.Path = append(so.Path, snake.Point{X: p[0], Y: p[1]})
+		}
+		os = append(os, so)
+	}
+	return os
+}
+
+// TileCells returns the ice and the mud as the board's.
+func (m *Map) TileCells() snake.Tiles {
+	var t snake.Tiles
+	for _, p := range m.Ice {
+		t.Ice = append(t.Ice, snake.Point{X: p[0], Y: p[1]})
+	}
+	for _, p := range m.Mud {
+		t.Mud = append(t.Mud, snake.Point{X: p[0], Y: p[1]})
+	}
+	return t
+}
+
+// PortalPairs returns the portals as the board's.
+func (m *Map) PortalPairs() []snake.Portal {
+	ps := make([]snake.Portal, 0, len(m.Portals))
+	for _, pt := range m.Portals {
+		ps = append(ps, snake.Portal{
+			{X: pt[0][0], Y: pt[0][1]},
+			{X: pt[1][0], Y: pt[1][1]},
+		})
+	}
+	return ps
+}
+
+func validText(s, what string, maxLen int) error {
+	if s == "" || utf8.RuneCountInString(s) > maxLen {
+		return fmt.Errorf("%s must be 1 to %d characters", what, maxLen)
+	}
+	for _, r := range s {
+		if !unicode.IsPrint(r) {
+			return fmt.Errorf("invalid character in %s", what)
+		}
+	}
+	return nil
+}
+
+// Validate checks the map can be played: the walls, portals, patrol paths
+// and tiles are on the board, there aren't too many, and they leave the
+// start and the first steps free. The tiles are off the walls and portals.
+func (m *Map) Validate() error {
+	m.Name = strings.TrimSpace(m.Name)
+	m.Author = strings.TrimSpace(m.Author)
+	if err := validText(m.Name, "name", maxNameLen); err != nil {
+		return err
+	}
+	if err := validText(m.Author, "author", maxAuthorLen); err != nil {
+		return err
+	}
+
+	if len(m.Walls) == 0 && len(m.Portals) == 0 && len(m.Obstacles) == 0 && len(m.Ice) == 0 && len(m.Mud) == 0 {
+		return errors.New("a map needs walls, portals, obstacles or tiles")
+	}
+	if len(m.Walls) > MaxWalls {
+		return fmt.Errorf("at most %d walls", MaxWalls)
+	}
+
+	seen := map[[2]int]bool{}
+	for _, w := range m.Walls {
+		if w[0] < 0 || w[0] > snake.BoardWidth || w[1] < 0 || w[1] > snake.BoardHeight {
+			return fmt.Errorf("wall %v out of the board", w)
+		}
+		if seen[w] {
+			return fmt.Errorf("wall %v twice", w)
+		}
+		seen[w] = true
+	}
+
+	if len(m.Portals) > MaxPortals {
+		return fmt.Errorf("at most %d portals", MaxPortals)
+	}
+	for _, pt := range m.Portals {
+		for _, p := range pt {
+			if p[0] < 0 || p[0] > snake.BoardWidth || p[1] < 0 || p[1] > snake.BoardHeight {
+				return fmt.Errorf("portal %v out of the board", p)
+			}
+			if seen[p] {
+				return fmt.Errorf("portal %v on a wall or another portal", p)
+			}
+			seen[p] = true
+		}
+	}
+
+	if len(m.Ice)+len(m.Mud) > MaxWalls {
+		return fmt.Errorf("at most %d tiles", MaxWalls)
+	}
+	for _, p := range slices.Concat(m.Ice, m.Mud) {
+		if p[0] < 0 || p[0] > snake.BoardWidth || p[1] < 0 || p[1] > snake.BoardHeight {
+			return fmt.Errorf("tile %v out of the board", p)
+		}
+		if seen[p] {
+			return fmt.Errorf("tile %v on a wall, a portal or another tile", p)
+		}
+		seen[p] = true
+	}
+
+	if len(m.Obstacles) > MaxObstacles {
+		return fmt.Errorf("at most %d obstacles", MaxObstacles)
+	}
+	patrolled := map[[2]int]bool{}
+	for _, o := range m.Obstacles {
+		if len(o.Path) == 0 || len(o.Path) > maxPatrol {
+			return fmt.Errorf("obstacle paths must be 1 to %d cells", maxPatrol)
+		}
+		if o.Every < 0 {
+			return fmt.Errorf("obstacle moving every %d steps", o.Every)
+		}
+		for i, p := range o.Path {
+			if p[0] < 0 || p[0] > snake.BoardWidth || p[1] < 0 || p[1] > snake.BoardHeight {
+				return fmt.Errorf("obstacle path %v out of the board", p)
+			}
+			if i > 0 && abs(p[0]-o.Path[i-1][0])+abs(p[1]-o.Path[i-1][1]) != 1 {
+				return fmt.Errorf("obstacle path jumps from %v to %v", o.Path[i-1], p)
+			}
+			patrolled[p] = true
+		}
+	}
+
+	// the snake starts heading right, on plain cells so it can turn
+	for i := range 4 {
+		if p := [2]int{snake.Start.X + i, snake.Start.Y}; seen[p] || patrolled[p] {
+			return fmt.Errorf("wall, portal, obstacle or tile %v blocks the start", p)
+		}
+	}
+	return nil
+}
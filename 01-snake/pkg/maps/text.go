@@ -0,0 +1,196 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package maps
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// ParseText reads a map drawn as text, the easiest way to make one: a
+// "name:" and an "author:" line, then the board row by row from the top,
+// '#' being a wall, a digit one end of a portal, the same digit twice making
+// a pair, '=' ice, '~' mud and anything else free. A "patrol:" line before the board adds an
+// obstacle: the steps it waits on a cell, then the corners of its path as
+// x,y, joined by straight lines.
+//
+//	name: Corridor
+//	author: jh
+//	patrol: 2 0,1 4,1
+//	1....
+//	.....
+//	.###.
+//	..==~
+//	....1
+func ParseText(data []byte) (Map, error) {
+	var m Map
+	// the cells of each digit, in the order they were seen
+	var ends [10][][2]int
+
+	s := bufio.NewScanner(bytes.NewReader(data))
+	y := 0
+	for s.Scan() {
+		line := strings.TrimRight(s.Text(), "\r")
+
+		if k, v, ok := strings.Cut(line, ":"); ok && y == 0 {
+			switch strings.ToLower(strings.TrimSpace(k)) {
+			case "name":
+				m.Name = strings.TrimSpace(v)
+				continue
+			case "author":
+				m.Author = strings.TrimSpace(v)
+				continue
+			case "patrol":
+				o, err := parsePatrol(v)
+				if err != nil {
+					return m, err
+				}
+				m.Obstacles = append(m.Obstacles, o)
+				continue
+			}
+		}
+
+		for x, r := range []rune(line) {
+			switch {
+			case r == '#':
+				m.Walls = append(m.Walls, [2]int{x, y})
+			case r >= '0' && r <= '9':
+				ends[r-'0'] = append(ends[r-'0'], [2]int{x, y})
+			case r == '=':
+				m.Ice = append(m.Ice, [2]int{x, y})
+			case r == '~':
+				m.Mud = append(m.Mud, [2]int{x, y})
+			}
+		}
+		y++
+	}
+	if err := s.Err(); err != nil {
+		return m, err
+	}
+
+	for d, cells := range ends {
+		switch len(cells) {
+		case 0:
+		case 2:
+			m.Portals = append(m.Portals, [2][2]int{cells[0], cells[1]})
+		default:
+			return m, fmt.Errorf("portal %d has %d ends, want 2", d, len(cells))
+		}
+	}
+
+	return m, m.Validate()
+}
+
+// parsePatrol reads the obstacle of a "patrol:" line.
+func parsePatrol(s string) (Obstacle, error) {
+	var o Obstacle
+	fields := strings.Fields(s)
+	if len(fields) < 2 {
+		return o, fmt.Errorf("patrol %q: want the steps per cell and the corners", s)
+	}
+	every, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return o, fmt.Errorf("patrol %q: %w", s, err)
+	}
+	o.Every = every
+
+	for _, f := range fields[1:] {
+		var p [2]int
+		if _, err := fmt.Sscanf(f, "%d,%d", &p[0], &p[1]); err != nil {
+			return o, fmt.Errorf("patrol %q: corner %q: %w", s, f, err)
+		}
+		if p[0] < 0 || p[0] > snake.BoardWidth || p[1] < 0 || p[1] > snake.BoardHeight {
+			return o, fmt.Errorf("patrol %q: corner %v out of the board", s, p)
+		}
+		if len(o.Path) == 0 {
+			o.Path = append(o.Path, p)
+			continue
+		}
+		last := o.Path[len(o.Path)-1]
+		if p[0] != last[0] && p[1] != last[1] {
+			return o, fmt.Errorf("patrol %q: %v isn't in line with %v", s, p, last)
+		}
+		for last != p {
+			last[0] += sign(p[0] - last[0])
+			last[1] += sign(p[1] - last[1])
+			o.Path = append(o.Path, last)
+		}
+	}
+	return o, nil
+}
+
+func sign(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
+
+// Parse reads a map file: JSON as kept by Install if name ends in .json,
+// drawn as text (see ParseText) otherwise.
+func Parse(name string, data []byte) (Map, error) {
+	if path.Ext(name) != ".json" {
+		return ParseText(data)
+	}
+
+	var m Map
+	if err := json.Unmarshal(data, &m); err != nil {
+		return m, err
+	}
+	return m, m.Validate()
+}
+
+// Load reads the maps in the .txt and .json files of dir in fsys, in the
+// order of the file names, e.g. levels shipped with a game:
+//
+//	//go:embed levels
+//	var levels embed.FS
+//
+//	ms, err := maps.Load(levels, "levels")
+func Load(fsys fs.FS, dir string) ([]Map, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var ms []Map
+	for _, e := range entries {
+		if ext := path.Ext(e.Name()); e.IsDir() || !slices.Contains([]string{".txt", ".json"}, ext) {
+			continue
+		}
+
+		name := path.Join(dir, e.Name())
+		data, err := fs.ReadFile(fsys, name)
+		if err != nil {
+			return nil, err
+		}
+		m, err := Parse(name, data)
+		if err != nil {
+			return nil, fmt.Errorf("maps: %s: %w", name, err)
+		}
+		ms = append(ms, m)
+	}
+	return ms, nil
+}
@@ -0,0 +1,308 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package metrics is a minimal Prometheus instrumentation library for the
+// game servers: counters, gauges and histograms served in the text exposition
+// format.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefBuckets are histogram buckets suited to request latencies, in seconds.
+var DefBuckets = []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5}
+
+type metric interface {
+	write(w *bufio.Writer)
+}
+
+// Registry holds the metrics and serves them over HTTP.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+func (r *Registry) register(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.metrics = append(r.metrics, m)
+}
+
+// ServeHTTP writes all metrics in the Prometheus text format.
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	metrics := slices.Clone(r.metrics)
+	r.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+
+	bw := bufio.NewWriter(w)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	bw.Flush()
+}
+
+type desc struct {
+	name   string
+	help   string
+	typ    string
+	labels []string
+}
+
+func (d *desc) header(w *bufio.Writer) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.name, escapeHelp(d.help), d.name, d.typ)
+}
+
+// key joins label values so they can be used as a map key.
+func (d *desc) key(values []string) string {
+	if len(values) != len(d.labels) {
+		panic(fmt.Sprintf("metrics: %s wants %d label values, got %d", d.name, len(d.labels), len(values)))
+	}
+	return strings.Join(values, "\xff")
+}
+
+// pairs formats the label pairs of key, extra are appended as is.
+func (d *desc) pairs(key string, extra ...string) string {
+	var parts []string
+	if len(d.labels) > 0 {
+		for i, v := range strings.Split(key, "\xff") {
+			parts = append(parts, d.labels[i]+`="`+escapeValue(v)+`"`)
+		}
+	}
+	parts = append(parts, extra...)
+
+	if len(parts) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+// values is a set of float values per label combination.
+type values struct {
+	desc
+
+	mu sync.Mutex
+	m  map[string]float64
+}
+
+func (v *values) add(delta float64, labels []string) {
+	k := v.key(labels)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.m[k] += delta
+}
+
+func (v *values) set(val float64, labels []string) {
+	k := v.key(labels)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.m[k] = val
+}
+
+func (v *values) write(w *bufio.Writer) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.header(w)
+	for _, k := range sortedKeys(v.m) {
+		fmt.Fprintf(w, "%s%s %s\n", v.name, v.pairs(k), formatFloat(v.m[k]))
+	}
+}
+
+// Counter is a value that only goes up, optionally split by labels.
+type Counter struct {
+	values
+}
+
+// NewCounter registers a counter, labels name its dimensions.
+func (r *Registry) NewCounter(name, help string, labels ...string) *Counter {
+	c := &Counter{values{desc: desc{name, help, "counter", labels}, m: map[string]float64{}}}
+	if len(labels) == 0 {
+		// show up as 0 before the first increment
+		c.m[""] = 0
+	}
+	r.register(c)
+	return c
+}
+
+// Inc adds one for the given label values.
+func (c *Counter) Inc(labels ...string) {
+	c.add(1, labels)
+}
+
+// Add adds delta, which must not be negative.
+func (c *Counter) Add(delta float64, labels ...string) {
+	if delta < 0 {
+		panic("metrics: counter decreased")
+	}
+	c.add(delta, labels)
+}
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	values
+}
+
+// NewGauge registers a gauge, labels name its dimensions.
+func (r *Registry) NewGauge(name, help string, labels ...string) *Gauge {
+	g := &Gauge{values{desc: desc{name, help, "gauge", labels}, m: map[string]float64{}}}
+	if len(labels) == 0 {
+		g.m[""] = 0
+	}
+	r.register(g)
+	return g
+}
+
+func (g *Gauge) Set(val float64, labels ...string) {
+	g.set(val, labels)
+}
+
+func (g *Gauge) Add(delta float64, labels ...string) {
+	g.add(delta, labels)
+}
+
+func (g *Gauge) Inc(labels ...string) {
+	g.add(1, labels)
+}
+
+func (g *Gauge) Dec(labels ...string) {
+	g.add(-1, labels)
+}
+
+// gaugeFunc is a gauge read when the metrics are scraped.
+type gaugeFunc struct {
+	desc
+	f func() float64
+}
+
+// NewGaugeFunc registers a gauge whose value is f's result at scrape time.
+// f must be safe to call from any goroutine.
+func (r *Registry) NewGaugeFunc(name, help string, f func() float64) {
+	r.register(&gaugeFunc{desc{name: name, help: help, typ: "gauge"}, f})
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	g.header(w)
+	fmt.Fprintf(w, "%s %s\n", g.name, formatFloat(g.f()))
+}
+
+// Histogram counts observations, like latencies, in buckets.
+type Histogram struct {
+	desc
+	buckets []float64
+
+	mu    sync.Mutex
+	count []uint64 // per bucket, not cumulative, the last one is +Inf
+	sum   float64
+	total uint64
+}
+
+// NewHistogram registers a histogram with the given upper bounds, sorted
+// ascending. DefBuckets is used when there are none.
+func (r *Registry) NewHistogram(name, help string, buckets []float64) *Histogram {
+	if len(buckets) == 0 {
+		buckets = DefBuckets
+	}
+	h := &Histogram{
+		desc:    desc{name: name, help: help, typ: "histogram"},
+		buckets: buckets,
+		count:   make([]uint64, len(buckets)+1),
+	}
+	r.register(h)
+	return h
+}
+
+func (h *Histogram) Observe(v float64) {
+	i := sort.SearchFloat64s(h.buckets, v)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.count[i]++
+	h.sum += v
+	h.total++
+}
+
+// Since observes the time passed since start, in seconds.
+func (h *Histogram) Since(start time.Time) {
+	h.Observe(time.Since(start).Seconds())
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.header(w)
+
+	var n uint64
+	for i, le := range h.buckets {
+		n += h.count[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", h.name, formatFloat(le), n)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.name, h.total)
+	fmt.Fprintf(w, "%s_sum %s\n", h.name, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count %d\n", h.name, h.total)
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	helpReplacer  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	valueReplacer = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(s string) string {
+	return helpReplacer.Replace(s)
+}
+
+func escapeValue(s string) string {
+	return valueReplacer.Replace(s)
+}
@@ -0,0 +1,138 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package particles is a small particle system for the effects of a game:
+// particles spawned with a velocity and a lifetime, moving, slowing down and
+// fading out as they age, one Update a tick.
+//
+// It has a random source of its own, the particles never taking anything
+// from the one of the game rules.
+package particles
+
+import (
+	"image/color"
+	"math"
+	"math/rand/v2"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// Particle is a dot moving across the screen. Positions are in pixels, the
+// velocity in pixels a tick and the lifetime in ticks.
+type Particle struct {
+	X, Y   float32
+	VX, VY float32
+	// Size is the side of the square drawn
+	Size  float32
+	Color color.Color
+	// Life is how many ticks it lives, Age how many it has
+	Life, Age int
+}
+
+// Burst is a number of particles spawned at once from a point, flying off
+// in all directions.
+type Burst struct {
+	// N is the number of particles
+	N int
+	// Speed is the fastest they fly off, in pixels a tick, each one at
+	// between half and all of it
+	Speed float32
+	// Life is the longest they live, in ticks, each one between half and
+	// all of it
+	Life  int
+	Size  float32
+	Color color.Color
+}
+
+// System is the particles alive. The zero value is an empty system ready to
+// use.
+type System struct {
+	// Drag is the part of their velocity the particles lose every tick,
+	// none by default
+	Drag float32
+	// Gravity is added to the vertical velocity every tick, none by default
+	Gravity float32
+
+	particles []Particle
+	rng       *rand.Rand
+}
+
+// Spawn adds a particle.
+func (s *System) Spawn(p Particle) {
+	if p.Life <= 0 {
+		return
+	}
+	s.particles = append(s.particles, p)
+}
+
+// Emit spawns the particles of b at (x, y).
+func (s *System) Emit(x, y float32, b Burst) {
+	if s.rng == nil {
+		s.rng = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	for range b.N {
+		a := s.rng.Float64() * 2 * math.Pi
+		v := b.Speed * (0.5 + s.rng.Float32()/2)
+		s.Spawn(Particle{
+			X:     x,
+			Y:     y,
+			VX:    v * float32(math.Cos(a)),
+			VY:    v * float32(math.Sin(a)),
+			Size:  b.Size,
+			Color: b.Color,
+			Life:  max(b.Life/2+s.rng.IntN(b.Life/2+1), 1),
+		})
+	}
+}
+
+// Update moves the particles by a tick, dropping those at the end of their
+// life.
+func (s *System) Update() {
+	kept := s.particles[:0]
+	for _, p := range s.particles {
+		if p.Age++; p.Age >= p.Life {
+			continue
+		}
+		p.X += p.VX
+		p.Y += p.VY
+		p.VX *= 1 - s.Drag
+		p.VY = p.VY*(1-s.Drag) + s.Gravity
+		kept = append(kept, p)
+	}
+	s.particles = kept
+}
+
+// Draw draws the particles on dst, fading out as they age.
+func (s *System) Draw(dst *ebiten.Image) {
+	for _, p := range s.particles {
+		r, g, b, a := p.Color.RGBA()
+		fade := 1 - float64(p.Age)/float64(p.Life)
+		c := color.RGBA64{
+			R: uint16(float64(r) * fade),
+			G: uint16(float64(g) * fade),
+			B: uint16(float64(b) * fade),
+			A: uint16(float64(a) * fade),
+		}
+		vector.DrawFilledRect(dst, p.X-p.Size/2, p.Y-p.Size/2, p.Size, p.Size, c, true)
+	}
+}
+
+// Len returns the number of particles alive.
+func (s *System) Len() int {
+	return len(s.particles)
+}
+
+// Clear drops all the particles.
+func (s *System) Clear() {
+	s.particles = s.particles[:0]
+}
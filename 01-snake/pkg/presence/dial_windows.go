@@ -0,0 +1,32 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package presence
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strconv"
+)
+
+// dial opens the first Discord IPC named pipe found.
+func dial() (io.ReadWriteCloser, error) {
+	for i := range 10 {
+		f, err := os.OpenFile(`\\.\pipe\discord-ipc-`+strconv.Itoa(i), os.O_RDWR, 0)
+		if err == nil {
+			return f, nil
+		}
+	}
+
+	return nil, errors.New("presence: discord is not running")
+}
@@ -0,0 +1,277 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package presence publishes what the player is doing to Discord Rich
+// Presence through the local Discord client's IPC socket.
+//
+// Everything is best effort: when Discord isn't running, or goes away, the
+// updates are silently dropped and the connection is retried later.
+package presence
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	opHandshake = 0
+	opFrame     = 1
+)
+
+const (
+	// Discord allows 5 activity updates per 20 seconds
+	updateInterval = 4 * time.Second
+	// how long to wait before trying to reach Discord again
+	retryInterval = 15 * time.Second
+	// how long Discord has to answer a request
+	replyTimeout = 5 * time.Second
+)
+
+// Activity is what's shown on the player's profile.
+type Activity struct {
+	// Details is the first line, e.g. the game being played.
+	Details string
+	// State is the second line, e.g. "Score 42".
+	State string
+	// Start is when the player started, shown as elapsed time.
+	Start time.Time
+}
+
+// Client publishes activities from a background goroutine, so Set never
+// blocks the game loop.
+type Client struct {
+	appID string
+
+	mu      sync.Mutex
+	pending *Activity
+
+	wake      chan struct{}
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New starts a client publishing for the given Discord application ID.
+func New(appID string) *Client {
+	c := &Client{
+		appID: appID,
+		wake:  make(chan struct{}, 1),
+		stop:  make(chan struct{}),
+		done:  make(chan struct{}),
+	}
+	go c.run()
+	return c
+}
+
+// Set queues a to be published, replacing any activity not sent yet.
+func (c *Client) Set(a Activity) {
+	c.mu.Lock()
+	c.pending = &a
+	c.mu.Unlock()
+
+	c.signal()
+}
+
+// Close stops the client and disconnects from Discord, which clears the
+// activity.
+func (c *Client) Close() {
+	c.closeOnce.Do(func() {
+		close(c.stop)
+	})
+	<-c.done
+}
+
+func (c *Client) signal() {
+	select {
+	case c.wake <- struct{}{}:
+	default:
+	}
+}
+
+// wait sleeps for d, returning false if the client got closed meanwhile.
+func (c *Client) wait(d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+
+	select {
+	case <-t.C:
+		return true
+	case <-c.stop:
+		return false
+	}
+}
+
+func (c *Client) run() {
+	defer close(c.done)
+
+	var (
+		conn  io.ReadWriteCloser
+		nonce int
+	)
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	for {
+		select {
+		case <-c.wake:
+		case <-c.stop:
+			return
+		}
+
+		c.mu.Lock()
+		a := c.pending
+		c.mu.Unlock()
+		if a == nil {
+			continue
+		}
+
+		if conn == nil {
+			var err error
+			if conn, err = c.connect(); err != nil {
+				conn = nil
+				if !c.wait(retryInterval) {
+					return
+				}
+				c.signal()
+				continue
+			}
+		}
+
+		nonce++
+		if err := setActivity(conn, a, nonce); err != nil {
+			conn.Close()
+			conn = nil
+			c.signal()
+			continue
+		}
+
+		c.mu.Lock()
+		if c.pending == a {
+			c.pending = nil
+		}
+		c.mu.Unlock()
+
+		if !c.wait(updateInterval) {
+			return
+		}
+	}
+}
+
+// setDeadline bounds the next exchange with Discord, if the connection
+// supports it.
+func setDeadline(conn any) {
+	if d, ok := conn.(interface{ SetDeadline(time.Time) error }); ok {
+		d.SetDeadline(time.Now().Add(replyTimeout))
+	}
+}
+
+func (c *Client) connect() (io.ReadWriteCloser, error) {
+	conn, err := dial()
+	if err != nil {
+		return nil, err
+	}
+
+	setDeadline(conn)
+	err = send(conn, opHandshake, map[string]any{
+		"v":         1,
+		"client_id": c.appID,
+	})
+	if err == nil {
+		err = receive(conn)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+func setActivity(conn io.ReadWriter, a *Activity, nonce int) error {
+	activity := map[string]any{}
+	if a.Details != "" {
+		activity["details"] = a.Details
+	}
+	if a.State != "" {
+		activity["state"] = a.State
+	}
+	if !a.Start.IsZero() {
+		activity["timestamps"] = map[string]any{"start": a.Start.Unix()}
+	}
+
+	setDeadline(conn)
+	err := send(conn, opFrame, map[string]any{
+		"cmd": "SET_ACTIVITY",
+		"args": map[string]any{
+			"pid":      os.Getpid(),
+			"activity": activity,
+		},
+		"nonce": strconv.Itoa(nonce),
+	})
+	if err != nil {
+		return err
+	}
+
+	return receive(conn)
+}
+
+// send writes a frame: little endian opcode and length followed by JSON.
+func send(w io.Writer, op uint32, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, 8, 8+len(data))
+	binary.LittleEndian.PutUint32(buf[0:], op)
+	binary.LittleEndian.PutUint32(buf[4:], uint32(len(data)))
+
+	_, err = w.Write(append(buf, data...))
+	return err
+}
+
+// receive reads a single frame, failing if Discord reported an error.
+func receive(r io.Reader) error {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return err
+	}
+
+	data := make([]byte, binary.LittleEndian.Uint32(header[4:]))
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+
+	var msg struct {
+		Evt  string `json:"evt"`
+		Data struct {
+			Message string `json:"message"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return err
+	}
+	if msg.Evt == "ERROR" {
+		return fmt.Errorf("presence: %s", msg.Data.Message)
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"slices"
+
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// Recorder records a run as it is played, with the state hash of every
+// step:
+//
+//	rec := replay.NewRecorder(seed)
+//	g := snake.New(seed)
+//	for g.State == snake.RUNNING {
+//		if d := next(); d != input.None {
+//			g.Turn(d.Delta())
+//			rec.Turn(input.DirOf(g.Direction.X, g.Direction.Y))
+//		}
+//		g.Step()
+//		rec.Step(g)
+//	}
+//	r := rec.Replay()
+type Recorder struct {
+	r Replay
+}
+
+// NewRecorder starts recording a game started with the seed.
+func NewRecorder(seed uint64) *Recorder {
+	return &Recorder{r: Replay{Seed: seed}}
+}
+
+// NewRulesRecorder starts recording a game started with the seed under the
+// rules, see RulesOf.
+func NewRulesRecorder(seed uint64, rules Rules) *Recorder {
+	return &Recorder{r: Replay{Seed: seed, Rules: rules}}
+}
+
+// Turn records the direction the snake heads to in the next step.
+func (rec *Recorder) Turn(d input.Dir) {
+	if n := len(rec.r.Turns); n > 0 && rec.r.Turns[n-1].Step == rec.r.Steps {
+		rec.r.Turns[n-1].Dir = d
+		return
+	}
+	rec.r.Turns = append(rec.r.Turns, Turn{Step: rec.r.Steps, Dir: d})
+}
+
+// Step records that g has just made a step.
+func (rec *Recorder) Step(g *snake.Game) {
+	rec.r.Steps++
+	rec.r.Score = g.Score
+	rec.r.Hashes = append(rec.r.Hashes, StepHash(g))
+}
+
+// Steps returns the number of steps recorded so far.
+func (rec *Recorder) Steps() int {
+	return rec.r.Steps
+}
+
+// Replay returns the run recorded so far.
+func (rec *Recorder) Replay() *Replay {
+	r := rec.r
+	r.Turns = slices.Clone(r.Turns)
+	r.Hashes = slices.Clone(r.Hashes)
+	return &r
+}
@@ -0,0 +1,341 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package replay records snake games as their seed plus the direction
+// changes per step, which is all the deterministic simulation in pkg/snake
+// needs to play them back, and verifies them.
+package replay
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+const (
+	magic = "SNKR"
+	// version changes with the format and with the rules, as replays only
+	// play back under the rules they were recorded with. TestGolden fails
+	// when the rules change the game without it.
+	version = 24
+
+	// MaxSteps bounds how long a replay can be, so verifying untrusted ones
+	// can't keep the server busy forever.
+	MaxSteps = 100_000
+)
+
+// Turn sets the direction right before the given step.
+type Turn struct {
+	Step int
+	Dir  input.Dir
+}
+
+// Replay is a single run, from the start to the crash.
+type Replay struct {
+	Seed uint64
+	// Rules are the rules and the board the run was played on.
+	Rules Rules
+	// Turns are the direction changes, ordered by step.
+	Turns []Turn
+	// Steps is the number of steps simulated, the last one crashing.
+	Steps int
+	// Score is the final score.
+	Score int
+	// Hashes are the state hashes after each step (StepHash), empty in
+	// replays recorded without them. Playing back compares them to find
+	// the exact step where the simulation went apart.
+	Hashes []uint32
+}
+
+// StepHash is the part of the state hash of g kept in replays.
+func StepHash(g *snake.Game) uint32 {
+	return uint32(g.Hash())
+}
+
+// DivergenceError tells where playing a replay back stopped matching the
+// recorded game.
+type DivergenceError struct {
+	Step      int
+	Got, Want uint32
+}
+
+func (e *DivergenceError) Error() string {
+	return fmt.Sprintf("replay: state diverged at step %d (hash %08x, recorded %08x)", e.Step, e.Got, e.Want)
+}
+
+// VersionError is returned for a replay of another version, recorded under
+// other rules or in another format: it can't be played back.
+type VersionError struct {
+	Version int
+}
+
+func (e *VersionError) Error() string {
+	if e.Version < version {
+		return fmt.Sprintf("replay: version %d was recorded under older rules, this game plays version %d", e.Version, version)
+	}
+	return fmt.Sprintf("replay: version %d is from a newer game, this one plays version %d", e.Version, version)
+}
+
+// MarshalBinary encodes the replay: the magic and version, then the seed,
+// steps, score and turns as varints, each turn's step relative to the
+// previous one, then the number of hashes and the hashes, 4 bytes each,
+// then the rules: the board's last column and row, 0 for the classic one,
+// the border, target, lives and growth, the flags (1 with combos, 2 in
+// zen, 4 with the chaser), the walls, portals, obstacles, ice and mud,
+// each list after its length, and the arena.
+func (r *Replay) MarshalBinary() ([]byte, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	var b bytes.Buffer
+	b.WriteString(magic)
+	b.WriteByte(version)
+
+	buf := make([]byte, binary.MaxVarintLen64)
+	put := func(v uint64) {
+		n := binary.PutUvarint(buf, v)
+		b.Write(buf[:n])
+	}
+
+	put(r.Seed)
+	put(uint64(r.Steps))
+	put(uint64(r.Score))
+	put(uint64(len(r.Turns)))
+
+	last := 0
+	for _, t := range r.Turns {
+		put(uint64(t.Step - last))
+		b.WriteByte(byte(t.Dir))
+		last = t.Step
+	}
+
+	put(uint64(len(r.Hashes)))
+	for _, h := range r.Hashes {
+		b.Write(binary.BigEndian.AppendUint32(nil, h))
+	}
+
+	rules := &r.Rules
+	point := func(p snake.Point) {
+		put(uint64(p.X))
+		put(uint64(p.Y))
+	}
+	flags := uint64(0)
+	if rules.Combos {
+		flags |= flagCombos
+	}
+	if rules.Zen {
+		flags |= flagZen
+	}
+	if rules.Chase {
+		flags |= flagChase
+	}
+	put(uint64(rules.Width))
+	put(uint64(rules.Height))
+	put(uint64(rules.Border))
+	put(uint64(rules.Target))
+	put(uint64(rules.Lives))
+	put(uint64(rules.Growth))
+	put(flags)
+	put(uint64(len(rules.Walls)))
+	for _, p := range rules.Walls {
+		point(p)
+	}
+	put(uint64(len(rules.Portals)))
+	for _, pt := range rules.Portals {
+		point(pt[0])
+		point(pt[1])
+	}
+	put(uint64(len(rules.Obstacles)))
+	for _, o := range rules.Obstacles {
+		put(uint64(o.Every))
+		put(uint64(len(o.Path)))
+		for _, p := range o.Path {
+			point(p)
+		}
+	}
+	for _, ps := range [...][]snake.Point{rules.Tiles.Ice, rules.Tiles.Mud} {
+		put(uint64(len(ps)))
+		for _, p := range ps {
+			point(p)
+		}
+	}
+	put(uint64(rules.Arena))
+
+	return b.Bytes(), nil
+}
+
+// UnmarshalBinary decodes a replay encoded by MarshalBinary.
+func (r *Replay) UnmarshalBinary(data []byte) error {
+	b := bytes.NewReader(data)
+
+	head := make([]byte, len(magic)+1)
+	if _, err := io.ReadFull(b, head); err != nil || string(head[:len(magic)]) != magic {
+		return errors.New("replay: not a replay")
+	}
+	if v := int(head[len(magic)]); v != version {
+		return &VersionError{Version: v}
+	}
+
+	var err error
+	get := func() uint64 {
+		if err != nil {
+			return 0
+		}
+		var v uint64
+		v, err = binary.ReadUvarint(b)
+		return v
+	}
+
+	seed, steps, score, n := get(), get(), get(), get()
+	if err != nil {
+		return fmt.Errorf("replay: %w", err)
+	}
+	if steps > MaxSteps || n > steps {
+		return errors.New("replay: too long")
+	}
+
+	*r = Replay{
+		Seed:  seed,
+		Steps: int(steps),
+		Score: int(score),
+		Turns: make([]Turn, 0, n),
+	}
+
+	step := 0
+	for range n {
+		step += int(get())
+
+		d, derr := b.ReadByte()
+		if err == nil {
+			err = derr
+		}
+		if err != nil {
+			return fmt.Errorf("replay: %w", err)
+		}
+
+		r.Turns = append(r.Turns, Turn{Step: step, Dir: input.Dir(d)})
+	}
+
+	nh := get()
+	if err != nil {
+		return fmt.Errorf("replay: %w", err)
+	}
+	if nh != 0 && nh != steps {
+		return errors.New("replay: hashes don't match the steps")
+	}
+
+	r.Hashes = make([]uint32, nh)
+	buf := make([]byte, 4)
+	for i := range r.Hashes {
+		if _, err := io.ReadFull(b, buf); err != nil {
+			return fmt.Errorf("replay: %w", err)
+		}
+		r.Hashes[i] = binary.BigEndian.Uint32(buf)
+	}
+
+	if err := r.Rules.unmarshal(get); err != nil {
+		return err
+	}
+	if err == nil && b.Len() > 0 {
+		err = errors.New("trailing data")
+	}
+	if err != nil {
+		return fmt.Errorf("replay: %w", err)
+	}
+
+	return r.validate()
+}
+
+func (r *Replay) validate() error {
+	if err := r.Rules.validate(); err != nil {
+		return err
+	}
+	if r.Steps <= 0 || r.Steps > MaxSteps {
+		return fmt.Errorf("replay: invalid number of steps %d", r.Steps)
+	}
+	if r.Score < 0 {
+		return errors.New("replay: negative score")
+	}
+	if len(r.Hashes) != 0 && len(r.Hashes) != r.Steps {
+		return fmt.Errorf("replay: %d hashes for %d steps", len(r.Hashes), r.Steps)
+	}
+
+	last := 0
+	for _, t := range r.Turns {
+		if t.Step < last || t.Step >= r.Steps {
+			return fmt.Errorf("replay: turn at step %d out of order", t.Step)
+		}
+		if t.Dir < input.Up || t.Dir > input.Left {
+			return fmt.Errorf("replay: invalid direction %d", t.Dir)
+		}
+		last = t.Step
+	}
+
+	return nil
+}
+
+// Play simulates r, returning the game right after its last step. It fails
+// if the run is over before that, or with a *DivergenceError at the first
+// step not matching the recorded hash.
+func Play(r *Replay) (*snake.Game, error) {
+	if err := r.validate(); err != nil {
+		return nil, err
+	}
+
+	g := r.Rules.New(r.Seed)
+
+	turns := r.Turns
+	for step := range r.Steps {
+		for len(turns) > 0 && turns[0].Step == step {
+			g.Direction.X, g.Direction.Y = turns[0].Dir.Delta()
+			turns = turns[1:]
+		}
+
+		// with lives left the snake shrinks and comes back
+		if g.State == snake.WON || (g.State != snake.RUNNING && g.LivesLeft() <= 1) {
+			return g, fmt.Errorf("replay: over at step %d of %d", step, r.Steps)
+		}
+		g.Step()
+
+		if len(r.Hashes) > 0 {
+			if h := StepHash(g); h != r.Hashes[step] {
+				return g, &DivergenceError{Step: step, Got: h, Want: r.Hashes[step]}
+			}
+		}
+	}
+
+	return g, nil
+}
+
+// Verify checks that r reproduces a run crashing, or won, at its last step
+// with the given score.
+func Verify(r *Replay, score int) error {
+	g, err := Play(r)
+	if err != nil {
+		return err
+	}
+
+	if g.State != snake.CRASHED && g.State != snake.WON {
+		return errors.New("replay: doesn't end with a crash or a win")
+	}
+	if g.Score != score || r.Score != score {
+		return fmt.Errorf("replay: score %d doesn't match the claimed %d", g.Score, score)
+	}
+
+	return nil
+}
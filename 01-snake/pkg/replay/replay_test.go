@@ -0,0 +1,228 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay_test
+
+import (
+	"encoding/binary"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"jhartman.pl/gamedev/pkg/bot"
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/replay"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+var update = flag.Bool("update", false, "record the golden replay again")
+
+// golden is a long run of the A* bot under the default rules, kept to catch
+// changes to the rules: played back by a game changed without bumping the
+// version, it diverges.
+var golden = filepath.Join("testdata", "golden.replay")
+
+// TestGolden plays back the golden run. After bumping the version, record
+// it again with -update.
+func TestGolden(t *testing.T) {
+	if *update {
+		const seed = 42
+		rec := replay.NewRecorder(seed)
+		g := snake.New(seed)
+		var b bot.AStar
+		for g.State == snake.RUNNING && rec.Steps() < 5000 {
+			if d := b.Observe(g); d != input.None {
+				g.Turn(d.Delta())
+				rec.Turn(input.DirOf(g.Direction.X, g.Direction.Y))
+			}
+			g.Step()
+			rec.Step(g)
+		}
+		data, err := rec.Replay().MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(golden, data, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var r replay.Replay
+	var verr *replay.VersionError
+	if err := r.UnmarshalBinary(data); errors.As(err, &verr) {
+		t.Fatalf("%v: record the golden run again with -update", err)
+	} else if err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := replay.Play(&r)
+	if err != nil {
+		t.Fatalf("%v: the rules changed, bump the version in replay.go and record the golden run again with -update", err)
+	}
+	if g.Score != r.Score {
+		t.Errorf("scored %d, recorded %d", g.Score, r.Score)
+	}
+}
+
+// recorded plays a run under the default rules, the A* bot eating until
+// the snake is 6 long, then it turning left every step until it
+// bites itself, and returns its replay.
+func recorded(t *testing.T) *replay.Replay {
+	t.Helper()
+	const seed = 1
+	rec := replay.NewRecorder(seed)
+	g := snake.New(seed)
+	var b bot.AStar
+	for g.State == snake.RUNNING && rec.Steps() < replay.MaxSteps {
+		d := input.DirOf(g.Direction.Y, -g.Direction.X)
+		if g.Snake.Len() < 6 {
+			d = b.Observe(g)
+		}
+		if d != input.None {
+			g.Turn(d.Delta())
+			rec.Turn(input.DirOf(g.Direction.X, g.Direction.Y))
+		}
+		g.Step()
+		rec.Step(g)
+	}
+	if g.State != snake.CRASHED {
+		t.Fatalf("the run ended in state %d with %d points", g.State, g.Score)
+	}
+	return rec.Replay()
+}
+
+func TestVerify(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// change tampers with the replay or the score claimed
+		change  func(r *replay.Replay, score *int)
+		wantErr string
+	}{
+		{"valid", func(r *replay.Replay, score *int) {}, ""},
+		{"score not matching", func(r *replay.Replay, score *int) {
+			*score++
+		}, "doesn't match the claimed"},
+		{"score of the replay not matching", func(r *replay.Replay, score *int) {
+			r.Score++
+		}, "doesn't match the claimed"},
+		{"turn changed", func(r *replay.Replay, score *int) {
+			r.Turns[0].Dir = r.Turns[0].Dir%4 + 1
+		}, "state diverged"},
+		{"turn changed without the hashes", func(r *replay.Replay, score *int) {
+			r.Hashes = nil
+			r.Turns[len(r.Turns)-1].Dir = r.Turns[len(r.Turns)-1].Dir%4 + 1
+		}, "doesn't end with a crash"},
+		{"cut short", func(r *replay.Replay, score *int) {
+			r.Steps--
+			r.Hashes = r.Hashes[:r.Steps]
+			for len(r.Turns) > 0 && r.Turns[len(r.Turns)-1].Step >= r.Steps {
+				r.Turns = r.Turns[:len(r.Turns)-1]
+			}
+		}, "doesn't end with a crash"},
+		{"played past the crash", func(r *replay.Replay, score *int) {
+			r.Steps++
+			r.Hashes = append(r.Hashes, 0)
+		}, "over at step"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := recorded(t)
+			score := r.Score
+			tc.change(r, &score)
+
+			err := replay.Verify(r, score)
+			switch {
+			case tc.wantErr == "" && err != nil:
+				t.Fatalf("got %v, want no error", err)
+			case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+				t.Errorf("got %v, want an error with %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestUnmarshalBinary(t *testing.T) {
+	r := recorded(t)
+	data, err := r.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got replay.Replay
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if err := replay.Verify(&got, r.Score); err != nil {
+		t.Errorf("decoded replay: %v", err)
+	}
+
+	// header returns the magic and version of data followed by the varints
+	header := func(vs ...uint64) []byte {
+		b := append([]byte(nil), data[:5]...)
+		for _, v := range vs {
+			b = binary.AppendUvarint(b, v)
+		}
+		return b
+	}
+	older := append([]byte(nil), data...)
+	older[4]--
+	newer := append([]byte(nil), data...)
+	newer[4]++
+
+	for _, tc := range []struct {
+		name    string
+		data    []byte
+		wantErr string
+	}{
+		{"empty", nil, "not a replay"},
+		{"other magic", append([]byte("PNG!"), data[4:]...), "not a replay"},
+		{"older version", older, "older rules"},
+		{"newer version", newer, "from a newer game"},
+		{"truncated header", data[:8], "replay:"},
+		{"truncated turns", data[:len(data)/4], "replay:"},
+		{"truncated hashes", data[:len(data)-40], "replay:"},
+		{"truncated rules", data[:len(data)-1], "replay:"},
+		{"trailing data", append(append([]byte(nil), data...), 0), "trailing data"},
+		{"too many steps", header(1, replay.MaxSteps+1, 0, 0), "too long"},
+		{"more turns than steps", header(1, 10, 0, 11), "too long"},
+		{"huge turn count", header(1, 10, 0, 1<<62), "too long"},
+		{"huge hash count", header(1, 10, 0, 0, 1<<62), "hashes don't match"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var r replay.Replay
+			err := r.UnmarshalBinary(tc.data)
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Errorf("got %v, want an error with %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestPlayDivergence(t *testing.T) {
+	r := recorded(t)
+	r.Hashes[len(r.Hashes)/2] ^= 1
+
+	_, err := replay.Play(r)
+	var div *replay.DivergenceError
+	if !errors.As(err, &div) || div.Step != len(r.Hashes)/2 {
+		t.Errorf("got %v, want a divergence at step %d", err, len(r.Hashes)/2)
+	}
+}
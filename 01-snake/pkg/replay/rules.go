@@ -0,0 +1,278 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package replay
+
+import (
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"slices"
+
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// the flags of the rules in the encoding
+const (
+	flagCombos = 1 << iota
+	flagZen
+	flagChase
+)
+
+// Rules are what the game is set up with besides the seed, the board and the
+// rules of snake.New if zero.
+type Rules struct {
+	// Width and Height are the last column and row of the board, see
+	// snake.NewLevelSize.
+	Width, Height int
+
+	Border snake.Border
+	Arena  snake.Arena
+	Target int
+	Lives  int
+	Growth int
+	Combos bool
+	Zen    bool
+	Chase  bool
+
+	Walls   []snake.Point
+	Portals []snake.Portal
+	// Obstacles are where they start, only their paths and pace count.
+	Obstacles []snake.Obstacle
+	Tiles     snake.Tiles
+}
+
+// RulesOf returns the rules g is set up with. Taken before the first step,
+// they start the same game again from its seed.
+func RulesOf(g *snake.Game) Rules {
+	r := Rules{
+		Border:  g.Border,
+		Arena:   g.Arena,
+		Target:  g.Target,
+		Lives:   g.Lives,
+		Growth:  g.Growth,
+		Combos:  g.Combos,
+		Zen:     g.Zen,
+		Chase:   g.Chase,
+		Walls:   slices.Clone(g.Walls),
+		Portals: slices.Clone(g.Portals),
+		Tiles:   snake.Tiles{Ice: slices.Clone(g.Tiles.Ice), Mud: slices.Clone(g.Tiles.Mud)},
+	}
+	for _, o := range g.Obstacles {
+		r.Obstacles = append(r.Obstacles, snake.Obstacle{Path: slices.Clone(o.Path), Every: o.Every})
+	}
+	if g.Width != snake.BoardWidth || g.Height != snake.BoardHeight {
+		r.Width, r.Height = g.Width, g.Height
+	}
+	return r
+}
+
+// Board returns the last column and row of the board.
+func (r *Rules) Board() (w, h int) {
+	if r.Width == 0 && r.Height == 0 {
+		return snake.BoardWidth, snake.BoardHeight
+	}
+	return r.Width, r.Height
+}
+
+// Default reports whether the rules are those of snake.New.
+func (r *Rules) Default() bool {
+	return r.Width == 0 && r.Height == 0 && r.Border == snake.BorderTurn && r.Arena == snake.RectArena && r.Target == 0 && r.Lives <= 1 && r.Growth <= 1 && !r.Combos && !r.Zen && !r.Chase &&
+		len(r.Walls) == 0 && len(r.Portals) == 0 && len(r.Obstacles) == 0 && r.Tiles.Empty()
+}
+
+// New starts a game under the rules, food placement being decided by seed.
+func (r *Rules) New(seed uint64) *snake.Game {
+	w, h := r.Board()
+	g := snake.NewLevelSize(seed, slices.Clone(r.Walls), w, h)
+	g.SetPortals(slices.Clone(r.Portals))
+	obstacles := make([]snake.Obstacle, 0, len(r.Obstacles))
+	for _, o := range r.Obstacles {
+		obstacles = append(obstacles, snake.Obstacle{Path: slices.Clone(o.Path), Every: o.Every})
+	}
+	g.SetObstacles(obstacles)
+	g.SetTiles(snake.Tiles{Ice: slices.Clone(r.Tiles.Ice), Mud: slices.Clone(r.Tiles.Mud)})
+	g.SetArena(r.Arena)
+	g.Border = r.Border
+	g.Target = r.Target
+	g.Lives = r.Lives
+	g.Growth = r.Growth
+	g.Combos = r.Combos
+	g.Zen = r.Zen
+	g.Chase = r.Chase
+	return g
+}
+
+// Same reports whether g still plays under the rules that can change
+// during a game, the board being set once.
+func (r *Rules) Same(g *snake.Game) bool {
+	return g.Border == r.Border && g.Target == r.Target && g.Lives == r.Lives && g.Growth == r.Growth && g.Combos == r.Combos && g.Zen == r.Zen && g.Chase == r.Chase
+}
+
+// ID returns a short name of the rules, the same for rules playing the same,
+// e.g. to file the runs played under them.
+func (r *Rules) ID() string {
+	h := fnv.New64a()
+	fmt.Fprint(h, r.Border, r.Target, max(r.Lives, 1), r.Combos, r.Zen, r.Walls, r.Portals)
+	if r.Growth > 1 {
+		// only when set, so the rules before it keep their IDs
+		fmt.Fprint(h, "growth", r.Growth)
+	}
+	if r.Chase {
+		// likewise
+		fmt.Fprint(h, "chase")
+	}
+	if !r.Tiles.Empty() {
+		// likewise
+		fmt.Fprint(h, "tiles", r.Tiles.Ice, r.Tiles.Mud)
+	}
+	if r.Width != 0 || r.Height != 0 {
+		// likewise
+		fmt.Fprint(h, r.Width, r.Height)
+	}
+	if r.Arena != snake.RectArena {
+		// likewise
+		fmt.Fprint(h, "arena", r.Arena)
+	}
+	for _, o := range r.Obstacles {
+		fmt.Fprint(h, o.Path, o.Every)
+	}
+	return fmt.Sprintf("%016x", h.Sum64())
+}
+
+func (r *Rules) validate() error {
+	if !slices.Contains(snake.Borders, r.Border) {
+		return fmt.Errorf("replay: invalid border %d", r.Border)
+	}
+	if !slices.Contains(snake.Arenas, r.Arena) {
+		return fmt.Errorf("replay: invalid arena %d", r.Arena)
+	}
+	if r.Target < 0 || r.Lives < 0 || r.Growth < 0 {
+		return errors.New("replay: negative target, lives or growth")
+	}
+
+	w, h := r.Board()
+	if w < snake.MinBoardSize || w > snake.MaxBoardSize || h < snake.MinBoardSize || h > snake.MaxBoardSize {
+		return fmt.Errorf("replay: invalid board %dx%d", w, h)
+	}
+	onBoard := func(p snake.Point) bool {
+		return p.X >= 0 && p.X <= w && p.Y >= 0 && p.Y <= h
+	}
+	for _, p := range r.Walls {
+		if !onBoard(p) {
+			return fmt.Errorf("replay: wall %v off the board", p)
+		}
+	}
+	for _, pt := range r.Portals {
+		if !onBoard(pt[0]) || !onBoard(pt[1]) {
+			return fmt.Errorf("replay: portal %v off the board", pt)
+		}
+	}
+	for _, p := range slices.Concat(r.Tiles.Ice, r.Tiles.Mud) {
+		if !onBoard(p) {
+			return fmt.Errorf("replay: tile %v off the board", p)
+		}
+	}
+	for _, o := range r.Obstacles {
+		if len(o.Path) == 0 || o.Every < 0 {
+			return errors.New("replay: invalid obstacle")
+		}
+		for _, p := range o.Path {
+			if !onBoard(p) {
+				return fmt.Errorf("replay: obstacle at %v off the board", p)
+			}
+		}
+	}
+	return nil
+}
+
+// unmarshal decodes the rules encoded by Replay.MarshalBinary, reading the
+// varints with get.
+func (r *Rules) unmarshal(get func() uint64) error {
+	point := func() snake.Point {
+		return snake.Point{X: int(get()), Y: int(get())}
+	}
+	width, height := get(), get()
+	if width > snake.MaxBoardSize || height > snake.MaxBoardSize {
+		return errors.New("replay: invalid board")
+	}
+	// no list can be longer than the board has cells
+	cells := uint64(snake.BoardWidth+1) * (snake.BoardHeight + 1)
+	if width != 0 || height != 0 {
+		cells = (width + 1) * (height + 1)
+	}
+
+	border, target, lives, growth, flags := get(), get(), get(), get(), get()
+	if border >= uint64(len(snake.Borders)) || target > cells || lives > cells || growth > cells || flags&^(flagCombos|flagZen|flagChase) != 0 {
+		return errors.New("replay: invalid rules")
+	}
+	*r = Rules{
+		Width:  int(width),
+		Height: int(height),
+		Border: snake.Border(border),
+		Target: int(target),
+		Lives:  int(lives),
+		Growth: int(growth),
+		Combos: flags&flagCombos != 0,
+		Zen:    flags&flagZen != 0,
+		Chase:  flags&flagChase != 0,
+	}
+
+	n := get()
+	if n > cells {
+		return errors.New("replay: too many walls")
+	}
+	for range n {
+		r.Walls = append(r.Walls, point())
+	}
+
+	n = get()
+	if n > cells {
+		return errors.New("replay: too many portals")
+	}
+	for range n {
+		r.Portals = append(r.Portals, snake.Portal{point(), point()})
+	}
+
+	n = get()
+	if n > cells {
+		return errors.New("replay: too many obstacles")
+	}
+	for range n {
+		o := snake.Obstacle{Every: int(get())}
+		steps := get()
+		if steps > cells || o.Every > MaxSteps {
+			return errors.New("replay: invalid obstacle")
+		}
+		for range steps {
+			o.Path = append(o.Path, point())
+		}
+		r.Obstacles = append(r.Obstacles, o)
+	}
+
+	for _, ps := range [...]*[]snake.Point{&r.Tiles.Ice, &r.Tiles.Mud} {
+		n = get()
+		if n > cells {
+			return errors.New("replay: too many tiles")
+		}
+		for range n {
+			*ps = append(*ps, point())
+		}
+	}
+
+	arena := get()
+	if arena >= uint64(len(snake.Arenas)) {
+		return errors.New("replay: invalid arena")
+	}
+	r.Arena = snake.Arena(arena)
+	return nil
+}
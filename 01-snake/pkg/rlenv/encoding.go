@@ -0,0 +1,131 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rlenv
+
+import (
+	"fmt"
+
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// Encoding turns the state of the game into an observation.
+type Encoding interface {
+	// Shape of the observations, their length is the product.
+	Shape() []int
+	Encode(g *snake.Game) []float32
+}
+
+const (
+	gridW = snake.BoardWidth + 1
+	gridH = snake.BoardHeight + 1
+)
+
+// grid channels
+const (
+	channelHead = iota
+	channelBody
+	channelFood
+	gridChannels
+)
+
+type gridEncoding struct{}
+
+// Grid encodes the board as a channels x height x width tensor of ones and
+// zeros, the channels being the head, the rest of the body and the food. Good
+// for convolutional networks.
+var Grid Encoding = gridEncoding{}
+
+func (gridEncoding) Shape() []int {
+	return []int{gridChannels, gridH, gridW}
+}
+
+func (gridEncoding) Encode(g *snake.Game) []float32 {
+	obs := make([]float32, gridChannels*gridH*gridW)
+
+	set := func(channel int, p snake.Point) {
+		if p.X >= 0 && p.X < gridW && p.Y >= 0 && p.Y < gridH {
+			obs[(channel*gridH+p.Y)*gridW+p.X] = 1
+		}
+	}
+
+	for i, p := range g.Snake.All() {
+		if i == 0 {
+			set(channelHead, p)
+		} else {
+			set(channelBody, p)
+		}
+	}
+	set(channelFood, g.Food)
+
+	return obs
+}
+
+type featureEncoding struct{}
+
+// Features encodes what matters around the head as a small vector, good for
+// simple networks and tabular methods:
+//
+//	0-2   crashing when going straight, turning left, turning right
+//	3-6   moving up, right, down, left
+//	7-10  food is up, right, down, left of the head
+//	11    length of the snake relative to the board
+var Features Encoding = featureEncoding{}
+
+func (featureEncoding) Shape() []int {
+	return []int{12}
+}
+
+func (featureEncoding) Encode(g *snake.Game) []float32 {
+	obs := make([]float32, 12)
+
+	dir := input.DirOf(g.Direction.X, g.Direction.Y)
+	if dir == input.None {
+		panic(fmt.Sprintf("rlenv: invalid direction %v", g.Direction))
+	}
+
+	// relative to the direction: straight, left, right
+	for i, d := range []input.Dir{dir, turn(dir, -1), turn(dir, 1)} {
+		if g.Blocked(g.Ahead(d.Delta())) {
+			obs[i] = 1
+		}
+	}
+
+	obs[3+int(dir-input.Up)] = 1
+
+	head, food := g.Snake.Head(), g.Food
+	obs[7] = flag(food.Y < head.Y)
+	obs[8] = flag(food.X > head.X)
+	obs[9] = flag(food.Y > head.Y)
+	obs[10] = flag(food.X < head.X)
+
+	obs[11] = float32(g.Snake.Len()) / float32(gridW*gridH)
+
+	return obs
+}
+
+// turn rotates d clockwise by quarter turns, counterclockwise if negative.
+func turn(d input.Dir, quarters int) input.Dir {
+	i := (int(d-input.Up) + quarters) % len(input.Dirs)
+	if i < 0 {
+		i += len(input.Dirs)
+	}
+	return input.Dirs[i]
+}
+
+func flag(b bool) float32 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,63 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rlenv
+
+import (
+	"fmt"
+
+	"jhartman.pl/gamedev/pkg/input"
+)
+
+// Policy picks the action for an observation.
+type Policy func(obs []float32) input.Dir
+
+// Stats summarize the episodes of an evaluation.
+type Stats struct {
+	Episodes  int
+	MeanScore float64
+	MaxScore  int
+	MeanSteps float64
+	Crashes   int // episodes that didn't end by starving
+}
+
+func (s Stats) String() string {
+	return fmt.Sprintf("%d episodes: score %.2f mean, %d max, %.1f steps mean, %d crashes",
+		s.Episodes, s.MeanScore, s.MaxScore, s.MeanSteps, s.Crashes)
+}
+
+// Evaluate plays episodes with p, seeded seed, seed+1, ..., so policies can
+// be compared on the same games.
+func Evaluate(e *Env, p Policy, episodes int, seed uint64) Stats {
+	s := Stats{Episodes: episodes}
+
+	var score, steps int
+	for i := range episodes {
+		obs := e.Reset(seed + uint64(i))
+		for done := false; !done; {
+			obs, _, done = e.Step(p(obs))
+		}
+
+		score += e.Score()
+		steps += e.Steps()
+		s.MaxScore = max(s.MaxScore, e.Score())
+		if !e.Truncated() {
+			s.Crashes++
+		}
+	}
+
+	if episodes > 0 {
+		s.MeanScore = float64(score) / float64(episodes)
+		s.MeanSteps = float64(steps) / float64(episodes)
+	}
+	return s
+}
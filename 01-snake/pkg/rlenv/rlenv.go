@@ -0,0 +1,158 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rlenv wraps the snake simulation as a reinforcement learning
+// environment in the style of Gym: Reset starts an episode, Step applies an
+// action and returns the observation, the reward and whether the episode is
+// over. Observations are flat float32 vectors, see Encoding.
+package rlenv
+
+import (
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// NumActions is the size of the action space, the actions being the
+// input.Dir values: keep going, up, right, down and left.
+const NumActions = int(input.Left) + 1
+
+// Rewards are given for what happened in a step.
+type Rewards struct {
+	Food  float64 // eating
+	Crash float64 // crashing, usually negative
+	Step  float64 // any other step, negative to hurry the agent up
+}
+
+// DefaultRewards are +1 for food and -1 for crashing.
+var DefaultRewards = Rewards{Food: 1, Crash: -1}
+
+// Options configure an environment, zero values select the defaults.
+type Options struct {
+	// Encoding of the observations, Grid by default.
+	Encoding Encoding
+	// Rewards, DefaultRewards if zero.
+	Rewards Rewards
+	// MaxIdleSteps ends an episode when the snake hasn't eaten for that
+	// many steps, as it could otherwise circle forever. Defaults to the
+	// number of cells of the board.
+	MaxIdleSteps int
+}
+
+// Env is an environment, it isn't safe for concurrent use. Run one per
+// goroutine to simulate in parallel.
+type Env struct {
+	enc          Encoding
+	rewards      Rewards
+	maxIdleSteps int
+
+	game      *snake.Game
+	steps     int
+	idleSteps int
+	done      bool
+	truncated bool
+}
+
+func New(opts Options) *Env {
+	e := &Env{
+		enc:          opts.Encoding,
+		rewards:      opts.Rewards,
+		maxIdleSteps: opts.MaxIdleSteps,
+	}
+	if e.enc == nil {
+		e.enc = Grid
+	}
+	if e.rewards == (Rewards{}) {
+		e.rewards = DefaultRewards
+	}
+	if e.maxIdleSteps <= 0 {
+		e.maxIdleSteps = (snake.BoardWidth + 1) * (snake.BoardHeight + 1)
+	}
+	return e
+}
+
+// Shape is the shape of the observations.
+func (e *Env) Shape() []int {
+	return e.enc.Shape()
+}
+
+// Reset starts an episode, seed decides where the food appears.
+func (e *Env) Reset(seed uint64) []float32 {
+	e.game = snake.New(seed)
+	e.steps = 0
+	e.idleSteps = 0
+	e.done = false
+	e.truncated = false
+
+	return e.enc.Encode(e.game)
+}
+
+// Step moves the snake after turning it to a. Once done, Reset has to be
+// called before stepping again.
+func (e *Env) Step(a input.Dir) (obs []float32, reward float64, done bool) {
+	if e.game == nil || e.done {
+		panic("rlenv: Step called without Reset")
+	}
+
+	g := e.game
+	if a != input.None {
+		g.Turn(a.Delta())
+	}
+
+	score := g.Score
+	g.Step()
+	e.steps++
+	e.idleSteps++
+
+	switch {
+	case g.State == snake.WON:
+		// won eating the last food
+		reward = e.rewards.Food
+		e.done = true
+	case g.State != snake.RUNNING:
+		reward = e.rewards.Crash
+		e.done = true
+	case g.Score > score:
+		reward = e.rewards.Food
+		e.idleSteps = 0
+	default:
+		reward = e.rewards.Step
+	}
+
+	if !e.done && e.idleSteps >= e.maxIdleSteps {
+		e.done = true
+		e.truncated = true
+	}
+
+	return e.enc.Encode(g), reward, e.done
+}
+
+// Truncated reports whether the episode ended because the snake went too
+// long without food rather than by crashing.
+func (e *Env) Truncated() bool {
+	return e.truncated
+}
+
+// Score is the score of the current episode.
+func (e *Env) Score() int {
+	return e.game.Score
+}
+
+// Steps is the number of steps of the current episode.
+func (e *Env) Steps() int {
+	return e.steps
+}
+
+// Game exposes the simulation, e.g. to render it or for scripted policies.
+// It must not be modified.
+func (e *Env) Game() *snake.Game {
+	return e.game
+}
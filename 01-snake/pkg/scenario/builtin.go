@@ -0,0 +1,289 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scenario
+
+import (
+	"fmt"
+	"slices"
+
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// start is where the snake starts, heading right
+var start = snake.Start
+
+// Builtin are the scenarios covering the rules.
+var Builtin = []Scenario{
+	{
+		Name:  "crashes into a wall and restarts",
+		Seed:  1,
+		Walls: []snake.Point{{X: start.X + 5, Y: start.Y}},
+		Steps: 7,
+		Want: func(r *Result) error {
+			return all(
+				steps("crashed", r.Crashes, 5),
+				// a step to stop, one to shrink back to the head
+				steps("restarted", r.Restarts, 7),
+				head(r, snake.Point{X: start.X + 5, Y: start.Y}),
+			)
+		},
+	},
+	{
+		Name:  "comes back at the start with a life left",
+		Seed:  1,
+		Walls: []snake.Point{{X: start.X + 5, Y: start.Y}},
+		Lives: 2,
+		Steps: 14,
+		Want: func(r *Result) error {
+			return all(
+				steps("crashed", r.Crashes, 5, 12),
+				steps("restarted", r.Restarts, 7, 14),
+				// the second crash is the last life, the run starts over
+				// where it ended
+				head(r, snake.Point{X: start.X + 5, Y: start.Y}),
+			)
+		},
+	},
+	{
+		Name: "goes through a portal",
+		Seed: 1,
+		Portals: []snake.Portal{
+			{{X: start.X + 3, Y: start.Y}, {X: 5, Y: 5}},
+		},
+		Steps: 5,
+		Want: func(r *Result) error {
+			return all(
+				steps("crashed", r.Crashes),
+				// out of the twin on the third step, then on to the right
+				head(r, snake.Point{X: 7, Y: 5}),
+			)
+		},
+	},
+	{
+		Name: "crashes into an obstacle moving into the head",
+		Seed: 1,
+		Obstacles: []snake.Obstacle{{
+			Path:  []snake.Point{{X: start.X + 6, Y: start.Y - 1}, {X: start.X + 6, Y: start.Y}},
+			Every: 2,
+		}},
+		Steps: 6,
+		Want: func(r *Result) error {
+			return all(
+				// the head gets there first, the obstacle moves down after
+				steps("crashed", r.Crashes, 6),
+				head(r, snake.Point{X: start.X + 6, Y: start.Y}),
+			)
+		},
+	},
+	{
+		Name: "ignores reversing into the neck",
+		// the first food is 2 cells ahead
+		Seed:   1785,
+		Walls:  []snake.Point{{X: start.X + 5, Y: start.Y}},
+		Script: map[int]input.Dir{3: input.Left},
+		Steps:  5,
+		Want: func(r *Result) error {
+			return all(
+				steps("ate", r.Eaten, 2),
+				steps("crashed", r.Crashes, 5),
+			)
+		},
+	},
+	{
+		Name:   "reverses a lone head",
+		Seed:   1,
+		Walls:  []snake.Point{{X: start.X - 3, Y: start.Y}},
+		Script: map[int]input.Dir{1: input.Left},
+		Steps:  3,
+		Want: func(r *Result) error {
+			return steps("crashed", r.Crashes, 3)
+		},
+	},
+	{
+		Name:   "turns right at the top",
+		Seed:   1,
+		Script: map[int]input.Dir{1: input.Up},
+		Steps:  start.Y + 1,
+		Want: func(r *Result) error {
+			return all(
+				head(r, snake.Point{X: start.X + 1, Y: 0}),
+				heading(r, input.Right),
+			)
+		},
+	},
+	{
+		Name:   "turns down in the top right corner",
+		Seed:   1,
+		Script: map[int]input.Dir{1: input.Up},
+		Steps:  start.Y + snake.BoardWidth - start.X + 1,
+		Want: func(r *Result) error {
+			return all(
+				head(r, snake.Point{X: snake.BoardWidth, Y: 1}),
+				heading(r, input.Down),
+			)
+		},
+	},
+	{
+		Name:   "crashes into a solid border",
+		Seed:   1,
+		Border: snake.BorderSolid,
+		Script: map[int]input.Dir{1: input.Up},
+		Steps:  start.Y + 1,
+		Want: func(r *Result) error {
+			return all(
+				steps("crashed", r.Crashes, start.Y+1),
+				// stopped at the edge
+				head(r, snake.Point{X: start.X, Y: 0}),
+			)
+		},
+	},
+	{
+		Name:   "wraps through the top",
+		Seed:   1,
+		Border: snake.BorderWrap,
+		Script: map[int]input.Dir{1: input.Up},
+		Steps:  start.Y + 2,
+		Want: func(r *Result) error {
+			return all(
+				steps("crashed", r.Crashes),
+				head(r, snake.Point{X: start.X, Y: snake.BoardHeight - 1}),
+				heading(r, input.Up),
+			)
+		},
+	},
+	{
+		Name: "timed food comes and goes",
+		Seed: 1,
+		// round the edge, out of its way
+		Steps: snake.TimedEvery + snake.TimedLife,
+		Want: func(r *Result) error {
+			g := r.Game
+			if g.Timed != nil || g.TimedWait != snake.TimedEvery {
+				return fmt.Errorf("timed food at %v, next in %d steps, want gone, next in %d", g.Timed, g.TimedWait, snake.TimedEvery)
+			}
+			return nil
+		},
+	},
+	{
+		Name:  "greedy eats, crashes and plays on",
+		Seed:  1,
+		Bot:   "greedy",
+		Steps: 3000,
+		Want: func(r *Result) error {
+			return all(
+				atLeast("best score", r.Best, 20),
+				atLeast("crashes", len(r.Crashes), 1),
+				atLeast("restarts", len(r.Restarts), len(r.Crashes)-1),
+			)
+		},
+	},
+	{
+		Name:  "astar eats a hundred without crashing",
+		Seed:  1,
+		Bot:   "astar",
+		Steps: 3000,
+		Want: func(r *Result) error {
+			return all(
+				steps("crashed", r.Crashes),
+				atLeast("best score", r.Best, 100),
+			)
+		},
+	},
+	{
+		Name:   "astar wins at the target length",
+		Seed:   1,
+		Bot:    "astar",
+		Target: 40,
+		Steps:  3000,
+		Want: func(r *Result) error {
+			g := r.Game
+			if g.State != snake.WON || g.Snake.Len() != 40 {
+				return fmt.Errorf("state %d at length %d, want won at 40", g.State, g.Snake.Len())
+			}
+			return all(
+				steps("crashed", r.Crashes),
+				atLeast("wins", len(r.Wins), 1),
+			)
+		},
+	},
+	{
+		Name:   "astar multiplies the food eaten quickly",
+		Seed:   1,
+		Bot:    "astar",
+		Combos: true,
+		Steps:  300,
+		Want: func(r *Result) error {
+			// the same game without the combos, the bot doesn't mind the
+			// score
+			plain, err := Scenario{Seed: 1, Bot: "astar", Steps: 300}.Run()
+			if err != nil {
+				return err
+			}
+			if !slices.Equal(r.Eaten, plain.Eaten) || r.Best <= plain.Best {
+				return fmt.Errorf("scored %d eating at %v, %d without combos eating at %v", r.Best, r.Eaten, plain.Best, plain.Eaten)
+			}
+			return nil
+		},
+	},
+	{
+		Name:  "hamiltonian never crashes",
+		Seed:  1,
+		Bot:   "hamiltonian",
+		Steps: 5000,
+		Want: func(r *Result) error {
+			return all(
+				steps("crashed", r.Crashes),
+				atLeast("best score", r.Best, 1),
+			)
+		},
+	},
+}
+
+// all returns the first error.
+func all(errs ...error) error {
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func steps(what string, got []int, want ...int) error {
+	if !slices.Equal(got, want) {
+		return fmt.Errorf("%s at steps %v, want %v", what, got, want)
+	}
+	return nil
+}
+
+func head(r *Result, want snake.Point) error {
+	if h := r.Game.Snake.Head(); h != want {
+		return fmt.Errorf("head at %v, want %v", h, want)
+	}
+	return nil
+}
+
+func heading(r *Result, want input.Dir) error {
+	if d := input.DirOf(r.Game.Direction.X, r.Game.Direction.Y); d != want {
+		return fmt.Errorf("heading %v, want %v", d, want)
+	}
+	return nil
+}
+
+func atLeast(what string, got, want int) error {
+	if got < want {
+		return fmt.Errorf("%s %d, want at least %d", what, got, want)
+	}
+	return nil
+}
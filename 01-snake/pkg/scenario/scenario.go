@@ -0,0 +1,143 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scenario plays whole games of the simulation in pkg/snake
+// headlessly, steered by a script or one of the bots, and checks how they
+// end: the crashes and restarts and the steps they happen at, the score, the
+// final state. The invariants and properties of the rules (snake.Game.Check
+// and snake.Monitor) are checked after every step on the way.
+//
+// Builtin has the scenarios run by go test. A rule change should come
+// with scenarios covering it.
+package scenario
+
+import (
+	"fmt"
+
+	"jhartman.pl/gamedev/pkg/bot"
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// Scenario is a game and what it must come to.
+type Scenario struct {
+	Name  string
+	Seed  uint64
+	Walls []snake.Point
+	// Portals are pairs of cells taking the head from one to the other.
+	Portals []snake.Portal
+	// Obstacles patrol the board.
+	Obstacles []snake.Obstacle
+	// Border is the rule at the edge of the board.
+	Border snake.Border
+	// Target is the length winning the run, see snake.Game.Target.
+	Target int
+	// Lives are the crashes a run takes, see snake.Game.Lives.
+	Lives int
+	// Combos multiply the points of the food eaten quickly, see
+	// snake.Game.Combos.
+	Combos bool
+	// Script turns the snake before the given steps, counted from 1.
+	Script map[int]input.Dir
+	// Bot steers the snake, one of bot.Names, after the script if both are
+	// set.
+	Bot string
+	// Steps is how many steps are played.
+	Steps int
+	// Want checks the game once played.
+	Want func(r *Result) error
+}
+
+// Result is how a game went.
+type Result struct {
+	// Game is the final state.
+	Game *snake.Game
+	// Eaten, Crashes, Restarts and Wins are the steps at which the snake
+	// ate, the game crashed, the game restarted after shrinking and the run
+	// was won.
+	Eaten    []int
+	Crashes  []int
+	Restarts []int
+	Wins     []int
+	// Best is the highest score.
+	Best int
+}
+
+// Run plays s.
+func (s Scenario) Run() (*Result, error) {
+	var c bot.Controller
+	if s.Bot != "" {
+		var err error
+		if c, err = bot.ByName(s.Bot); err != nil {
+			return nil, err
+		}
+	}
+
+	g := snake.NewLevel(s.Seed, s.Walls)
+	g.Border = s.Border
+	g.SetPortals(s.Portals)
+	g.SetObstacles(s.Obstacles)
+	g.Target = s.Target
+	g.Lives = s.Lives
+	g.Combos = s.Combos
+	r := &Result{Game: g}
+
+	var m snake.Monitor
+	if err := check(g, &m); err != nil {
+		return r, fmt.Errorf("at the start: %w", err)
+	}
+
+	for step := 1; step <= s.Steps; step++ {
+		if d, ok := s.Script[step]; ok {
+			g.Turn(d.Delta())
+		}
+		if c != nil && g.State == snake.RUNNING {
+			if d := c.Observe(g); d != input.None {
+				g.Turn(d.Delta())
+			}
+		}
+
+		state, score := g.State, g.Score
+		g.Step()
+
+		if err := check(g, &m); err != nil {
+			return r, fmt.Errorf("step %d: %w", step, err)
+		}
+
+		if g.Score > score {
+			r.Eaten = append(r.Eaten, step)
+			r.Best = max(r.Best, g.Score)
+		}
+		switch {
+		case state == snake.RUNNING && g.State == snake.CRASHED:
+			r.Crashes = append(r.Crashes, step)
+		case state == snake.CRASHING && g.State == snake.RUNNING:
+			r.Restarts = append(r.Restarts, step)
+		case state == snake.RUNNING && g.State == snake.WON:
+			r.Wins = append(r.Wins, step)
+		}
+	}
+
+	if s.Want != nil {
+		if err := s.Want(r); err != nil {
+			return r, err
+		}
+	}
+	return r, nil
+}
+
+func check(g *snake.Game, m *snake.Monitor) error {
+	if err := g.Check(); err != nil {
+		return err
+	}
+	return m.Observe(g)
+}
@@ -0,0 +1,38 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scenario_test
+
+import (
+	"testing"
+
+	"jhartman.pl/gamedev/pkg/scenario"
+)
+
+// TestBuiltin plays the built-in scenarios, each a subtest named after it:
+//
+//	go test ./pkg/scenario -run Builtin/corner -v
+func TestBuiltin(t *testing.T) {
+	for _, s := range scenario.Builtin {
+		t.Run(s.Name, func(t *testing.T) {
+			t.Parallel()
+			r, err := s.Run()
+			if err == nil {
+				return
+			}
+			if r != nil {
+				t.Logf("ended with head %v, length %d, score %d, state %d", r.Game.Snake.Head(), r.Game.Snake.Len(), r.Game.Score, r.Game.State)
+			}
+			t.Fatal(err)
+		})
+	}
+}
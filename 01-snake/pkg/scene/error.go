@@ -0,0 +1,115 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package scene
+
+import (
+	"image/color"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/ebitenutil"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// width of a character of the debug font
+const charWidth = 6
+
+// errorScene replaces a scene whose Update failed, showing why until the
+// player presses anything.
+type errorScene struct {
+	err error
+	// failed is the scene that failed, for its layout
+	failed ebiten.Game
+	// last is whether there's no scene to go back to
+	last bool
+
+	done bool
+	keys []ebiten.Key
+	ids  []ebiten.TouchID
+	pads []ebiten.GamepadID
+}
+
+// pressed reports whether the player pressed anything since the last tick.
+func (s *errorScene) pressed() bool {
+	s.keys = inpututil.AppendJustPressedKeys(s.keys[:0])
+	s.ids = inpututil.AppendJustPressedTouchIDs(s.ids[:0])
+	if len(s.keys) > 0 || len(s.ids) > 0 || inpututil.IsMouseButtonJustPressed(ebiten.MouseButtonLeft) {
+		return true
+	}
+
+	s.pads = ebiten.AppendGamepadIDs(s.pads[:0])
+	for _, id := range s.pads {
+		for b := range ebiten.StandardGamepadButtonMax + 1 {
+			if inpututil.IsStandardGamepadButtonJustPressed(id, b) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (s *errorScene) Update() error {
+	if s.pressed() {
+		s.done = true
+	}
+	return nil
+}
+
+func (s *errorScene) Draw(screen *ebiten.Image) {
+	screen.Fill(color.Black)
+
+	msg := "Something went wrong:\n\n" + wrap(s.err.Error(), screen.Bounds().Dx()/charWidth-2) + "\n\n"
+	if s.last {
+		msg += "Press any key to quit"
+	} else {
+		msg += "Press any key to go back"
+	}
+	ebitenutil.DebugPrintAt(screen, msg, charWidth, 16)
+}
+
+func (s *errorScene) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return s.failed.Layout(outsideWidth, outsideHeight)
+}
+
+// wrap breaks msg into lines of at most width characters, between words
+// where it can.
+func wrap(msg string, width int) string {
+	width = max(width, 1)
+
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(msg) {
+		for len(word) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			lines = append(lines, word[:width])
+			word = word[width:]
+		}
+
+		switch {
+		case line == "":
+			line = word
+		case len(line)+1+len(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,107 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package scene switches between the screens of a game, or between whole
+// games sharing a window. Scenes are plain ebiten.Games kept on a stack,
+// the top one runs.
+//
+// A scene failing, its Update returning an error, is replaced by a screen
+// telling the player what went wrong. Once they've read it the manager goes
+// back to the scene below, or, at the bottom, returns the error to end the
+// game cleanly instead of in the middle of a frame.
+package scene
+
+import (
+	"errors"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// Back is the key returning from a scene to the one below.
+const Back = ebiten.KeyEscape
+
+// Manager is an ebiten.Game running the scene on top of its stack.
+type Manager struct {
+	stack []ebiten.Game
+}
+
+// New returns a manager starting with the scene first, which stays at the
+// bottom.
+func New(first ebiten.Game) *Manager {
+	return &Manager{stack: []ebiten.Game{first}}
+}
+
+// Push starts s on top of the current scene.
+func (m *Manager) Push(s ebiten.Game) {
+	m.stack = append(m.stack, s)
+}
+
+// Pop returns to the scene below the current one, if there is one.
+func (m *Manager) Pop() {
+	if len(m.stack) < 2 {
+		return
+	}
+	m.stack[len(m.stack)-1] = nil
+	m.stack = m.stack[:len(m.stack)-1]
+
+	// undo what the scene may have changed
+	ebiten.SetScreenClearedEveryFrame(true)
+}
+
+func (m *Manager) top() ebiten.Game {
+	return m.stack[len(m.stack)-1]
+}
+
+// Update runs the current scene. Back or the scene returning
+// ebiten.Termination goes back to the scene below; at the bottom,
+// ebiten.Termination ends the game. Any other error shows the error screen.
+func (m *Manager) Update() error {
+	if len(m.stack) > 1 && inpututil.IsKeyJustPressed(Back) {
+		m.Pop()
+		return nil
+	}
+
+	top := m.top()
+	err := top.Update()
+	if s, ok := top.(*errorScene); ok && s.done {
+		if s.last {
+			return s.err
+		}
+		m.Pop()
+		return nil
+	}
+
+	switch {
+	case err == nil:
+	case errors.Is(err, ebiten.Termination):
+		if len(m.stack) > 1 {
+			m.Pop()
+			return nil
+		}
+		return err
+	default:
+		log.Printf("scene: %v", err)
+		m.stack[len(m.stack)-1] = &errorScene{err: err, failed: top, last: len(m.stack) == 1}
+		ebiten.SetScreenClearedEveryFrame(true)
+	}
+	return nil
+}
+
+func (m *Manager) Draw(screen *ebiten.Image) {
+	m.top().Draw(screen)
+}
+
+func (m *Manager) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return m.top().Layout(outsideWidth, outsideHeight)
+}
@@ -0,0 +1,153 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+
+	"jhartman.pl/gamedev/pkg/mailbox"
+)
+
+// Every file written with WriteFile has its SHA-256 next to it, and the
+// previous good version is kept as a backup:
+//
+//	snake-stats.json
+//	snake-stats.json.sha256
+//	snake-stats.json.bak
+//	snake-stats.json.bak.sha256
+const (
+	sumExt    = ".sha256"
+	backupExt = ".bak"
+)
+
+// ErrCorrupt is returned by ReadFile when neither a file nor its backup
+// match their checksums, or there is no backup yet.
+var ErrCorrupt = errors.New("corrupt, with no good backup")
+
+// Recovered receives the names of the files ReadFile found corrupt and read
+// from their backup instead, for the game to tell the player.
+var Recovered mailbox.Mailbox[string]
+
+// WriteFile replaces the file name with data atomically, so being killed
+// mid-write leaves the previous version intact. The directory is created if
+// needed. The version being replaced is kept as the backup, unless it's
+// corrupt.
+func WriteFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+
+	if old, err := os.ReadFile(name); err == nil && verify(name, old) {
+		if err := writeChecked(name+backupExt, old); err != nil {
+			return err
+		}
+	}
+
+	return writeChecked(name, data)
+}
+
+// ReadFile reads a file written with WriteFile. If it doesn't match its
+// checksum, e.g. because the process was killed while the disk was still
+// writing it, the backup is read instead and the name is posted to
+// Recovered. Files without a checksum, from before there were any, are
+// read as they are.
+func ReadFile(name string) ([]byte, error) {
+	return readFile(name, nil)
+}
+
+// readFile is ReadFile for files people may edit, which are fine despite
+// the checksum as long as valid accepts them.
+func readFile(name string, valid func(data []byte) bool) ([]byte, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	if verify(name, data) || valid != nil && valid(data) {
+		return data, nil
+	}
+
+	backup, err := os.ReadFile(name + backupExt)
+	if err != nil || !verify(name+backupExt, backup) {
+		return nil, fmt.Errorf("%s: %w", filepath.Base(name), ErrCorrupt)
+	}
+
+	log.Printf("settings: %s is corrupt, read its backup", name)
+	Recovered.Post(filepath.Base(name))
+	return backup, nil
+}
+
+// verify reports whether data matches the checksum stored for the file
+// name, or there is none.
+func verify(name string, data []byte) bool {
+	want, err := os.ReadFile(name + sumExt)
+	if errors.Is(err, fs.ErrNotExist) {
+		return true
+	}
+	return err == nil && bytes.Equal(bytes.TrimSpace(want), sum(data))
+}
+
+func sum(data []byte) []byte {
+	h := sha256.Sum256(data)
+	return []byte(hex.EncodeToString(h[:]))
+}
+
+// writeChecked writes data and then its checksum. Being killed in between
+// leaves the old checksum, which ReadFile takes for corruption and falls
+// back to the backup: the previous version.
+func writeChecked(name string, data []byte) error {
+	if err := writeAtomic(name, data); err != nil {
+		return err
+	}
+	return writeAtomic(name+sumExt, append(sum(data), '\n'))
+}
+
+func writeAtomic(name string, data []byte) error {
+	f, err := os.CreateTemp(filepath.Dir(name), filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), name)
+}
+
+// RemoveFile removes a file written with WriteFile, its backup included.
+func RemoveFile(name string) error {
+	var errs []error
+	for _, n := range []string{name, name + sumExt, name + backupExt, name + backupExt + sumExt} {
+		if err := os.Remove(n); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
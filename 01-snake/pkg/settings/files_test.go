@@ -0,0 +1,153 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package settings_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"jhartman.pl/gamedev/pkg/settings"
+)
+
+// written returns the path of a file written twice with WriteFile, "old"
+// being kept as the backup of "new".
+func written(t *testing.T) string {
+	t.Helper()
+	name := filepath.Join(t.TempDir(), "stats.json")
+	for _, data := range []string{"old", "new"} {
+		if err := settings.WriteFile(name, []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return name
+}
+
+// corrupt overwrites name, leaving its checksum as it is.
+func corrupt(t *testing.T, name string) {
+	t.Helper()
+	if err := os.WriteFile(name, []byte("\x00garbage"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReadFile(t *testing.T) {
+	name := written(t)
+	settings.Recovered.Receive()
+
+	data, err := settings.ReadFile(name)
+	if err != nil || string(data) != "new" {
+		t.Fatalf("got %q, %v, want %q", data, err, "new")
+	}
+	if got := settings.Recovered.Receive(); len(got) != 0 {
+		t.Errorf("recovered %v from a good file", got)
+	}
+}
+
+func TestReadFileBackup(t *testing.T) {
+	name := written(t)
+	corrupt(t, name)
+	settings.Recovered.Receive()
+
+	data, err := settings.ReadFile(name)
+	if err != nil || string(data) != "old" {
+		t.Fatalf("got %q, %v, want the backup %q", data, err, "old")
+	}
+	if got, ok := settings.Recovered.Last(); !ok || got != "stats.json" {
+		t.Errorf("recovered %q, want stats.json", got)
+	}
+}
+
+func TestReadFileCorrupt(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		// damage corrupts the files written to name
+		damage func(t *testing.T, name string)
+	}{
+		{"both corrupt", func(t *testing.T, name string) {
+			corrupt(t, name)
+			corrupt(t, name+".bak")
+		}},
+		{"no backup", func(t *testing.T, name string) {
+			corrupt(t, name)
+			os.Remove(name + ".bak")
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			name := written(t)
+			tc.damage(t, name)
+
+			data, err := settings.ReadFile(name)
+			if !errors.Is(err, settings.ErrCorrupt) {
+				t.Errorf("got %q, %v, want ErrCorrupt", data, err)
+			}
+		})
+	}
+}
+
+// configDir points the user's config directory to a new temporary one and
+// returns the settings path in it.
+func configDir(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", dir)
+	t.Setenv("AppData", dir)
+	t.Setenv("HOME", dir)
+
+	name, err := settings.Path()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return name
+}
+
+func TestLoad(t *testing.T) {
+	name := configDir(t)
+
+	s, err := settings.Load()
+	if err != nil || s != settings.Default() {
+		t.Fatalf("no file: got %+v, %v, want the defaults", s, err)
+	}
+
+	old := settings.Default()
+	old.TPS = 120
+	saved := old
+	saved.TPS = 240
+	for _, s := range []settings.Settings{old, saved} {
+		if err := settings.Save(s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s, err := settings.Load(); err != nil || s != saved {
+		t.Errorf("got %+v, %v, want %+v", s, err, saved)
+	}
+
+	// edited by hand: the checksum doesn't match, the JSON is valid
+	if err := os.WriteFile(name, []byte(`{"tps": 30}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if s, err := settings.Load(); err != nil || s.TPS != 30 || !s.Vsync {
+		t.Errorf("edited: got %+v, %v, want 30 TPS and the defaults", s, err)
+	}
+
+	corrupt(t, name)
+	if s, err := settings.Load(); err != nil || s != old {
+		t.Errorf("corrupt: got %+v, %v, want the backup %+v", s, err, old)
+	}
+
+	corrupt(t, name+".bak")
+	if s, err := settings.Load(); !errors.Is(err, settings.ErrCorrupt) || s != settings.Default() {
+		t.Errorf("both corrupt: got %+v, %v, want the defaults and ErrCorrupt", s, err)
+	}
+}
@@ -0,0 +1,160 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package settings persists the user's preferences shared by the examples.
+package settings
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Settings are stored as JSON under the user's config directory.
+type Settings struct {
+	// DiscordPresence publishes the game status to Discord Rich Presence.
+	DiscordPresence bool `json:"discord_presence"`
+	// DiscordAppID is the Discord application the presence is published for.
+	DiscordAppID string `json:"discord_app_id,omitempty"`
+	// Monitor is the name or number (from 1) of the monitor to open the
+	// window on, the last or the primary one if empty.
+	Monitor string `json:"monitor,omitempty"`
+	// DisplayMode is windowed, fullscreen or borderless, from the device
+	// preset if empty.
+	DisplayMode string `json:"display_mode,omitempty"`
+	// RememberWindow restores the window where it was left the last time.
+	RememberWindow bool `json:"remember_window"`
+	// TPS is the number of game updates per second. Raising it lowers the
+	// input latency, the game keeps its speed.
+	TPS int `json:"tps"`
+	// Difficulty is easy, normal or hard, the pace of the snake. Normal if
+	// empty.
+	Difficulty string `json:"difficulty,omitempty"`
+	// Speed is how many cells per second the snake moves, fractions
+	// allowed, overriding the difficulty's if set.
+	Speed float64 `json:"speed,omitempty"`
+	// SpeedUp makes the snake faster every few points, the longer the game
+	// the harder.
+	SpeedUp bool `json:"speed_up"`
+	// Border is what the edge of the board does to the snake: turn (the
+	// classic rule), solid, crashing it, or wrap, to the opposite edge. Turn
+	// if empty.
+	Border string `json:"border,omitempty"`
+	// Sprites draws the snake and the food with sprites instead of plain
+	// squares.
+	Sprites bool `json:"sprites,omitempty"`
+	// Skin is how the snake and the food are drawn: flat, sprites or the
+	// path of a PNG sprite sheet. Flat if empty, unless Sprites is set.
+	Skin string `json:"skin,omitempty"`
+	// Smooth slides the snake from cell to cell rather than jumping.
+	Smooth bool `json:"smooth,omitempty"`
+	// Vsync syncs drawing with the display's refresh rate.
+	Vsync bool `json:"vsync"`
+	// KeyboardLayout is qwerty, azerty or qwertz, detected if empty.
+	KeyboardLayout string `json:"keyboard_layout,omitempty"`
+	// MapsServer is the URL of the server sharing community maps, see
+	// cmd/mapsd.
+	MapsServer string `json:"maps_server,omitempty"`
+	// LeaderboardServer is the URL of the score server the final scores
+	// are submitted to, see cmd/scored.
+	LeaderboardServer string `json:"leaderboard_server,omitempty"`
+	// CloudSync configures syncing the per user files, see pkg/cloudsync.
+	CloudSync *CloudSync `json:"cloud_sync,omitempty"`
+}
+
+// CloudSync is the storage the files are synced with.
+type CloudSync struct {
+	// Kind is webdav or s3.
+	Kind string `json:"kind"`
+	// URL of the WebDAV collection, or of the S3 endpoint followed by the
+	// bucket and an optional prefix.
+	URL string `json:"url"`
+
+	Username string `json:"username,omitempty"` // WebDAV
+	Password string `json:"password,omitempty"`
+
+	Region    string `json:"region,omitempty"` // S3
+	AccessKey string `json:"access_key,omitempty"`
+	SecretKey string `json:"secret_key,omitempty"`
+}
+
+// Default returns the settings used when nothing has been saved yet.
+func Default() Settings {
+	return Settings{
+		RememberWindow: true,
+		TPS:            60,
+		SpeedUp:        true,
+		Vsync:          true,
+	}
+}
+
+// Dir returns the directory where the settings and other per user files
+// are kept.
+func Dir() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "gamedev"), nil
+}
+
+// Path returns the location of the settings file.
+func Path() (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "settings.json"), nil
+}
+
+// Load reads the settings, returning the defaults if there are none yet.
+// Fields missing from the file keep their default values.
+func Load() (Settings, error) {
+	s := Default()
+
+	name, err := Path()
+	if err != nil {
+		return s, err
+	}
+
+	// edited by hand, so a changed checksum only means corrupt if it isn't
+	// JSON anymore
+	data, err := readFile(name, func(data []byte) bool { return json.Valid(data) })
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return s, err
+	}
+
+	if err := json.Unmarshal(data, &s); err != nil {
+		return Default(), err
+	}
+
+	return s, nil
+}
+
+// Save writes the settings, creating the config directory if needed.
+func Save(s Settings) error {
+	name, err := Path()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return WriteFile(name, data)
+}
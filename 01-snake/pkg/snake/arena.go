@@ -0,0 +1,117 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Arena is the shape of the board, a mask over its cells: those out of it
+// are solid like the walls, nothing being placed there and the snake
+// crashing into them.
+type Arena int
+
+const (
+	// RectArena is the whole board.
+	RectArena Arena = iota
+	// CircleArena is the disc filling the board, an ellipse on a board
+	// that isn't square.
+	CircleArena
+	// CrossArena is the plus of the bands across the middle third of the
+	// board.
+	CrossArena
+	// DonutArena is the disc with a hole half its size in the middle.
+	DonutArena
+)
+
+// Arenas lists the arenas.
+var Arenas = []Arena{RectArena, CircleArena, CrossArena, DonutArena}
+
+var arenaNames = [...]string{
+	RectArena:   "rect",
+	CircleArena: "circle",
+	CrossArena:  "cross",
+	DonutArena:  "donut",
+}
+
+func (a Arena) String() string {
+	if a < 0 || int(a) >= len(arenaNames) {
+		return fmt.Sprintf("Arena(%d)", int(a))
+	}
+	return arenaNames[a]
+}
+
+// ArenaByName looks an arena up by name, "" being the whole board.
+func ArenaByName(name string) (Arena, error) {
+	if name == "" {
+		return RectArena, nil
+	}
+	for a, n := range arenaNames {
+		if strings.EqualFold(name, n) {
+			return Arena(a), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown arena %q, expected %s", name, strings.Join(arenaNames[:], ", "))
+}
+
+// contains reports whether p, on the board b, is in the arena.
+func (a Arena) contains(b bounds, p Point) bool {
+	// from the middle of the board, the disc reaching its edges
+	dx := (float64(p.X) - float64(b.w)/2) / (float64(b.w)/2 + 0.5)
+	dy := (float64(p.Y) - float64(b.h)/2) / (float64(b.h)/2 + 0.5)
+	switch a {
+	case CircleArena:
+		return dx*dx+dy*dy <= 1
+	case CrossArena:
+		return max(dx, -dx) <= 1.0/3 || max(dy, -dy) <= 1.0/3
+	case DonutArena:
+		d := dx*dx + dy*dy
+		return d <= 1 && d >= 0.25
+	}
+	return true
+}
+
+// IsPlayable reports whether the cell at (x, y) is on the board and in the
+// arena.
+func (g *Game) IsPlayable(x, y int) bool {
+	return g.playable(Point{x, y})
+}
+
+func (g *Game) playable(p Point) bool {
+	return g.inBoard(p) && g.Arena.contains(g.bounds(), p)
+}
+
+// SetArena replaces the arena, moving the snake to the start and the food
+// elsewhere if they're out of it.
+func (g *Game) SetArena(a Arena) {
+	g.Arena = a
+	if !g.playable(g.Snake.Head()) {
+		g.Snake = newBodyIn(g.bounds(), g.start())
+	}
+	if g.occupied(g.Food) {
+		g.setFood()
+	}
+}
+
+// start is where the snake starts, the start of the board unless it's out
+// of the arena, the hole of the donut: then the first cell above in it.
+func (g *Game) start() Point {
+	p := g.bounds().start()
+	for q := p; q.Y >= 0; q.Y-- {
+		if g.playable(q) {
+			return q
+		}
+	}
+	return p
+}
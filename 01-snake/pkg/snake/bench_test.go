@@ -0,0 +1,83 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake_test
+
+import (
+	"testing"
+
+	"jhartman.pl/gamedev/pkg/bench"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// trackLengths are the snakes benchmarked: a short one, a long one and one
+// filling the board.
+var trackLengths = []struct {
+	name string
+	n    int
+}{
+	{"10", 10},
+	{"500", 500},
+	{"full", bench.CycleLength},
+}
+
+// BenchmarkTick benchmarks a tick of the simulation, a turn and a step.
+func BenchmarkTick(b *testing.B) {
+	for _, l := range trackLengths {
+		b.Run(l.name, func(b *testing.B) {
+			t := bench.NewTrack(l.n)
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				t.Tick()
+			}
+			if t.Game.State != snake.RUNNING {
+				b.Fatal("the snake crashed")
+			}
+		})
+	}
+}
+
+// BenchmarkCollision benchmarks the collision check, on every cell in turn.
+func BenchmarkCollision(b *testing.B) {
+	for _, l := range trackLengths {
+		b.Run(l.name, func(b *testing.B) {
+			g := bench.NewTrack(l.n).Game
+			blocked := 0
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := range b.N {
+				p := snake.Point{X: i % (snake.BoardWidth + 1), Y: i / (snake.BoardWidth + 1) % (snake.BoardHeight + 1)}
+				if g.Blocked(p) {
+					blocked++
+				}
+			}
+			if b.N > 1 && blocked == 0 {
+				b.Fatal("nothing blocked")
+			}
+		})
+	}
+}
+
+// BenchmarkFood benchmarks placing the food.
+func BenchmarkFood(b *testing.B) {
+	for _, l := range trackLengths {
+		b.Run(l.name, func(b *testing.B) {
+			g := bench.NewTrack(l.n).Game
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				g.PlaceFood()
+			}
+		})
+	}
+}
@@ -0,0 +1,146 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+import "iter"
+
+// Body is the snake, head first. It is kept in a ring buffer, so a step is
+// pushing the new head and popping the tail whatever the length, and tracks
+// the cells it covers, so telling whether it is on a cell is a lookup.
+type Body struct {
+	buf []Point
+	// head is the index of the head in buf, the rest follow it
+	head int
+	n    int
+
+	cells grid
+}
+
+// NewBody returns a body of the points, head first.
+func NewBody(points ...Point) Body {
+	return newBodyIn(classic, points...)
+}
+
+// NewBody returns a body of the points on the board of g, head first.
+func (g *Game) NewBody(points ...Point) Body {
+	return newBodyIn(g.bounds(), points...)
+}
+
+// newBodyIn returns a body of the points on a board of bounds b.
+func newBodyIn(b bounds, points ...Point) Body {
+	return newBodyOn(newGridOf(b), points...)
+}
+
+func newBodyOn(cells grid, points ...Point) Body {
+	b := Body{buf: make([]Point, max(len(points), 16)), cells: cells}
+	for _, p := range points {
+		b.buf[b.n] = p
+		b.n++
+		b.cells.add(p)
+	}
+	return b
+}
+
+func (b *Body) Len() int {
+	return b.n
+}
+
+// At returns the i-th segment, 0 being the head.
+func (b *Body) At(i int) Point {
+	if i < 0 || i >= b.n {
+		panic("snake: segment index out of range")
+	}
+	return b.buf[(b.head+i)%len(b.buf)]
+}
+
+func (b *Body) Head() Point {
+	return b.At(0)
+}
+
+func (b *Body) Tail() Point {
+	return b.At(b.n - 1)
+}
+
+// PushHead adds p in front of the head.
+func (b *Body) PushHead(p Point) {
+	if b.n == len(b.buf) {
+		b.grow()
+	}
+	if b.cells.cells == nil {
+		b.cells = newGrid()
+	}
+	b.head = (b.head - 1 + len(b.buf)) % len(b.buf)
+	b.buf[b.head] = p
+	b.n++
+	b.cells.add(p)
+}
+
+// PopTail removes the tail and returns it.
+func (b *Body) PopTail() Point {
+	p := b.Tail()
+	b.n--
+	b.cells.remove(p)
+	return p
+}
+
+func (b *Body) grow() {
+	buf := make([]Point, max(2*len(b.buf), 16))
+	b.copyTo(buf)
+	b.buf, b.head = buf, 0
+}
+
+// copyTo copies the segments, head first, to dst.
+func (b *Body) copyTo(dst []Point) {
+	n := copy(dst, b.buf[b.head:min(b.head+b.n, len(b.buf))])
+	copy(dst[n:], b.buf[:b.n-n])
+}
+
+// Points returns a copy of the segments, head first.
+func (b *Body) Points() []Point {
+	ps := make([]Point, b.n)
+	b.copyTo(ps)
+	return ps
+}
+
+// Contains reports whether a segment is at p.
+func (b *Body) Contains(p Point) bool {
+	return b.cells.count(p) > 0
+}
+
+// Count returns how many segments are at p, more than one only after a
+// crash.
+func (b *Body) Count(p Point) int {
+	return b.cells.count(p)
+}
+
+// All yields the segments with their index, from the head.
+func (b *Body) All() iter.Seq2[int, Point] {
+	return func(yield func(int, Point) bool) {
+		for i := range b.n {
+			if !yield(i, b.buf[(b.head+i)%len(b.buf)]) {
+				return
+			}
+		}
+	}
+}
+
+// Backward yields the segments with their index, from the tail.
+func (b *Body) Backward() iter.Seq2[int, Point] {
+	return func(yield func(int, Point) bool) {
+		for i := b.n - 1; i >= 0; i-- {
+			if !yield(i, b.buf[(b.head+i)%len(b.buf)]) {
+				return
+			}
+		}
+	}
+}
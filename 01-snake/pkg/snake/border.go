@@ -0,0 +1,121 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Border is what the edge of the board does to the snake.
+type Border int
+
+const (
+	// BorderTurn turns the snake along the edge, the classic rule.
+	BorderTurn Border = iota
+	// BorderSolid crashes the snake running into the edge.
+	BorderSolid
+	// BorderWrap takes the snake through the edge, back onto the board at
+	// the opposite one.
+	BorderWrap
+)
+
+// Borders lists the borders.
+var Borders = []Border{BorderTurn, BorderSolid, BorderWrap}
+
+var borderNames = [...]string{
+	BorderTurn:  "turn",
+	BorderSolid: "solid",
+	BorderWrap:  "wrap",
+}
+
+func (b Border) String() string {
+	if b < 0 || int(b) >= len(borderNames) {
+		return fmt.Sprintf("Border(%d)", int(b))
+	}
+	return borderNames[b]
+}
+
+// BorderByName looks a border up by name, "" being the classic one.
+func BorderByName(name string) (Border, error) {
+	if name == "" {
+		return BorderTurn, nil
+	}
+	for b, n := range borderNames {
+		if strings.EqualFold(name, n) {
+			return Border(b), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown border %q, expected %s", name, strings.Join(borderNames[:], ", "))
+}
+
+// bounds is a board spanning 0..w and 0..h, both inclusive.
+type bounds struct {
+	w, h int
+}
+
+// classic is the board of Game.
+var classic = bounds{BoardWidth, BoardHeight}
+
+func (b bounds) contains(p Point) bool {
+	return p.X >= 0 && p.X <= b.w && p.Y >= 0 && p.Y <= b.h
+}
+
+// start is where the snake starts on the board, Start on the classic one.
+func (b bounds) start() Point {
+	if b == classic {
+		return Start
+	}
+	return Point{b.w / 2, b.h / 2}
+}
+
+// wrap brings p back onto the board through the opposite edge.
+func (b bounds) wrap(p Point) Point {
+	w, h := b.w+1, b.h+1
+	return Point{(p.X%w + w) % w, (p.Y%h + h) % h}
+}
+
+// turn turns dir along the edge if going on from p would leave the board,
+// the classic rule.
+func (b bounds) turn(p Point, dir *Point) {
+	// twice, as in a corner the turn at one border can face the other
+	for range 2 {
+		if p.X+dir.X < 0 {
+			dir.X = 0
+			dir.Y = -1
+		} else if p.X+dir.X > b.w {
+			dir.X = 0
+			dir.Y = 1
+		}
+
+		if p.Y+dir.Y < 0 {
+			dir.X = 1
+			dir.Y = 0
+		} else if p.Y+dir.Y > b.h {
+			dir.X = -1
+			dir.Y = 0
+		}
+	}
+}
+
+// wrapOffset is a difference of coordinates on an axis of size cells, the
+// short way across the edge when wrapping.
+func wrapOffset(d, size int) int {
+	switch {
+	case d > size/2:
+		return d - size
+	case d < -size/2:
+		return d + size
+	}
+	return d
+}
@@ -0,0 +1,129 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+import "container/heap"
+
+// ChaserScore is the score the chaser comes at, with Chase on. It starts on
+// the free cell furthest from the head and moves a cell towards it every
+// running step, along the shortest way around the walls, the obstacles and
+// the body, found with A*. Running into it or being caught crashes the
+// snake; it goes with the crash.
+const ChaserScore = 30
+
+// onChaser reports whether the chaser is at p.
+func (g *Game) onChaser(p Point) bool {
+	return g.Chasing && g.Chaser == p
+}
+
+// chaserBlocked reports whether the chaser can't go to p.
+func (g *Game) chaserBlocked(p Point) bool {
+	return !g.playable(p) || g.wallCells.count(p) > 0 || g.obstacleAt(p) ||
+		(g.Snake.Contains(p) && p != g.Snake.Head())
+}
+
+// moveChaser moves the chaser a cell towards the head, or places it once
+// the score reaches ChaserScore. It stays where it is with no way there.
+func (g *Game) moveChaser() {
+	if !g.Chase {
+		return
+	}
+	if !g.Chasing {
+		if g.Score >= ChaserScore {
+			g.placeChaser()
+		}
+		return
+	}
+	if p, ok := g.chaserStep(); ok {
+		g.Chaser = p
+	}
+}
+
+// placeChaser puts the chaser on the free cell furthest from the head, the
+// first in reading order of those as far, none when there's no free cell.
+func (g *Game) placeChaser() {
+	head := g.Snake.Head()
+	best, far := Point{}, -1
+	for y := range g.Height + 1 {
+		for x := range g.Width + 1 {
+			p := Point{x, y}
+			if d := abs(p.X-head.X) + abs(p.Y-head.Y); d > far && !g.occupied(p) {
+				best, far = p, d
+			}
+		}
+	}
+	if far >= 0 {
+		g.Chaser, g.Chasing = best, true
+	}
+}
+
+// chaserNode is a cell reached by the search, cost being the steps there
+// plus the distance left.
+type chaserNode struct {
+	p           Point
+	steps, cost int
+}
+
+type chaserQueue []chaserNode
+
+func (q chaserQueue) Len() int           { return len(q) }
+func (q chaserQueue) Less(i, j int) bool { return q[i].cost < q[j].cost }
+func (q chaserQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *chaserQueue) Push(x any)        { *q = append(*q, x.(chaserNode)) }
+func (q *chaserQueue) Pop() any {
+	old := *q
+	n := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return n
+}
+
+// chaserDirs are the moves of the chaser, tried in this order.
+var chaserDirs = [...]Point{{0, -1}, {1, 0}, {0, 1}, {-1, 0}}
+
+// chaserStep returns the cell after the chaser on the shortest path to the
+// head, false if there's no path.
+func (g *Game) chaserStep() (Point, bool) {
+	start, goal := g.Chaser, g.Snake.Head()
+	if start == goal {
+		return start, false
+	}
+	cols := g.Width + 1
+	index := func(p Point) int { return p.Y*cols + p.X }
+	distance := func(p Point) int { return abs(p.X-goal.X) + abs(p.Y-goal.Y) }
+
+	// from is where each cell was reached from, plus one, 0 when it wasn't
+	from := make([]int, cols*(g.Height+1))
+	from[index(start)] = index(start) + 1
+
+	q := &chaserQueue{{p: start, cost: distance(start)}}
+	for q.Len() > 0 {
+		n := heap.Pop(q).(chaserNode)
+		for _, d := range chaserDirs {
+			p := Point{n.p.X + d.X, n.p.Y + d.Y}
+			if g.chaserBlocked(p) || from[index(p)] != 0 {
+				continue
+			}
+			from[index(p)] = index(n.p) + 1
+			if p == goal {
+				// back to the cell after the start
+				for from[index(p)]-1 != index(start) {
+					i := from[index(p)] - 1
+					p = Point{i % cols, i / cols}
+				}
+				return p, true
+			}
+			heap.Push(q, chaserNode{p, n.steps + 1, n.steps + 1 + distance(p)})
+		}
+	}
+	return start, false
+}
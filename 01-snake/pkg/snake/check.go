@@ -0,0 +1,229 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+import "fmt"
+
+// Check verifies the invariants the rules keep after every step, returning
+// the first one broken:
+//
+//   - the snake is on the board and each segment is next to the previous one,
+//     through the edge too when it wraps, or to its portal
+//   - the portals are on the board, a cell in one at most
+//   - each obstacle is on its path, a path of cells next to each other, and
+//     waits at most Every steps
+//   - no two segments share a cell while the snake is running, but with
+//     the ghost
+//   - the food is where it can be placed, off the snake and the walls
+//     unless they fill the board
+//   - the timed food is on a free cell besides the food, for at most
+//     TimedLife steps, the power-up likewise besides both for PowerUpLife
+//     and the golden apple besides all three for GoldenLife, once one
+//     was placed in the run, the shrink pill besides all four for PillLife
+//   - an event goes on for at most its steps, the extra food on free cells
+//     besides the food and the items only in the frenzy
+//   - each effect is on once, for at most EffectSteps
+//   - neither the growth nor the segments to come are negative
+//   - the chaser is in the arena off the walls and the obstacles, off the
+//     body while running, with Chase on only
+//   - a won run is Target long or fills the board
+//   - a life is left
+func (g *Game) Check() error {
+	if g.Snake.Len() == 0 {
+		return fmt.Errorf("snake: no segments")
+	}
+
+	for i, v := range g.Snake.All() {
+		if !g.inBoard(v) {
+			return fmt.Errorf("snake: segment %d at %v is off the board", i, v)
+		}
+		if i == 0 {
+			continue
+		}
+
+		prev := g.Snake.At(i - 1)
+		entry, portal := g.twin(prev)
+		if !g.adjacent(v, prev) && (!portal || !g.adjacent(v, entry)) {
+			return fmt.Errorf("snake: segment %d at %v is not next to %v", i, v, prev)
+		}
+	}
+
+	for _, o := range g.Obstacles {
+		if len(o.Path) == 0 || o.At < 0 || o.At >= len(o.Path) {
+			return fmt.Errorf("snake: obstacle at %d off its path of %d cells", o.At, len(o.Path))
+		}
+		if o.Wait < 1 || o.Wait > max(o.Every, 1) {
+			return fmt.Errorf("snake: obstacle waits %d steps, every %d", o.Wait, o.Every)
+		}
+		for i, p := range o.Path {
+			if !g.inBoard(p) || (i > 0 && !g.adjacent(p, o.Path[i-1])) {
+				return fmt.Errorf("snake: obstacle path %v is off the board or broken at %v", o.Path, p)
+			}
+		}
+	}
+
+	for i, pt := range g.Portals {
+		if !g.inBoard(pt[0]) || !g.inBoard(pt[1]) || pt[0] == pt[1] {
+			return fmt.Errorf("snake: portal %v is off the board or its own twin", pt)
+		}
+		for _, q := range g.Portals[:i] {
+			if q[0] == pt[0] || q[0] == pt[1] || q[1] == pt[0] || q[1] == pt[1] {
+				return fmt.Errorf("snake: portals %v and %v share a cell", q, pt)
+			}
+		}
+	}
+
+	if g.State == RUNNING && !g.IsActive(Ghost) {
+		seen := make(map[Point]int, g.Snake.Len())
+		for i, v := range g.Snake.All() {
+			if j, ok := seen[v]; ok {
+				return fmt.Errorf("snake: segments %d and %d are both at %v", j, i, v)
+			}
+			seen[v] = i
+		}
+	}
+
+	f := g.Food
+	if !g.inBoard(f) {
+		return fmt.Errorf("snake: food at %v is out of bounds", g.Food)
+	}
+	if g.occupied(f) && len(g.free) > 0 {
+		return fmt.Errorf("snake: food at %v is on the snake or a wall", g.Food)
+	}
+
+	if t := g.Timed; t != nil {
+		if !g.inBoard(*t) {
+			return fmt.Errorf("snake: timed food at %v is out of bounds", t)
+		}
+		if *t == f || g.occupied(*t) {
+			return fmt.Errorf("snake: timed food at %v is on the food, the snake or a wall", t)
+		}
+		if g.TimedLeft < 1 || g.TimedLeft > TimedLife {
+			return fmt.Errorf("snake: timed food has %d steps left", g.TimedLeft)
+		}
+	}
+
+	if p := g.PowerUp; p != nil {
+		if !g.inBoard(*p) {
+			return fmt.Errorf("snake: power-up at %v is out of bounds", p)
+		}
+		if *p == f || g.onTimed(*p) || g.occupied(*p) {
+			return fmt.Errorf("snake: power-up at %v is on the food, the snake or a wall", p)
+		}
+		if g.PowerUpLeft < 1 || g.PowerUpLeft > PowerUpLife {
+			return fmt.Errorf("snake: power-up has %d steps left", g.PowerUpLeft)
+		}
+	}
+	if p := g.Golden; p != nil {
+		if !g.inBoard(*p) {
+			return fmt.Errorf("snake: golden apple at %v is out of bounds", p)
+		}
+		if *p == f || g.onTimed(*p) || g.onPowerUp(*p) || g.occupied(*p) {
+			return fmt.Errorf("snake: golden apple at %v is on the food, another item, the snake or a wall", p)
+		}
+		if g.GoldenLeft < 1 || g.GoldenLeft > GoldenLife {
+			return fmt.Errorf("snake: golden apple has %d steps left", g.GoldenLeft)
+		}
+	}
+	if p := g.Pill; p != nil {
+		if !g.inBoard(*p) {
+			return fmt.Errorf("snake: shrink pill at %v is out of bounds", p)
+		}
+		if *p == f || g.onTimed(*p) || g.onPowerUp(*p) || g.onGolden(*p) || g.occupied(*p) {
+			return fmt.Errorf("snake: shrink pill at %v is on the food, another item, the snake or a wall", p)
+		}
+		if g.PillLeft < 1 || g.PillLeft > PillLife {
+			return fmt.Errorf("snake: shrink pill has %d steps left", g.PillLeft)
+		}
+	}
+	if g.PillCharged < 0 {
+		return fmt.Errorf("snake: shrink pill charged %d", g.PillCharged)
+	}
+	if g.Goldens < 0 || (g.Goldens == 0 && (g.Golden != nil || g.Event == GoldenRush)) {
+		return fmt.Errorf("snake: %d golden apples placed, one at %v, %v on", g.Goldens, g.Golden, g.Event)
+	}
+	if steps, ok := eventSteps[g.Event]; g.Event != NoEvent && (!ok || g.EventLeft < 1 || g.EventLeft > steps) {
+		return fmt.Errorf("snake: %v has %d steps left", g.Event, g.EventLeft)
+	}
+	if g.Event == NoEvent && g.EventLeft != 0 {
+		return fmt.Errorf("snake: no event with %d steps left", g.EventLeft)
+	}
+	if len(g.Extra) > 0 && g.Event != Frenzy || len(g.Extra) > FrenzyFoods {
+		return fmt.Errorf("snake: %d extra foods in %v", len(g.Extra), g.Event)
+	}
+	for i, p := range g.Extra {
+		if !g.inBoard(p) || p == f || g.occupied(p) || g.onTimed(p) || g.onPowerUp(p) || g.onGolden(p) || g.onPill(p) || g.extraAt(p) != i {
+			return fmt.Errorf("snake: extra food at %v is off the board, on the food, another item, the snake or a wall", p)
+		}
+	}
+	for _, b := range g.Bites {
+		if b < 0 || b > FrenzyWindow {
+			return fmt.Errorf("snake: food eaten towards the frenzy %d steps ago", FrenzyWindow-b)
+		}
+	}
+	if g.Multiplier < 0 || g.Multiplier > MaxMultiplier || g.ComboLeft < 0 || g.ComboLeft > ComboWindow || (g.Multiplier == 0) != (g.ComboLeft == 0) {
+		return fmt.Errorf("snake: combo x%d with %d steps left", g.Multiplier, g.ComboLeft)
+	}
+	if !g.Combos && g.Multiplier != 0 {
+		return fmt.Errorf("snake: combo x%d without combos", g.Multiplier)
+	}
+	if g.Growth < 0 || g.Growing < 0 {
+		return fmt.Errorf("snake: growth %d with %d segments to come", g.Growth, g.Growing)
+	}
+	if c := g.Chaser; g.Chasing {
+		if !g.Chase {
+			return fmt.Errorf("snake: chaser at %v without the chase", c)
+		}
+		if !g.playable(c) || g.wallCells.count(c) > 0 || g.obstacleAt(c) {
+			return fmt.Errorf("snake: chaser at %v is off the board or the arena, on a wall or an obstacle", c)
+		}
+		if g.State == RUNNING && g.Snake.Contains(c) {
+			return fmt.Errorf("snake: chaser at %v is on the running snake", c)
+		}
+	}
+	if g.LivesLeft() < 1 {
+		return fmt.Errorf("snake: %d deaths with %d lives", g.Deaths, g.Lives)
+	}
+	if g.State == WON && !g.won() {
+		return fmt.Errorf("snake: won at length %d with cells left", g.Snake.Len())
+	}
+
+	for i, a := range g.Active {
+		if a.Left < 1 || a.Left > EffectSteps {
+			return fmt.Errorf("snake: %v has %d steps left", a.Effect, a.Left)
+		}
+		for _, b := range g.Active[:i] {
+			if b.Effect == a.Effect {
+				return fmt.Errorf("snake: %v is on twice", a.Effect)
+			}
+		}
+	}
+	return nil
+}
+
+// adjacent reports whether a and b are next to each other, through the edge
+// too when it wraps.
+func (g *Game) adjacent(a, b Point) bool {
+	dx, dy := a.X-b.X, a.Y-b.Y
+	if g.Border == BorderWrap {
+		dx, dy = wrapOffset(dx, g.Width+1), wrapOffset(dy, g.Height+1)
+	}
+	return abs(dx)+abs(dy) == 1
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -0,0 +1,70 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+// The combo multiplies the points of the food eaten quickly one after the
+// other, with Combos on: eating within ComboWindow steps of the last food
+// raises the multiplier by one, up to MaxMultiplier. Every ComboWindow steps
+// without eating it drops by one, the combo being over back at 1. Poison
+// breaks it at once.
+const (
+	// ComboWindow is the number of running steps the next food keeps the
+	// combo going in.
+	ComboWindow = 30
+	// MaxMultiplier caps the multiplier.
+	MaxMultiplier = 5
+)
+
+// combo scores points worth of food eaten, multiplied by the combo, which
+// it goes on with, and by the frenzy.
+func (g *Game) combo(points int) {
+	if g.Combos && points > 0 {
+		if g.ComboLeft > 0 {
+			g.Multiplier = min(g.Multiplier+1, MaxMultiplier)
+		} else {
+			g.Multiplier = 1
+		}
+		g.ComboLeft = ComboWindow
+		points *= g.Multiplier
+	} else if points < 0 {
+		g.breakCombo()
+	}
+	if g.Event == Frenzy && points > 0 {
+		points *= FrenzyFactor
+	}
+	g.Score = max(g.Score+points, 0)
+}
+
+// breakCombo ends the combo.
+func (g *Game) breakCombo() {
+	g.Multiplier = 0
+	g.ComboLeft = 0
+}
+
+// tickCombo counts a running step down for the combo, the multiplier
+// dropping once the window is over.
+func (g *Game) tickCombo() {
+	if g.ComboLeft == 0 {
+		return
+	}
+	g.ComboLeft--
+	if g.ComboLeft > 0 {
+		return
+	}
+	if g.Multiplier > 2 {
+		g.Multiplier--
+		g.ComboLeft = ComboWindow
+	} else {
+		g.breakCombo()
+	}
+}
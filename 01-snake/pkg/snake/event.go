@@ -0,0 +1,77 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+// Event is a board-wide event, changing the rules for a number of running
+// steps. One goes on at a time.
+type Event int
+
+const (
+	NoEvent Event = iota
+	// GoldenRush makes the food bonus food, poison included, started by
+	// eating a golden apple.
+	GoldenRush
+	// Frenzy doubles the points and puts more food on the board, started
+	// by eating quickly, see FrenzyBites.
+	Frenzy
+)
+
+func (e Event) String() string {
+	switch e {
+	case NoEvent:
+		return "none"
+	case GoldenRush:
+		return "golden rush"
+	case Frenzy:
+		return "frenzy"
+	}
+	return "unknown"
+}
+
+// eventSteps are the running steps each event lasts.
+var eventSteps = map[Event]int{
+	GoldenRush: RushSteps,
+	Frenzy:     FrenzySteps,
+}
+
+// startEvent starts e for its steps, ending the event going on if any.
+func (g *Game) startEvent(e Event) {
+	g.endEvent()
+	g.Event, g.EventLeft = e, eventSteps[e]
+	switch e {
+	case GoldenRush:
+		if g.FoodKind == NormalFood || g.FoodKind == PoisonFood {
+			g.FoodKind = BonusFood
+		}
+	case Frenzy:
+		g.Bites = [FrenzyBites - 1]int{}
+		g.placeExtra()
+	}
+}
+
+// endEvent ends the event going on, the extra food of the frenzy going
+// with it. The food the golden rush changed stays as it is until eaten.
+func (g *Game) endEvent() {
+	g.Event, g.EventLeft = NoEvent, 0
+	g.Extra = g.Extra[:0]
+}
+
+// tickEvent counts a running step down for the event going on.
+func (g *Game) tickEvent() {
+	if g.Event == NoEvent {
+		return
+	}
+	if g.EventLeft--; g.EventLeft <= 0 {
+		g.endEvent()
+	}
+}
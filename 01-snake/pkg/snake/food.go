@@ -0,0 +1,117 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+// FoodKind is what the food is worth.
+type FoodKind int
+
+const (
+	NormalFood FoodKind = iota
+	BonusFood
+	RareFood
+	// PoisonFood shrinks the snake by 2 segments and costs points, it is
+	// only placed when the snake is long enough
+	PoisonFood
+)
+
+// poisonMinLength is the shortest snake poison is placed for, so eating it
+// leaves the head at least
+const poisonMinLength = 3
+
+// foodKinds are the kinds placed, with their chances out of 100 and points.
+var foodKinds = [...]struct {
+	weight, value int
+}{
+	NormalFood: {80, 1},
+	BonusFood:  {12, 5},
+	RareFood:   {3, 10},
+	PoisonFood: {5, -3},
+}
+
+// Value returns the points eating the food scores, negative for poison. The
+// score doesn't go below 0.
+func (k FoodKind) Value() int {
+	if k < 0 || int(k) >= len(foodKinds) {
+		return 0
+	}
+	return foodKinds[k].value
+}
+
+func (k FoodKind) String() string {
+	switch k {
+	case NormalFood:
+		return "normal"
+	case BonusFood:
+		return "bonus"
+	case RareFood:
+		return "rare"
+	case PoisonFood:
+		return "poison"
+	}
+	return "unknown"
+}
+
+// pickFoodKind draws the kind of the next food by the weights, normal
+// instead of poison for a snake too short for it, bonus instead of both in
+// the golden rush.
+func (g *Game) pickFoodKind() FoodKind {
+	k := g.drawFoodKind()
+	if g.Event == GoldenRush && (k == NormalFood || k == PoisonFood) {
+		return BonusFood
+	}
+	return k
+}
+
+// drawFoodKind draws the kind of the next food by the weights, see
+// pickFoodKind.
+func (g *Game) drawFoodKind() FoodKind {
+	n := g.rng.IntN(100)
+	for k, f := range foodKinds {
+		if n >= f.weight {
+			n -= f.weight
+			continue
+		}
+		if FoodKind(k) == PoisonFood && g.Snake.Len() < poisonMinLength {
+			return NormalFood
+		}
+		return FoodKind(k)
+	}
+	return NormalFood
+}
+
+// eat scores the food, the snake's head being on it, then places the next
+// one. Poison takes 2 segments off the growth to come, then off the tail,
+// always leaving the head: the snake may have shrunk since the poison was
+// placed, after a crash.
+func (g *Game) eat() {
+	g.combo(g.FoodKind.Value())
+	if g.FoodKind == PoisonFood {
+		for range 2 {
+			if g.Growing > 0 {
+				g.Growing--
+			} else if g.Snake.Len() > 1 {
+				g.Snake.PopTail()
+			}
+		}
+	} else {
+		g.grow()
+		g.bite()
+	}
+	g.setFood()
+}
+
+// grow adds the segments of a food past the one the step keeps to those to
+// come, see Game.Growth.
+func (g *Game) grow() {
+	g.Growing += max(g.Growth, 1) - 1
+}
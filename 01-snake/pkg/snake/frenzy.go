@@ -0,0 +1,93 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+// Eating FrenzyBites foods within FrenzyWindow steps, about 5 seconds at the
+// default speed, starts the frenzy unless another event is going on: for
+// FrenzySteps the points are doubled and FrenzyFoods more foods are on the
+// board, going with the frenzy if they're not eaten.
+const (
+	// FrenzyBites are the foods starting the frenzy, the timed one
+	// included.
+	FrenzyBites = 3
+	// FrenzyWindow is the number of running steps they're eaten in.
+	FrenzyWindow = 35
+	// FrenzySteps is the number of running steps the frenzy lasts.
+	FrenzySteps = 70
+	// FrenzyFoods are the foods placed as it starts, besides the food.
+	FrenzyFoods = 6
+	// FrenzyFactor multiplies the points scored in the frenzy.
+	FrenzyFactor = 2
+)
+
+// extraAt returns the index of the extra food at p, -1 if there's none.
+func (g *Game) extraAt(p Point) int {
+	for i, e := range g.Extra {
+		if e == p {
+			return i
+		}
+	}
+	return -1
+}
+
+// eatExtra scores the i-th extra food, the snake's head being on it, which
+// grows the snake like the food.
+func (g *Game) eatExtra(i int) {
+	g.combo(NormalFood.Value())
+	g.grow()
+	g.Extra = append(g.Extra[:i], g.Extra[i+1:]...)
+}
+
+// bite counts a food eaten towards the frenzy, starting it with the last
+// of FrenzyBites.
+func (g *Game) bite() {
+	if g.Event != NoEvent {
+		return
+	}
+	n := 0
+	for _, b := range g.Bites {
+		if b > 0 {
+			n++
+		}
+	}
+	if n+1 < FrenzyBites {
+		for i, b := range g.Bites {
+			if b == 0 {
+				g.Bites[i] = FrenzyWindow
+				break
+			}
+		}
+		return
+	}
+	g.startEvent(Frenzy)
+}
+
+// tickBites counts a running step down for the foods eaten towards the
+// frenzy, forgetting those out of the window.
+func (g *Game) tickBites() {
+	for i := range g.Bites {
+		g.Bites[i] = max(g.Bites[i]-1, 0)
+	}
+}
+
+// placeExtra places the extra foods of the frenzy, as many as there's room
+// for.
+func (g *Game) placeExtra() {
+	for range FrenzyFoods {
+		p, ok := g.pickItemCell()
+		if !ok {
+			return
+		}
+		g.Extra = append(g.Extra, p)
+	}
+}
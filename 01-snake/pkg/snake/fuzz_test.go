@@ -0,0 +1,222 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake_test
+
+import (
+	"bytes"
+	"math/rand/v2"
+	"testing"
+
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// fuzzGame sets a game up from seed: up to 16 walls, 3 pairs of portals and
+// 3 obstacles off the row of the start, any border and arena, up to 3
+// lives, the combos and the chase or not, and now and then a target short
+// enough to be won at random.
+func fuzzGame(seed uint64) *snake.Game {
+	rng := rand.New(rand.NewPCG(seed, ^seed))
+	var walls []snake.Point
+	for range rng.IntN(17) {
+		p := snake.Point{X: rng.IntN(snake.BoardWidth + 1), Y: rng.IntN(snake.BoardHeight + 1)}
+		if p.Y != snake.Start.Y {
+			walls = append(walls, p)
+		}
+	}
+
+	g := snake.NewLevel(seed, walls)
+	g.SetPortals(fuzzPortals(rng))
+	g.SetObstacles(fuzzObstacles(rng))
+	g.Border = snake.Borders[rng.IntN(len(snake.Borders))]
+	g.SetArena(snake.Arenas[rng.IntN(len(snake.Arenas))])
+	g.Lives = rng.IntN(4)
+	g.Combos = rng.IntN(2) == 0
+	g.Chase = rng.IntN(2) == 0
+	if rng.IntN(4) == 0 {
+		g.Target = 2 + rng.IntN(6)
+	}
+	return g
+}
+
+// fuzzPortals places up to 3 random pairs of portals off the row of the
+// start and each other, some on walls.
+func fuzzPortals(rng *rand.Rand) []snake.Portal {
+	var ps []snake.Portal
+	for range rng.IntN(4) {
+		var pt snake.Portal
+		for i := range pt {
+			pt[i] = snake.Point{X: rng.IntN(snake.BoardWidth + 1), Y: rng.IntN(snake.BoardHeight + 1)}
+		}
+		if pt[0] == pt[1] || pt[0].Y == snake.Start.Y || pt[1].Y == snake.Start.Y {
+			continue
+		}
+		taken := false
+		for _, q := range ps {
+			taken = taken || q[0] == pt[0] || q[0] == pt[1] || q[1] == pt[0] || q[1] == pt[1]
+		}
+		if !taken {
+			ps = append(ps, pt)
+		}
+	}
+	return ps
+}
+
+// fuzzObstacles places up to 3 random obstacles patrolling straight paths
+// off the row of the start.
+func fuzzObstacles(rng *rand.Rand) []snake.Obstacle {
+	var os []snake.Obstacle
+	for range rng.IntN(4) {
+		p := snake.Point{X: rng.IntN(snake.BoardWidth + 1), Y: rng.IntN(snake.BoardHeight + 1)}
+		d := snake.Point{X: 1}
+		if rng.IntN(2) == 0 {
+			d = snake.Point{Y: 1}
+		}
+		o := snake.Obstacle{Every: rng.IntN(4)}
+		for range 1 + rng.IntN(8) {
+			if p.Y == snake.Start.Y || p.X > snake.BoardWidth || p.Y > snake.BoardHeight {
+				break
+			}
+			o.Path = append(o.Path, p)
+			p.X, p.Y = p.X+d.X, p.Y+d.Y
+		}
+		if len(o.Path) > 0 {
+			os = append(os, o)
+		}
+	}
+	return os
+}
+
+// FuzzStep plays the moves on the game of the seed, a step a byte, and
+// checks the invariants of the rules after each. The low bits of a byte
+// turn the snake, up, right, down or left, or not at all from 4 on; the
+// high bit restarts a won run.
+//
+//	go test ./pkg/snake -fuzz FuzzStep
+func FuzzStep(f *testing.F) {
+	f.Add(uint64(1), []byte{})
+	// straight on, into the border or along it
+	f.Add(uint64(2), bytes.Repeat([]byte{4}, 200))
+	// round in a square, then back into the neck
+	f.Add(uint64(3), []byte{1, 4, 2, 4, 3, 4, 0, 4, 2, 0, 2, 0})
+	// zigzagging down the board, restarting the runs won
+	f.Add(uint64(4), bytes.Repeat([]byte{0x82, 0x81, 0x82, 0x83}, 50))
+
+	f.Fuzz(func(t *testing.T, seed uint64, moves []byte) {
+		g := fuzzGame(seed)
+		if err := g.Check(); err != nil {
+			t.Fatalf("at the start: %v", err)
+		}
+
+		for i, b := range moves {
+			if k := int(b & 7); k < len(input.Dirs) {
+				g.Turn(input.Dirs[k].Delta())
+			}
+			g.Step()
+			if err := g.Check(); err != nil {
+				t.Fatalf("step %d: %v", i+1, err)
+			}
+
+			if g.State == snake.WON && b&0x80 != 0 {
+				g.Restart()
+				if err := g.Check(); err != nil {
+					t.Fatalf("restarting after step %d: %v", i+1, err)
+				}
+			}
+		}
+	})
+}
+
+// fuzzMatch sets a match up from seed: 2 to 7 snakes, any border, and now
+// and then a battle royale on a board four times as large.
+func fuzzMatch(seed uint64) *snake.Match {
+	rng := rand.New(rand.NewPCG(seed, ^seed))
+	n := 2 + rng.IntN(6)
+	m := snake.NewMatch(seed, n)
+	if rng.IntN(3) == 0 {
+		m = snake.NewMatchSize(seed, n, 2*snake.BoardWidth+1, 2*snake.BoardHeight+1)
+		m.LastStanding = true
+	}
+	m.Border = snake.Borders[rng.IntN(len(snake.Borders))]
+	return m
+}
+
+// FuzzMatch plays the moves in the match of the seed and checks its
+// invariants after each step. A step takes a byte per snake, turning it as
+// FuzzStep does; a match over starts the next one from the seed after.
+// The long inputs take a while to minimize, bound it to keep fuzzing:
+//
+//	go test ./pkg/snake -fuzz FuzzMatch -fuzzminimizetime 100x
+func FuzzMatch(f *testing.F) {
+	f.Add(uint64(1), []byte{})
+	// straight on, the snakes meeting or turned by the border
+	f.Add(uint64(2), bytes.Repeat([]byte{4}, 400))
+	// all turning together
+	f.Add(uint64(3), bytes.Repeat([]byte{0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1, 1, 1, 1}, 40))
+	// a battle royale, the snakes going their own ways
+	f.Add(uint64(5), bytes.Repeat([]byte{0, 1, 2, 3, 4, 5, 6}, 80))
+
+	f.Fuzz(func(t *testing.T, seed uint64, moves []byte) {
+		m := fuzzMatch(seed)
+		if err := m.Check(); err != nil {
+			t.Fatalf("at the start: %v", err)
+		}
+
+		for step := 1; len(moves) >= len(m.Players); step++ {
+			for i, p := range m.Players {
+				if k := int(moves[i] & 7); k < len(input.Dirs) {
+					p.Turn(input.Dirs[k].Delta())
+				}
+			}
+			moves = moves[len(m.Players):]
+			m.Step()
+			if err := m.Check(); err != nil {
+				t.Fatalf("step %d: %v", step, err)
+			}
+
+			if m.Over {
+				seed++
+				m = fuzzMatch(seed)
+			}
+		}
+	})
+}
+
+// TestMatches plays random matches, see fuzzMatch, the snakes turning at
+// random, and checks the invariants after every step, the next match
+// starting once one is over.
+func TestMatches(t *testing.T) {
+	games, steps := 100, 2000
+	if testing.Short() {
+		games = 10
+	}
+
+	for seed := range uint64(games) {
+		m := fuzzMatch(seed)
+		rng := rand.New(rand.NewPCG(seed, seed))
+		for step := 1; step <= steps; step++ {
+			if m.Over {
+				m = fuzzMatch(rng.Uint64())
+			}
+			for _, p := range m.Players {
+				if rng.IntN(10) < 3 {
+					p.Turn(input.Dirs[rng.IntN(len(input.Dirs))].Delta())
+				}
+			}
+			m.Step()
+			if err := m.Check(); err != nil {
+				t.Fatalf("seed %d, step %d: %v", seed, step, err)
+			}
+		}
+	}
+}
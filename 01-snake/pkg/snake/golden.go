@@ -0,0 +1,62 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+// A golden apple comes every GoldenEvery points of the run, staying like
+// the timed food. Eating it starts the golden rush, about 10 seconds at the
+// default speed.
+const (
+	// GoldenEvery is the points scored between two golden apples.
+	GoldenEvery = 50
+	// GoldenLife is the number of running steps a golden apple stays.
+	GoldenLife = 50
+	// GoldenValue is what eating a golden apple scores, it grows the snake
+	// by a segment like the food.
+	GoldenValue = 10
+	// RushSteps is the number of running steps the golden rush lasts.
+	RushSteps = 70
+)
+
+// onGolden reports whether the golden apple is at p.
+func (g *Game) onGolden(p Point) bool {
+	return g.Golden != nil && *g.Golden == p
+}
+
+// eatGolden scores the golden apple, the snake's head being on it, and
+// starts the golden rush.
+func (g *Game) eatGolden() {
+	g.combo(GoldenValue)
+	g.grow()
+	g.Golden = nil
+	g.startEvent(GoldenRush)
+}
+
+// tickGolden counts a running step down for the golden apple, placing the
+// next one once the score reaches it.
+func (g *Game) tickGolden() {
+	if g.Golden != nil {
+		if g.GoldenLeft--; g.GoldenLeft <= 0 {
+			g.Golden = nil
+		}
+		return
+	}
+	if g.Score < (g.Goldens+1)*GoldenEvery {
+		return
+	}
+	// with no room, tried again next step
+	if p, ok := g.pickItemCell(); ok {
+		g.Golden = &p
+		g.GoldenLeft = GoldenLife
+		g.Goldens++
+	}
+}
@@ -0,0 +1,57 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+// grid counts what is on each cell of the board, so looking a cell up is a
+// single read. Counts rather than bits, as a crashed snake overlaps itself.
+type grid struct {
+	cells []uint8
+	// w and h are the number of columns and rows
+	w, h int
+}
+
+// newGrid returns a grid of the classic board.
+func newGrid() grid {
+	return newGridOf(classic)
+}
+
+func newGridOf(b bounds) grid {
+	return grid{make([]uint8, (b.w+1)*(b.h+1)), b.w + 1, b.h + 1}
+}
+
+func (g grid) index(p Point) (int, bool) {
+	if p.X < 0 || p.X >= g.w || p.Y < 0 || p.Y >= g.h {
+		return 0, false
+	}
+	return p.Y*g.w + p.X, true
+}
+
+// count returns what is on p, 0 off the board.
+func (g grid) count(p Point) int {
+	if i, ok := g.index(p); ok {
+		return int(g.cells[i])
+	}
+	return 0
+}
+
+func (g grid) add(p Point) {
+	if i, ok := g.index(p); ok {
+		g.cells[i]++
+	}
+}
+
+func (g grid) remove(p Point) {
+	if i, ok := g.index(p); ok && g.cells[i] > 0 {
+		g.cells[i]--
+	}
+}
@@ -0,0 +1,187 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+)
+
+// Hash returns a hash of the whole state of the game, the random generator
+// included, so two simulations that went apart are told at the first step
+// where they differ. It only depends on the state: the same game hashes the
+// same on any platform.
+func (g *Game) Hash() uint64 {
+	h := fnv.New64a()
+
+	var buf []byte
+	put := func(v int) {
+		buf = binary.AppendVarint(buf, int64(v))
+	}
+
+	put(g.State)
+	put(g.Score)
+	put(g.Direction.X)
+	put(g.Direction.Y)
+	put(g.Food.X)
+	put(g.Food.Y)
+	put(int(g.FoodKind))
+
+	put(g.Snake.Len())
+	for _, p := range g.Snake.All() {
+		put(p.X)
+		put(p.Y)
+	}
+	put(len(g.Walls))
+	for _, p := range g.Walls {
+		put(p.X)
+		put(p.Y)
+	}
+	put(g.TimedWait)
+	if g.Timed != nil {
+		put(1)
+		put(g.Timed.X)
+		put(g.Timed.Y)
+		put(g.TimedLeft)
+	} else {
+		put(0)
+	}
+	put(g.PowerUpWait)
+	if g.PowerUp != nil {
+		put(1)
+		put(g.PowerUp.X)
+		put(g.PowerUp.Y)
+		put(int(g.PowerUpEffect))
+		put(g.PowerUpLeft)
+	} else {
+		put(0)
+	}
+	put(len(g.Active))
+	for _, a := range g.Active {
+		put(int(a.Effect))
+		put(a.Left)
+	}
+	if g.Border != BorderTurn {
+		// only when set, so the classic games hash as they always did
+		put(int(g.Border))
+	}
+	if len(g.Obstacles) > 0 {
+		// likewise
+		put(len(g.Obstacles))
+		for _, o := range g.Obstacles {
+			put(o.At)
+			put(o.Every)
+			put(o.Wait)
+			if o.Back {
+				put(1)
+			} else {
+				put(0)
+			}
+			put(len(o.Path))
+			for _, p := range o.Path {
+				put(p.X)
+				put(p.Y)
+			}
+		}
+	}
+	if g.Combos {
+		// likewise
+		put(g.Multiplier)
+		put(g.ComboLeft)
+	}
+	if len(g.Portals) > 0 {
+		// likewise
+		put(len(g.Portals))
+		for _, pt := range g.Portals {
+			put(pt[0].X)
+			put(pt[0].Y)
+			put(pt[1].X)
+			put(pt[1].Y)
+		}
+	}
+	if g.Goldens > 0 {
+		// likewise, the golden apple only comes after one was placed
+		put(g.Goldens)
+		if g.Golden != nil {
+			put(1)
+			put(g.Golden.X)
+			put(g.Golden.Y)
+			put(g.GoldenLeft)
+		} else {
+			put(0)
+		}
+	}
+	if g.Event != NoEvent || g.Bites != [FrenzyBites - 1]int{} {
+		// likewise, the events only come once food was eaten
+		put(int(g.Event))
+		put(g.EventLeft)
+		for _, b := range g.Bites {
+			put(b)
+		}
+		put(len(g.Extra))
+		for _, p := range g.Extra {
+			put(p.X)
+			put(p.Y)
+		}
+	}
+	if !g.Tiles.Empty() {
+		// likewise
+		for _, ps := range [...][]Point{g.Tiles.Ice, g.Tiles.Mud} {
+			put(len(ps))
+			for _, p := range ps {
+				put(p.X)
+				put(p.Y)
+			}
+		}
+	}
+	if g.Chasing {
+		// likewise
+		put(g.Chaser.X)
+		put(g.Chaser.Y)
+	}
+	if g.PillCharged > 0 || g.Pill != nil {
+		// likewise, the pill charges once the snake is long enough
+		put(g.PillCharged)
+		if g.Pill != nil {
+			put(1)
+			put(g.Pill.X)
+			put(g.Pill.Y)
+			put(g.PillLeft)
+		} else {
+			put(0)
+		}
+	}
+	if g.Growth > 1 {
+		// likewise
+		put(g.Growth)
+		put(g.Growing)
+	}
+	if g.bounds() != classic {
+		// likewise
+		put(g.Width)
+		put(g.Height)
+	}
+	if g.Arena != RectArena {
+		// likewise
+		put(int(g.Arena))
+	}
+	h.Write(buf)
+
+	if g.src != nil {
+		// never fails for a PCG
+		state, _ := g.src.MarshalBinary()
+		h.Write(state)
+	}
+
+	return h.Sum64()
+}
@@ -0,0 +1,352 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+// Match is a game of several snakes on the same board, racing for the same
+// food, a point and a segment each. A snake crashes into itself and into the
+// others, both when heads meet. The match is over as soon as one crashes,
+// or with LastStanding once a single one is left.
+//
+// It's a game of its own next to Game: no walls or kinds of food, no
+// restarts, the border turns the snakes unless set otherwise.
+type Match struct {
+	Players []*Player
+	Food    Point
+	Border  Border
+	Over    bool
+	// Width and Height are the last column and row, the board spans
+	// 0..Width and 0..Height like the one of Game.
+	Width, Height int
+
+	// LastStanding goes on until a single snake is left, the crashed ones
+	// turning into Pellets.
+	LastStanding bool
+	// Pellets are food left by the crashed snakes, a point and a segment
+	// each like the food.
+	Pellets []Point
+	pellets grid
+
+	// cells counts the segments of the snakes in the match on each cell
+	cells grid
+	// free is reused by setFood
+	free []Point
+
+	rng *rand.Rand
+}
+
+// Player is a snake in a match.
+type Player struct {
+	Snake     Body
+	Direction Point
+	Score     int
+	Crashed   bool
+	// Out is set once it has crashed in a match going on, its body turned
+	// into pellets and off the board.
+	Out bool
+
+	// next is where the head goes in the step being made
+	next Point
+}
+
+// NewMatch starts a match of n snakes on the board of Game, food placement
+// being decided by seed.
+func NewMatch(seed uint64, n int) *Match {
+	return NewMatchSize(seed, n, BoardWidth, BoardHeight)
+}
+
+// NewMatchSize starts a match of n snakes on a board spanning 0..width and
+// 0..height. They start spread over the rows, every other one on the right
+// heading left.
+func NewMatchSize(seed uint64, n, width, height int) *Match {
+	m := &Match{
+		Food:   Point{},
+		Width:  width,
+		Height: height,
+		rng:    rand.New(rand.NewPCG(seed, seed)),
+	}
+	b := m.bounds()
+	m.cells = newGridOf(b)
+	m.pellets = newGridOf(b)
+
+	for i := range n {
+		y := (i + 1) * height / (n + 1)
+		p := &Player{
+			Snake:     newBodyOn(m.cells, Point{width / 4, y}),
+			Direction: Point{1, 0},
+		}
+		if i%2 == 1 {
+			p.Snake = newBodyOn(m.cells, Point{width - width/4, y})
+			p.Direction = Point{-1, 0}
+		}
+		m.Players = append(m.Players, p)
+	}
+	m.setFood()
+	return m
+}
+
+func (m *Match) bounds() bounds {
+	return bounds{m.Width, m.Height}
+}
+
+// Turn changes the direction unless it would reverse the snake, as
+// Game.Turn.
+func (p *Player) Turn(x, y int) {
+	if p.Snake.Len() > 1 && x == -p.Direction.X && y == -p.Direction.Y {
+		return
+	}
+	p.Direction = Point{x, y}
+}
+
+// Count returns how many segments of the snakes still in the match are at p.
+func (m *Match) Count(p Point) int {
+	return m.cells.count(p)
+}
+
+// Pellet reports whether a pellet is at p.
+func (m *Match) Pellet(p Point) bool {
+	return m.pellets.count(p) > 0
+}
+
+// Ahead returns where the head of p will be after the next step if it turns
+// to (x, y) first, as Game.Ahead.
+func (m *Match) Ahead(p *Player, x, y int) Point {
+	dir := p.Direction
+	if p.Snake.Len() == 1 || x != -dir.X || y != -dir.Y {
+		dir = Point{x, y}
+	}
+	head, _ := m.move(p.Snake.Head(), dir)
+	return head
+}
+
+// move returns where a head heading dir goes next, and the direction it
+// goes in once turned at the border.
+func (m *Match) move(head, dir Point) (Point, Point) {
+	if m.Border == BorderTurn {
+		m.bounds().turn(head, &dir)
+	}
+
+	head.X += dir.X
+	head.Y += dir.Y
+	if m.Border == BorderWrap {
+		head = m.bounds().wrap(head)
+	}
+	return head, dir
+}
+
+// Blocked reports whether a head moving to p in the next step crashes,
+// counting on the tails moving out of the way. Off the board is always
+// blocked.
+func (m *Match) Blocked(p Point) bool {
+	if !m.bounds().contains(p) {
+		return true
+	}
+	n := m.Count(p)
+	for _, pl := range m.Players {
+		if !pl.Out && pl.Snake.Tail() == p {
+			n--
+		}
+	}
+	return n > 0
+}
+
+// Alive returns how many snakes haven't crashed.
+func (m *Match) Alive() int {
+	n := 0
+	for _, p := range m.Players {
+		if !p.Crashed {
+			n++
+		}
+	}
+	return n
+}
+
+// Step moves all the snakes at once, growing those eating, then crashes those
+// whose head landed on a snake or out of the board.
+func (m *Match) Step() {
+	if m.Over {
+		return
+	}
+
+	ate := false
+	for _, p := range m.Players {
+		if p.Crashed {
+			continue
+		}
+		p.next, p.Direction = m.move(p.Snake.Head(), p.Direction)
+		if !m.bounds().contains(p.next) {
+			// into a solid border, it stops at the edge
+			p.Crashed = true
+			continue
+		}
+		switch {
+		case p.next == m.Food:
+			ate = true
+			p.Score++
+		case m.Pellet(p.next):
+			m.removePellet(p.next)
+			p.Score++
+		default:
+			p.Snake.PopTail()
+		}
+	}
+	// the tails first, a snake can follow another's tail
+	for _, p := range m.Players {
+		if !p.Crashed {
+			p.Snake.PushHead(p.next)
+		}
+	}
+
+	crashed := false
+	for _, p := range m.Players {
+		if !p.Crashed && m.Count(p.Snake.Head()) > 1 {
+			p.Crashed = true
+		}
+		crashed = crashed || (p.Crashed && !p.Out)
+	}
+
+	if m.LastStanding {
+		// once all the crashes are known, heads meeting crash both
+		for _, p := range m.Players {
+			if p.Crashed && !p.Out {
+				m.knockOut(p)
+			}
+		}
+		m.Over = m.Alive() <= 1
+	} else {
+		m.Over = crashed
+	}
+
+	if ate {
+		m.setFood()
+	}
+}
+
+// knockOut takes a crashed snake off the board, leaving a pellet on each
+// cell of its body no other snake is on.
+func (m *Match) knockOut(p *Player) {
+	p.Out = true
+	for p.Snake.Len() > 1 {
+		m.leavePellet(p.Snake.PopTail())
+	}
+	// the head stays in the body for the snake to be drawn where it
+	// crashed, off the cells of the match
+	head := p.Snake.Head()
+	m.leavePellet(head)
+	p.Snake = newBodyIn(m.bounds(), head)
+}
+
+func (m *Match) leavePellet(p Point) {
+	m.cells.remove(p)
+	if m.cells.count(p) > 0 || m.Pellet(p) || p == m.Food || !m.bounds().contains(p) {
+		return
+	}
+	m.Pellets = append(m.Pellets, p)
+	m.pellets.add(p)
+}
+
+func (m *Match) removePellet(p Point) {
+	m.pellets.remove(p)
+	for i, q := range m.Pellets {
+		if q == p {
+			m.Pellets = append(m.Pellets[:i], m.Pellets[i+1:]...)
+			return
+		}
+	}
+}
+
+// setFood puts the food on one of the cells free of the snakes and the
+// pellets, all equally likely. It stays where it is if there's none.
+func (m *Match) setFood() {
+	m.free = m.free[:0]
+	for y := range m.Height + 1 {
+		for x := range m.Width + 1 {
+			if p := (Point{x, y}); m.Count(p) == 0 && !m.Pellet(p) {
+				m.free = append(m.free, p)
+			}
+		}
+	}
+	if len(m.free) > 0 {
+		m.Food = m.free[m.rng.IntN(len(m.free))]
+	}
+}
+
+// Check verifies the invariants of a match, as Game.Check: each snake is on
+// the board and in one piece, they only overlap once the match is over, the
+// food is off them while there's room and the pellets are on free cells.
+func (m *Match) Check() error {
+	b := m.bounds()
+	for i, p := range m.Players {
+		if p.Snake.Len() == 0 {
+			return fmt.Errorf("snake %d: no segments", i+1)
+		}
+		if p.Out {
+			if p.Snake.Len() != 1 || !p.Crashed {
+				return fmt.Errorf("snake %d: out with %d segments", i+1, p.Snake.Len())
+			}
+			continue
+		}
+		for j, v := range p.Snake.All() {
+			if !b.contains(v) {
+				return fmt.Errorf("snake %d: segment %d at %v is off the board", i+1, j, v)
+			}
+			if j == 0 {
+				continue
+			}
+			prev := p.Snake.At(j - 1)
+			dx, dy := v.X-prev.X, v.Y-prev.Y
+			if m.Border == BorderWrap {
+				dx, dy = wrapOffset(dx, b.w+1), wrapOffset(dy, b.h+1)
+			}
+			if abs(dx)+abs(dy) != 1 {
+				return fmt.Errorf("snake %d: segment %d at %v is not next to %v", i+1, j, v, prev)
+			}
+			if !m.Over && m.Count(v) > 1 {
+				return fmt.Errorf("snake %d: segment %d at %v overlaps a snake", i+1, j, v)
+			}
+		}
+		if !m.Over && (p.Crashed || m.Count(p.Snake.Head()) > 1) {
+			return fmt.Errorf("snake %d: crashed while the match goes on", i+1)
+		}
+	}
+
+	f := m.Food
+	if !b.contains(f) {
+		return fmt.Errorf("food at %v is out of bounds", f)
+	}
+	if (m.Count(f) > 0 || m.Pellet(f)) && len(m.free) > 0 {
+		return fmt.Errorf("food at %v is on a snake or a pellet", f)
+	}
+
+	if len(m.Pellets) != m.pelletCount() {
+		return fmt.Errorf("%d pellets listed, %d on the board", len(m.Pellets), m.pelletCount())
+	}
+	for _, p := range m.Pellets {
+		if m.pellets.count(p) != 1 || (!m.Over && m.Count(p) > 0) {
+			return fmt.Errorf("pellet at %v is on a snake or another pellet", p)
+		}
+	}
+	return nil
+}
+
+func (m *Match) pelletCount() int {
+	n := 0
+	for _, c := range m.pellets.cells {
+		n += int(c)
+	}
+	return n
+}
@@ -0,0 +1,248 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Monitor checks the properties of the rules that span steps, where Check
+// only looks at a single state:
+//
+//   - the score is the value of the foods eaten since the run started, the
+//     timed ones, the golden apples and the extra food included, times the
+//     combo when it's on and doubled in the frenzy
+//   - the snake is one segment plus Growth per food eaten, less 2 per
+//     poison and a third per shrink pill, the growth to come included
+//   - it crashes only when the head lands on the body, a wall, an obstacle
+//     or the chaser, one of those moves into it, or it runs into a solid
+//     border
+//   - after a crash it shrinks back to the head, a segment per step
+//   - the score is kept while shrinking, the next run starts from 0; with
+//     lives left the snake comes back where it started instead, keeping
+//     the score
+//   - a won run stays as it is until Restart, back to the head
+//
+// Call Observe with a new game, then after every Step and Restart.
+type Monitor struct {
+	started bool
+	// of the game before the step
+	state  int
+	length int
+	food   Point
+	kind   FoodKind
+	timed  Point
+	// timedOn tells whether there was a timed food
+	timedOn bool
+	golden  Point
+	// goldenOn tells whether there was a golden apple
+	goldenOn bool
+	pill     Point
+	// pillOn tells whether there was a shrink pill
+	pillOn bool
+	// growing is the growth to come before the step
+	growing int
+	// the event and the extra food before the step
+	event Event
+	extra []Point
+	// the combo before the step
+	multiplier int
+	comboLeft  int
+
+	deaths int
+
+	eaten  int
+	points int
+	// grown is the segments added by the food, less those taken by poison,
+	// the growth to come included
+	grown int
+	// shrink is the number of steps left to shrink after a crash
+	shrink int
+}
+
+// Observe checks g, which has made a step since the last call.
+func (m *Monitor) Observe(g *Game) error {
+	if !m.started {
+		m.started = true
+		m.eaten, m.points, m.grown = 0, 0, 0
+		m.remember(g)
+		return m.checkRun(g)
+	}
+	defer m.remember(g)
+
+	head := g.Snake.Head()
+	switch m.state {
+	case RUNNING:
+		if head == m.food {
+			m.eaten++
+			m.points = max(m.points+m.kind.Value()*m.combo(g, m.kind.Value()), 0)
+			if m.kind == PoisonFood {
+				m.grown = max(m.grown-2, 0)
+			} else {
+				m.grown += max(g.Growth, 1)
+			}
+		}
+		if m.timedOn && head == m.timed {
+			m.eaten++
+			m.points += TimedValue * m.combo(g, TimedValue)
+			m.grown += max(g.Growth, 1)
+		}
+		if m.goldenOn && head == m.golden {
+			m.eaten++
+			m.points += GoldenValue * m.combo(g, GoldenValue)
+			m.grown += max(g.Growth, 1)
+		}
+		if slices.Contains(m.extra, head) {
+			m.eaten++
+			m.points += NormalFood.Value() * m.combo(g, NormalFood.Value())
+			m.grown += max(g.Growth, 1)
+		}
+		if m.pillOn && head == m.pill {
+			// the snake as it moved, before the pill
+			n := m.length
+			if m.growing > 0 {
+				n++
+			}
+			m.grown -= pillCut(n)
+		}
+
+		switch g.State {
+		case RUNNING, WON:
+		case CRASHED:
+			if g.Snake.Count(head) < 2 && g.wallCells.count(head) == 0 && g.playable(head) && !g.obstacleAt(head) && !g.onChaser(head) && !g.intoBorder() {
+				return fmt.Errorf("snake: crashed at %v with nothing there", head)
+			}
+		default:
+			return fmt.Errorf("snake: went from running to state %d", g.State)
+		}
+		return m.checkRun(g)
+
+	case CRASHED:
+		if g.State != CRASHING {
+			return fmt.Errorf("snake: went from crashed to state %d", g.State)
+		}
+		if g.Score != m.points {
+			return fmt.Errorf("snake: score %d changed to %d after the crash", m.points, g.Score)
+		}
+		m.shrink = m.length
+
+	case CRASHING:
+		m.shrink--
+		if m.shrink < 0 {
+			return fmt.Errorf("snake: still shrinking after %d steps", m.length)
+		}
+
+		switch g.State {
+		case CRASHING:
+			if g.Score != m.points {
+				return fmt.Errorf("snake: score %d changed to %d while shrinking", m.points, g.Score)
+			}
+			if g.Snake.Len() != max(m.length-1, 1) {
+				return fmt.Errorf("snake: shrank from %d to %d segments", m.length, g.Snake.Len())
+			}
+		case RUNNING:
+			if g.Snake.Len() != 1 {
+				return fmt.Errorf("snake: restarted with %d segments", g.Snake.Len())
+			}
+			if g.Deaths > 0 {
+				// a life lost, the run goes on
+				if g.Deaths != m.deaths+1 || g.Snake.Head() != g.start() {
+					return fmt.Errorf("snake: came back at %v after %d deaths, had %d", g.Snake.Head(), g.Deaths, m.deaths)
+				}
+				m.grown = 0
+				return m.checkRun(g)
+			}
+			// a new run
+			m.eaten, m.points, m.grown = 0, 0, 0
+			return m.checkRun(g)
+		default:
+			return fmt.Errorf("snake: went from shrinking to state %d", g.State)
+		}
+
+	case WON:
+		switch g.State {
+		case WON:
+			if g.Score != m.points || g.Snake.Len() != m.length {
+				return fmt.Errorf("snake: score %d and length %d changed to %d and %d after winning", m.points, m.length, g.Score, g.Snake.Len())
+			}
+		case RUNNING:
+			if g.Snake.Len() != 1 {
+				return fmt.Errorf("snake: restarted with %d segments", g.Snake.Len())
+			}
+			m.eaten, m.points, m.grown = 0, 0, 0
+			return m.checkRun(g)
+		default:
+			return fmt.Errorf("snake: went from won to state %d", g.State)
+		}
+	}
+	return nil
+}
+
+func (m *Monitor) remember(g *Game) {
+	m.state = g.State
+	m.deaths = g.Deaths
+	m.length = g.Snake.Len()
+	m.food = g.Food
+	m.kind = g.FoodKind
+	m.multiplier, m.comboLeft = g.Multiplier, g.ComboLeft
+	m.timedOn = g.Timed != nil
+	if m.timedOn {
+		m.timed = *g.Timed
+	}
+	m.goldenOn = g.Golden != nil
+	if m.goldenOn {
+		m.golden = *g.Golden
+	}
+	m.pillOn = g.Pill != nil
+	if m.pillOn {
+		m.pill = *g.Pill
+	}
+	m.growing = g.Growing
+	m.event = g.Event
+	m.extra = append(m.extra[:0], g.Extra...)
+}
+
+// combo returns what the combo and the frenzy multiply points worth of
+// food eaten in the step by.
+func (m *Monitor) combo(g *Game, points int) int {
+	if points <= 0 {
+		return 1
+	}
+	n := 1
+	if g.Combos && m.comboLeft > 0 {
+		n = min(m.multiplier+1, MaxMultiplier)
+	}
+	if m.event == Frenzy {
+		n *= FrenzyFactor
+	}
+	return n
+}
+
+// checkRun checks the score and length while running.
+func (m *Monitor) checkRun(g *Game) error {
+	if g.Score != m.points {
+		return fmt.Errorf("snake: score %d after eating %d worth %d", g.Score, m.eaten, m.points)
+	}
+	if g.Snake.Len()+g.Growing != 1+m.grown {
+		return fmt.Errorf("snake: %d segments and %d to come after eating %d, %d grown", g.Snake.Len(), g.Growing, m.eaten, m.grown)
+	}
+	return nil
+}
+
+// intoBorder reports whether the head faces a solid border it can't go
+// through.
+func (g *Game) intoBorder() bool {
+	head := g.Snake.Head()
+	return g.Border == BorderSolid && !g.inBoard(Point{head.X + g.Direction.X, head.Y + g.Direction.Y})
+}
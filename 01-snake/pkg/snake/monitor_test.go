@@ -0,0 +1,62 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake_test
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// TestProperties plays random games, see fuzzGame, turning at random, and
+// watches the properties spanning steps with a Monitor, the invariants of
+// each state with Check, through the crashes, the lives and the restarts.
+func TestProperties(t *testing.T) {
+	games, steps := 300, 2000
+	if testing.Short() {
+		games = 30
+	}
+
+	for seed := range uint64(games) {
+		g := fuzzGame(seed)
+		rng := rand.New(rand.NewPCG(seed, seed))
+		var m snake.Monitor
+		observe := func(when string) {
+			t.Helper()
+			if err := g.Check(); err != nil {
+				t.Fatalf("seed %d, %s: %v", seed, when, err)
+			}
+			if err := m.Observe(g); err != nil {
+				t.Fatalf("seed %d, %s: %v", seed, when, err)
+			}
+		}
+
+		observe("at the start")
+		for step := 1; step <= steps; step++ {
+			if rng.IntN(10) < 3 {
+				g.Turn(input.Dirs[rng.IntN(len(input.Dirs))].Delta())
+			}
+			g.Step()
+			observe(fmt.Sprintf("step %d", step))
+
+			// a won run waits a few steps for the restart
+			if g.State == snake.WON && rng.IntN(5) == 0 {
+				g.Restart()
+				observe(fmt.Sprintf("restarting after step %d", step))
+			}
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+// Obstacle patrols the board: it moves a cell along Path every Every steps,
+// to the end and back again. The snake crashes running into it, or when it
+// runs into the head; the body it goes over.
+type Obstacle struct {
+	// Path are the cells patrolled, each next to the one before.
+	Path []Point
+	// Every is the steps it waits on a cell, 1 if less.
+	Every int
+
+	// At is the cell of Path it's on, Back set while going back to the
+	// first one, and Wait the steps until it moves.
+	At   int
+	Back bool
+	Wait int
+}
+
+// Pos returns the cell the obstacle is on.
+func (o *Obstacle) Pos() Point {
+	return o.Path[o.At]
+}
+
+// move counts a step down, moving a cell along the path once it's time.
+func (o *Obstacle) move() {
+	if len(o.Path) < 2 {
+		return
+	}
+	o.Wait--
+	if o.Wait > 0 {
+		return
+	}
+	o.Wait = max(o.Every, 1)
+
+	if o.At == len(o.Path)-1 {
+		o.Back = true
+	} else if o.At == 0 {
+		o.Back = false
+	}
+	if o.Back {
+		o.At--
+	} else {
+		o.At++
+	}
+}
+
+// SetObstacles replaces the obstacles, starting each at the first cell of its
+// path, and moves the food off their paths.
+func (g *Game) SetObstacles(obstacles []Obstacle) {
+	g.Obstacles = obstacles
+	for i := range g.Obstacles {
+		o := &g.Obstacles[i]
+		o.At, o.Back, o.Wait = 0, false, max(o.Every, 1)
+	}
+	if g.onPatrol(g.Food) {
+		g.setFood()
+	}
+}
+
+// obstacleAt reports whether an obstacle is at p.
+func (g *Game) obstacleAt(p Point) bool {
+	for i := range g.Obstacles {
+		if g.Obstacles[i].Pos() == p {
+			return true
+		}
+	}
+	return false
+}
+
+// obstacleNext reports whether an obstacle will be at p once moved in the
+// next step.
+func (g *Game) obstacleNext(p Point) bool {
+	for _, o := range g.Obstacles {
+		if o.move(); o.Pos() == p {
+			return true
+		}
+	}
+	return false
+}
+
+// onPatrol reports whether p is on the path of an obstacle, where nothing is
+// placed.
+func (g *Game) onPatrol(p Point) bool {
+	for _, o := range g.Obstacles {
+		for _, q := range o.Path {
+			if q == p {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// moveObstacles moves the obstacles after the snake, in a running step.
+func (g *Game) moveObstacles() {
+	for i := range g.Obstacles {
+		g.Obstacles[i].move()
+	}
+}
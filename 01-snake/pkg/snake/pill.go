@@ -0,0 +1,74 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+// The shrink pill takes a third of the snake off, the score and the combo
+// staying as they are: a way out of a long game. It comes sooner the longer
+// the snake, every running step charging it by the length, and stays like
+// the timed food.
+const (
+	// PillCharge is the charge the next pill comes at, a snake of 20
+	// segments taking 300 steps.
+	PillCharge = 6000
+	// PillLife is the number of running steps a pill stays.
+	PillLife = 60
+	// PillMinLength is the length a pill is left at, at least, and the
+	// shortest snake the pill charges for.
+	PillMinLength = 3
+)
+
+// pillMinCharging is the shortest snake charging the pill, one the pill
+// takes a segment off.
+const pillMinCharging = 2 * PillMinLength
+
+// onPill reports whether the shrink pill is at p.
+func (g *Game) onPill(p Point) bool {
+	return g.Pill != nil && *g.Pill == p
+}
+
+// pillCut returns the segments the pill takes off a snake n long: a third,
+// rounded down, leaving at least PillMinLength.
+func pillCut(n int) int {
+	return max(min(n/3, n-PillMinLength), 0)
+}
+
+// takePill shrinks the snake by the pill, its head being on it.
+func (g *Game) takePill() {
+	for range pillCut(g.Snake.Len()) {
+		g.Snake.PopTail()
+	}
+	g.Pill = nil
+}
+
+// tickPill counts a running step down for the pill on the board, or charges
+// the next one by the length of the snake.
+func (g *Game) tickPill() {
+	if g.Pill != nil {
+		if g.PillLeft--; g.PillLeft <= 0 {
+			g.Pill = nil
+		}
+		return
+	}
+	if g.Snake.Len() < pillMinCharging {
+		return
+	}
+	if g.PillCharged += g.Snake.Len(); g.PillCharged < PillCharge {
+		return
+	}
+	// with no room it charges again, as the timed food waits again
+	g.PillCharged = 0
+	if p, ok := g.pickItemCell(); ok {
+		g.Pill = &p
+		g.PillLeft = PillLife
+	}
+}
@@ -0,0 +1,44 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+// Portal is a pair of cells: the head moving into either comes out of the
+// other, going on in the same direction. Nothing is placed on them.
+type Portal [2]Point
+
+// SetPortals replaces the portals, moving the food off them.
+func (g *Game) SetPortals(portals []Portal) {
+	g.Portals = portals
+	if g.onPortal(g.Food) {
+		g.setFood()
+	}
+}
+
+// twin returns the other end of the portal at p, if there's one.
+func (g *Game) twin(p Point) (Point, bool) {
+	for _, pt := range g.Portals {
+		switch p {
+		case pt[0]:
+			return pt[1], true
+		case pt[1]:
+			return pt[0], true
+		}
+	}
+	return p, false
+}
+
+// onPortal reports whether a portal is at p.
+func (g *Game) onPortal(p Point) bool {
+	_, ok := g.twin(p)
+	return ok
+}
@@ -0,0 +1,181 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+// Effect is what a power-up does for a while once collected.
+type Effect int
+
+const (
+	// Ghost lets the snake through its own body.
+	Ghost Effect = iota
+	// SlowMo halves the pace of the snake. The steps are the same, it's up
+	// to the game to make them slower.
+	SlowMo
+	// Magnet pulls the food towards the head once it's within 2 cells.
+	Magnet
+	// Mirror swaps the player's controls, left for right and up for down,
+	// the one power-up better left alone. Like SlowMo, it's up to the game.
+	Mirror
+)
+
+// Effects lists the effects, as power-ups come with them.
+var Effects = []Effect{Ghost, SlowMo, Magnet, Mirror}
+
+func (e Effect) String() string {
+	switch e {
+	case Ghost:
+		return "ghost"
+	case SlowMo:
+		return "slowmo"
+	case Magnet:
+		return "magnet"
+	case Mirror:
+		return "mirror"
+	}
+	return "unknown"
+}
+
+// Power-ups come and go like the timed food, their effects last a number of
+// running steps.
+const (
+	// PowerUpEvery is the number of running steps between two power-ups.
+	PowerUpEvery = 200
+	// PowerUpLife is the number of running steps a power-up stays.
+	PowerUpLife = 60
+	// EffectSteps is the number of running steps an effect lasts.
+	EffectSteps = 60
+)
+
+// magnetRange is how close the food has to be, in cells along both axes,
+// for the magnet to pull it.
+const magnetRange = 2
+
+// ActiveEffect is the effect of a power-up collected.
+type ActiveEffect struct {
+	Effect Effect
+	// Left is the number of running steps it still lasts.
+	Left int
+}
+
+// onPowerUp reports whether the power-up is at p.
+func (g *Game) onPowerUp(p Point) bool {
+	return g.PowerUp != nil && *g.PowerUp == p
+}
+
+// IsActive reports whether the effect of a power-up is on.
+func (g *Game) IsActive(e Effect) bool {
+	for _, a := range g.Active {
+		if a.Effect == e {
+			return true
+		}
+	}
+	return false
+}
+
+// collect starts the effect of the power-up, the snake's head being on it,
+// again for EffectSteps if it's already on.
+func (g *Game) collect() {
+	e := g.PowerUpEffect
+	g.PowerUp = nil
+	g.PowerUpWait = PowerUpEvery
+
+	for i := range g.Active {
+		if g.Active[i].Effect == e {
+			g.Active[i].Left = EffectSteps
+			return
+		}
+	}
+	g.Active = append(g.Active, ActiveEffect{Effect: e, Left: EffectSteps})
+}
+
+// tickPowerUps counts a running step down for the effects and the power-up
+// on the board, which comes and goes like the timed food.
+func (g *Game) tickPowerUps() {
+	n := 0
+	for _, a := range g.Active {
+		a.Left--
+		if a.Left <= 0 && a.Effect == Ghost && g.tangled() {
+			// the ghost lasts until the snake is out of itself
+			a.Left = 1
+		}
+		if a.Left > 0 {
+			g.Active[n] = a
+			n++
+		}
+	}
+	g.Active = g.Active[:n]
+
+	if g.PowerUp != nil {
+		g.PowerUpLeft--
+		if g.PowerUpLeft <= 0 {
+			g.PowerUp = nil
+			g.PowerUpWait = PowerUpEvery
+		}
+		return
+	}
+
+	g.PowerUpWait--
+	if g.PowerUpWait > 0 {
+		return
+	}
+	if p, ok := g.pickItemCell(); ok {
+		g.PowerUp = &p
+		g.PowerUpEffect = Effects[g.rng.IntN(len(Effects))]
+		g.PowerUpLeft = PowerUpLife
+	} else {
+		// no room, try again later
+		g.PowerUpWait = PowerUpEvery
+	}
+}
+
+// tangled reports whether segments of the snake share a cell, as they can
+// with the ghost.
+func (g *Game) tangled() bool {
+	for _, p := range g.Snake.All() {
+		if g.Snake.Count(p) > 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// pull moves the food a cell towards the head, along the axis it's further
+// on, when it's within magnetRange. It stays if that cell is taken, or out
+// of where food is placed.
+func (g *Game) pull() {
+	h, f := g.Snake.Head(), g.Food
+	dx, dy := h.X-f.X, h.Y-f.Y
+	if abs(dx) > magnetRange || abs(dy) > magnetRange {
+		return
+	}
+
+	if abs(dx) >= abs(dy) {
+		f.X += sign(dx)
+	} else {
+		f.Y += sign(dy)
+	}
+	if !g.playable(f) || g.occupied(f) || g.onItem(f) {
+		return
+	}
+	g.Food = f
+}
+
+func sign(x int) int {
+	switch {
+	case x < 0:
+		return -1
+	case x > 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,515 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package snake holds the rules of the snake game, without any rendering or
+// input handling, so they can be simulated headlessly: by the server
+// verifying replays, by bots, in tests.
+//
+// The simulation is deterministic: the same seed and the same direction
+// changes at the same steps always give the same game.
+package snake
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+// The board spans 0..BoardWidth and 0..BoardHeight, both inclusive.
+const (
+	BoardWidth  = 38
+	BoardHeight = 28
+)
+
+// The last column and row of a board started with NewLevelSize are
+// between MinBoardSize and MaxBoardSize.
+const (
+	MinBoardSize = 9
+	MaxBoardSize = 255
+)
+
+// Start is where the snake starts, heading right.
+var Start = Point{BoardHeight / 2, BoardWidth / 2}
+
+const (
+	RUNNING = iota
+	CRASHED
+	CRASHING
+	// WON is the end of a run filling the board, or reaching Target, until
+	// Restart.
+	WON
+)
+
+type Point struct {
+	X int
+	Y int
+}
+
+func (p Point) String() string {
+	return fmt.Sprintf("[%d,%d]", p.X, p.Y)
+}
+
+// Game is the state of a game, advanced one movement step at a time by Step.
+type Game struct {
+	// Snake is the body, head first.
+	Snake Body
+	// Food is the cell the food is on.
+	Food Point
+	// FoodKind is what the food is worth, see FoodKind.Value. Its color is
+	// up to the front end, see foodColors in pkg/snakegame.
+	FoodKind FoodKind
+	// Timed is the timed food, nil when there's none, going in TimedLeft
+	// steps. The next one comes in TimedWait steps.
+	Timed     *Point
+	TimedLeft int
+	TimedWait int
+	// PowerUp is the power-up to collect, nil when there's none, starting
+	// PowerUpEffect, going in PowerUpLeft steps. The next one comes in
+	// PowerUpWait steps.
+	PowerUp       *Point
+	PowerUpEffect Effect
+	PowerUpLeft   int
+	PowerUpWait   int
+	// Active are the effects of the power-ups collected, until the run
+	// ends.
+	Active []ActiveEffect
+	// Golden is the golden apple, nil when there's none, going in
+	// GoldenLeft steps. Goldens are those placed in the run, the next
+	// coming at GoldenEvery points more.
+	Golden     *Point
+	GoldenLeft int
+	Goldens    int
+	// Pill is the shrink pill, nil when there's none, going in PillLeft
+	// steps. The next one comes once PillCharged reaches PillCharge.
+	Pill        *Point
+	PillLeft    int
+	PillCharged int
+	// Event is the board-wide event going on, ending in EventLeft steps.
+	Event     Event
+	EventLeft int
+	// Extra are the foods of the frenzy besides the food. Bites are the
+	// steps left in the window of the foods eaten towards it, 0 for none.
+	Extra []Point
+	Bites [FrenzyBites - 1]int
+
+	Direction Point
+	Score     int
+	State     int
+	// Target is the length winning the run, 0 for only filling the board.
+	Target int
+	// Lives are the crashes a run takes, 0 counting as 1. Until the last,
+	// the snake comes back where it started after shrinking, keeping the
+	// score.
+	Lives int
+	// Deaths are the lives lost in the run.
+	Deaths int
+	// Combos multiply the points of the food eaten quickly, see
+	// ComboWindow. Multiplier is the current one, 0 out of a combo, going
+	// down in ComboLeft steps.
+	Combos     bool
+	Multiplier int
+	ComboLeft  int
+	// Growth are the segments a food adds, the timed one too, 0 counting as
+	// 1. They come one a step, the tail staying put, Growing being those
+	// still to come.
+	Growth  int
+	Growing int
+	// Chase brings the chaser, once the score reaches ChaserScore. Chasing
+	// is whether it's on the board, Chaser where it is.
+	Chase   bool
+	Chasing bool
+	Chaser  Point
+	// Zen stops the snake in front of what would crash it rather than
+	// crashing it, Stalled being set after a step it stood still. Nothing
+	// moves meanwhile.
+	Zen     bool
+	Stalled bool
+	// Walls crash the snake, they make the board a level. Change them
+	// with SetWalls.
+	Walls []Point
+	// wallCells indexes Walls
+	wallCells grid
+	// Portals take the head from a cell to its twin, change them with
+	// SetPortals.
+	Portals []Portal
+	// Obstacles patrol the board, change them with SetObstacles.
+	Obstacles []Obstacle
+	// Tiles are the ice and the mud on the board, change them with
+	// SetTiles.
+	Tiles Tiles
+	// tiles is the layer of Tiles, nil for a plain board
+	tiles []Tile
+	// Border is what the edge of the board does to the snake, turning it
+	// by default.
+	Border Border
+	// Arena is the shape of the board, all of it by default. Change it
+	// with SetArena.
+	Arena Arena
+	// Width and Height are the last column and row, BoardWidth and
+	// BoardHeight unless started with NewLevelSize.
+	Width, Height int
+
+	// free is reused by setFood
+	free []Point
+
+	rng *rand.Rand
+	// src is the state of rng, kept for Hash
+	src *rand.PCG
+}
+
+// New starts a game, food placement being decided by seed.
+func New(seed uint64) *Game {
+	return NewLevel(seed, nil)
+}
+
+// NewLevel starts a game on a board with walls. Without walls it is the
+// same game as New.
+func NewLevel(seed uint64, walls []Point) *Game {
+	return NewLevelSize(seed, walls, BoardWidth, BoardHeight)
+}
+
+// NewLevelSize starts a game on a board spanning 0..width and 0..height,
+// with walls. The snake starts in the middle, at Start on the board of New.
+func NewLevelSize(seed uint64, walls []Point, width, height int) *Game {
+	b := bounds{width, height}
+	src := rand.NewPCG(seed, seed)
+	g := &Game{
+		Snake:       newBodyIn(b, b.start()),
+		Width:       width,
+		Height:      height,
+		Direction:   Point{1, 0},
+		Food:        Point{},
+		State:       RUNNING,
+		TimedWait:   TimedEvery,
+		PowerUpWait: PowerUpEvery,
+		rng:         rand.New(src),
+		src:         src,
+	}
+
+	g.SetWalls(walls)
+	g.setFood()
+
+	return g
+}
+
+// Turn changes the direction unless it would reverse the snake, see
+// Reverses, or the head is on ice.
+func (g *Game) Turn(x, y int) {
+	if g.Reverses(x, y) || g.sliding() {
+		return
+	}
+	g.Direction.X = x
+	g.Direction.Y = y
+}
+
+// Reverses reports whether turning to (x, y) would send the snake back into
+// its neck, which Turn ignores. A lone head can go back the way it came.
+func (g *Game) Reverses(x, y int) bool {
+	return g.Snake.Len() > 1 && x == -g.Direction.X && y == -g.Direction.Y
+}
+
+func (g *Game) detectBorder(p *Point) {
+	g.bounds().turn(*p, &g.Direction)
+}
+
+func (g *Game) bounds() bounds {
+	return bounds{g.Width, g.Height}
+}
+
+func (g *Game) inBoard(p Point) bool {
+	return g.bounds().contains(p)
+}
+
+// SetWalls replaces the walls.
+func (g *Game) SetWalls(walls []Point) {
+	g.Walls = walls
+	g.wallCells = newGridOf(g.bounds())
+	for _, p := range walls {
+		g.wallCells.add(p)
+	}
+}
+
+func (g *Game) detectCollision(h Point) bool {
+	// the head is one of the segments there
+	return (g.Snake.Count(h) > 1 && !g.IsActive(Ghost)) || g.wallCells.count(h) > 0 || !g.playable(h) || g.obstacleAt(h) || g.onChaser(h)
+}
+
+// Ahead returns where the head will be after the next step if the snake
+// turns to (x, y) first, the turns at the borders included.
+func (g *Game) Ahead(x, y int) Point {
+	dir := g.Direction
+	if !g.Reverses(x, y) && !g.sliding() {
+		dir = Point{x, y}
+	}
+
+	head := g.Snake.Head()
+	if g.Border == BorderTurn {
+		g.bounds().turn(head, &dir)
+	}
+
+	p := Point{head.X + dir.X, head.Y + dir.Y}
+	if g.Border == BorderWrap {
+		p = g.bounds().wrap(p)
+	}
+	p, _ = g.twin(p)
+	return p
+}
+
+// Blocked reports whether moving the head to p in the next step crashes the
+// snake. The tail moves out of the way unless the snake grows, the body
+// with the ghost. Off the board or the arena is always blocked, and so are
+// the obstacles where they are and where they go, and the chaser.
+func (g *Game) Blocked(p Point) bool {
+	if !g.playable(p) {
+		return true
+	}
+	segments := g.Snake.Count(p)
+	if p == g.Snake.Tail() && p != g.Food && !g.onTimed(p) && !g.onGolden(p) && g.extraAt(p) < 0 {
+		segments--
+	}
+	return (segments > 0 && !g.IsActive(Ghost)) || g.wallCells.count(p) > 0 || g.obstacleAt(p) || g.obstacleNext(p) || g.onChaser(p)
+}
+
+// PlaceFood moves the food to one of the free cells, as after it's eaten.
+func (g *Game) PlaceFood() {
+	g.setFood()
+}
+
+// setFood puts the food on one of the free cells of the whole board, the
+// last column and row included, all equally likely, so never on the snake,
+// a wall or an item, and draws its kind. The free cells are listed again
+// each time: picking random cells until one is free would take ever longer
+// as the snake fills the board.
+func (g *Game) setFood() {
+	g.free = g.free[:0]
+	for y := range g.Height + 1 {
+		for x := range g.Width + 1 {
+			if p := (Point{x, y}); !g.occupied(p) && !g.onItem(p) {
+				g.free = append(g.free, p)
+			}
+		}
+	}
+	if len(g.free) == 0 {
+		// the snake fills the board and the run is won, see won, or the
+		// items take the cells left: the food stays where it was eaten,
+		// showing again as the snake moves on
+		return
+	}
+
+	g.Food = g.free[g.rng.IntN(len(g.free))]
+	g.FoodKind = g.pickFoodKind()
+}
+
+// occupied reports whether p is taken by a wall, a portal, a patrol path or
+// the snake, or out of the arena.
+func (g *Game) occupied(p Point) bool {
+	return g.wallCells.count(p) > 0 || !g.playable(p) || g.Snake.Contains(p) || g.onPortal(p) || g.onPatrol(p)
+}
+
+// Step advances the game by one movement step: moves the snake (eating and
+// crashing included) while running, or shrinks it after a crash. The score
+// is reset when the snake runs again.
+func (g *Game) Step() {
+	switch g.State {
+	case RUNNING:
+		// head update
+		head := g.Snake.Head()
+		if g.Border == BorderTurn {
+			g.detectBorder(&head)
+		}
+
+		head.X += g.Direction.X
+		head.Y += g.Direction.Y
+		if g.Border == BorderWrap {
+			head = g.bounds().wrap(head)
+		}
+
+		if next, _ := g.twin(head); g.Zen && g.Blocked(next) {
+			g.Stalled = true
+			return
+		}
+		g.Stalled = false
+
+		if !g.inBoard(head) {
+			// into a solid border, the snake stops at the edge
+			g.State = CRASHED
+			return
+		}
+		head, _ = g.twin(head)
+
+		// Snake
+		//
+		// The new head is pushed and the tail popped, the segments in
+		// between stay where they are. Grabbing the food? If so:
+		// - keep the tail, the snake grows by a segment
+		// - set a new peiece
+		// Unless it's poison, which shrinks the snake, see eat. The timed
+		// food, the golden apple and the extra food grow it too, and so
+		// does the growth still to come.
+		ate := head == g.Food
+		ateTimed := g.onTimed(head)
+		ateGolden := g.onGolden(head)
+		extra := g.extraAt(head)
+		if (!ate || g.FoodKind == PoisonFood) && !ateTimed && !ateGolden && extra < 0 {
+			if g.Growing > 0 {
+				g.Growing--
+			} else {
+				g.Snake.PopTail()
+			}
+		}
+		g.Snake.PushHead(head)
+
+		if ateTimed {
+			g.eatTimed()
+		}
+		if ateGolden {
+			g.eatGolden()
+		}
+		if extra >= 0 {
+			g.eatExtra(extra)
+		}
+		if g.onPill(head) {
+			g.takePill()
+		}
+		if g.onPowerUp(head) {
+			g.collect()
+		}
+		if ate {
+			g.eat()
+		}
+
+		// check for collision and reinit if needed, the obstacles and the
+		// chaser moving into the head too
+		if g.detectCollision(head) {
+			g.State = CRASHED
+		} else if g.moveObstacles(); g.obstacleAt(head) {
+			g.State = CRASHED
+		} else if g.moveChaser(); g.onChaser(head) {
+			g.State = CRASHED
+		} else if g.won() {
+			g.State = WON
+			return
+		} else if g.IsActive(Magnet) {
+			g.pull()
+		}
+		g.tickTimed()
+		g.tickPowerUps()
+		g.tickEvent()
+		g.tickBites()
+		g.tickGolden()
+		g.tickPill()
+		g.tickCombo()
+	case CRASHED:
+		g.State = CRASHING
+		g.Growing = 0
+
+	case CRASHING:
+		// the score is kept until the new run starts, to be shown meanwhile
+		if g.Snake.Len() > 1 {
+			g.Snake.PopTail()
+		} else if g.LivesLeft() > 1 {
+			g.respawn()
+		} else {
+			g.newRun()
+		}
+	}
+}
+
+// LivesLeft returns the lives left in the run, the current one included.
+func (g *Game) LivesLeft() int {
+	return max(g.Lives, 1) - g.Deaths
+}
+
+// respawn takes a life and puts the snake back where it started, heading
+// right. The items in the way go, the food elsewhere.
+func (g *Game) respawn() {
+	start := g.start()
+	g.Deaths++
+	g.Snake = newBodyIn(g.bounds(), start)
+	g.Growing = 0
+	g.Direction = Point{1, 0}
+	g.Active = g.Active[:0]
+	g.breakCombo()
+	g.endEvent()
+	g.Bites = [FrenzyBites - 1]int{}
+	g.Chasing = false
+	g.State = RUNNING
+
+	if g.onTimed(start) {
+		g.Timed = nil
+		g.TimedWait = TimedEvery
+	}
+	if g.onPowerUp(start) {
+		g.PowerUp = nil
+		g.PowerUpWait = PowerUpEvery
+	}
+	if g.onGolden(start) {
+		g.Golden = nil
+	}
+	if g.onPill(start) {
+		g.Pill = nil
+	}
+	if g.Food == start {
+		g.setFood()
+	}
+}
+
+// won reports whether the run is won: the snake is Target long, or it
+// fills the board, every cell but the walls, portals and patrol paths. The
+// items don't count, the food having no room left only because of them
+// doesn't win.
+func (g *Game) won() bool {
+	return (g.Target > 0 && g.Snake.Len() >= g.Target) || g.filled()
+}
+
+// filled reports whether the snake takes every cell it can go to. The first
+// cell left, usually near the top left corner, ends the scan.
+func (g *Game) filled() bool {
+	for y := range g.Height + 1 {
+		for x := range g.Width + 1 {
+			if !g.occupied(Point{x, y}) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Restart starts a new run after a win, from the head, as after a crash.
+// It does nothing unless the run is won.
+func (g *Game) Restart() {
+	if g.State != WON {
+		return
+	}
+	for g.Snake.Len() > 1 {
+		g.Snake.PopTail()
+	}
+	g.newRun()
+	if len(g.free) == 0 {
+		// won filling the board, the last food is still under the head
+		g.setFood()
+	}
+}
+
+// newRun starts the next run once the snake is back to its head.
+func (g *Game) newRun() {
+	g.Score = 0
+	g.Deaths = 0
+	g.Growing = 0
+	g.Active = g.Active[:0]
+	g.breakCombo()
+	// the golden apples come again with the score
+	g.Golden, g.Goldens = nil, 0
+	g.endEvent()
+	g.Bites = [FrenzyBites - 1]int{}
+	g.Chasing = false
+	g.State = RUNNING
+}
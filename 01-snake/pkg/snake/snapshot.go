@@ -0,0 +1,65 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+import (
+	"math/rand/v2"
+	"slices"
+)
+
+// CopyTo makes dst the same game as g, going on the same from there, reusing
+// the memory dst holds so keeping snapshots doesn't allocate once they are
+// warm. The walls, portals, paths and tiles are shared, they are only ever
+// replaced, as are the items on the board.
+func (g *Game) CopyTo(dst *Game) {
+	snake, active, obstacles, free, extra := dst.Snake, dst.Active, dst.Obstacles, dst.free, dst.Extra
+	rng, src := dst.rng, dst.src
+
+	*dst = *g
+	dst.Snake = g.Snake.cloneInto(snake)
+	dst.Active = append(active[:0], g.Active...)
+	dst.Extra = append(extra[:0], g.Extra...)
+	dst.Obstacles = append(obstacles[:0], g.Obstacles...)
+	dst.free = append(free[:0], g.free...)
+
+	if src == nil {
+		src = new(rand.PCG)
+		rng = rand.New(src)
+	}
+	*src = *g.src
+	dst.rng, dst.src = rng, src
+}
+
+// Clone returns a copy of g, see CopyTo.
+func (g *Game) Clone() *Game {
+	var c Game
+	g.CopyTo(&c)
+	return &c
+}
+
+// cloneInto returns a copy of b, in the memory of dst if it is large enough.
+func (b *Body) cloneInto(dst Body) Body {
+	if len(dst.buf) < b.n {
+		dst.buf = make([]Point, len(b.buf))
+	}
+	b.copyTo(dst.buf)
+	dst.head, dst.n = 0, b.n
+
+	if len(dst.cells.cells) != len(b.cells.cells) {
+		dst.cells.cells = slices.Clone(b.cells.cells)
+	} else {
+		copy(dst.cells.cells, b.cells.cells)
+	}
+	dst.cells.w, dst.cells.h = b.cells.w, b.cells.h
+	return dst
+}
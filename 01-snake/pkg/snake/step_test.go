@@ -0,0 +1,74 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake_test
+
+import (
+	"testing"
+
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// stepWarmup is the steps played before measuring, until the buffers have
+// grown.
+const stepWarmup = 600
+
+// steadyGame returns a game running steadily: left alone, the snake goes
+// round along the border without crashing.
+func steadyGame() *snake.Game {
+	g := snake.New(1)
+	for range stepWarmup {
+		g.Step()
+	}
+	return g
+}
+
+// TestStepAllocs checks that a step doesn't allocate once the game runs,
+// so the garbage collector never interrupts play.
+func TestStepAllocs(t *testing.T) {
+	g := steadyGame()
+	if allocs := testing.AllocsPerRun(stepWarmup, g.Step); allocs != 0 {
+		t.Errorf("a step makes %.1f allocations, want 0", allocs)
+	}
+}
+
+func BenchmarkStep(b *testing.B) {
+	g := steadyGame()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for range b.N {
+		g.Step()
+	}
+}
+
+// TestWonFillingTheBoard has the snake eat the food next to the timed food
+// on the last free cell: with the board not filled yet it goes on, eating
+// the timed food fills the board and wins.
+func TestWonFillingTheBoard(t *testing.T) {
+	g := snake.NewLevelSize(1, nil, 2, 0)
+	g.Snake = g.NewBody(snake.Point{X: 0, Y: 0})
+	g.Food = snake.Point{X: 1, Y: 0}
+	g.Timed, g.TimedLeft = &snake.Point{X: 2, Y: 0}, snake.TimedLife
+
+	g.Step()
+	if g.State != snake.RUNNING || g.Snake.Len() != 2 {
+		t.Fatalf("eating the food: state %d at length %d, want running at 2", g.State, g.Snake.Len())
+	}
+	if err := g.Check(); err != nil {
+		t.Fatal(err)
+	}
+
+	g.Step()
+	if g.State != snake.WON || g.Snake.Len() != 3 {
+		t.Errorf("eating the timed food: state %d at length %d, want won at 3", g.State, g.Snake.Len())
+	}
+}
@@ -0,0 +1,96 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+// Tile is the terrain of a cell, changing how the snake goes over it while
+// the head is there. Like SlowMo, the pace is up to the game, see Pace; the
+// steps are the same.
+type Tile uint8
+
+const (
+	Plain Tile = iota
+	// Ice keeps the snake going the way it slid onto it, at twice the
+	// pace: Turn does nothing meanwhile.
+	Ice
+	// Mud halves the pace.
+	Mud
+)
+
+func (t Tile) String() string {
+	switch t {
+	case Plain:
+		return "plain"
+	case Ice:
+		return "ice"
+	case Mud:
+		return "mud"
+	}
+	return "unknown"
+}
+
+// Tiles are the cells of the board that aren't plain. Nothing keeps off
+// them, the food and the items included.
+type Tiles struct {
+	Ice []Point
+	Mud []Point
+}
+
+// Empty reports whether the board is all plain.
+func (t *Tiles) Empty() bool {
+	return len(t.Ice) == 0 && len(t.Mud) == 0
+}
+
+// SetTiles replaces the tiles, a cell in both being mud.
+func (g *Game) SetTiles(t Tiles) {
+	g.Tiles = t
+	g.tiles = nil
+	if t.Empty() {
+		return
+	}
+	g.tiles = make([]Tile, (g.Width+1)*(g.Height+1))
+	for _, p := range t.Ice {
+		if g.inBoard(p) {
+			g.tiles[p.Y*(g.Width+1)+p.X] = Ice
+		}
+	}
+	for _, p := range t.Mud {
+		if g.inBoard(p) {
+			g.tiles[p.Y*(g.Width+1)+p.X] = Mud
+		}
+	}
+}
+
+// TileAt returns the tile at p, plain off the board.
+func (g *Game) TileAt(p Point) Tile {
+	if g.tiles == nil || !g.inBoard(p) {
+		return Plain
+	}
+	return g.tiles[p.Y*(g.Width+1)+p.X]
+}
+
+// Pace returns how much faster than usual the snake goes with the head where
+// it is: 2 on ice, 0.5 in mud, 1 elsewhere.
+func (g *Game) Pace() float64 {
+	switch g.TileAt(g.Snake.Head()) {
+	case Ice:
+		return 2
+	case Mud:
+		return 0.5
+	}
+	return 1
+}
+
+// sliding reports whether the head is on ice, the snake not turning.
+func (g *Game) sliding() bool {
+	return g.TileAt(g.Snake.Head()) == Ice
+}
@@ -0,0 +1,118 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snake
+
+// The timed food is a bonus appearing now and then besides the food, and
+// going again if it's not eaten in time. It's counted in steps like the rest
+// of the rules, about every 15 seconds for 7 at the default speed.
+const (
+	// TimedEvery is the number of running steps between two timed foods.
+	TimedEvery = 110
+	// TimedLife is the number of running steps a timed food stays.
+	TimedLife = 50
+	// TimedValue is what eating a timed food scores, it grows the snake
+	// by a segment like the food.
+	TimedValue = 5
+)
+
+// onTimed reports whether the timed food is at p.
+func (g *Game) onTimed(p Point) bool {
+	return g.Timed != nil && *g.Timed == p
+}
+
+// onItem reports whether the timed food, the power-up, the golden apple,
+// the shrink pill or an extra food is at p.
+func (g *Game) onItem(p Point) bool {
+	return g.onTimed(p) || g.onPowerUp(p) || g.onGolden(p) || g.onPill(p) || g.extraAt(p) >= 0
+}
+
+// eatTimed scores the timed food, the snake's head being on it.
+func (g *Game) eatTimed() {
+	g.combo(TimedValue)
+	g.grow()
+	g.bite()
+	g.Timed = nil
+	g.TimedWait = TimedEvery
+}
+
+// tickTimed counts a running step down for the timed food: it goes once its
+// life is over, the next one comes TimedEvery steps after.
+func (g *Game) tickTimed() {
+	if g.Timed != nil {
+		g.TimedLeft--
+		if g.TimedLeft <= 0 {
+			g.Timed = nil
+			g.TimedWait = TimedEvery
+		}
+		return
+	}
+
+	g.TimedWait--
+	if g.TimedWait > 0 {
+		return
+	}
+	if p, ok := g.pickItemCell(); ok {
+		g.Timed = &p
+		g.TimedLeft = TimedLife
+	} else {
+		// no room, try again later
+		g.TimedWait = TimedEvery
+	}
+}
+
+// itemTries is how many random cells pickItemCell tries before counting the
+// free ones.
+const itemTries = 8
+
+// pickItemCell picks a cell for an item, the timed food, a power-up, a
+// golden apple, a shrink pill or an extra food, one of the
+// free cells besides the food and the other items, all equally likely.
+// Random cells are tried first, which is cheap while the board is mostly
+// free, then the free cells are counted instead of listed in free, which
+// tells Step and Check whether the board is full for the food.
+func (g *Game) pickItemCell() (Point, bool) {
+	free := func(p Point) bool {
+		return !g.occupied(p) && p != g.Food && !g.onItem(p)
+	}
+
+	for range itemTries {
+		if p := (Point{g.rng.IntN(g.Width + 1), g.rng.IntN(g.Height + 1)}); free(p) {
+			return p, true
+		}
+	}
+
+	n := 0
+	for y := range g.Height + 1 {
+		for x := range g.Width + 1 {
+			if p := (Point{x, y}); free(p) {
+				n++
+			}
+		}
+	}
+	if n == 0 {
+		return Point{}, false
+	}
+
+	k := g.rng.IntN(n)
+	for y := range g.Height + 1 {
+		for x := range g.Width + 1 {
+			if p := (Point{x, y}); free(p) {
+				if k == 0 {
+					return p, true
+				}
+				k--
+			}
+		}
+	}
+	panic("unreachable")
+}
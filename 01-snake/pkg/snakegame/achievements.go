@@ -0,0 +1,145 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	_ "embed"
+	"image/color"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"jhartman.pl/gamedev/pkg/achievements"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// achievementsFile keeps the achievements unlocked, next to the settings.
+const achievementsFile = "snake-achievements.json"
+
+// toastDuration is how long an achievement unlocked is shown.
+const toastDuration = 3 * time.Second
+
+// achievementDefs are the achievements of the snake. The statistics
+// reported are the length, the score, the seconds and the multiplier while
+// playing, and the final score of a game without a wall hit as clean_score.
+//
+//go:embed achievements.json
+var achievementDefs []byte
+
+var toastBackground = color.RGBA{20, 20, 40, 220}
+
+// toasts are the achievements unlocked waiting to be shown, the first one
+// for timer more ticks.
+type toasts struct {
+	queue []string
+	timer int
+}
+
+func loadAchievements() *achievements.Tracker {
+	defs, err := achievements.Parse(achievementDefs)
+	if err != nil {
+		// embedded, so caught when changed
+		panic(err)
+	}
+	t, err := achievements.Load(achievementsFile, defs)
+	if err != nil {
+		log.Printf("achievements: %v", err)
+	}
+	return t
+}
+
+// report tells the tracker the statistic stat is at value, saving and
+// showing the achievements it unlocks. Games steered by a controller and
+// versus matches don't count.
+func (g *Game) report(stat string, value float64) {
+	if g.achievements == nil || g.controller != nil || g.versus != nil {
+		return
+	}
+	unlocked := g.achievements.Report(stat, value, time.Now())
+	if len(unlocked) == 0 {
+		return
+	}
+
+	for _, a := range unlocked {
+		g.toasts.queue = append(g.toasts.queue, a.Name)
+	}
+	if err := g.achievements.Save(); err != nil {
+		log.Printf("achievements: %v", err)
+	}
+}
+
+// reportStep reports the statistics changing while playing, after a step.
+func (g *Game) reportStep() {
+	if g.core.State == snake.CRASHED && g.hitWall() {
+		g.run.wallHit = true
+	}
+	if g.core.State != snake.RUNNING && g.core.State != snake.WON {
+		return
+	}
+	g.report("length", float64(g.core.Snake.Len()))
+	g.report("score", float64(g.core.Score))
+	g.report("seconds", float64(g.run.ticks)/float64(g.clock.TPS()))
+	g.report("multiplier", float64(g.core.Multiplier))
+}
+
+// reportRun reports the statistics of the run just over.
+func (g *Game) reportRun() {
+	if !g.run.wallHit {
+		g.report("clean_score", float64(g.core.Score))
+	}
+}
+
+// hitWall reports whether the snake just crashed into a wall or a solid
+// border, rather than into itself or an obstacle.
+func (g *Game) hitWall() bool {
+	head := g.core.Snake.Head()
+	if slices.Contains(g.core.Walls, head) {
+		return true
+	}
+	// the head stops short of a solid border
+	ahead := snake.Point{X: head.X + g.core.Direction.X, Y: head.Y + g.core.Direction.Y}
+	return g.core.Border == snake.BorderSolid &&
+		(ahead.X < 0 || ahead.X > g.core.Width || ahead.Y < 0 || ahead.Y > g.core.Height)
+}
+
+// tickToasts counts down the achievement shown, moving on to the next one.
+func (g *Game) tickToasts() {
+	if len(g.toasts.queue) == 0 {
+		return
+	}
+	if g.toasts.timer == 0 {
+		g.toasts.timer = g.ticks(toastDuration)
+	}
+	if g.toasts.timer--; g.toasts.timer == 0 {
+		g.toasts.queue = g.toasts.queue[1:]
+	}
+}
+
+// drawToast draws the achievement unlocked shown, if any, under the top
+// of the screen.
+func (g *Game) drawToast() {
+	if len(g.toasts.queue) == 0 {
+		return
+	}
+	msg := "Unlocked: " + g.toasts.queue[0]
+	w, h := text.Measure(msg, g.hudFace, 0)
+	x, y := (g.screenWidth-w)/2, 22.0
+
+	vector.DrawFilledRect(g.offscreen, float32(x-6), float32(y-3), float32(w+12), float32(h+6), toastBackground, false)
+	op := g.textOptions(x, y)
+	op.ColorScale.ScaleWithColor(comboColor)
+	text.Draw(g.offscreen, msg, g.hudFace, op)
+}
@@ -0,0 +1,52 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// allocsWarmup is the ticks played before measuring, until the buffers have
+// grown.
+const allocsWarmup = 600
+
+// TestUpdateDrawAllocs checks that a tick of the game, Update and Draw,
+// doesn't allocate once it runs, so the garbage collector never interrupts
+// play.
+func TestUpdateDrawAllocs(t *testing.T) {
+	needGraphics(t)
+	if !ebiten.IsFocused() {
+		t.Skip("the window isn't focused, the game would pause")
+	}
+
+	g := NewGame(WithClock(&TickClock{}))
+	g.SetInputSource(NewScriptedInput())
+	screen := ebiten.NewImage(ScreenWidth, ScreenHeight)
+	defer screen.Deallocate()
+	g.Layout(ScreenWidth, ScreenHeight)
+
+	tick := func() {
+		if err := g.Update(); err != nil {
+			t.Fatal(err)
+		}
+		g.Draw(screen)
+	}
+	for range allocsWarmup {
+		tick()
+	}
+	if allocs := testing.AllocsPerRun(allocsWarmup, tick); allocs != 0 {
+		t.Errorf("a tick makes %.1f allocations, want 0", allocs)
+	}
+}
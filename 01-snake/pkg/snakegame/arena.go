@@ -0,0 +1,49 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// arenaColor fills the cells out of the arena, darker than the walls.
+var arenaColor = color.Gray{45}
+
+// SetArena sets the shape of the board for the runs from the next one on,
+// the current one too if it hasn't started: the cells out of it are solid.
+// The daily challenge keeps the whole board.
+func (g *Game) SetArena(a snake.Arena) {
+	g.arena = a
+	if g.core != nil && g.run.steps == 0 && g.daily == 0 {
+		g.core.SetArena(a)
+	}
+}
+
+// drawArena fills the cells out of the arena.
+func (g *Game) drawArena(dst *ebiten.Image) {
+	if g.core.Arena == snake.RectArena {
+		return
+	}
+	for y := range g.core.Height + 1 {
+		for x := range g.core.Width + 1 {
+			if !g.core.IsPlayable(x, y) {
+				vector.DrawFilledRect(dst, float32(5+x*g.box), float32(5+y*g.box), float32(g.box), float32(g.box), arenaColor, false)
+			}
+		}
+	}
+}
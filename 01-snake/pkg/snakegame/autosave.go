@@ -0,0 +1,342 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/settings"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+const (
+	autosaveName = "snake-autosave.json"
+	// save every 5 seconds while running
+	autosaveInterval = 5 * time.Second
+)
+
+type snapshot struct {
+	// Width and Height are the last column and row of the board, unset for
+	// the classic one
+	Width     int      `json:"width,omitempty"`
+	Height    int      `json:"height,omitempty"`
+	Snake     [][2]int `json:"snake"`
+	Food      [2]int   `json:"food"`
+	FoodKind  int      `json:"food_kind,omitempty"`
+	Direction [2]int   `json:"direction"`
+	Score     int      `json:"score"`
+	// Deaths are the lives lost in the run
+	Deaths int `json:"deaths,omitempty"`
+	// Growing are the segments still to come
+	Growing int `json:"growing,omitempty"`
+	// Walls of the level being played
+	Walls [][2]int `json:"walls,omitempty"`
+	// Portals of the level, pairs of cells
+	Portals [][2][2]int `json:"portals,omitempty"`
+	// Obstacles of the level, where they are on their paths
+	Obstacles []obstacleSnapshot `json:"obstacles,omitempty"`
+	// Ice and Mud are the tiles of the level
+	Ice [][2]int `json:"ice,omitempty"`
+	Mud [][2]int `json:"mud,omitempty"`
+	// Arena is the shape of the board, unset for the whole of it
+	Arena string `json:"arena,omitempty"`
+	// Multiplier and ComboLeft are the combo going on
+	Multiplier int `json:"multiplier,omitempty"`
+	ComboLeft  int `json:"combo_left,omitempty"`
+	// TimeLeft is the seconds left on the clock of a time attack
+	TimeLeft float64 `json:"time_left,omitempty"`
+}
+
+type obstacleSnapshot struct {
+	Path  [][2]int `json:"path"`
+	Every int      `json:"every,omitempty"`
+	At    int      `json:"at"`
+	Back  bool     `json:"back,omitempty"`
+	Wait  int      `json:"wait"`
+}
+
+func autosavePath() (string, error) {
+	dir, err := settings.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, autosaveName), nil
+}
+
+// save writes the running game, atomically.
+func (g *Game) save() error {
+	s := snapshot{
+		Food:       [2]int{g.core.Food.X, g.core.Food.Y},
+		FoodKind:   int(g.core.FoodKind),
+		Direction:  [2]int{g.core.Direction.X, g.core.Direction.Y},
+		Score:      g.core.Score,
+		Deaths:     g.core.Deaths,
+		Growing:    g.core.Growing,
+		Multiplier: g.core.Multiplier,
+		ComboLeft:  g.core.ComboLeft,
+	}
+	if g.core.Width != snake.BoardWidth || g.core.Height != snake.BoardHeight {
+		s.Width, s.Height = g.core.Width, g.core.Height
+	}
+	if g.core.Arena != snake.RectArena {
+		s.Arena = g.core.Arena.String()
+	}
+	if g.mode == TimeAttackMode {
+		s.TimeLeft = float64(g.timeLeft) / float64(g.clock.TPS())
+	}
+	for _, p := range g.core.Snake.All() {
+		s.Snake = append(s.Snake, [2]int{p.X, p.Y})
+	}
+	for _, p := range g.core.Walls {
+		s.Walls = append(s.Walls, [2]int{p.X, p.Y})
+	}
+	for _, pt := range g.core.Portals {
+		s.Portals = append(s.Portals, [2][2]int{{pt[0].X, pt[0].Y}, {pt[1].X, pt[1].Y}})
+	}
+	for _, p := range g.core.Tiles.Ice {
+		s.Ice = append(s.Ice, [2]int{p.X, p.Y})
+	}
+	for _, p := range g.core.Tiles.Mud {
+		s.Mud = append(s.Mud, [2]int{p.X, p.Y})
+	}
+	for _, o := range g.core.Obstacles {
+		os := obstacleSnapshot{Every: o.Every, At: o.At, Back: o.Back, Wait: o.Wait}
+		for _, p := range o.Path {
+			os.Path = append(os.Path, [2]int{p.X, p.Y})
+		}
+		s.Obstacles = append(s.Obstacles, os)
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	name, err := autosavePath()
+	if err != nil {
+		return err
+	}
+
+	return settings.WriteFile(name, data)
+}
+
+// discardSave removes the autosave once the run it belongs to is over.
+func (g *Game) discardSave() {
+	name, err := autosavePath()
+	if err != nil {
+		return
+	}
+
+	if err := settings.RemoveFile(name); err != nil {
+		log.Printf("autosave: %v", err)
+	}
+}
+
+// board returns the last column and row of the board the game was saved on.
+func (s *snapshot) board() (w, h int) {
+	if s.Width == 0 && s.Height == 0 {
+		return snake.BoardWidth, snake.BoardHeight
+	}
+	return s.Width, s.Height
+}
+
+func (s *snapshot) validate() error {
+	w, h := s.board()
+	inBounds := func(x, y int) bool {
+		return x >= 0 && x <= w && y >= 0 && y <= h
+	}
+	if len(s.Snake) == 0 {
+		return errors.New("empty snake")
+	}
+	for _, p := range s.Snake {
+		if !inBounds(p[0], p[1]) {
+			return fmt.Errorf("segment %v out of the board", p)
+		}
+	}
+	for _, p := range s.Walls {
+		if !inBounds(p[0], p[1]) {
+			return fmt.Errorf("wall %v out of the board", p)
+		}
+	}
+	for _, pt := range s.Portals {
+		if !inBounds(pt[0][0], pt[0][1]) || !inBounds(pt[1][0], pt[1][1]) {
+			return fmt.Errorf("portal %v out of the board", pt)
+		}
+	}
+	for _, p := range slices.Concat(s.Ice, s.Mud) {
+		if !inBounds(p[0], p[1]) {
+			return fmt.Errorf("tile %v out of the board", p)
+		}
+	}
+	for _, o := range s.Obstacles {
+		for i, p := range o.Path {
+			if !inBounds(p[0], p[1]) || (i > 0 && abs(p[0]-o.Path[i-1][0])+abs(p[1]-o.Path[i-1][1]) != 1) {
+				return fmt.Errorf("obstacle path %v broken at %v", o.Path, p)
+			}
+		}
+		if o.At < 0 || o.At >= len(o.Path) || o.Wait < 1 || o.Wait > max(o.Every, 1) {
+			return fmt.Errorf("obstacle at %d of %d cells, waiting %d", o.At, len(o.Path), o.Wait)
+		}
+	}
+	if !inBounds(s.Food[0], s.Food[1]) {
+		return fmt.Errorf("food %v out of the board", s.Food)
+	}
+	if _, err := snake.ArenaByName(s.Arena); err != nil {
+		return err
+	}
+	if snake.FoodKind(s.FoodKind).Value() == 0 {
+		return fmt.Errorf("invalid food kind %d", s.FoodKind)
+	}
+	if abs(s.Direction[0])+abs(s.Direction[1]) != 1 {
+		return fmt.Errorf("invalid direction %v", s.Direction)
+	}
+	if s.Deaths < 0 {
+		return fmt.Errorf("invalid deaths %d", s.Deaths)
+	}
+	if s.Growing < 0 {
+		return fmt.Errorf("invalid growth to come %d", s.Growing)
+	}
+	if s.Multiplier < 0 || s.Multiplier > snake.MaxMultiplier || s.ComboLeft < 0 || s.ComboLeft > snake.ComboWindow || (s.Multiplier == 0) != (s.ComboLeft == 0) {
+		return fmt.Errorf("invalid combo x%d with %d steps left", s.Multiplier, s.ComboLeft)
+	}
+	if s.TimeLeft < 0 {
+		return fmt.Errorf("invalid time left %g", s.TimeLeft)
+	}
+	return nil
+}
+
+// restore continues the autosaved game, if there is one. It starts paused
+// so the player can get ready first.
+func (g *Game) restore() {
+	name, err := autosavePath()
+	if err != nil {
+		return
+	}
+
+	data, err := settings.ReadFile(name)
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			log.Printf("autosave: %v", err)
+		}
+		return
+	}
+
+	var s snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		log.Printf("autosave: %v", err)
+		return
+	}
+	if err := s.validate(); err != nil {
+		log.Printf("autosave: %v", err)
+		return
+	}
+
+	c := g.core
+	if w, h := s.board(); w != c.Width || h != c.Height {
+		log.Printf("autosave: saved on a board of %dx%d cells, playing on %dx%d", w+1, h+1, c.Width+1, c.Height+1)
+		return
+	}
+	body := make([]snake.Point, 0, len(s.Snake))
+	for _, p := range s.Snake {
+		body = append(body, snake.Point{X: p[0], Y: p[1]})
+	}
+	c.Snake = c.NewBody(body...)
+	c.Food.X, c.Food.Y = s.Food[0], s.Food[1]
+	c.FoodKind = snake.FoodKind(s.FoodKind)
+	c.Direction.X, c.Direction.Y = s.Direction[0], s.Direction[1]
+	c.Score = s.Score
+	// the golden apples due by then came
+	c.Goldens = s.Score / snake.GoldenEvery
+	c.Deaths = min(s.Deaths, max(c.Lives, 1)-1)
+	c.Growing = s.Growing
+	c.Multiplier, c.ComboLeft = s.Multiplier, s.ComboLeft
+	c.State = snake.RUNNING
+	var walls []snake.Point
+	for _, p := range s.Walls {
+		walls = append(walls, snake.Point{X: p[0], Y: p[1]})
+	}
+	c.SetWalls(walls)
+	var portals []snake.Portal
+	for _, pt := range s.Portals {
+		portals = append(portals, snake.Portal{{X: pt[0][0], Y: pt[0][1]}, {X: pt[1][0], Y: pt[1][1]}})
+	}
+	c.SetPortals(portals)
+	var tiles snake.Tiles
+	for _, p := range s.Ice {
+		tiles.Ice = append(tiles.Ice, snake.Point{X: p[0], Y: p[1]})
+	}
+	for _, p := range s.Mud {
+		tiles.Mud = append(tiles.Mud, snake.Point{X: p[0], Y: p[1]})
+	}
+	c.SetTiles(tiles)
+	// validated
+	arena, _ := snake.ArenaByName(s.Arena)
+	c.SetArena(arena)
+	var obstacles []snake.Obstacle
+	for _, o := range s.Obstacles {
+		so := snake.Obstacle{Every: o.Every}
+		for _, p := range o.Path {
+			so.Path = append(so.Path, snake.Point{X: p[0], Y: p[1]})
+		}
+		obstacles = append(obstacles, so)
+	}
+	c.SetObstacles(obstacles)
+	for i, o := range s.Obstacles {
+		// SetObstacles starts them over
+		c.Obstacles[i].At, c.Obstacles[i].Back, c.Obstacles[i].Wait = o.At, o.Back, o.Wait
+	}
+	// the run didn't start from a seed this time
+	g.stopRecording()
+	if s.TimeLeft > 0 {
+		g.timeLeft = max(int(s.TimeLeft*float64(g.clock.TPS())), 1)
+	}
+
+	g.pause()
+}
+
+// autosaveTick saves the game every few seconds while it's running.
+func (g *Game) autosaveTick() error {
+	if !g.autosave || g.core.State != snake.RUNNING {
+		return nil
+	}
+
+	g.saveTimer++
+	if g.saveTimer < g.ticks(autosaveInterval) {
+		return nil
+	}
+	g.saveTimer = 0
+
+	if err := g.save(); err != nil {
+		return fmt.Errorf("saving the game: %w", err)
+	}
+	return nil
+}
+
+// Close saves what isn't saved yet, the running game when autosaving. Call
+// it once the game loop is over, however it ended.
+func (g *Game) Close() error {
+	if !g.autosave || g.core.State != snake.RUNNING || g.outOfTime {
+		return nil
+	}
+	if err := g.save(); err != nil {
+		return fmt.Errorf("saving the game: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"jhartman.pl/gamedev/pkg/bench"
+)
+
+// BenchmarkUpdateDraw benchmarks a tick of the game on screen, Update and
+// Draw, with a short snake, a long one and one filling the board, going
+// round a bench.Track.
+func BenchmarkUpdateDraw(b *testing.B) {
+	needGraphics(b)
+	screen := ebiten.NewImage(ScreenWidth, ScreenHeight)
+	defer screen.Deallocate()
+
+	for _, l := range []struct {
+		name string
+		n    int
+	}{
+		{"10", 10},
+		{"500", 500},
+		{"full", bench.CycleLength},
+	} {
+		b.Run(l.name, func(b *testing.B) {
+			t := bench.NewTrack(l.n)
+			g := NewGame(WithClock(&TickClock{}), WithCore(t.Game), WithController(t))
+			g.SetInputSource(NewScriptedInput())
+			g.Layout(ScreenWidth, ScreenHeight)
+
+			b.ReportAllocs()
+			b.ResetTimer()
+			for range b.N {
+				g.Update()
+				g.Draw(screen)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"errors"
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"jhartman.pl/gamedev/pkg/maps"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// Level is a stage of the campaign: a layout, the food to eat to clear it
+// and the speed it's played at.
+type Level struct {
+	// Map is the layout, nil for the plain board.
+	Map *maps.Map
+	// Quota is the food to eat to clear the level.
+	Quota int
+	// Speed is in cells per second.
+	Speed float64
+}
+
+// Name returns the name of the layout.
+func (l *Level) Name() string {
+	if l.Map == nil {
+		return "Open field"
+	}
+	return l.Map.Name
+}
+
+// Campaign returns the levels of the campaign, the built-in layouts after
+// the plain board, the quota and the speed growing with each.
+func Campaign() []Level {
+	levels := []Level{{Quota: 5, Speed: DefaultSpeed}}
+	for _, m := range maps.Builtin() {
+		n := len(levels)
+		levels = append(levels, Level{
+			Map:   &m,
+			Quota: 5 + 2*n,
+			Speed: DefaultSpeed * (1 + 0.1*float64(n)),
+		})
+	}
+	return levels
+}
+
+// transitionDelay is how long the name of the next level shows before it
+// starts.
+const transitionDelay = 2 * time.Second
+
+// campaign are the levels played in order, see PlayCampaign.
+type campaign struct {
+	levels  []Level
+	current int
+	// eaten is the food eaten on the current level
+	eaten int
+	// total is the score of the levels cleared
+	total int
+	// transition is the ticks left showing the level about to start
+	transition int
+}
+
+// PlayCampaign starts the campaign on the first of levels: eating the quota
+// of food of a level clears it, the next one starting after its name shows.
+// Crashing plays the level again, the score of those cleared kept. Picking
+// another level stops it.
+func (g *Game) PlayCampaign(levels []Level) error {
+	if len(levels) == 0 {
+		return errors.New("a campaign needs levels")
+	}
+	if !g.classicBoard() {
+		return errResized
+	}
+	for i, l := range levels {
+		if l.Quota <= 0 || l.Speed <= 0 {
+			return fmt.Errorf("level %d: the quota and the speed must be positive, got %d and %g", i+1, l.Quota, l.Speed)
+		}
+	}
+	g.mazes = mazes{}
+	g.campaign = campaign{levels: levels}
+	g.startLevel()
+	return nil
+}
+
+// stopCampaign leaves the campaign, if playing it, keeping the speed of the
+// level.
+func (g *Game) stopCampaign() {
+	if g.campaign.levels == nil {
+		return
+	}
+	g.campaign = campaign{}
+	g.SetSpeed(float64(g.speed))
+}
+
+// startLevel loads the current level of the campaign, showing its name
+// first.
+func (g *Game) startLevel() {
+	c := &g.campaign
+	l := &c.levels[c.current]
+	g.playLevel(l.Map)
+	g.SetSpeed(l.Speed)
+	g.difficulty = fmt.Sprintf("Level %d/%d", c.current+1, len(c.levels))
+	c.eaten = 0
+	c.transition = g.ticks(transitionDelay)
+}
+
+// campaignStep counts the food eaten on the current level, moving on to the
+// next one once the quota is reached. The last one cleared ends the
+// campaign, won, starting it over.
+func (g *Game) campaignStep(score int) error {
+	c := &g.campaign
+	if c.levels == nil {
+		return nil
+	}
+	if g.core.State == snake.CRASHED && g.core.LivesLeft() == 1 {
+		// the level starts over
+		c.eaten = 0
+		return nil
+	}
+	if g.core.State != snake.RUNNING || g.core.Score <= score {
+		return nil
+	}
+
+	c.eaten++
+	if c.eaten < c.levels[c.current].Quota {
+		return nil
+	}
+
+	c.total += g.core.Score
+	if _, err := g.recordGame(); err != nil {
+		return err
+	}
+	if c.current++; c.current < len(c.levels) {
+		g.startLevel()
+		return nil
+	}
+
+	g.gameOver = true
+	g.gameOverTimer = g.ticks(gameOverDelay)
+	g.finalScore = fmt.Sprintf("Campaign complete, %d points", c.total)
+	*c = campaign{levels: c.levels}
+	g.startLevel()
+	return nil
+}
+
+// drawTransition shows the level about to start over the dimmed board.
+func (g *Game) drawTransition() {
+	c := &g.campaign
+	if c.transition <= 0 {
+		return
+	}
+	vector.DrawFilledRect(g.offscreen, 0, 0, float32(g.screenWidth), float32(g.screenHeight), color.RGBA{0, 0, 0, 160}, false)
+
+	l := &c.levels[c.current]
+	title := fmt.Sprintf("Level %d", c.current+1)
+	tw, th := text.Measure(title, mplusBigFace, 0)
+	text.Draw(g.offscreen, title, mplusBigFace, g.textOptions((g.screenWidth-tw)/2, g.screenHeight/2-th-4))
+
+	msg := fmt.Sprintf("%s, eat %d", l.Name(), l.Quota)
+	w, _ := text.Measure(msg, mplusNormalFace, 0)
+	text.Draw(g.offscreen, msg, mplusNormalFace, g.textOptions((g.screenWidth-w)/2, g.screenHeight/2))
+}
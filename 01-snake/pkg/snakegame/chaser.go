@@ -0,0 +1,52 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// chaserColor is the color of the chaser, its eyes chaserEyeColor.
+var (
+	chaserColor    = color.RGBA{200, 30, 30, 255}
+	chaserEyeColor = color.White
+)
+
+// SetChase brings the chaser from the current game on, once the score
+// reaches snake.ChaserScore: it hunts the head around the walls and the
+// body, a cell a step. Turning it off takes it off the board.
+func (g *Game) SetChase(on bool) {
+	g.chase = on
+	if g.core != nil {
+		g.core.Chase = on
+		if !on {
+			g.core.Chasing = false
+		}
+	}
+}
+
+// drawChaser draws the chaser, a round body with two eyes.
+func (g *Game) drawChaser() {
+	c := g.core.Chaser
+	if !g.core.Chasing {
+		return
+	}
+	r := float32(g.box-1) / 2
+	x, y := float32(5+c.X*g.box)+r, float32(5+c.Y*g.box)+r
+	vector.DrawFilledCircle(g.offscreen, x, y, r, chaserColor, true)
+	eye := max(r/4, 1)
+	vector.DrawFilledCircle(g.offscreen, x-r/2.5, y-r/4, eye, chaserEyeColor, true)
+	vector.DrawFilledCircle(g.offscreen, x+r/2.5, y-r/4, eye, chaserEyeColor, true)
+}
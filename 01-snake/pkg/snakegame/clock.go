@@ -0,0 +1,62 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// Clock is the game's sense of time: how many updates make a second, which
+// paces the snake, and the time of day, which tells a suspended app and
+// dates the statistics.
+type Clock interface {
+	// Tick is called at the start of every Update.
+	Tick()
+	Now() time.Time
+	TPS() int
+}
+
+// ebitenClock is the wall clock and the TPS the game runs at.
+type ebitenClock struct{}
+
+func (ebitenClock) Tick()          {}
+func (ebitenClock) Now() time.Time { return time.Now() }
+func (ebitenClock) TPS() int       { return ebiten.TPS() }
+
+// TickClock is a Clock counting updates instead of following the wall
+// clock, so each run of the same input plays out the same.
+type TickClock struct {
+	// Start is the time before the first tick.
+	Start time.Time
+	// Rate is the number of ticks per second, 60 if zero.
+	Rate int
+
+	ticks int64
+}
+
+func (c *TickClock) Tick() {
+	c.ticks++
+}
+
+func (c *TickClock) TPS() int {
+	if c.Rate <= 0 {
+		return baseTPS
+	}
+	return c.Rate
+}
+
+func (c *TickClock) Now() time.Time {
+	return c.Start.Add(time.Duration(c.ticks) * time.Second / time.Duration(c.TPS()))
+}
@@ -0,0 +1,58 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// multiplierTexts are the multipliers of a combo from x2 on, formatted once.
+var multiplierTexts = [...]string{"x2", "x3", "x4", "x5"}
+
+// comboColor is for the multiplier next to the score.
+var comboColor = color.RGBA{255, 200, 40, 255}
+
+// SetCombos turns the combos on or off from the current game on: the food
+// eaten quickly after the last one scores double, then triple and so on,
+// see snake.ComboWindow. Turning them off ends the combo going on.
+func (g *Game) SetCombos(on bool) {
+	g.combos = on
+	if g.core != nil {
+		g.core.Combos = on
+		if !on {
+			g.core.Multiplier, g.core.ComboLeft = 0, 0
+		}
+	}
+}
+
+// drawCombo draws the multiplier of the combo going on at x, y, next to the
+// score, with a bar under it running out until it drops.
+func (g *Game) drawCombo(x, y float64) {
+	m := g.core.Multiplier
+	if m < 2 {
+		return
+	}
+	msg := multiplierTexts[min(m, snake.MaxMultiplier)-2]
+	op := g.textOptions(x, y)
+	op.ColorScale.ScaleWithColor(comboColor)
+	text.Draw(g.offscreen, msg, g.hudFace, op)
+
+	w, h := text.Measure(msg, g.hudFace, 0)
+	left := float32(g.core.ComboLeft) / snake.ComboWindow
+	vector.DrawFilledRect(g.offscreen, float32(x), float32(y+h), float32(w)*left, 2, comboColor, false)
+}
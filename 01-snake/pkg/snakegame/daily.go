@@ -0,0 +1,42 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+	"time"
+)
+
+// dailySeed returns the seed of the daily challenge on the day of t, the
+// same the world over: the date in UTC as a number, e.g. 20261016.
+func dailySeed(t time.Time) uint64 {
+	y, m, d := t.UTC().Date()
+	return uint64(y*10000 + int(m)*100 + d)
+}
+
+// shareCode returns the code of a daily challenge run, the day's seed and
+// the score, for the players to compare their runs, e.g. DAILY-20261016-42.
+func shareCode(seed uint64, score int) string {
+	return fmt.Sprintf("DAILY-%d-%d", seed, score)
+}
+
+// dailyRules makes the run the one every player gets on the day, whatever
+// the settings: the plain board, a single life, no target, no combos, no
+// chaser and a segment per food.
+func (g *Game) dailyRules() {
+	g.core.Target = 0
+	g.core.Lives = 1
+	g.core.Combos = false
+	g.core.Growth = 1
+	g.core.Chase = false
+}
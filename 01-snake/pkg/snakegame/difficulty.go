@@ -0,0 +1,98 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+)
+
+// Difficulty is a preset pace of the snake.
+type Difficulty struct {
+	Name string
+	// Speed is in cells per second
+	Speed float64
+}
+
+var (
+	Easy   = Difficulty{"Easy", 5}
+	Normal = Difficulty{"Normal", DefaultSpeed}
+	Hard   = Difficulty{"Hard", 11}
+)
+
+// Difficulties lists the presets, easiest first.
+var Difficulties = []Difficulty{Easy, Normal, Hard}
+
+// DifficultyByName looks a difficulty up by name, in any case, "" being
+// Normal.
+func DifficultyByName(name string) (Difficulty, error) {
+	if name == "" {
+		return Normal, nil
+	}
+	for _, d := range Difficulties {
+		if strings.EqualFold(name, d.Name) {
+			return d, nil
+		}
+	}
+	return Difficulty{}, fmt.Errorf("unknown difficulty %q, expected easy, normal or hard", name)
+}
+
+// SetDifficulty sets the pace of the snake to the preset's. T switches
+// between them while paused and on the game over screen, before the next
+// run. SetSpeed overrides it.
+func (g *Game) SetDifficulty(d Difficulty) {
+	g.difficulty = d.Name
+	g.speed = float32(d.Speed)
+}
+
+// cycleDifficulty switches to the next difficulty, telling which it is.
+func (g *Game) cycleDifficulty() {
+	i := slices.IndexFunc(Difficulties, func(d Difficulty) bool { return d.Name == g.difficulty })
+	d := Difficulties[(i+1)%len(Difficulties)]
+	g.SetDifficulty(d)
+	g.notify("Difficulty: " + d.Name)
+}
+
+// SpeedUp is how the snake speeds up as the score grows, so long games get
+// harder: every Every points the speed is multiplied by Factor, up to Max
+// times the starting one. The zero value keeps the pace.
+type SpeedUp struct {
+	Every  int
+	Factor float64
+	// Max caps the speed up, none if zero
+	Max float64
+}
+
+// DefaultSpeedUp is 10% faster every 5 points, up to twice as fast.
+var DefaultSpeedUp = SpeedUp{Every: 5, Factor: 1.1, Max: 2}
+
+// factor returns how much faster than the starting speed the snake moves at
+// score.
+func (s SpeedUp) factor(score int) float64 {
+	if s.Every <= 0 || s.Factor <= 0 {
+		return 1
+	}
+	f := math.Pow(s.Factor, float64(score/s.Every))
+	if s.Max > 0 {
+		f = min(f, s.Max)
+	}
+	return f
+}
+
+// SetSpeedUp sets how the snake speeds up as the score grows, SpeedUp{} to
+// keep the pace. It applies on top of the difficulty or SetSpeed.
+func (g *Game) SetSpeedUp(s SpeedUp) {
+	g.speedUp = s
+}
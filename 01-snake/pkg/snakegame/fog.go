@@ -0,0 +1,83 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// fogRadius is how far from the head the board is seen in the fog
+	// mode, in cells.
+	fogRadius = 5
+	// fogFade is the width of the edge of the light, in cells.
+	fogFade = 1.5
+)
+
+// fogColor is the darkness over the rest of the board.
+var fogColor = color.RGBA{0, 0, 0, 235}
+
+// fog darkens the board but around the head, see FogMode.
+type fog struct {
+	// dark is the darkness drawn over the board, light the round hole
+	// cut into it, made for cells of box pixels
+	dark, light *ebiten.Image
+	box         int
+	op          ebiten.DrawImageOptions
+}
+
+// lightImage returns the light around the head for cells of box pixels: a
+// disc, opaque up to fogRadius-fogFade cells from the center of the cell
+// and fading out to fogRadius.
+func lightImage(box int) *ebiten.Image {
+	size := (2*fogRadius + 1) * box
+	pix := make([]byte, 4*size*size)
+	c := float64(size) / 2
+	for y := range size {
+		for x := range size {
+			d := math.Hypot(float64(x)+0.5-c, float64(y)+0.5-c) / float64(box)
+			a := math.Max(0, math.Min(1, (fogRadius-d)/fogFade))
+			pix[4*(y*size+x)+3] = uint8(a * 255)
+		}
+	}
+	img := ebiten.NewImage(size, size)
+	img.WritePixels(pix)
+	return img
+}
+
+// drawFog darkens the board in the fog mode but for the light around the
+// head, lifted once the run is over.
+func (g *Game) drawFog() {
+	if g.mode != FogMode || g.gameOver || g.versus != nil {
+		return
+	}
+	f := &g.fog
+	if f.dark == nil || f.dark.Bounds() != g.offscreen.Bounds() {
+		f.dark = ebiten.NewImage(g.ScreenSize())
+	}
+	if f.light == nil || f.box != g.box {
+		f.light = lightImage(g.box)
+		f.box = g.box
+	}
+
+	f.dark.Fill(fogColor)
+	head := g.core.Snake.Head()
+	f.op = ebiten.DrawImageOptions{Blend: ebiten.BlendDestinationOut}
+	f.op.GeoM.Translate(float64(5+(head.X-fogRadius)*g.box), float64(5+(head.Y-fogRadius)*g.box))
+	f.dark.DrawImage(f.light, &f.op)
+
+	g.offscreen.DrawImage(f.dark, nil)
+}
@@ -0,0 +1,1376 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package snakegame implements the snake example as an ebiten.Game so it can
+// be run from the desktop binary as well as from the mobile bindings, or be
+// embedded in another program as a minigame:
+//
+//	g := snakegame.NewGame(snakegame.WithSeed(42), snakegame.WithSpeed(10))
+//	err := ebiten.RunGame(g) // or scenes.Push(g), see pkg/scene
+package snakegame
+
+import (
+	"bytes"
+	"fmt"
+	"image/color"
+	"log"
+	"math"
+	"math/rand/v2"
+	"slices"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/examples/resources/fonts"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"jhartman.pl/gamedev/pkg/achievements"
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/mailbox"
+	"jhartman.pl/gamedev/pkg/maps"
+	"jhartman.pl/gamedev/pkg/particles"
+	"jhartman.pl/gamedev/pkg/settings"
+	"jhartman.pl/gamedev/pkg/snake"
+	"jhartman.pl/gamedev/pkg/stats"
+)
+
+const (
+	baseTPS = 60
+	// the head pulses independently of the steps, at their default pace
+	pulsesPerSecond = DefaultSpeed
+	// frames per phase of the body's shimmer
+	animationFrames = 30
+)
+
+// ScreenWidth and ScreenHeight are the logical resolution of the game on
+// the classic board, see ScreenSize.
+const (
+	ScreenWidth  = 320
+	ScreenHeight = 240
+)
+
+// DefaultCellSize is the size of a cell of the board in pixels, see
+// WithCellSize.
+const DefaultCellSize = 8
+
+// DefaultSpeed is the classic pace of the snake in cells per second, as it
+// was when a 0-255 color cycle advancing by 31 per tick at 60 TPS made it
+// step on every wrap.
+const DefaultSpeed = 31 * baseTPS / 255.0
+
+// borderColors tell the borders apart: red crashes, dark lets through.
+var borderColors = map[snake.Border]color.Color{
+	snake.BorderTurn:  color.Gray{200},
+	snake.BorderSolid: color.RGBA{200, 60, 60, 255},
+	snake.BorderWrap:  color.Gray{80},
+}
+
+// foodColors tell the kinds of food apart: red for 1 point, gold for 5, blue
+// for 10 and purple for poison.
+var foodColors = map[snake.FoodKind]color.Color{
+	snake.NormalFood: color.RGBA{255, 0, 0, 255},
+	snake.BonusFood:  color.RGBA{255, 200, 0, 255},
+	snake.RareFood:   color.RGBA{60, 140, 255, 255},
+	snake.PoisonFood: color.RGBA{150, 40, 190, 255},
+}
+
+// timedColor is the color of the timed food.
+var timedColor = color.RGBA{0, 220, 200, 255}
+
+// goldenColor is the color of the golden apple, and of the border flashing
+// in the golden rush.
+var goldenColor = color.RGBA{255, 215, 60, 255}
+
+// pillColor is the color of the shrink pill.
+var pillColor = color.RGBA{40, 90, 255, 255}
+
+// frenzyColor tints the board in the frenzy, pulsing.
+var frenzyColor = color.NRGBA{255, 40, 160, 255}
+
+// timedHurry is the number of steps left from which the timed food and the
+// power-ups blink every step rather than every animation phase.
+const timedHurry = 15
+
+// effectColors tell the power-ups apart, on the board and in the HUD.
+var effectColors = map[snake.Effect]color.Color{
+	snake.Ghost:  color.RGBA{210, 210, 255, 255},
+	snake.SlowMo: color.RGBA{80, 220, 80, 255},
+	snake.Magnet: color.RGBA{255, 120, 40, 255},
+	snake.Mirror: mirrorColor,
+}
+
+// effectNotices are shown as the power-ups are collected.
+var effectNotices = map[snake.Effect]string{
+	snake.Ghost:  "Ghost!",
+	snake.SlowMo: "Slow motion!",
+	snake.Magnet: "Magnet!",
+	snake.Mirror: "Mirrored!",
+}
+
+// suspendGap is the pause between two Update calls after which the game is
+// considered to have been suspended (e.g. the mobile app was backgrounded).
+const suspendGap = time.Second
+
+// gameOverDelay is how long the game over screen waits for a controller
+// steering the snake (a bot, the chat) before the next run starts anyway.
+const gameOverDelay = 3 * time.Second
+
+type Game struct {
+	core      *snake.Game
+	offscreen *ebiten.Image
+	frame     uint32
+
+	// width and height are the last column and row of the board, box the
+	// size of a cell in pixels. The screen fits them, see resize.
+	width, height             int
+	box                       int
+	screenWidth, screenHeight float64
+
+	// speed is in cells per second
+	speed float32
+	// difficulty is the name of the preset speed is from, shown in the HUD
+	difficulty string
+	// speedUp makes the snake faster than speed as the score grows
+	speedUp SpeedUp
+	// border is the rule at the edge, kept for the games to come
+	border snake.Border
+	// stepAcc is the progress towards the next step, the snake steps when
+	// it reaches 1
+	stepAcc float32
+	// pulse is the phase of the head's pulse, 0 to 1
+	pulse float32
+
+	paused     bool
+	lastUpdate time.Time
+
+	// turns from the player waiting for the next steps
+	turns turnQueue
+
+	// gameOver holds the crashed snake with the final score until the
+	// player asks for the next run, the won one with how it went
+	gameOver      bool
+	gameOverTimer int
+	finalScore    string
+	runSummary    string
+	// shareCode is the code of the daily challenge run that just ended
+	shareCode string
+	// winLength is the length winning a run, 0 for filling the board, kept
+	// for the games to come
+	winLength int
+	// lives are the crashes a run takes, kept for the games to come
+	lives int
+	// combos multiply the food eaten quickly, kept likewise
+	combos bool
+	// growth is the segments a food adds, kept likewise
+	growth int
+	// chase brings the chaser, kept likewise
+	chase bool
+	// mirror swaps the controls all along, see SetMirror
+	mirror bool
+	// hardcore takes a single life, see SetHardcore
+	hardcore bool
+	// arena is the shape of the board, see SetArena
+	arena snake.Arena
+	// fog hides the board away from the head in the fog mode
+	fog fog
+	// countdown is the ticks left before the snake moves again after losing
+	// a life
+	countdown int
+	// stall is the ticks left of the beat the snake waits in the zen mode
+	stall int
+	// mode is the rules of the runs, timeLeft the ticks left on the clock
+	// of a time attack, outOfTime set once it ran out until the next run
+	// and daily the seed of the daily challenge being played, 0 outside
+	mode      GameMode
+	daily     uint64
+	timeLeft  int
+	outOfTime bool
+	// the time left as drawn, formatted again only when it changes
+	clockText  string
+	clockShown int
+
+	touch touchState
+	pads  gamepadState
+
+	// last device used, decides the button prompts
+	lastDevice device
+	keys       []ebiten.Key
+	keymap     keymap
+
+	notice      string
+	noticeTimer int
+
+	// popups float the points scored up from the food eaten
+	popups []popup
+	// particles burst off the food eaten and trail the crashed snake
+	particles particles.System
+
+	hudFace *text.GoTextFace
+
+	// reused by Draw so drawing a frame doesn't allocate
+	textOp       text.DrawOptions
+	screenOp     ebiten.DrawImageOptions
+	spriteOp     ebiten.DrawImageOptions
+	backgroundOp ebiten.DrawImageOptions
+	scoreOp      ebiten.DrawImageOptions
+	tailOp       ebiten.DrawTrianglesOptions
+	tail         [4]ebiten.Vertex
+
+	// the score rendered with scoreFace, laid out again only when the score
+	// or the face change
+	scoreImage *ebiten.Image
+	scoreShown int
+	scoreWidth float64
+	scoreFace  *text.GoTextFace
+
+	difficultyLabel label
+
+	// dirty is set by Update when what the offscreen shows changed, it's
+	// only drawn again then or when the animation moves to the next phase.
+	// While overlaid, the segments moving every tick, the pulsing head, the
+	// fading tail and the sliding snake, are left out of it and drawn on
+	// moving over it every frame instead, see snakeOverlay.
+	dirty      bool
+	drawnPhase uint32
+	overlaid   bool
+	moving     *ebiten.Image
+
+	// sprites replace the plain squares when useSprites is set, see
+	// SetSkin
+	sprites    *sprites
+	useSprites bool
+	smooth     smooth
+
+	// border, walls, tiles and arena, drawn again when they change
+	background       *ebiten.Image
+	backgroundWalls  []snake.Point
+	backgroundTiles  snake.Tiles
+	backgroundBorder snake.Border
+	backgroundArena  snake.Arena
+
+	autosave  bool
+	saveTimer int
+
+	// safe area reported by the mobile host, posted from the host's UI
+	// thread and taken in Layout
+	safeAreas mailbox.Mailbox[Insets]
+	safeArea  Insets
+
+	// where the offscreen is drawn on the (possibly larger) screen
+	originX, originY float64
+
+	statusFunc    func(status string)
+	lastStatus    string
+	lastStatusKey statusKey
+
+	// optional extra source of direction changes, e.g. the Twitch chat
+	controller input.Controller
+
+	stats *stats.Stats
+	run   run
+
+	achievements *achievements.Tracker
+	toasts       toasts
+
+	latency  latencyProbe
+	throttle throttle
+
+	start  startScreen
+	scores highScores
+	board  globalBoard
+	levels levelSelect
+	maps   *maps.Client
+	mazes  mazes
+	// campaign are the levels played in order, nil levels outside of it
+	campaign campaign
+	// versus is the two player mode, nil playing alone
+	versus *versus
+
+	recording recording
+	// playback is the run played back, nil playing
+	playback *playback
+	// ghost is the best run going along, see SetGhost
+	ghost    *ghost
+	ghostOn  bool
+	rewind   rewind
+	speedrun speedrun
+
+	clock Clock
+	// rng seeds the boards
+	rng *rand.Rand
+}
+
+// statusKey is what the status depends on.
+type statusKey struct {
+	paused   bool
+	gameOver bool
+	state    int
+	score    int
+}
+
+// hudder is implemented by controllers with state worth showing on screen,
+// like the running chat vote.
+type hudder interface {
+	HUD() string
+}
+
+// Insets describes the parts of the screen covered by notches, rounded
+// corners or system bars, in device-independent pixels.
+type Insets struct {
+	Top, Right, Bottom, Left int
+}
+
+var (
+	mplusFaceSource *text.GoTextFaceSource
+	mplusNormalFace *text.GoTextFace
+	mplusBigFace    *text.GoTextFace
+)
+
+func init() {
+	s, err := text.NewGoTextFaceSource(bytes.NewReader(fonts.MPlus1pRegular_ttf))
+	if err != nil {
+		log.Fatal(err)
+	}
+	mplusFaceSource = s
+
+	mplusNormalFace = &text.GoTextFace{
+		Source: mplusFaceSource,
+		Size:   24,
+	}
+	mplusBigFace = &text.GoTextFace{
+		Source: mplusFaceSource,
+		Size:   32,
+	}
+}
+
+func (g *Game) handleKeyboard() {
+	for _, d := range input.Dirs {
+		if g.keymap.justPressed(steerActions[d]) {
+			g.turn(d)
+		}
+	}
+}
+
+// perTick converts a rate per second to the amount per tick, so the game
+// keeps its pace whatever the TPS: a higher TPS only means input is picked
+// up sooner.
+func (g *Game) perTick(perSecond float32) float32 {
+	return perSecond / float32(g.clock.TPS())
+}
+
+// SetSpeed sets how many cells per second the snake moves, DefaultSpeed if
+// zero. Fractions carry over from tick to tick, so any rate works and it can
+// be changed at any time, e.g. a little after each food; the snake steps at
+// most once per tick though, so the TPS is the limit.
+func (g *Game) SetSpeed(cellsPerSecond float64) {
+	if cellsPerSecond <= 0 {
+		g.SetDifficulty(Normal)
+		return
+	}
+	g.speed = float32(cellsPerSecond)
+	g.difficulty = "Custom"
+}
+
+// Speed returns the cells per second the snake moves.
+func (g *Game) Speed() float64 {
+	return float64(g.speed)
+}
+
+// SetBorder sets what the edge of the board does to the snake, from the
+// current game on. The classic rule turns it along the edge, a solid border
+// crashes it and a wrapping one lets it through to the opposite edge. B
+// switches between them while paused and on the game over screen.
+func (g *Game) SetBorder(b snake.Border) {
+	g.border = b
+	if g.core != nil {
+		g.core.Border = b
+	}
+}
+
+// SetWinLength sets the length winning a run from the current game on, 0
+// for only filling the board.
+func (g *Game) SetWinLength(n int) {
+	g.winLength = n
+	if g.core != nil {
+		g.core.Target = n
+	}
+}
+
+// cycleBorder switches to the next border, telling which it is.
+func (g *Game) cycleBorder() {
+	i := slices.Index(snake.Borders, g.border)
+	g.SetBorder(snake.Borders[(i+1)%len(snake.Borders)])
+	g.notify("Border: " + g.border.String())
+}
+
+// ticks returns how many ticks last d at the current TPS.
+func (g *Game) ticks(d time.Duration) int {
+	return int(d.Seconds() * float64(g.clock.TPS()))
+}
+
+func (g *Game) pause() {
+	if g.paused {
+		return
+	}
+	g.paused = true
+	g.dirty = true
+
+	// pausing is often followed by the device going to sleep
+	if g.autosave && g.core.State == snake.RUNNING {
+		if err := g.save(); err != nil {
+			log.Printf("autosave: %v", err)
+		}
+	}
+}
+
+func (g *Game) resume() {
+	g.paused = false
+	g.dirty = true
+}
+
+// SetStatusFunc registers f to be called with a short human readable status
+// ("Score 42", "Paused", ...) whenever it changes, e.g. to show it in Discord.
+func (g *Game) SetStatusFunc(f func(status string)) {
+	g.statusFunc = f
+}
+
+// SetController lets c steer the snake alongside the keyboard and touch. It
+// is asked for a direction once per movement step.
+func (g *Game) SetController(c input.Controller) {
+	g.controller = c
+}
+
+// Core returns the simulation of the game, e.g. for bots to look at. It must
+// only be read, from the game loop.
+func (g *Game) Core() *snake.Game {
+	return g.core
+}
+
+func (g *Game) status() string {
+	if g.paused {
+		return "Paused"
+	}
+	if g.versus != nil && g.versus.royale {
+		return "Battle royale"
+	}
+	if g.versus != nil && g.versus.rival != nil {
+		return "Racing the computer"
+	}
+	if g.versus != nil {
+		return "Two players"
+	}
+	if g.mode == ZenMode {
+		return "Relaxing in zen mode"
+	}
+	if g.core.State == snake.WON {
+		return fmt.Sprintf("Won, score %d", g.core.Score)
+	}
+	if g.gameOver {
+		return fmt.Sprintf("Game over, score %d", g.core.Score)
+	}
+	switch g.core.State {
+	case snake.CRASHED, snake.CRASHING:
+		return "Crashed"
+	}
+	return fmt.Sprintf("Score %d", g.core.Score)
+}
+
+func (g *Game) reportStatus() {
+	if g.statusFunc == nil {
+		return
+	}
+
+	// formatting the status allocates, only do it when it may change
+	key := statusKey{g.paused, g.gameOver, g.core.State, g.core.Score}
+	if g.lastStatus != "" && key == g.lastStatusKey {
+		return
+	}
+	g.lastStatusKey = key
+
+	if s := g.status(); s != g.lastStatus {
+		g.lastStatus = s
+		g.statusFunc(s)
+	}
+}
+
+func (g *Game) Update() error {
+	defer g.reportStatus()
+	// what moves on its own is drawn once more on the tick it stops
+	defer g.markDirty(g.animating())
+	g.clock.Tick()
+	g.latency.tick()
+
+	// Update isn't called while the app is in the background, so a long gap
+	// since the last call means we've just been resumed
+	now := g.clock.Now()
+	if g.lastUpdate.IsZero() {
+		// key names are only known once the game runs
+		g.keymap.detect()
+	}
+	dt := now.Sub(g.lastUpdate)
+	if !ebiten.IsFocused() || (!g.lastUpdate.IsZero() && dt > suspendGap) {
+		g.pause()
+	}
+	g.lastUpdate = now
+
+	if g.noticeTimer > 0 {
+		g.noticeTimer--
+	}
+	g.tickPopups()
+	g.particles.Update()
+	g.tickToasts()
+	for _, apply := range g.board.results.Receive() {
+		apply()
+		g.dirty = true
+	}
+	for _, name := range settings.Recovered.Receive() {
+		g.notify(name + " was damaged, backup restored")
+	}
+
+	device := g.lastDevice
+	pressed := len(g.keymap.src.AppendJustPressedKeys(g.keys[:0])) > 0
+	if pressed {
+		g.lastDevice = keyboard
+		// the layout can be switched while playing
+		g.keymap.detect()
+	}
+
+	tapped := g.handleTouch()
+	confirmed := g.handleGamepads()
+	if pressed || tapped || confirmed || g.lastDevice != device {
+		// the screens and the prompts change with the input
+		g.dirty = true
+	}
+
+	if g.keymap.justPressed(actionExportStats) {
+		g.exportStats()
+	}
+	if g.keymap.justPressed(actionLatency) {
+		g.ShowLatency(!g.latency.on)
+	}
+
+	if g.scores.open {
+		g.updateScores(tapped || confirmed)
+		return nil
+	}
+	if g.start.open {
+		g.updateStart(tapped || confirmed)
+		return nil
+	}
+
+	if g.keymap.justPressed(actionLevels) && !g.levels.open && g.versus == nil && g.classicBoard() {
+		g.openLevels()
+		return nil
+	}
+	if g.levels.open {
+		g.updateLevels()
+		return nil
+	}
+
+	if g.paused || g.gameOver {
+		if g.keymap.justPressed(actionBorder) {
+			g.cycleBorder()
+		}
+		if g.keymap.justPressed(actionDifficulty) && g.campaign.levels == nil {
+			g.cycleDifficulty()
+		}
+		if g.keymap.justPressed(actionMode) && g.campaign.levels == nil && g.versus == nil {
+			g.cycleMode()
+		}
+		if g.keymap.justPressed(actionScores) && g.versus == nil {
+			g.openScores(-1)
+			return nil
+		}
+	}
+
+	if g.paused {
+		if tapped || confirmed || g.keymap.justPressed(actionResume) || g.keymap.justPressed(actionPause) {
+			g.resume()
+		}
+		return nil
+	}
+	if g.keymap.justPressed(actionPause) {
+		g.pause()
+		return nil
+	}
+
+	if g.gameOver {
+		g.gameOverTimer--
+		if tapped || confirmed || g.keymap.justPressed(actionSelect) || (g.controller != nil && g.gameOverTimer <= 0) {
+			g.gameOver = false
+			g.dirty = true
+			g.nextRun()
+		}
+		return nil
+	}
+
+	if g.versus != nil {
+		return g.updateVersus()
+	}
+	g.tickSpeedrun(dt)
+	if g.rewind.offer > 0 || g.rewind.rewinding {
+		return g.updateRewind()
+	}
+
+	if g.playback == nil {
+		g.handleKeyboard()
+	}
+	if g.countdown > 0 {
+		// the turns queue up meanwhile, the first one taken at the start
+		g.countdown--
+		g.pulse += g.perTick(pulsesPerSecond)
+		g.pulse -= float32(int(g.pulse))
+		return nil
+	}
+	if g.campaign.transition > 0 {
+		g.campaign.transition--
+		return nil
+	}
+	if g.stall > 0 {
+		g.stall--
+		return nil
+	}
+	g.rewind.tick++
+	if g.mode == TimeAttackMode && g.playback == nil {
+		if err := g.tickClock(); err != nil || g.gameOver {
+			return err
+		}
+	}
+	if g.playback == nil {
+		g.statsTick()
+	}
+
+	progress := g.perTick(g.speed * float32(g.speedFactor(g.core.Score)))
+	if g.core.IsActive(snake.SlowMo) {
+		progress /= 2
+	}
+	if g.core.State == snake.RUNNING {
+		// sliding on ice, stuck in mud
+		progress *= float32(g.core.Pace())
+	}
+	if g.core.State == snake.CRASHING {
+		// the snake shrinks faster than it moves
+		progress *= 3
+	}
+	g.stepAcc += progress
+
+	g.pulse += g.perTick(pulsesPerSecond)
+	g.pulse -= float32(int(g.pulse))
+
+	if g.stepAcc >= 1 {
+		if g.playback != nil {
+			g.playbackTurn()
+		}
+		if d, ok := g.turns.pop(); ok && g.core.State == snake.RUNNING && g.playback == nil {
+			g.core.Turn(d.Delta())
+			g.recordTurn()
+		}
+		if g.core.State == snake.RUNNING && g.controller != nil && g.playback == nil {
+			if d := g.controller.Next(); d != input.None {
+				g.core.Turn(d.Delta())
+				g.recordTurn()
+			}
+		}
+
+		if g.core.State == snake.RUNNING {
+			g.run.steps++
+			g.latency.step()
+		}
+		score, powerUp, deaths := g.core.Score, g.core.PowerUp, g.core.Deaths
+		food, kind, timed := g.core.Food, g.core.FoodKind, g.core.Timed
+		golden, pill, length := g.core.Golden, g.core.Pill, g.core.Snake.Len()
+		event, chasing := g.core.Event, g.core.Chasing
+		running := g.core.State == snake.RUNNING
+		crashing, tail := g.core.State == snake.CRASHING, g.core.Snake.Tail()
+		if running {
+			g.snapshot()
+		}
+		g.keepCells()
+		g.core.Step()
+		g.dirty = true
+		g.recordStep()
+		if running && g.core.Score != score {
+			g.addPopup(g.core.Snake.Head(), g.core.Score-score)
+		}
+		if running && g.core.Snake.Head() == food {
+			g.splitSpeedrun()
+			g.burstFood(food)
+		}
+		if crashing && g.core.Snake.Len() < length {
+			g.trailCrash(tail)
+		}
+		if g.core.Stalled {
+			g.stall = g.ticks(zenBeat)
+		}
+		if g.playback == nil && g.mode != ZenMode {
+			g.reportStep()
+		}
+		if g.core.Deaths > deaths {
+			g.countdown = g.ticks(respawnDelay)
+		}
+		if g.mode == TimeAttackMode {
+			g.addBonusTime(food, kind, timed)
+		}
+		if g.core.Score > score && g.speedFactor(g.core.Score) > g.speedFactor(score) {
+			g.notify("Faster!")
+		}
+		if powerUp != nil && g.core.PowerUp == nil && g.core.Snake.Head() == *powerUp {
+			g.notify(effectNotices[g.core.PowerUpEffect])
+		}
+		if !chasing && g.core.Chasing {
+			g.notify("The chaser is coming!")
+		}
+		if event != snake.Frenzy && g.core.Event == snake.Frenzy {
+			g.notify(fmt.Sprintf("Frenzy! Points x%d", snake.FrenzyFactor))
+		}
+		if golden == nil && g.core.Golden != nil {
+			g.notify("Golden apple!")
+		} else if golden != nil && g.core.Golden == nil && g.core.Snake.Head() == *golden {
+			g.notify("Golden rush!")
+		}
+		if pill != nil && g.core.Pill == nil && g.core.Snake.Head() == *pill && g.core.Snake.Len() < length {
+			g.notify(fmt.Sprintf("Shrunk by %d", length-g.core.Snake.Len()))
+		}
+
+		if g.core.State == snake.CRASHED && g.core.LivesLeft() > 1 {
+			g.turns.clear()
+			g.notify("Life lost")
+		} else if g.core.State == snake.CRASHED && g.offerRewind() {
+			// over unless rewound, see updateRewind
+		} else if g.core.State == snake.CRASHED || g.core.State == snake.WON {
+			if err := g.endRun(); err != nil {
+				return err
+			}
+		}
+		if g.playback != nil {
+			if err := g.playbackStep(); err != nil {
+				return err
+			}
+		}
+
+		if err := g.mazeDone(); err != nil {
+			return err
+		}
+		if err := g.campaignStep(score); err != nil {
+			return err
+		}
+
+		// a step per tick at most, the rest is left for the next ones
+		g.stepAcc = min(g.stepAcc-1, 1)
+	}
+
+	return g.autosaveTick()
+}
+
+// animating reports whether something on the offscreen moves on its own,
+// drawing it again every tick: the notices, the popups and the particles
+// fading, the clocks and the countdowns running, the frenzy pulsing, the
+// zen colors, the heads of the versus and the HUD of the controller.
+func (g *Game) animating() bool {
+	running := !g.paused && !g.gameOver && g.core.State == snake.RUNNING
+	_, hud := g.controller.(hudder)
+	return hud || g.noticeTimer > 0 || len(g.toasts.queue) > 0 || len(g.popups) > 0 || g.particles.Len() > 0 ||
+		g.countdown > 0 || g.campaign.transition > 0 || g.rewind.offer > 0 || g.rewind.rewinding ||
+		(running && (g.mode == TimeAttackMode || g.speedrun.on)) ||
+		g.core.Event == snake.Frenzy || g.mode == ZenMode || g.versus != nil
+}
+
+// markDirty has the offscreen drawn again when something on it moved this
+// tick, or moved on the last one.
+func (g *Game) markDirty(animated bool) {
+	if animated || g.animating() {
+		g.dirty = true
+	}
+}
+
+// snakeOverlay reports whether the segments moving every tick are drawn over
+// the offscreen: while the snake runs, unless the offscreen is drawn again
+// every tick anyway or something on it covers the snake.
+func (g *Game) snakeOverlay() bool {
+	return g.versus == nil && g.mode != FogMode && !g.animating() &&
+		!g.paused && !g.gameOver && !g.levels.open && !g.scores.open && !g.start.open
+}
+
+// moves reports whether segment i is drawn over the offscreen, see
+// snakeOverlay: all of them as they slide, else the head and the tail.
+func (g *Game) moves(i int) bool {
+	return g.overlaid && (g.smooth.on || i == 0 || i == g.core.Snake.Len()-1)
+}
+
+// endRun records the run, crashed, won or out of time, and shows the game
+// over screen.
+func (g *Game) endRun() error {
+	if g.playback != nil {
+		g.endPlayback()
+		return nil
+	}
+	g.saveReplay()
+
+	g.runSummary = ""
+	if g.core.State == snake.WON {
+		g.runSummary = fmt.Sprintf("Length %d in %d steps, %s", g.core.Snake.Len(), g.run.steps, formatPlayed(g.run.ticks/g.clock.TPS()))
+	}
+	g.shareCode = ""
+	if g.daily != 0 {
+		g.shareCode = shareCode(g.daily, g.core.Score)
+		// to copy from the terminal
+		log.Printf("daily challenge: %s", g.shareCode)
+	}
+	g.reportRun()
+	place, err := g.recordGame()
+	if err != nil {
+		return err
+	}
+	if place >= 0 {
+		// submitted once named
+		g.openScores(place)
+	} else if g.hardcore && g.controller == nil {
+		// only a new best goes to the hardcore board
+		g.hardcoreBest()
+	} else if g.controller == nil {
+		g.submitScore(g.core.Score)
+	}
+
+	// the run is over, don't continue it after a restart
+	if g.autosave {
+		g.discardSave()
+	}
+
+	g.turns.clear()
+	g.gameOver = true
+	g.gameOverTimer = g.ticks(gameOverDelay)
+	g.finalScore = fmt.Sprintf("Final score: %d", g.core.Score)
+	return nil
+}
+
+func (g *Game) Draw(screen *ebiten.Image) {
+	if g.throttle.skipFrame() {
+		return
+	}
+	start := time.Now()
+
+	// the state only changes in Update, the last frame drawn is still good
+	// unless it changed or an animation moved on
+	overlay := g.snakeOverlay()
+	if g.dirty || g.frame/animationFrames != g.drawnPhase || g.latency.on || overlay != g.overlaid {
+		g.overlaid = overlay
+		g.drawOffscreen()
+		g.dirty = false
+		g.drawnPhase = g.frame / animationFrames
+	}
+
+	g.screenOp.GeoM.Reset()
+	g.screenOp.GeoM.Translate(g.originX, g.originY)
+	screen.DrawImage(g.offscreen, &g.screenOp)
+	if g.overlaid {
+		g.moving.Clear()
+		g.drawSnake(g.moving, true)
+		screen.DrawImage(g.moving, &g.screenOp)
+	}
+	g.frame += 1
+	g.latency.drawn()
+	g.throttle.record(time.Since(start))
+}
+
+// drawBackground redraws the parts of the board that only change with the
+// level: the border, the walls, the tiles and the arena.
+func (g *Game) drawBackground() {
+	t := &g.core.Tiles
+	if g.background != nil && slices.Equal(g.backgroundWalls, g.core.Walls) && g.backgroundBorder == g.core.Border &&
+		slices.Equal(g.backgroundTiles.Ice, t.Ice) && slices.Equal(g.backgroundTiles.Mud, t.Mud) && g.backgroundArena == g.core.Arena {
+		return
+	}
+	if g.background == nil {
+		g.background = ebiten.NewImage(g.ScreenSize())
+	}
+	g.backgroundWalls = slices.Clone(g.core.Walls)
+	g.backgroundBorder = g.core.Border
+	g.backgroundTiles = snake.Tiles{Ice: slices.Clone(t.Ice), Mud: slices.Clone(t.Mud)}
+	g.backgroundArena = g.core.Arena
+
+	g.background.Clear()
+	vector.StrokeRect(g.background, 2, 2, float32(g.screenWidth-4), float32(g.screenHeight-4), 2, borderColors[g.core.Border], true)
+	g.drawTiles(g.background)
+	g.drawArena(g.background)
+	g.drawWalls(g.background)
+}
+
+// drawPaused dims the board and tells how to go on.
+func (g *Game) drawPaused() {
+	vector.DrawFilledRect(g.offscreen, 0, 0, float32(g.screenWidth), float32(g.screenHeight), color.RGBA{0, 0, 0, 160}, false)
+
+	const title = "Paused"
+	tw, th := text.Measure(title, mplusBigFace, 0)
+	text.Draw(g.offscreen, title, mplusBigFace, g.textOptions((g.screenWidth-tw)/2, g.screenHeight/2-th-4))
+
+	msg := g.prompt("resume")
+	w, h := text.Measure(msg, mplusNormalFace, 0)
+	text.Draw(g.offscreen, msg, mplusNormalFace, g.textOptions((g.screenWidth-w)/2, g.screenHeight/2))
+
+	if g.lastDevice == keyboard {
+		hint := "Steer with " + g.keymap.steering()
+		if g.versus != nil && g.versus.rival == nil {
+			hint = "P1 steers with " + g.keymap.steerKeys(0) + ", P2 with " + g.keymap.steerKeys(1)
+		}
+		hw, hh := text.Measure(hint, g.hudFace, 0)
+
+		text.Draw(g.offscreen, hint, g.hudFace, g.textOptions((g.screenWidth-hw)/2, g.screenHeight/2+h+4))
+
+		g.drawSetupHints(g.screenHeight/2 + h + hh + 8)
+	}
+}
+
+// drawSetupHints tells, from y down, how to change what the screens between
+// runs allow to change, as many hints to a line as fit.
+func (g *Game) drawSetupHints(y float64) {
+	const gap = "   "
+	hints := [...]string{
+		g.keymap.label(actionBorder) + " border: " + g.border.String(),
+		g.keymap.label(actionDifficulty) + " difficulty: " + g.difficulty,
+		g.keymap.label(actionMode) + " mode: " + g.mode.String(),
+		g.keymap.label(actionScores) + " high scores",
+	}
+	lines := []string{hints[0]}
+	for _, hint := range hints[1:] {
+		last := &lines[len(lines)-1]
+		if w, _ := text.Measure(*last+gap+hint, g.hudFace, 0); w <= g.screenWidth-10 {
+			*last += gap + hint
+		} else {
+			lines = append(lines, hint)
+		}
+	}
+	for _, line := range lines {
+		w, h := text.Measure(line, g.hudFace, 0)
+		text.Draw(g.offscreen, line, g.hudFace, g.textOptions((g.screenWidth-w)/2, y))
+		y += h + 4
+	}
+}
+
+// drawGameOver dims the board, shows the final score and how to play again.
+func (g *Game) drawGameOver() {
+	vector.DrawFilledRect(g.offscreen, 0, 0, float32(g.screenWidth), float32(g.screenHeight), color.RGBA{0, 0, 0, 160}, false)
+
+	title := "Game over"
+	if g.versus == nil && g.core.State == snake.WON {
+		title = "You won!"
+	} else if g.versus == nil && g.outOfTime {
+		title = "Time's up!"
+	}
+	tw, th := text.Measure(title, mplusBigFace, 0)
+	text.Draw(g.offscreen, title, mplusBigFace, g.textOptions((g.screenWidth-tw)/2, g.screenHeight/2-th-4))
+
+	sw, sh := text.Measure(g.finalScore, mplusNormalFace, 0)
+	text.Draw(g.offscreen, g.finalScore, mplusNormalFace, g.textOptions((g.screenWidth-sw)/2, g.screenHeight/2))
+	if g.versus == nil && g.core.State == snake.WON {
+		rw, rh := text.Measure(g.runSummary, g.hudFace, 0)
+		text.Draw(g.offscreen, g.runSummary, g.hudFace, g.textOptions((g.screenWidth-rw)/2, g.screenHeight/2+sh+2))
+		sh += rh + 2
+	}
+	if g.versus == nil && g.shareCode != "" {
+		msg := "Share code: " + g.shareCode
+		cw, ch := text.Measure(msg, g.hudFace, 0)
+		text.Draw(g.offscreen, msg, g.hudFace, g.textOptions((g.screenWidth-cw)/2, g.screenHeight/2+sh+2))
+		sh += ch + 2
+	}
+
+	msg := g.prompt("restart")
+	if g.lastDevice == keyboard {
+		msg = "Press " + g.keymap.label(actionSelect) + " to restart"
+	}
+	w, h := text.Measure(msg, g.hudFace, 0)
+	text.Draw(g.offscreen, msg, g.hudFace, g.textOptions((g.screenWidth-w)/2, g.screenHeight/2+sh+4))
+
+	if g.lastDevice == keyboard {
+		g.drawSetupHints(g.screenHeight/2 + sh + h + 12)
+	}
+}
+
+// drawSnake draws the snake and the food on dst, with the sprites or as
+// plain squares: the segments that move, see moves, when moving is set,
+// the others and the food otherwise.
+func (g *Game) drawSnake(dst *ebiten.Image, moving bool) {
+	if g.useSprites {
+		g.drawSprites(dst, moving)
+	} else {
+		g.drawSquares(dst, moving)
+	}
+}
+
+// drawSquares draws the snake and the food as plain squares, the head with
+// eyes and the tail tapered, see drawSnake.
+func (g *Game) drawSquares(dst *ebiten.Image, moving bool) {
+	for i, v := range g.core.Snake.Backward() {
+		if g.moves(i) != moving {
+			continue
+		}
+		var c color.Color
+
+		if i == 0 {
+			// head update
+			c = color.Gray{uint8(g.pulse * math.MaxUint8)}
+		} else if i == g.core.Snake.Len()-1 && g.core.Snake.Len() > 1 {
+			// last tail section
+			c = color.Gray{uint8((1 - g.stepAcc) * math.MaxUint8)}
+		} else {
+			// middle sections
+			c = color.Gray{uint8(math.Sin(float64(i+int(g.frame/animationFrames)))*64 + 128)}
+		}
+		if i > 0 && g.mode == ZenMode {
+			c = g.zenColor(i)
+		}
+
+		// the head looking ahead, the tail tapering away from the body
+		x, y := g.segmentPos(i, v)
+		front, _ := segmentSides(g.core, i)
+		switch {
+		case i == 0:
+			g.drawHead(dst, x, y, front, c)
+		case i == g.core.Snake.Len()-1 && front >= 0:
+			g.drawTail(dst, x, y, front, c)
+		default:
+			vector.DrawFilledRect(dst, x, y, float32(g.box-1), float32(g.box-1), c, true)
+		}
+	}
+	if moving {
+		return
+	}
+
+	// food
+	vector.DrawFilledRect(dst,
+		float32(5+g.core.Food.X*g.box),
+		float32(5+g.core.Food.Y*g.box),
+		float32(g.box-1),
+		float32(g.box-1),
+		foodColors[g.core.FoodKind],
+		true)
+}
+
+// drawSprites draws the snake and the food with the sprites, squares where
+// the snake overlaps itself after a crash, see drawSnake.
+func (g *Game) drawSprites(dst *ebiten.Image, moving bool) {
+	s := g.sprites
+	body := &g.core.Snake
+
+	for i, v := range body.Backward() {
+		if g.moves(i) != moving {
+			continue
+		}
+		x, y := g.segmentPos(i, v)
+		img := s.segmentSprite(g.core, i)
+		if img == nil {
+			vector.DrawFilledRect(dst, x, y, float32(g.box-1), float32(g.box-1), color.Gray{128}, true)
+			continue
+		}
+
+		op := g.spriteOptionsAt(x, y)
+		if i == body.Len()-1 && i > 0 {
+			// the tail fades as it's about to move
+			op.ColorScale.ScaleAlpha(1 - g.stepAcc)
+		}
+		if g.mode == ZenMode {
+			op.ColorScale.ScaleWithColor(g.zenColor(i))
+		}
+		dst.DrawImage(img, op)
+	}
+	if moving {
+		return
+	}
+
+	if f := g.core.Food; g.core.FoodKind != snake.NormalFood {
+		// the apple on a square the color of the kind
+		vector.DrawFilledRect(dst, float32(5+f.X*g.box), float32(5+f.Y*g.box), float32(g.box-1), float32(g.box-1), foodColors[g.core.FoodKind], true)
+	}
+	if g.core.FoodKind == snake.PoisonFood {
+		// no apple, it's not to be eaten
+		return
+	}
+	dst.DrawImage(s.food, g.spriteOptions(g.core.Food))
+}
+
+// drawTimed draws the timed food, the power-up, the golden apple and the
+// shrink pill, blinking, and the extra food of the frenzy.
+func (g *Game) drawTimed() {
+	if t := g.core.Timed; t != nil && g.blinkOn(g.core.TimedLeft) {
+		vector.DrawFilledRect(g.offscreen, float32(5+t.X*g.box), float32(5+t.Y*g.box), float32(g.box-1), float32(g.box-1), timedColor, true)
+		if g.useSprites {
+			g.offscreen.DrawImage(g.sprites.food, g.spriteOptions(*t))
+		}
+	}
+
+	if p := g.core.PowerUp; p != nil && g.blinkOn(g.core.PowerUpLeft) {
+		r := float32(g.box-1) / 2
+		vector.DrawFilledCircle(g.offscreen, float32(5+p.X*g.box)+r, float32(5+p.Y*g.box)+r, r, effectColors[g.core.PowerUpEffect], true)
+	}
+
+	if p := g.core.Golden; p != nil && g.blinkOn(g.core.GoldenLeft) {
+		vector.DrawFilledRect(g.offscreen, float32(5+p.X*g.box), float32(5+p.Y*g.box), float32(g.box-1), float32(g.box-1), goldenColor, true)
+		if g.useSprites {
+			g.offscreen.DrawImage(g.sprites.food, g.spriteOptions(*p))
+		}
+	}
+
+	if p := g.core.Pill; p != nil && g.blinkOn(g.core.PillLeft) {
+		// a capsule, half the height of the cell
+		h := float32(g.box-1) / 2
+		vector.DrawFilledRect(g.offscreen, float32(5+p.X*g.box), float32(5+p.Y*g.box)+h/2, float32(g.box-1), h, pillColor, true)
+	}
+
+	for _, p := range g.core.Extra {
+		if g.useSprites {
+			g.offscreen.DrawImage(g.sprites.food, g.spriteOptions(p))
+			continue
+		}
+		vector.DrawFilledRect(g.offscreen, float32(5+p.X*g.box), float32(5+p.Y*g.box), float32(g.box-1), float32(g.box-1), foodColors[snake.NormalFood], true)
+	}
+}
+
+// drawFrenzy tints the board in the frenzy, the tint pulsing.
+func (g *Game) drawFrenzy() {
+	if g.core.Event != snake.Frenzy {
+		return
+	}
+	c := frenzyColor
+	c.A = uint8(25 + 25*math.Sin(2*math.Pi*float64(g.pulse)))
+	vector.DrawFilledRect(g.offscreen, 4, 4, float32(g.screenWidth-8), float32(g.screenHeight-8), c, false)
+}
+
+// drawRush flashes the border in the golden rush, faster as it's about to
+// end.
+func (g *Game) drawRush() {
+	if g.core.Event != snake.GoldenRush || !g.blinkOn(g.core.EventLeft) {
+		return
+	}
+	vector.StrokeRect(g.offscreen, 2, 2, float32(g.screenWidth-4), float32(g.screenHeight-4), 2, goldenColor, true)
+}
+
+// blinkOn tells whether an item with the steps left is shown in this frame:
+// it blinks slowly while it has time, every step once it's about to go.
+func (g *Game) blinkOn(left int) bool {
+	if left <= timedHurry {
+		return left%2 == 1
+	}
+	return g.frame/animationFrames%2 == 0
+}
+
+// drawEffects draws an icon per active effect in the bottom left corner,
+// with a bar of the time it has left.
+func (g *Game) drawEffects() {
+	const size = 7
+	for i, a := range g.core.Active {
+		x := float32(5 + i*(size+4))
+		y := float32(g.screenHeight - 8 - size)
+		c := effectColors[a.Effect]
+		vector.DrawFilledCircle(g.offscreen, x+size/2, y+size/2, size/2, c, true)
+		vector.DrawFilledRect(g.offscreen, x, y+size+1, size*float32(a.Left)/snake.EffectSteps, 2, c, true)
+	}
+}
+
+// spriteOptions returns the options for drawing a sprite on cell p, reused
+// every frame so drawing doesn't allocate.
+func (g *Game) spriteOptions(p snake.Point) *ebiten.DrawImageOptions {
+	return g.spriteOptionsAt(float32(5+p.X*g.box), float32(5+p.Y*g.box))
+}
+
+// spriteOptionsAt is spriteOptions with the top left corner at x, y.
+func (g *Game) spriteOptionsAt(x, y float32) *ebiten.DrawImageOptions {
+	g.spriteOp = ebiten.DrawImageOptions{}
+	scale := float64(g.box) / float64(g.sprites.size)
+	g.spriteOp.GeoM.Scale(scale, scale)
+	g.spriteOp.GeoM.Translate(float64(x), float64(y))
+	return &g.spriteOp
+}
+
+// drawOffscreen draws the frame.
+func (g *Game) drawOffscreen() {
+	g.offscreen.Clear()
+
+	// board
+	g.drawBackground()
+	g.offscreen.DrawImage(g.background, &g.backgroundOp)
+	if g.versus == nil {
+		g.drawRush()
+	}
+
+	// snake
+	if g.versus != nil {
+		g.drawVersus()
+	} else {
+		g.drawPortals()
+		g.drawObstacles()
+		g.drawGhost()
+		g.drawSnake(g.offscreen, false)
+		g.particles.Draw(g.offscreen)
+		g.drawTimed()
+		g.drawChaser()
+		g.drawFrenzy()
+		g.drawFog()
+
+		// score, none to care about in the zen mode
+		if g.mode != ZenMode {
+			g.drawPopups()
+			g.drawScore(g.offscreen, 5, 3)
+			g.drawCombo(5+g.scoreWidth+6, 3)
+		}
+		g.drawClock()
+		g.drawSpeedrun()
+		g.drawEffects()
+		g.drawMirror()
+		g.drawLives()
+		g.drawCountdown()
+		g.drawRewind()
+		g.drawTransition()
+	}
+
+	g.difficultyLabel.drawCentered(g.offscreen, g.difficulty, g.hudFace, 3)
+
+	if h, ok := g.controller.(hudder); ok {
+		msg := h.HUD()
+		w, _ := text.Measure(msg, g.hudFace, 0)
+
+		text.Draw(g.offscreen, msg, g.hudFace, g.textOptions(g.screenWidth-5-w, 3))
+	}
+
+	if g.levels.open {
+		g.drawLevels(g.offscreen)
+	} else if g.scores.open {
+		g.drawScores()
+	} else if g.start.open {
+		g.drawStart()
+	} else if g.paused {
+		g.drawPaused()
+	} else if g.gameOver {
+		g.drawGameOver()
+	}
+
+	if g.noticeTimer > 0 {
+		w, h := text.Measure(g.notice, g.hudFace, 0)
+
+		text.Draw(g.offscreen, g.notice, g.hudFace, g.textOptions((g.screenWidth-w)/2, g.screenHeight-5-h))
+	}
+	g.drawToast()
+
+	if g.latency.on {
+		g.drawLatency(g.offscreen)
+	}
+}
+
+// drawScore draws "Score: N" at x, y.
+func (g *Game) drawScore(dst *ebiten.Image, x, y float64) {
+	if g.scoreImage == nil || g.scoreShown != g.core.Score || g.scoreFace != g.hudFace {
+		msg := fmt.Sprintf("Score: %d", g.core.Score)
+		w, h := text.Measure(msg, g.hudFace, 0)
+
+		iw, ih := int(math.Ceil(w)), int(math.Ceil(h))
+		if g.scoreImage == nil || g.scoreImage.Bounds().Dx() < iw || g.scoreImage.Bounds().Dy() < ih {
+			if g.scoreImage != nil {
+				g.scoreImage.Deallocate()
+			}
+			// room for a few more digits
+			g.scoreImage = ebiten.NewImage(iw+ih*2, ih)
+		}
+
+		g.scoreImage.Clear()
+		text.Draw(g.scoreImage, msg, g.hudFace, g.textOptions(0, 0))
+		g.scoreShown, g.scoreFace, g.scoreWidth = g.core.Score, g.hudFace, w
+	}
+
+	g.scoreOp.GeoM.Reset()
+	g.scoreOp.GeoM.Translate(x, y)
+	dst.DrawImage(g.scoreImage, &g.scoreOp)
+}
+
+// textOptions returns the options for drawing text at x, y, reused every
+// frame so drawing doesn't allocate.
+func (g *Game) textOptions(x, y float64) *text.DrawOptions {
+	g.textOp = text.DrawOptions{}
+	g.textOp.GeoM.Translate(x, y)
+	return &g.textOp
+}
+
+// SetSafeArea sets the insets the game must keep clear of. It is safe to call
+// from any goroutine.
+func (g *Game) SetSafeArea(insets Insets) {
+	g.safeAreas.Post(insets)
+}
+
+func (g *Game) Layout(outsideWidth, outsideHeight int) (int, int) {
+	if insets, ok := g.safeAreas.Last(); ok {
+		g.safeArea = insets
+	}
+	insets := g.safeArea
+
+	w := outsideWidth - insets.Left - insets.Right
+	h := outsideHeight - insets.Top - insets.Bottom
+	if insets == (Insets{}) || w <= 0 || h <= 0 {
+		g.originX, g.originY = 0, 0
+		return g.ScreenSize()
+	}
+
+	// keep the logical resolution, scaled to fit inside the safe area, and
+	// extend the screen around it so the board is centered there
+	scale := min(float64(w)/g.screenWidth, float64(h)/g.screenHeight)
+
+	g.originX = float64(insets.Left)/scale + (float64(w)/scale-g.screenWidth)/2
+	g.originY = float64(insets.Top)/scale + (float64(h)/scale-g.screenHeight)/2
+
+	return int(float64(outsideWidth) / scale), int(float64(outsideHeight) / scale)
+}
+
+// resize makes the screen fit the board, with a margin for the border.
+func (g *Game) resize() {
+	g.screenWidth = float64((g.width+1)*g.box + 8)
+	g.screenHeight = float64((g.height+1)*g.box + 8)
+	g.offscreen = ebiten.NewImage(g.ScreenSize())
+	g.moving = ebiten.NewImage(g.ScreenSize())
+	g.background = nil
+	g.dirty = true
+}
+
+// ScreenSize returns the logical resolution of the game, ScreenWidth by
+// ScreenHeight unless the board or its cells are resized.
+func (g *Game) ScreenSize() (int, int) {
+	return int(g.screenWidth), int(g.screenHeight)
+}
+
+// NewGame returns a game keeping time with the wall clock and seeding the
+// boards randomly, unless told otherwise by the options.
+func NewGame(opts ...Option) *Game {
+	g := &Game{
+		frame:        0,
+		width:        snake.BoardWidth,
+		height:       snake.BoardHeight,
+		box:          DefaultCellSize,
+		hudFace:      &text.GoTextFace{Source: mplusFaceSource, Size: 16},
+		stats:        loadStats(),
+		achievements: loadAchievements(),
+		keymap:       newKeymap(QWERTY, true),
+		dirty:        true,
+		speed:        DefaultSpeed,
+		difficulty:   Normal.Name,
+		speedUp:      DefaultSpeedUp,
+		ghostOn:      true,
+		rewind:       rewind{on: true},
+		clock:        ebitenClock{},
+		rng:          rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())),
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	g.resize()
+	if g.core == nil {
+		seed := g.rng.Uint64()
+		g.core = snake.NewLevelSize(seed, nil, g.width, g.height)
+		g.core.Border = g.border
+		g.startRecording(seed)
+	}
+
+	return g
+}
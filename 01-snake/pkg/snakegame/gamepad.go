@@ -0,0 +1,160 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"jhartman.pl/gamedev/pkg/input"
+)
+
+// device is what the player last used, it decides which button prompts are
+// shown.
+type device int
+
+const (
+	keyboard device = iota
+	touchscreen
+	xbox
+	playstation
+)
+
+// how far the stick has to be pushed to turn
+const stickThreshold = 0.5
+
+// how long notices like "Controller connected" stay on screen
+const noticeDuration = 2 * time.Second
+
+type gamepadState struct {
+	ids []ebiten.GamepadID
+
+	// the gamepad last used, only its input is taken into account
+	active    ebiten.GamepadID
+	hasActive bool
+}
+
+// gamepadFamily guesses the kind of gamepad to show matching glyphs. Sony's
+// USB vendor ID is part of the SDL GUID, the name covers the rest.
+func gamepadFamily(id ebiten.GamepadID) device {
+	guid := ebiten.GamepadSDLID(id)
+	if len(guid) >= 12 && guid[8:12] == "4c05" {
+		return playstation
+	}
+
+	name := strings.ToLower(ebiten.GamepadName(id))
+	for _, v := range []string{"playstation", "dualshock", "dualsense", "ps3", "ps4", "ps5"} {
+		if strings.Contains(name, v) {
+			return playstation
+		}
+	}
+
+	return xbox
+}
+
+// gamepadUsed reports whether any button of id was just pressed or the stick
+// is pushed.
+func gamepadUsed(id ebiten.GamepadID) bool {
+	for b := range ebiten.StandardGamepadButtonMax + 1 {
+		if inpututil.IsStandardGamepadButtonJustPressed(id, b) {
+			return true
+		}
+	}
+
+	return abs64(ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)) > stickThreshold ||
+		abs64(ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickVertical)) > stickThreshold
+}
+
+// handleGamepads tracks connected gamepads, picks the last used one as the
+// active one and steers with its D-pad or left stick. The game is paused
+// when the active gamepad goes away. It reports whether A or Start was just
+// pressed.
+func (g *Game) handleGamepads() bool {
+	p := &g.pads
+
+	for _, id := range inpututil.AppendJustConnectedGamepadIDs(p.ids[:0]) {
+		if ebiten.IsStandardGamepadLayoutAvailable(id) {
+			g.notify("Controller connected")
+		}
+	}
+
+	if p.hasActive && inpututil.IsGamepadJustDisconnected(p.active) {
+		p.hasActive = false
+		g.lastDevice = keyboard
+		g.pause()
+		g.notify("Controller disconnected")
+	}
+
+	p.ids = ebiten.AppendGamepadIDs(p.ids[:0])
+	for _, id := range p.ids {
+		if !ebiten.IsStandardGamepadLayoutAvailable(id) || !gamepadUsed(id) {
+			continue
+		}
+		p.active = id
+		p.hasActive = true
+		g.lastDevice = gamepadFamily(id)
+	}
+
+	if !p.hasActive {
+		return false
+	}
+	id := p.active
+
+	if !g.paused {
+		h := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickHorizontal)
+		v := ebiten.StandardGamepadAxisValue(id, ebiten.StandardGamepadAxisLeftStickVertical)
+
+		switch {
+		case ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButtonLeftTop) || v < -stickThreshold:
+			g.turn(input.Up)
+		case ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButtonLeftRight) || h > stickThreshold:
+			g.turn(input.Right)
+		case ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButtonLeftBottom) || v > stickThreshold:
+			g.turn(input.Down)
+		case ebiten.IsStandardGamepadButtonPressed(id, ebiten.StandardGamepadButtonLeftLeft) || h < -stickThreshold:
+			g.turn(input.Left)
+		}
+	}
+
+	return inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonRightBottom) ||
+		inpututil.IsStandardGamepadButtonJustPressed(id, ebiten.StandardGamepadButtonCenterRight)
+}
+
+// prompt returns how to press the "confirm" button on the last used device.
+func (g *Game) prompt(action string) string {
+	switch g.lastDevice {
+	case touchscreen:
+		return "Tap to " + action
+	case xbox:
+		return "Press A to " + action
+	case playstation:
+		return "Press × to " + action
+	}
+	return "Press " + g.keymap.label(actionResume) + " to " + action
+}
+
+// notify shows msg at the bottom of the screen for a little while.
+func (g *Game) notify(msg string) {
+	g.notice = msg
+	g.noticeTimer = g.ticks(noticeDuration)
+}
+
+func abs64(v float64) float64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,136 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"errors"
+	"image/color"
+	"io/fs"
+	"log"
+
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"jhartman.pl/gamedev/pkg/replay"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// ghostColor is for the snake of the best run, see SetGhost.
+var ghostColor = color.NRGBA{210, 210, 255, 90}
+
+// ghost is the best run under the same rules played back alongside the
+// run, a step for each of the snake's. It only shows, nothing collides with
+// it.
+type ghost struct {
+	core  *snake.Game
+	r     *replay.Replay
+	turns []replay.Turn
+	step  int
+}
+
+// SetGhost shows the best run recorded on the board and under the rules of
+// the one being played, in the same mode, as a translucent snake going along
+// with the player's, like the ghosts of racing games. It takes effect from
+// the next run.
+func (g *Game) SetGhost(on bool) {
+	g.ghostOn = on
+	if !on {
+		g.ghost = nil
+	}
+}
+
+// bestName is the name of the best run under rules in the current mode, in
+// the replays directory.
+func (g *Game) bestName(rules *replay.Rules) string {
+	return "best-" + boardMode(g.mode, g.hardcore) + "-" + rules.ID()
+}
+
+// readBest reads the best run under rules in the current mode, nil if there
+// is none yet. A best run recorded by another version of the game can't be
+// played back, it's nil too, and the next run replaces it.
+func (g *Game) readBest(rules *replay.Rules) (*replay.Replay, error) {
+	path, err := replayPath(g.bestName(rules))
+	if err != nil {
+		return nil, err
+	}
+	r, err := ReadReplay(path)
+	var verr *replay.VersionError
+	if errors.Is(err, fs.ErrNotExist) || errors.As(err, &verr) {
+		return nil, nil
+	}
+	return r, err
+}
+
+// loadGhost starts the ghost of the best run under rules, if there is one,
+// as the run starts.
+func (g *Game) loadGhost(rules *replay.Rules) {
+	g.ghost = nil
+	if !g.ghostOn {
+		return
+	}
+	r, err := g.readBest(rules)
+	if err != nil {
+		log.Printf("ghost: %v", err)
+	}
+	if r == nil {
+		return
+	}
+	g.ghost = &ghost{core: r.Rules.New(r.Seed), r: r, turns: r.Turns}
+}
+
+// stepGhost makes the ghost's step along with the snake's, until its run is
+// over.
+func (g *Game) stepGhost() {
+	gh := g.ghost
+	if gh == nil || gh.step >= gh.r.Steps {
+		return
+	}
+	for len(gh.turns) > 0 && gh.turns[0].Step == gh.step {
+		gh.core.Direction.X, gh.core.Direction.Y = gh.turns[0].Dir.Delta()
+		gh.turns = gh.turns[1:]
+	}
+	gh.core.Step()
+	gh.step++
+}
+
+// keepBest keeps r as the best run under its rules in the current mode if it
+// scored more than the one kept so far.
+func (g *Game) keepBest(r *replay.Replay) {
+	best, err := g.readBest(&r.Rules)
+	if err != nil {
+		log.Printf("ghost: %v", err)
+	}
+	if best != nil && best.Score >= r.Score {
+		return
+	}
+	if err := writeReplay(g.bestName(&r.Rules), r); err != nil {
+		log.Printf("ghost: %v", err)
+	}
+}
+
+// drawGhost draws the ghost's snake under the player's, gone once its run
+// is over.
+func (g *Game) drawGhost() {
+	gh := g.ghost
+	if gh == nil || gh.step >= gh.r.Steps {
+		return
+	}
+	for _, v := range gh.core.Snake.All() {
+		vector.DrawFilledRect(g.offscreen,
+			float32(5+v.X*g.box),
+			float32(5+v.Y*g.box),
+			float32(g.box-1),
+			float32(g.box-1),
+			ghostColor,
+			true)
+	}
+}
@@ -0,0 +1,173 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"errors"
+	"flag"
+	"image"
+	"io/fs"
+	"math/rand/v2"
+	"path/filepath"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"jhartman.pl/gamedev/pkg/golden"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+var update = flag.Bool("update", false, "store the rendered frames as the new reference")
+
+const (
+	// goldenDir holds the reference images, one per scene.
+	goldenDir = "testdata/golden"
+	// goldenTolerance is how far (0-255) a channel may be off before the
+	// pixel counts as different, absorbing the antialiasing of the GPUs.
+	goldenTolerance = 8
+	// goldenMaxDiff is the share of the pixels that may differ.
+	goldenMaxDiff = 0.001
+)
+
+// render draws a frame of g and reads it back.
+func render(g *Game) *image.RGBA {
+	w, h := ScreenWidth, ScreenHeight
+	screen := ebiten.NewImage(w, h)
+	defer screen.Deallocate()
+
+	g.Layout(w, h)
+	g.Draw(screen)
+
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	screen.ReadPixels(img.Pix)
+	return img
+}
+
+// TestGolden compares the frames of the scenes with the images stored in
+// testdata/golden. A frame that changed is written next to its image as
+// <scene>.got.png, with <scene>.diff.png showing where. After an intended
+// change, store the new ones with
+//
+//	go test ./pkg/snakegame -run Golden -update
+func TestGolden(t *testing.T) {
+	needGraphics(t)
+	for _, s := range goldenScenes() {
+		t.Run(s.Name, func(t *testing.T) {
+			img := render(s.Game)
+			path := filepath.Join(goldenDir, s.Name+".png")
+			if *update {
+				if err := golden.Save(path, img); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+
+			want, err := golden.Load(path)
+			if errors.Is(err, fs.ErrNotExist) {
+				t.Skipf("no reference image %s yet, store it with -update", path)
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			res, err := golden.Compare(img, want, goldenTolerance)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if res.Fraction() <= goldenMaxDiff {
+				return
+			}
+
+			base := filepath.Join(goldenDir, s.Name)
+			if err := golden.Save(base+".got.png", img); err != nil {
+				t.Fatal(err)
+			}
+			if err := golden.Save(base+".diff.png", res.Diff); err != nil {
+				t.Fatal(err)
+			}
+			t.Errorf("%d of %d pixels differ, see %s.diff.png", res.Differing, res.Total, base)
+		})
+	}
+}
+
+// goldenScene is a known state of the game whose frame TestGolden compares
+// with a stored image.
+type goldenScene struct {
+	Name string
+	Game *Game
+}
+
+// goldenScenes returns the states covering what the game draws: the board,
+// a long snake in squares and in sprites, walls, the crash and the overlays.
+// The states are set up directly, so they don't change with the rules.
+func goldenScenes() []goldenScene {
+	scene := func(name string, setup func(g *Game)) goldenScene {
+		g := NewGame(WithRand(rand.New(rand.NewPCG(1, 1))), WithClock(&TickClock{}))
+		g.keymap = newKeymap(QWERTY, false)
+		setup(g)
+		return goldenScene{name, g}
+	}
+
+	// an L shaped snake of 12 segments, head first
+	long := func(g *Game) {
+		var body []snake.Point
+		for x := 20; x > 12; x-- {
+			body = append(body, snake.Point{X: x, Y: 10})
+		}
+		for y := 11; y < 15; y++ {
+			body = append(body, snake.Point{X: 13, Y: y})
+		}
+		g.core.Snake = snake.NewBody(body...)
+		g.core.Food = snake.Point{X: 30, Y: 5}
+		g.core.Score = 11
+		g.stepAcc = 0.5
+		g.pulse = 0.5
+		g.frame = 90
+	}
+
+	return []goldenScene{
+		scene("start", func(g *Game) {}),
+		scene("long", long),
+		scene("crashed", func(g *Game) {
+			long(g)
+			g.core.State = snake.CRASHING
+		}),
+		scene("gameover", func(g *Game) {
+			long(g)
+			g.core.State = snake.CRASHED
+			g.gameOver = true
+			g.finalScore = "Final score: 11"
+		}),
+		scene("sprites", func(g *Game) {
+			long(g)
+			if err := g.SetSprites(true); err != nil {
+				panic(err)
+			}
+		}),
+		scene("walls", func(g *Game) {
+			var walls []snake.Point
+			for y := 5; y < 24; y++ {
+				walls = append(walls, snake.Point{X: 8, Y: y}, snake.Point{X: 30, Y: y})
+			}
+			g.core = snake.NewLevel(1, walls)
+		}),
+		scene("paused", func(g *Game) {
+			long(g)
+			g.paused = true
+		}),
+		scene("latency", func(g *Game) {
+			g.ShowLatency(true)
+		}),
+		scene("levels", func(g *Game) {
+			g.levels = levelSelect{open: true}
+		}),
+	}
+}
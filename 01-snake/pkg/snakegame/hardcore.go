@@ -0,0 +1,43 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+
+	"jhartman.pl/gamedev/pkg/stats"
+)
+
+// SetHardcore plays the runs from the current one on in the hardcore: a
+// single life whatever SetLives says and no rewind, the first crash ending
+// it. The runs have a high score table of their own, locally and on the
+// leaderboard, that only takes a new best.
+func (g *Game) SetHardcore(on bool) {
+	g.hardcore = on
+	g.SetLives(g.lives)
+}
+
+// topScores is the local high score table the runs go to.
+func (g *Game) topScores() []stats.Game {
+	if g.hardcore {
+		return g.stats.Hardcore
+	}
+	return g.stats.Top
+}
+
+// hardcoreBest tells the best hardcore score, for a run not beating it.
+func (g *Game) hardcoreBest() {
+	if len(g.stats.Hardcore) > 0 {
+		g.notify(fmt.Sprintf("Hardcore best: %d", g.stats.Hardcore[0].Score))
+	}
+}
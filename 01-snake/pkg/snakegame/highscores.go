@@ -0,0 +1,206 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+	"image/color"
+	"log"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// initialsLength is how many letters the initials take.
+const initialsLength = 3
+
+// newScoreColor is for the game that just made the table.
+var newScoreColor = color.RGBA{255, 200, 40, 255}
+
+// highScores is the screen showing the high score table of the statistics,
+// asking for the initials of the game that just made it first.
+type highScores struct {
+	open bool
+	// entering is the place of the game being named, -1 for none
+	entering int
+	initials []byte
+	// global shows the top 10 of the leaderboard instead
+	global bool
+}
+
+// openScores shows the high score table, the initials for the game at
+// place asked first unless place is -1.
+func (g *Game) openScores(place int) {
+	s := &g.scores
+	*s = highScores{open: true, entering: place, initials: s.initials, global: s.global}
+	if place >= 0 {
+		// the last initials are kept for the scores submitted until then
+		s.initials = s.initials[:0]
+		s.global = false
+	} else if s.global {
+		g.refreshBoard()
+	}
+}
+
+// updateScores handles the high score table while it's open: the letters
+// typed make the initials, confirming them saves them, then anything closes
+// the table.
+func (g *Game) updateScores(confirmed bool) {
+	s := &g.scores
+	done := confirmed || g.keymap.justPressed(actionSelect)
+	if s.entering < 0 {
+		if g.board.client != nil && (g.keymap.justPressed(actionLeft) || g.keymap.justPressed(actionRight)) {
+			s.global = !s.global
+			if s.global {
+				g.refreshBoard()
+			}
+		}
+		if done || g.keymap.justPressed(actionResume) || g.keymap.justPressed(actionScores) || g.keymap.justPressed(actionPause) {
+			s.open = false
+		}
+		return
+	}
+
+	for _, k := range g.keymap.src.AppendJustPressedKeys(g.keys[:0]) {
+		switch name := g.keymap.keyName(k); {
+		case k == ebiten.KeyBackspace && len(s.initials) > 0:
+			s.initials = s.initials[:len(s.initials)-1]
+		case len(name) == 1 && name[0] >= 'A' && name[0] <= 'Z' && len(s.initials) < initialsLength:
+			s.initials = append(s.initials, name[0])
+		}
+	}
+	if !done {
+		return
+	}
+
+	if top := g.topScores(); s.entering < len(top) {
+		e := &top[s.entering]
+		e.Initials = string(s.initials)
+		if err := g.stats.Save(); err != nil {
+			log.Printf("stats: %v", err)
+		}
+		g.submitScore(e.Score)
+	}
+	s.entering = -1
+}
+
+// scoreRow is a line of the high score table.
+type scoreRow struct {
+	name  string
+	score int
+	date  time.Time
+	c     color.Color
+}
+
+// drawScores draws the high score table over the dimmed board, the game
+// being named highlighted, or the global top 10 of its tab.
+func (g *Game) drawScores() {
+	s := &g.scores
+	vector.DrawFilledRect(g.offscreen, 0, 0, float32(g.screenWidth), float32(g.screenHeight), color.RGBA{0, 0, 0, 230}, false)
+
+	line := g.hudFace.Size * 1.1
+	y := 6.0
+	draw := func(msg string, x float64, c color.Color) {
+		op := g.textOptions(x, y)
+		op.ColorScale.ScaleWithColor(c)
+		text.Draw(g.offscreen, msg, g.hudFace, op)
+	}
+
+	title := "High scores"
+	if g.hardcore {
+		title = "Hardcore"
+	}
+	switch {
+	case s.entering >= 0 && g.hardcore:
+		title = "New hardcore best! Type your initials"
+	case s.entering >= 0:
+		title = "New high score! Type your initials"
+	case g.board.client != nil && s.global:
+		title += "  Local  [Global]"
+	case g.board.client != nil:
+		title += "  [Local]  Global"
+	}
+	w, _ := text.Measure(title, g.hudFace, 0)
+	draw(title, (g.screenWidth-w)/2, color.White)
+	y += line * 1.4
+
+	rows, empty := g.localRows(), "No games yet"
+	if s.global {
+		rows, empty = g.globalRows()
+	}
+	if len(rows) == 0 {
+		draw(empty, 40, color.Gray{160})
+		y += line
+	}
+	for i, r := range rows {
+		draw(fmt.Sprintf("%2d.", i+1), 40, r.c)
+		draw(r.name, 72, r.c)
+		score := fmt.Sprint(r.score)
+		sw, _ := text.Measure(score, g.hudFace, 0)
+		draw(score, 196-sw, r.c)
+		draw(r.date.Local().Format("2006-01-02"), 208, r.c)
+		y += line
+	}
+
+	msg := g.prompt("close")
+	if s.entering >= 0 {
+		msg = g.prompt("confirm")
+		if g.lastDevice == keyboard {
+			msg = "Press " + g.keymap.label(actionSelect) + " to confirm"
+		}
+	}
+	w, _ = text.Measure(msg, g.hudFace, 0)
+	y = max(y+4, g.screenHeight-6-line)
+	draw(msg, (g.screenWidth-w)/2, color.White)
+}
+
+// localRows are the lines of the high score table of the statistics.
+func (g *Game) localRows() []scoreRow {
+	s := &g.scores
+	top := g.topScores()
+	rows := make([]scoreRow, 0, len(top))
+	for i, e := range top {
+		r := scoreRow{e.Initials, e.Score, e.Start, color.Gray{200}}
+		if i == s.entering {
+			r.c = newScoreColor
+			r.name = string(s.initials)
+			if len(s.initials) < initialsLength && g.frame/animationFrames%2 == 0 {
+				r.name += "_"
+			}
+		} else if r.name == "" {
+			r.name = "---"
+		}
+		rows = append(rows, r)
+	}
+	return rows
+}
+
+// globalRows are the lines of the top 10 of the leaderboard, or why there
+// are none.
+func (g *Game) globalRows() ([]scoreRow, string) {
+	b := &g.board
+	switch {
+	case b.offline && b.top == nil:
+		return nil, "Offline, the local scores only"
+	case b.top == nil:
+		return nil, "Loading..."
+	}
+	rows := make([]scoreRow, 0, len(b.top))
+	for _, e := range b.top {
+		name := []rune(e.Name)
+		rows = append(rows, scoreRow{string(name[:min(len(name), 10)]), e.Score, e.CreatedAt, color.Gray{200}})
+	}
+	return rows, ""
+}
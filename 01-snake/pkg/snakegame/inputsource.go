@@ -0,0 +1,105 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+)
+
+// InputSource tells which keys are held down in the current tick. The game
+// reads the keyboard through it, so Update can be driven without a display.
+type InputSource interface {
+	IsKeyPressed(k ebiten.Key) bool
+	IsKeyJustPressed(k ebiten.Key) bool
+	AppendJustPressedKeys(keys []ebiten.Key) []ebiten.Key
+}
+
+// ebitenInput is the real keyboard.
+type ebitenInput struct{}
+
+func (ebitenInput) IsKeyPressed(k ebiten.Key) bool { return ebiten.IsKeyPressed(k) }
+
+func (ebitenInput) IsKeyJustPressed(k ebiten.Key) bool { return inpututil.IsKeyJustPressed(k) }
+
+func (ebitenInput) AppendJustPressedKeys(keys []ebiten.Key) []ebiten.Key {
+	return inpututil.AppendJustPressedKeys(keys)
+}
+
+// SetInputSource makes the game read the keyboard from src, nil restores
+// the real one.
+func (g *Game) SetInputSource(src InputSource) {
+	if src == nil {
+		src = ebitenInput{}
+	}
+	g.keymap.src = src
+}
+
+// ScriptedInput is an InputSource playing back the keys held in each tick,
+// one entry per call to Advance:
+//
+//	in := snakegame.NewScriptedInput(nil, []ebiten.Key{ebiten.KeyArrowUp}, nil)
+//	g.SetInputSource(in)
+//	for in.Advance() {
+//		g.Update()
+//	}
+type ScriptedInput struct {
+	ticks [][]ebiten.Key
+	// tick is the current entry, -1 before the first Advance
+	tick int
+}
+
+// NewScriptedInput returns the script, each argument being the keys held in
+// a tick.
+func NewScriptedInput(ticks ...[]ebiten.Key) *ScriptedInput {
+	return &ScriptedInput{ticks: ticks, tick: -1}
+}
+
+// Advance moves to the next tick, false once the script is over.
+func (s *ScriptedInput) Advance() bool {
+	if s.tick < len(s.ticks) {
+		s.tick++
+	}
+	return s.tick < len(s.ticks)
+}
+
+func (s *ScriptedInput) held(tick int, k ebiten.Key) bool {
+	if tick < 0 || tick >= len(s.ticks) {
+		return false
+	}
+	return slices.Contains(s.ticks[tick], k)
+}
+
+func (s *ScriptedInput) IsKeyPressed(k ebiten.Key) bool {
+	return s.held(s.tick, k)
+}
+
+// IsKeyJustPressed reports whether k is held in this tick but wasn't in the
+// previous one.
+func (s *ScriptedInput) IsKeyJustPressed(k ebiten.Key) bool {
+	return s.held(s.tick, k) && !s.held(s.tick-1, k)
+}
+
+func (s *ScriptedInput) AppendJustPressedKeys(keys []ebiten.Key) []ebiten.Key {
+	if s.tick < 0 || s.tick >= len(s.ticks) {
+		return keys
+	}
+	for _, k := range s.ticks[s.tick] {
+		if s.IsKeyJustPressed(k) {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
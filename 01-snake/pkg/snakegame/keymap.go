@@ -0,0 +1,285 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2"
+
+	"jhartman.pl/gamedev/pkg/input"
+)
+
+// action is something the player can do with the keyboard.
+type action int
+
+const (
+	actionUp action = iota
+	actionRight
+	actionDown
+	actionLeft
+	actionResume
+	actionPause
+	actionExportStats
+	actionLatency
+	actionLevels
+	actionSelect
+	actionRate
+	actionBorder
+	actionDifficulty
+	actionMode
+	actionScores
+	actionRewind
+	actionMirror
+)
+
+// steerActions are the actions turning the snake, by direction.
+var steerActions = map[input.Dir]action{
+	input.Up:    actionUp,
+	input.Right: actionRight,
+	input.Down:  actionDown,
+	input.Left:  actionLeft,
+}
+
+// Layout is a keyboard layout. ebiten keys are physical positions named
+// after a US keyboard, the layout tells what is printed on them, which
+// decides the letter keys bound by default and the names shown on screen.
+type Layout struct {
+	Name string
+	// steer are the letters of the alternative up, left, down and right
+	// keys
+	steer string
+	// labels of the keys printed differently than on a US keyboard
+	labels map[ebiten.Key]string
+}
+
+var (
+	QWERTY = Layout{
+		Name:  "qwerty",
+		steer: "WASD",
+	}
+
+	AZERTY = Layout{
+		Name:  "azerty",
+		steer: "ZQSD",
+		labels: map[ebiten.Key]string{
+			ebiten.KeyQ:         "A",
+			ebiten.KeyW:         "Z",
+			ebiten.KeyA:         "Q",
+			ebiten.KeyZ:         "W",
+			ebiten.KeySemicolon: "M",
+			ebiten.KeyM:         ",",
+		},
+	}
+
+	QWERTZ = Layout{
+		Name:  "qwertz",
+		steer: "WASD",
+		labels: map[ebiten.Key]string{
+			ebiten.KeyY: "Z",
+			ebiten.KeyZ: "Y",
+		},
+	}
+)
+
+// LayoutByName looks a layout up by name.
+func LayoutByName(name string) (Layout, error) {
+	switch strings.ToLower(name) {
+	case QWERTY.Name:
+		return QWERTY, nil
+	case AZERTY.Name:
+		return AZERTY, nil
+	case QWERTZ.Name:
+		return QWERTZ, nil
+	}
+	return Layout{}, fmt.Errorf("unknown keyboard layout %q, expected qwerty, azerty or qwertz", name)
+}
+
+// SetKeyboardLayout picks the layout by name. An empty name or "auto"
+// detects it from the system once the game runs, QWERTY until then or if it
+// can't be told.
+func (g *Game) SetKeyboardLayout(name string) error {
+	src := g.keymap.src
+	if name == "" || strings.EqualFold(name, "auto") {
+		g.keymap = newKeymap(QWERTY, true)
+	} else {
+		l, err := LayoutByName(name)
+		if err != nil {
+			return err
+		}
+		g.keymap = newKeymap(l, false)
+	}
+	g.keymap.src = src
+	return nil
+}
+
+// detectLayout tells the layout from the names the system gives to the keys
+// that differ. It only works on desktops and browsers once the game runs.
+func detectLayout() (Layout, bool) {
+	switch {
+	case strings.EqualFold(ebiten.KeyName(ebiten.KeyQ), "a"):
+		return AZERTY, true
+	case strings.EqualFold(ebiten.KeyName(ebiten.KeyY), "z"):
+		return QWERTZ, true
+	case strings.EqualFold(ebiten.KeyName(ebiten.KeyQ), "q"):
+		return QWERTY, true
+	}
+	return Layout{}, false
+}
+
+// key returns the key labelled with the letter.
+func (l Layout) key(label string) ebiten.Key {
+	for k, v := range l.labels {
+		if v == label {
+			return k
+		}
+	}
+
+	var k ebiten.Key
+	if err := k.UnmarshalText([]byte(label)); err != nil {
+		panic(fmt.Sprintf("layout %s: no key %q", l.Name, label))
+	}
+	return k
+}
+
+// keymap binds the actions to keys.
+type keymap struct {
+	layout Layout
+	// auto follows the layout of the system
+	auto bool
+
+	bindings map[action][]ebiten.Key
+	// src is where the keys are read from
+	src InputSource
+}
+
+func newKeymap(l Layout, auto bool) keymap {
+	m := keymap{layout: l, auto: auto, src: ebitenInput{}}
+	m.bind()
+	return m
+}
+
+// bind sets the default bindings of the layout.
+func (m *keymap) bind() {
+	up, left, down, right := m.layout.steer[0:1], m.layout.steer[1:2], m.layout.steer[2:3], m.layout.steer[3:4]
+
+	m.bindings = map[action][]ebiten.Key{
+		actionUp:          {ebiten.KeyArrowUp, m.layout.key(up)},
+		actionRight:       {ebiten.KeyArrowRight, m.layout.key(right)},
+		actionDown:        {ebiten.KeyArrowDown, m.layout.key(down)},
+		actionLeft:        {ebiten.KeyArrowLeft, m.layout.key(left)},
+		actionResume:      {ebiten.KeySpace},
+		actionPause:       {m.layout.key("P"), ebiten.KeyEscape},
+		actionExportStats: {ebiten.KeyF9},
+		actionLatency:     {ebiten.KeyF3},
+		actionLevels:      {ebiten.KeyL},
+		actionSelect:      {ebiten.KeyEnter, ebiten.KeyNumpadEnter},
+		actionBorder:      {ebiten.KeyB},
+		actionDifficulty:  {ebiten.KeyT},
+		actionMode:        {ebiten.KeyM},
+		actionScores:      {ebiten.KeyH},
+		actionRewind:      {ebiten.KeyBackspace},
+		actionMirror:      {ebiten.KeyI},
+		actionRate:        {ebiten.KeyDigit1, ebiten.KeyDigit2, ebiten.KeyDigit3, ebiten.KeyDigit4, ebiten.KeyDigit5},
+	}
+}
+
+// detect switches to the layout of the system, if it can be told.
+func (m *keymap) detect() {
+	if !m.auto {
+		return
+	}
+	if l, ok := detectLayout(); ok && l.Name != m.layout.Name {
+		m.layout = l
+		m.bind()
+	}
+}
+
+func (m *keymap) justPressed(a action) bool {
+	for _, k := range m.bindings[a] {
+		if m.src.IsKeyJustPressed(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// pressed reports whether a key bound to the action is held down.
+func (m *keymap) pressed(a action) bool {
+	for _, k := range m.bindings[a] {
+		if m.src.IsKeyPressed(k) {
+			return true
+		}
+	}
+	return false
+}
+
+// justPressedIndex returns which of the keys bound to the action was just
+// pressed, counting from 1, or 0 if none.
+func (m *keymap) justPressedIndex(a action) int {
+	for i, k := range m.bindings[a] {
+		if m.src.IsKeyJustPressed(k) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// keyName returns what is printed on the key.
+func (m *keymap) keyName(k ebiten.Key) string {
+	if l, ok := m.layout.labels[k]; ok {
+		return l
+	}
+
+	switch k {
+	case ebiten.KeyArrowUp:
+		return "↑"
+	case ebiten.KeyArrowRight:
+		return "→"
+	case ebiten.KeyArrowDown:
+		return "↓"
+	case ebiten.KeyArrowLeft:
+		return "←"
+	}
+	return k.String()
+}
+
+// label names the first key bound to the action.
+func (m *keymap) label(a action) string {
+	if ks := m.bindings[a]; len(ks) > 0 {
+		return m.keyName(ks[0])
+	}
+	return "?"
+}
+
+// steering describes the keys turning the snake, e.g. "↑←↓→ or ZQSD".
+func (m *keymap) steering() string {
+	primary, alt := m.steerKeys(0), m.steerKeys(1)
+	if alt == "" {
+		return primary
+	}
+	return primary + " or " + alt
+}
+
+// steerKeys names the i-th keys bound to up, left, down and right, e.g.
+// "ZQSD" for the second ones.
+func (m *keymap) steerKeys(i int) string {
+	var b strings.Builder
+	for _, a := range []action{actionUp, actionLeft, actionDown, actionRight} {
+		if ks := m.bindings[a]; len(ks) > i {
+			b.WriteString(m.keyName(ks[i]))
+		}
+	}
+	return b.String()
+}
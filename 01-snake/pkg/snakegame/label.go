@@ -0,0 +1,56 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"math"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// label is text rendered to an image, laid out again only when the text or
+// the face change, so drawing it doesn't allocate.
+type label struct {
+	img  *ebiten.Image
+	text string
+	face *text.GoTextFace
+	w    float64
+	op   ebiten.DrawImageOptions
+}
+
+// drawCentered draws s horizontally centered on dst, from y down.
+func (l *label) drawCentered(dst *ebiten.Image, s string, face *text.GoTextFace, y float64) {
+	l.set(s, face)
+	l.drawAt(dst, (float64(dst.Bounds().Dx())-l.w)/2, y)
+}
+
+// set lays s out with face, unless it already is.
+func (l *label) set(s string, face *text.GoTextFace) {
+	if l.img == nil || l.text != s || l.face != face {
+		w, h := text.Measure(s, face, 0)
+		if l.img != nil {
+			l.img.Deallocate()
+		}
+		l.img = ebiten.NewImage(max(int(math.Ceil(w)), 1), max(int(math.Ceil(h)), 1))
+		text.Draw(l.img, s, face, nil)
+		l.text, l.face, l.w = s, face, w
+	}
+}
+
+// drawAt draws the text last set with its top left corner at x, y.
+func (l *label) drawAt(dst *ebiten.Image, x, y float64) {
+	l.op.GeoM.Reset()
+	l.op.GeoM.Translate(x, y)
+	dst.DrawImage(l.img, &l.op)
+}
@@ -0,0 +1,136 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// latencySamples is how many of the last turns the overlay averages over.
+const latencySamples = 50
+
+// latencyProbe measures how long a turn takes from the tick the key press is
+// seen to the frame drawing the snake moving the new way. Presses between
+// ticks and the compositor aren't included, so it's a lower bound, but
+// enough to compare TPS, speed and vsync settings.
+type latencyProbe struct {
+	on    bool
+	ticks int
+
+	// the turn waiting to be drawn
+	pending   bool
+	stepped   bool
+	pressAt   time.Time
+	pressTick int
+
+	samples []latencySample
+	next    int
+}
+
+type latencySample struct {
+	d     time.Duration
+	ticks int
+}
+
+// ShowLatency turns the input latency overlay on or off, F3 toggles it too.
+func (g *Game) ShowLatency(on bool) {
+	g.latency.on = on
+	g.latency.reset()
+}
+
+func (p *latencyProbe) reset() {
+	p.pending, p.stepped = false, false
+	p.samples = p.samples[:0]
+	p.next = 0
+}
+
+// tick is called at the start of every Update.
+func (p *latencyProbe) tick() {
+	p.ticks++
+}
+
+// turned records a turn asked for by the player. Further presses
+// before the snake moves are part of the same turn.
+func (p *latencyProbe) turned() {
+	if !p.on || p.pending {
+		return
+	}
+	p.pending = true
+	p.pressAt = time.Now()
+	p.pressTick = p.ticks
+}
+
+// step is called when the snake moves.
+func (p *latencyProbe) step() {
+	if p.pending {
+		p.stepped = true
+	}
+}
+
+// drawn is called when a frame has been drawn.
+func (p *latencyProbe) drawn() {
+	if !p.stepped {
+		return
+	}
+	s := latencySample{time.Since(p.pressAt), p.ticks - p.pressTick}
+	p.pending, p.stepped = false, false
+
+	if len(p.samples) < latencySamples {
+		p.samples = append(p.samples, s)
+		return
+	}
+	p.samples[p.next] = s
+	p.next = (p.next + 1) % latencySamples
+}
+
+func (g *Game) latencySummary() string {
+	p := &g.latency
+
+	vsync := "off"
+	if ebiten.IsVsyncEnabled() {
+		vsync = "on"
+	}
+	info := fmt.Sprintf("TPS %.0f FPS %.0f vsync %s\n%v", ebiten.ActualTPS(), ebiten.ActualFPS(), vsync, &g.throttle)
+
+	if len(p.samples) == 0 {
+		return info + "\nturn to measure latency"
+	}
+
+	var sum time.Duration
+	var sumTicks int
+	var worst latencySample
+	for _, s := range p.samples {
+		sum += s.d
+		sumTicks += s.ticks
+		if s.d > worst.d {
+			worst = s
+		}
+	}
+	n := len(p.samples)
+
+	return fmt.Sprintf("%s\nlatency avg %.0fms %.1f ticks\nworst %.0fms %d ticks (%d turns)",
+		info,
+		float64(sum.Microseconds())/float64(n)/1000, float64(sumTicks)/float64(n),
+		float64(worst.d.Microseconds())/1000, worst.ticks, n)
+}
+
+func (g *Game) drawLatency(dst *ebiten.Image) {
+	op := &text.DrawOptions{}
+	op.GeoM.Translate(5, 3+g.hudFace.Size*1.2)
+	op.LineSpacing = g.hudFace.Size * 1.2
+	text.Draw(dst, g.latencySummary(), g.hudFace, op)
+}
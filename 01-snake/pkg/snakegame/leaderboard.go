@@ -0,0 +1,181 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/leaderboard"
+	"jhartman.pl/gamedev/pkg/mailbox"
+	"jhartman.pl/gamedev/pkg/replay"
+	"jhartman.pl/gamedev/pkg/settings"
+)
+
+const (
+	// leaderboardGame is the game the scores are submitted for.
+	leaderboardGame = "snake"
+	// leaderboardTimeout bounds a submission or a query, the game going on
+	// meanwhile.
+	leaderboardTimeout = 5 * time.Second
+	// deviceFile keeps the identity the server handed out.
+	deviceFile = "leaderboard-device.json"
+	// anonymous names the scores submitted before any initials were entered.
+	anonymous = "anonymous"
+)
+
+// globalBoard is the online leaderboard, see SetLeaderboard.
+type globalBoard struct {
+	client *leaderboard.Client
+	// top is the global top 10 of the mode last fetched, offline set when
+	// the server couldn't be reached
+	top     []leaderboard.Entry
+	mode    GameMode
+	offline bool
+	// busy is set while a request is on the way
+	busy bool
+
+	// results of the requests, applied on the game loop
+	results mailbox.Mailbox[func()]
+}
+
+// SetLeaderboard submits the final scores to the score server at url, see
+// cmd/scored, and shows its top 10 with the high scores. The requests run
+// in the background: without the server the game goes on with the local
+// table only.
+func (g *Game) SetLeaderboard(url string) {
+	c := leaderboard.New(url)
+	if name, err := devicePath(); err == nil {
+		d, err := leaderboard.ReadDevice(name)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("leaderboard: %v", err)
+		}
+		c.Device = d
+	}
+	g.board = globalBoard{client: c}
+}
+
+func devicePath() (string, error) {
+	dir, err := settings.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, deviceFile), nil
+}
+
+// boardMode is the board of the mode on the server, a board of its own for
+// the hardcore.
+func boardMode(m GameMode, hardcore bool) string {
+	board := strings.ReplaceAll(strings.ToLower(m.String()), " ", "-")
+	if hardcore {
+		board += "-hardcore"
+	}
+	return board
+}
+
+// globalBoard is the board of the current mode and rules on the server: that
+// of the mode under the default rules, else one of their own, named after
+// them, as the runs played with more lives, the combos or a target aren't
+// comparable and can't be verified.
+func (g *Game) globalBoard() string {
+	board := boardMode(g.mode, g.hardcore)
+	if rules := replay.RulesOf(g.core); !rules.Default() {
+		// the server takes 32 characters, plenty to tell the rules apart
+		board += "-" + rules.ID()[:8]
+	}
+	return board
+}
+
+// submitScore sends score, made in the current mode, to the server, then
+// fetches the top 10 of the mode, see globalBoard. Without initials entered
+// yet, it goes under anonymous.
+func (g *Game) submitScore(score int) {
+	b := &g.board
+	if b.client == nil {
+		return
+	}
+	name := string(g.scores.initials)
+	if name == "" {
+		name = anonymous
+	}
+	s := leaderboard.Score{Game: leaderboardGame, Mode: g.globalBoard(), Name: name, Score: score, Replay: g.lastReplay()}
+	g.requestBoard(func(ctx context.Context, c *leaderboard.Client) (string, error) {
+		res, err := c.Submit(ctx, s)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("Global rank #%d", res.Rank), nil
+	})
+}
+
+// refreshBoard fetches the top 10 of the current mode, unless it's on its
+// way.
+func (g *Game) refreshBoard() {
+	if g.board.client == nil || g.board.busy {
+		return
+	}
+	g.requestBoard(func(context.Context, *leaderboard.Client) (string, error) {
+		return "", nil
+	})
+}
+
+// requestBoard runs first on a copy of the client in the background, then
+// fetches the top 10. Back on the game loop the top replaces the one shown,
+// and what first returned is shown as a notice.
+func (g *Game) requestBoard(first func(ctx context.Context, c *leaderboard.Client) (string, error)) {
+	b := &g.board
+	b.busy = true
+	c := *b.client
+	mode, board := g.mode, g.globalBoard()
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), leaderboardTimeout)
+		defer cancel()
+
+		device := c.Device
+		notice, err := first(ctx, &c)
+		var page leaderboard.Page
+		if err == nil {
+			page, err = c.Top(ctx, leaderboard.Query{Game: leaderboardGame, Mode: board, Limit: 10})
+		}
+		if c.Device != device {
+			// registered on the way, kept for the next runs
+			if name, err := devicePath(); err == nil {
+				if err := leaderboard.WriteDevice(name, c.Device); err != nil {
+					log.Printf("leaderboard: %v", err)
+				}
+			}
+		}
+
+		b.results.Post(func() {
+			b.busy = false
+			b.client.Device = c.Device
+			b.mode = mode
+			if err != nil {
+				log.Printf("leaderboard: %v", err)
+				b.offline = true
+				return
+			}
+			b.offline = false
+			b.top = page.Entries
+			if notice != "" {
+				g.notify(notice)
+			}
+		})
+	}()
+}
@@ -0,0 +1,513 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image/color"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+	"jhartman.pl/gamedev/pkg/mailbox"
+	"jhartman.pl/gamedev/pkg/maps"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+const (
+	// rows of the list shown at once
+	levelRows = 8
+	// how long requests to the maps server may take
+	mapsTimeout = 10 * time.Second
+)
+
+type levelTab int
+
+const (
+	installedTab levelTab = iota
+	onlineTab
+)
+
+// levelSelect is the screen picking the board to play: the plain one, the
+// built-in and downloaded maps, or the ones on the maps server.
+type levelSelect struct {
+	open     bool
+	tab      levelTab
+	selected int
+	status   string
+
+	builtin   []maps.Map
+	installed []maps.Map
+	online    []maps.Map
+
+	// results of requests to the maps server, applied on the game loop
+	results mailbox.Mailbox[func()]
+}
+
+// errResized is returned starting levels on a board of another size than
+// the classic one they are made for.
+var errResized = errors.New("levels are made for the classic board, not a resized one")
+
+// classicBoard reports whether the board is the classic one, see
+// WithBoardSize.
+func (g *Game) classicBoard() bool {
+	return g.width == snake.BoardWidth && g.height == snake.BoardHeight
+}
+
+// SetMapsServer lets the level select browse the maps shared on the server
+// at url, see cmd/mapsd.
+func (g *Game) SetMapsServer(url string) {
+	g.maps = maps.New(url)
+}
+
+// request runs f in the background, then apply with its result on the game
+// loop.
+func (g *Game) request(f func(ctx context.Context) error, apply func(err error)) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mapsTimeout)
+		defer cancel()
+
+		err := f(ctx)
+
+		g.levels.results.Post(func() { apply(err) })
+	}()
+}
+
+func (g *Game) openLevels() {
+	g.pause()
+
+	l := &g.levels
+	l.open = true
+	l.selected = 0
+	l.status = ""
+
+	if l.builtin == nil {
+		l.builtin = maps.Builtin()
+	}
+	var err error
+	if l.installed, err = maps.Installed(); err != nil {
+		log.Printf("maps: %v", err)
+	}
+
+	if g.maps != nil {
+		g.refreshOnline()
+	}
+}
+
+func (g *Game) refreshOnline() {
+	var page maps.Page
+
+	g.levels.status = "Loading..."
+	g.request(func(ctx context.Context) (err error) {
+		page, err = g.maps.List(ctx, maps.Query{Limit: 50})
+		return err
+	}, func(err error) {
+		if err != nil {
+			g.levels.status = "Maps server unavailable"
+			log.Printf("maps: %v", err)
+			return
+		}
+		g.levels.online = page.Maps
+		g.levels.status = ""
+	})
+}
+
+// entries are the rows of the current tab, nil standing for the plain
+// board.
+func (l *levelSelect) entries() []*maps.Map {
+	if l.tab == onlineTab {
+		es := make([]*maps.Map, len(l.online))
+		for i := range l.online {
+			es[i] = &l.online[i]
+		}
+		return es
+	}
+
+	es := []*maps.Map{nil}
+	for i := range l.builtin {
+		es = append(es, &l.builtin[i])
+	}
+	for i := range l.installed {
+		es = append(es, &l.installed[i])
+	}
+	return es
+}
+
+// updateLevels handles the level select while it is open.
+func (g *Game) updateLevels() {
+	l := &g.levels
+
+	for _, apply := range l.results.Receive() {
+		apply()
+	}
+
+	es := l.entries()
+	switch {
+	case g.keymap.justPressed(actionLevels):
+		l.open = false
+	case g.keymap.justPressed(actionUp) && l.selected > 0:
+		l.selected--
+	case g.keymap.justPressed(actionDown) && l.selected < len(es)-1:
+		l.selected++
+	case g.keymap.justPressed(actionLeft) || g.keymap.justPressed(actionRight):
+		if g.maps != nil {
+			l.tab = 1 - l.tab
+			l.selected = 0
+		}
+	case g.keymap.justPressed(actionSelect) && l.selected < len(es):
+		g.pickLevel(es[l.selected])
+	}
+
+	if stars := g.keymap.justPressedIndex(actionRate); stars > 0 && l.selected < len(es) {
+		g.rateLevel(es[l.selected], stars)
+	}
+}
+
+// pickLevel plays m, downloading it first from the online tab.
+func (g *Game) pickLevel(m *maps.Map) {
+	g.mazes = mazes{}
+	g.stopCampaign()
+	if m == nil || g.levels.tab == installedTab {
+		g.playLevel(m)
+		return
+	}
+
+	var full maps.Map
+	id := m.ID
+	g.levels.status = "Downloading..."
+	g.request(func(ctx context.Context) (err error) {
+		full, err = g.maps.Get(ctx, id)
+		return err
+	}, func(err error) {
+		if err == nil {
+			err = maps.Install(full)
+		}
+		if err != nil {
+			g.levels.status = "Download failed"
+			log.Printf("maps: %s: %v", id, err)
+			return
+		}
+		g.levels.installed, _ = maps.Installed()
+		g.playLevel(&full)
+	})
+}
+
+func (g *Game) rateLevel(m *maps.Map, stars int) {
+	if m == nil || m.ID == "" || g.maps == nil {
+		return
+	}
+
+	var rated maps.Map
+	id := m.ID
+	g.request(func(ctx context.Context) (err error) {
+		rated, err = g.maps.Rate(ctx, id, stars)
+		return err
+	}, func(err error) {
+		if err != nil {
+			g.levels.status = "Rating failed"
+			log.Printf("maps: %s: %v", id, err)
+			return
+		}
+		g.levels.status = fmt.Sprintf("Rated %s %d/5", rated.Name, stars)
+		for i := range g.levels.online {
+			if g.levels.online[i].ID == id {
+				g.levels.online[i].Rating, g.levels.online[i].Votes = rated.Rating, rated.Votes
+			}
+		}
+	})
+}
+
+// mazes are levels played one after the other, see PlayMazes.
+type mazes struct {
+	levels  []maps.Map
+	target  int
+	current int
+}
+
+// PlayMazes starts a new game on the first of levels, moving on to the next
+// one, and back to the first after the last, each time the score reaches
+// target. Picking another level stops it.
+func (g *Game) PlayMazes(levels []maps.Map, target int) error {
+	if len(levels) == 0 || target <= 0 {
+		return fmt.Errorf("mazes need levels and a target score, got %d and %d", len(levels), target)
+	}
+	if !g.classicBoard() {
+		return errResized
+	}
+	g.mazes = mazes{levels: levels, target: target}
+	g.stopCampaign()
+	g.playLevel(&levels[0])
+	return nil
+}
+
+// mazeDone moves on to the next maze once the score reaches the target,
+// the run on the current one ending there.
+func (g *Game) mazeDone() error {
+	m := &g.mazes
+	if m.target == 0 || g.core.State != snake.RUNNING || g.core.Score < m.target {
+		return nil
+	}
+
+	if _, err := g.recordGame(); err != nil {
+		return err
+	}
+	m.current = (m.current + 1) % len(m.levels)
+	next := &m.levels[m.current]
+	g.playLevel(next)
+	g.notify(fmt.Sprintf("Level %d: %s", m.current+1, next.Name))
+	return nil
+}
+
+// SetLevel starts a new game on the built-in or downloaded map called name,
+// in any case, the plain board if name is empty.
+func (g *Game) SetLevel(name string) error {
+	g.mazes = mazes{}
+	g.campaign = campaign{}
+	if name == "" {
+		g.playLevel(nil)
+		return nil
+	}
+	if !g.classicBoard() {
+		return errResized
+	}
+
+	installed, err := maps.Installed()
+	if err != nil {
+		log.Printf("maps: %v", err)
+	}
+	for _, m := range append(maps.Builtin(), installed...) {
+		if strings.EqualFold(m.Name, name) {
+			g.playLevel(&m)
+			return nil
+		}
+	}
+	return fmt.Errorf("no level called %q", name)
+}
+
+// playLevel starts a new game on m, the plain board if nil.
+func (g *Game) playLevel(m *maps.Map) {
+	var walls []snake.Point
+	var portals []snake.Portal
+	var obstacles []snake.Obstacle
+	var tiles snake.Tiles
+	if m != nil {
+		walls = m.Points()
+		portals = m.PortalPairs()
+		obstacles = m.Patrols()
+		tiles = m.TileCells()
+	}
+
+	g.playLayout(walls, portals, obstacles, tiles)
+}
+
+// playLayout starts a new run on a board with walls, portals, obstacles and
+// tiles.
+func (g *Game) playLayout(walls []snake.Point, portals []snake.Portal, obstacles []snake.Obstacle, tiles snake.Tiles) {
+	seed := g.rng.Uint64()
+	g.daily = 0
+	if g.mode == DailyMode && g.mazes.levels == nil && g.classicBoard() {
+		// the plain board whatever the level, the mazes and the resized
+		// boards being played as usual
+		seed = dailySeed(g.clock.Now())
+		g.daily = seed
+		walls, portals, obstacles, tiles = nil, nil, nil, snake.Tiles{}
+	}
+	arena := g.arena
+	if g.daily != 0 {
+		arena = snake.RectArena
+	}
+	g.core = snake.NewLevelSize(seed, walls, g.width, g.height)
+	g.core.SetPortals(portals)
+	g.core.SetObstacles(obstacles)
+	g.core.SetTiles(tiles)
+	g.core.SetArena(arena)
+	g.forgetCells()
+	g.core.Border = g.border
+	g.core.Target = g.winLength
+	g.core.Lives = g.lives
+	if g.hardcore {
+		g.core.Lives = 1
+	}
+	g.core.Combos = g.combos
+	g.core.Growth = g.growth
+	g.core.Chase = g.chase
+	g.core.Zen = g.mode == ZenMode
+	if g.daily != 0 {
+		g.dailyRules()
+	}
+	g.startRecording(seed)
+	g.playback = nil
+	g.resetRewind()
+	g.resetSpeedrun()
+	g.countdown = 0
+	g.stall = 0
+	g.popups = g.popups[:0]
+	g.particles.Clear()
+	g.outOfTime = false
+	g.resetClock()
+	g.turns.clear()
+	g.run = run{}
+	g.gameOver = false
+	g.levels.open = false
+	if g.autosave {
+		g.discardSave()
+	}
+	g.resume()
+}
+
+// tileColors are the colors of the tiles, under the rest.
+var tileColors = map[snake.Tile]color.Color{
+	snake.Ice: color.RGBA{150, 210, 240, 255},
+	snake.Mud: color.RGBA{100, 70, 40, 255},
+}
+
+// drawTiles draws the ice and the mud, a cell each.
+func (g *Game) drawTiles(dst *ebiten.Image) {
+	for _, t := range [...]snake.Tile{snake.Ice, snake.Mud} {
+		cells := g.core.Tiles.Ice
+		if t == snake.Mud {
+			cells = g.core.Tiles.Mud
+		}
+		for _, p := range cells {
+			vector.DrawFilledRect(dst, float32(5+p.X*g.box), float32(5+p.Y*g.box), float32(g.box-1), float32(g.box-1), tileColors[t], true)
+		}
+	}
+}
+
+func (g *Game) drawWalls(dst *ebiten.Image) {
+	for _, w := range g.core.Walls {
+		vector.DrawFilledRect(dst,
+			float32(5+w.X*g.box),
+			float32(5+w.Y*g.box),
+			float32(g.box-1),
+			float32(g.box-1),
+			color.Gray{90},
+			true)
+	}
+}
+
+// portalColors tell the pairs of portals apart.
+var portalColors = [...]color.RGBA{
+	{90, 110, 255, 255},
+	{230, 90, 230, 255},
+	{60, 220, 255, 255},
+	{255, 170, 40, 255},
+	{150, 255, 90, 255},
+}
+
+// drawPortals draws the portals as frames shimmering towards white, the
+// two ends of a pair in the same color.
+func (g *Game) drawPortals() {
+	const period = 4
+	t := float32(g.frame/animationFrames%period) / period
+	t = 1 - 2*min(t, 1-t)
+	b := float32(g.box)
+	for i, pt := range g.core.Portals {
+		c := lighten(portalColors[i%len(portalColors)], 0.6*t)
+		for _, p := range pt {
+			x, y := float32(5+p.X*g.box), float32(5+p.Y*g.box)
+			vector.StrokeRect(g.offscreen, x+0.5, y+0.5, b-2, b-2, 1, c, true)
+			vector.DrawFilledRect(g.offscreen, x+3, y+3, b-7, b-7, c, true)
+		}
+	}
+}
+
+var (
+	obstacleColor = color.RGBA{220, 70, 40, 255}
+	patrolColor   = color.RGBA{110, 50, 40, 255}
+)
+
+// drawObstacles draws the obstacles, their paths faintly under them.
+func (g *Game) drawObstacles() {
+	mid := g.box/2 - 1
+	for _, o := range g.core.Obstacles {
+		for _, p := range o.Path {
+			vector.DrawFilledRect(g.offscreen, float32(5+p.X*g.box+mid), float32(5+p.Y*g.box+mid), 1, 1, patrolColor, false)
+		}
+	}
+	b := float32(g.box)
+	for _, o := range g.core.Obstacles {
+		p := o.Pos()
+		x, y := float32(5+p.X*g.box), float32(5+p.Y*g.box)
+		vector.DrawFilledRect(g.offscreen, x, y, b-1, b-1, obstacleColor, true)
+		vector.StrokeLine(g.offscreen, x+1, y+1, x+b-2, y+b-2, 1, color.Black, true)
+		vector.StrokeLine(g.offscreen, x+b-2, y+1, x+1, y+b-2, 1, color.Black, true)
+	}
+}
+
+func (g *Game) drawLevels(dst *ebiten.Image) {
+	l := &g.levels
+	line := g.hudFace.Size * 1.25
+
+	vector.DrawFilledRect(dst, 0, 0, float32(g.screenWidth), float32(g.screenHeight), color.RGBA{0, 0, 0, 230}, false)
+
+	y := 6.0
+	draw := func(s string, x float64, c color.Color) {
+		op := &text.DrawOptions{}
+		op.GeoM.Translate(x, y)
+		op.ColorScale.ScaleWithColor(c)
+		text.Draw(dst, s, g.hudFace, op)
+	}
+
+	tabs := "Installed"
+	if g.maps != nil {
+		tabs = "[Installed]  Online"
+		if l.tab == onlineTab {
+			tabs = "Installed  [Online]"
+		}
+	}
+	draw("Levels  "+tabs, 8, color.White)
+	y += line * 1.5
+
+	es := l.entries()
+	first := max(0, min(l.selected-levelRows/2, len(es)-levelRows))
+	for i := first; i < min(first+levelRows, len(es)); i++ {
+		m := es[i]
+
+		c := color.Color(color.Gray{160})
+		if i == l.selected {
+			c = color.White
+			draw(">", 8, c)
+		}
+
+		if m == nil {
+			draw("Classic (no walls)", 20, c)
+		} else {
+			draw(m.Name+" by "+m.Author, 20, c)
+			if m.Votes > 0 {
+				stars := fmt.Sprintf("%.1f*", m.Rating)
+				w, _ := text.Measure(stars, g.hudFace, 0)
+				draw(stars, g.screenWidth-8-w, c)
+			}
+		}
+		y += line
+	}
+	if len(es) == 0 && l.status == "" {
+		draw("No maps yet", 20, color.Gray{160})
+	}
+
+	y = g.screenHeight - 8 - line*2
+	draw(l.status, 8, color.Gray{200})
+	y += line
+
+	hints := []string{"Enter play", g.keymap.label(actionLevels) + " close"}
+	if g.maps != nil {
+		hints = append(hints, "1-5 rate", "←→ tab")
+	}
+	draw(strings.Join(hints, ", "), 8, color.Gray{200})
+}
@@ -0,0 +1,78 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+)
+
+// respawnDelay is the countdown before a snake that lost a life moves again.
+const respawnDelay = 3 * time.Second
+
+// countdownTexts are the seconds of the countdown, formatted once.
+var countdownTexts = [...]string{"1", "2", "3"}
+
+// lifeColor is for the lives left in the bottom right corner.
+var lifeColor = color.RGBA{230, 60, 80, 255}
+
+// SetLives sets the crashes a run takes from the current game on, 0 or 1
+// for the classic single one. Until the last, the snake comes back at the
+// start after a countdown, keeping the score. The hardcore takes one.
+func (g *Game) SetLives(n int) {
+	g.lives = n
+	if g.hardcore {
+		n = 1
+	}
+	if g.core != nil {
+		g.core.Lives = n
+		g.core.Deaths = min(g.core.Deaths, max(n, 1)-1)
+	}
+}
+
+// SetGrowth sets the segments a food adds from the current game on, 0 or 1
+// for the classic single one. They come one a step, the tail staying put.
+func (g *Game) SetGrowth(n int) {
+	g.growth = n
+	if g.core != nil {
+		g.core.Growth = n
+	}
+}
+
+// drawLives draws a mark per life left in the bottom right corner, when
+// there's more than one to a run.
+func (g *Game) drawLives() {
+	if g.core.Lives <= 1 {
+		return
+	}
+	const size = 5
+	for i := range g.core.LivesLeft() {
+		x := float32(g.screenWidth) - 5 - float32((i+1)*(size+3))
+		vector.DrawFilledRect(g.offscreen, x, float32(g.screenHeight)-8-size, size, size, lifeColor, true)
+	}
+}
+
+// drawCountdown draws the seconds left before the snake moves again.
+func (g *Game) drawCountdown() {
+	if g.countdown <= 0 {
+		return
+	}
+	tps := g.clock.TPS()
+	i := min((g.countdown+tps-1)/tps, len(countdownTexts)) - 1
+	msg := countdownTexts[i]
+	w, h := text.Measure(msg, mplusBigFace, 0)
+	text.Draw(g.offscreen, msg, mplusBigFace, g.textOptions((g.screenWidth-w)/2, (g.screenHeight-h)/2))
+}
@@ -0,0 +1,82 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"flag"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+// graphics is set when the tests run in ebiten's game loop, see TestMain.
+var graphics bool
+
+// TestMain runs the tests in ebiten's game loop, the only place it draws
+// off-screen images and reads pixels back, so a small window is opened for
+// the time of the tests. Keep it focused, an unfocused game pauses. Without
+// a display, as on a headless CI, they run as usual and those drawing are
+// skipped.
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if !display() {
+		os.Exit(m.Run())
+	}
+
+	ebiten.SetWindowTitle("snakegame tests")
+	ebiten.SetWindowSize(64, 64)
+	t := &tests{m: m, code: 1}
+	if err := ebiten.RunGame(t); err != nil {
+		panic(err)
+	}
+	os.Exit(t.code)
+}
+
+// display reports whether there's a display to open the window on, none on
+// Linux and the BSDs without an X or a Wayland server.
+func display() bool {
+	switch runtime.GOOS {
+	case "linux", "freebsd", "netbsd", "openbsd", "dragonfly":
+		return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+	}
+	return true
+}
+
+// needGraphics skips a test or a benchmark that draws when there's no game
+// loop to draw in.
+func needGraphics(tb testing.TB) {
+	tb.Helper()
+	if !graphics {
+		tb.Skip("no display to draw on")
+	}
+}
+
+// tests runs the tests in its first update.
+type tests struct {
+	m    *testing.M
+	code int
+}
+
+func (t *tests) Update() error {
+	graphics = true
+	t.code = t.m.Run()
+	return ebiten.Termination
+}
+
+func (t *tests) Draw(screen *ebiten.Image) {}
+
+func (t *tests) Layout(outsideWidth, outsideHeight int) (int, int) {
+	return outsideWidth, outsideHeight
+}
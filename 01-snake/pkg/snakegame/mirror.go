@@ -0,0 +1,65 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// mirrorColor is for the mirror power-up and the HUD telling the controls
+// are mirrored.
+var mirrorColor = color.RGBA{190, 90, 255, 255}
+
+// mirrorLabel tells the controls are mirrored, in the HUD.
+const mirrorLabel = "Mirrored"
+
+// SetMirror swaps the controls for the runs from the current one on, left
+// for right and up for down, as the mirror power-up does for a while. The
+// start screen toggles it too. The snake is steered the other way, the
+// replays recording where it went.
+func (g *Game) SetMirror(on bool) {
+	g.mirror = on
+}
+
+// mirrored reports whether the player's turns go the other way, from the
+// modifier or the power-up. A bot or the chat steering isn't mirrored.
+func (g *Game) mirrored() bool {
+	if g.start.open {
+		return false
+	}
+	return g.mirror || (g.core != nil && g.core.IsActive(snake.Mirror))
+}
+
+// drawMirror tells the controls are mirrored at the top of the board, under
+// the difficulty, blinking in the last steps of the power-up. The bottom
+// line is the notices'.
+func (g *Game) drawMirror() {
+	if !g.mirrored() || g.gameOver {
+		return
+	}
+	if !g.mirror {
+		for _, a := range g.core.Active {
+			if a.Effect == snake.Mirror && a.Left <= timedHurry && !g.blinkOn(a.Left) {
+				return
+			}
+		}
+	}
+	w, h := text.Measure(mirrorLabel, g.hudFace, 0)
+	op := g.textOptions((g.screenWidth-w)/2, 3+h+2)
+	op.ColorScale.ScaleWithColor(mirrorColor)
+	text.Draw(g.offscreen, mirrorLabel, g.hudFace, op)
+}
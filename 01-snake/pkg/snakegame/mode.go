@@ -0,0 +1,133 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// GameMode is the rules a run is played by, on top of the border and the
+// level.
+type GameMode int
+
+const (
+	// FreeMode is that of the runs no mode was picked for, a level, the
+	// mazes or a bot's: the border and the speed-up are those set, by the
+	// flags and the settings or when embedding.
+	FreeMode GameMode = iota
+	// ClassicMode plays until the snake crashes, into the border too, at a
+	// fixed speed.
+	ClassicMode
+	// EndlessMode takes the snake through the border to the opposite edge,
+	// faster and faster as the score grows.
+	EndlessMode
+	// TimeAttackMode plays against the clock, see timeAttackLength.
+	TimeAttackMode
+	// ZenMode never crashes the snake: it stops for a beat in front of what
+	// it runs into. The score isn't shown and the palette slowly shifts,
+	// for relaxing, the young ones or a demo.
+	ZenMode
+	// DailyMode is the classic mode on the board and with the food of the
+	// day, the same for every player, see dailySeed. The game over screen
+	// shows a code of the score to compare.
+	DailyMode
+	// FogMode is the classic mode in the dark, only the cells near the
+	// head seen, see fogRadius.
+	FogMode
+)
+
+// GameModes lists the modes to pick from, FreeMode aside.
+var GameModes = []GameMode{ClassicMode, EndlessMode, TimeAttackMode, ZenMode, DailyMode, FogMode}
+
+var gameModeNames = [...]string{
+	FreeMode:       "Free",
+	ClassicMode:    "Classic",
+	EndlessMode:    "Endless",
+	TimeAttackMode: "Time attack",
+	ZenMode:        "Zen",
+	DailyMode:      "Daily",
+	FogMode:        "Fog",
+}
+
+func (m GameMode) String() string {
+	if m < 0 || int(m) >= len(gameModeNames) {
+		return fmt.Sprintf("GameMode(%d)", int(m))
+	}
+	return gameModeNames[m]
+}
+
+// GameModeByName looks a mode to pick up by name, in any case and with
+// dashes for spaces, "" being FreeMode.
+func GameModeByName(name string) (GameMode, error) {
+	if name == "" {
+		return FreeMode, nil
+	}
+	name = strings.ReplaceAll(name, "-", " ")
+	for _, m := range GameModes {
+		if strings.EqualFold(name, m.String()) {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown mode %q, expected classic, endless, time-attack, zen, daily or fog", name)
+}
+
+// SetMode sets the rules of the runs from the current one on, the border of
+// the classic and endless modes with them. The start screen picks it, M
+// switches between them while paused and on the game over screen; the clock
+// of a time attack keeps the time it has left until the next run. The daily
+// challenge starts on the day's board right away unless a run is going on.
+func (g *Game) SetMode(m GameMode) {
+	g.mode = m
+	switch m {
+	case ClassicMode:
+		g.SetBorder(snake.BorderSolid)
+	case EndlessMode:
+		g.SetBorder(snake.BorderWrap)
+	case TimeAttackMode:
+		if g.timeLeft <= 0 {
+			g.resetClock()
+		}
+	case ZenMode:
+		g.SetBorder(snake.BorderWrap)
+	case FogMode:
+		g.SetBorder(snake.BorderSolid)
+	case DailyMode:
+		g.SetBorder(snake.BorderSolid)
+		if g.core != nil && g.run.steps == 0 && !g.paused && !g.gameOver {
+			g.playLayout(nil, nil, nil, snake.Tiles{})
+		}
+	}
+	if g.core != nil {
+		g.core.Zen = m == ZenMode
+	}
+}
+
+// speedFactor returns how much faster than the starting speed the snake
+// moves at score, the classic, zen, daily and fog modes keeping the pace.
+func (g *Game) speedFactor(score int) float64 {
+	if g.mode == ClassicMode || g.mode == ZenMode || g.mode == DailyMode || g.mode == FogMode {
+		return 1
+	}
+	return g.speedUp.factor(score)
+}
+
+// cycleMode switches to the next mode, telling which it is.
+func (g *Game) cycleMode() {
+	i := slices.Index(GameModes, g.mode)
+	g.SetMode(GameModes[(i+1)%len(GameModes)])
+	g.notify("Mode: " + g.mode.String())
+}
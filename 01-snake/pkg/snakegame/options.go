@@ -0,0 +1,104 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"math/rand/v2"
+
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// Option configures a Game created by NewGame.
+type Option func(*Game)
+
+// WithClock makes the game keep time with c instead of the wall clock.
+func WithClock(c Clock) Option {
+	return func(g *Game) {
+		g.clock = c
+	}
+}
+
+// WithRand makes the game draw the seeds of its boards from r, e.g. one
+// seeded for a replay.
+func WithRand(r *rand.Rand) Option {
+	return func(g *Game) {
+		g.rng = r
+	}
+}
+
+// WithSeed makes the boards follow from seed, the same every run.
+func WithSeed(seed uint64) Option {
+	return WithRand(rand.New(rand.NewPCG(seed, seed)))
+}
+
+// WithSpeed sets how many cells per second the snake moves, see SetSpeed.
+func WithSpeed(cellsPerSecond float64) Option {
+	return func(g *Game) {
+		g.SetSpeed(cellsPerSecond)
+	}
+}
+
+// WithDifficulty sets the pace of the snake to a preset, see SetDifficulty.
+func WithDifficulty(d Difficulty) Option {
+	return func(g *Game) {
+		g.SetDifficulty(d)
+	}
+}
+
+// WithSpeedUp sets how the snake speeds up as the score grows, see
+// SetSpeedUp.
+func WithSpeedUp(s SpeedUp) Option {
+	return func(g *Game) {
+		g.SetSpeedUp(s)
+	}
+}
+
+// WithBorder sets what the edge of the board does to the snake, see
+// SetBorder.
+func WithBorder(b snake.Border) Option {
+	return func(g *Game) {
+		g.SetBorder(b)
+	}
+}
+
+// WithController lets c steer the snake, see SetController.
+func WithController(c input.Controller) Option {
+	return func(g *Game) {
+		g.SetController(c)
+	}
+}
+
+// WithBoardSize makes the board columns by rows cells rather than the
+// classic 39 by 29, the screen growing or shrinking to fit.
+func WithBoardSize(columns, rows int) Option {
+	return func(g *Game) {
+		g.width, g.height = columns-1, rows-1
+	}
+}
+
+// WithCellSize draws the cells px pixels wide rather than DefaultCellSize,
+// the screen growing or shrinking to fit.
+func WithCellSize(px int) Option {
+	return func(g *Game) {
+		g.box = px
+	}
+}
+
+// WithCore plays c instead of a new game, e.g. a state set up for a
+// benchmark.
+func WithCore(c *snake.Game) Option {
+	return func(g *Game) {
+		g.core = c
+	}
+}
@@ -0,0 +1,66 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"image/color"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/particles"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+const (
+	// particleDrag is how much of their velocity the particles lose in a
+	// second, about.
+	particleDrag = 5
+	// burstParticles fly off the food eaten, as fast as burstSpeed cells a
+	// second, for up to burstLife.
+	burstParticles = 12
+	burstSpeed     = 8
+	burstLife      = 600 * time.Millisecond
+	// trailParticles rise from each cell the crashed snake shrinks off.
+	trailParticles = 4
+	trailSpeed     = 2
+	trailLife      = 800 * time.Millisecond
+)
+
+// burstColor and trailColor color the particles of the food eaten and of
+// the crashed snake shrinking.
+var (
+	burstColor = color.RGBA{255, 40, 40, 255}
+	trailColor = color.Gray{150}
+)
+
+// emitParticles spawns n particles flying off the middle of the cell p.
+func (g *Game) emitParticles(p snake.Point, n int, speed float32, life time.Duration, c color.Color) {
+	g.particles.Drag = g.perTick(particleDrag)
+	half := float32(g.box-1) / 2
+	g.particles.Emit(float32(5+p.X*g.box)+half, float32(5+p.Y*g.box)+half, particles.Burst{
+		N:     n,
+		Speed: g.perTick(speed * float32(g.box)),
+		Life:  g.ticks(life),
+		Size:  max(float32(g.box)/6, 1),
+		Color: c,
+	})
+}
+
+// burstFood bursts the food eaten at p.
+func (g *Game) burstFood(p snake.Point) {
+	g.emitParticles(p, burstParticles, burstSpeed, burstLife, burstColor)
+}
+
+// trailCrash leaves a trail of the crashed snake shrinking off p.
+func (g *Game) trailCrash(p snake.Point) {
+	g.emitParticles(p, trailParticles, trailSpeed, trailLife, trailColor)
+}
@@ -0,0 +1,113 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"image"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// The eyes of the flat head, white with a black pupil.
+var (
+	eyeColor   = color.White
+	pupilColor = color.Black
+)
+
+// whiteImage is the source of the filled shapes, their vertices coloring it.
+var whiteImage = func() *ebiten.Image {
+	img := ebiten.NewImage(3, 3)
+	img.Fill(color.White)
+	return img.SubImage(image.Rect(1, 1, 2, 2)).(*ebiten.Image)
+}()
+
+// segmentSides returns the sides, clockwise from up, of segment i of g's
+// snake that the segments before and after it are next to, -1 where there
+// is none or it isn't a neighbour, after a crash. The front of the head is
+// where it faces: away from the next segment, or where it's heading.
+func segmentSides(g *snake.Game, i int) (front, back int) {
+	body := &g.Snake
+	p := body.At(i)
+	n := body.Len()
+
+	front, back = -1, -1
+	if i < n-1 {
+		back = side(g, p, body.At(i+1))
+	}
+	if i > 0 {
+		return side(g, p, body.At(i-1)), back
+	}
+
+	switch d := input.DirOf(g.Direction.X, g.Direction.Y); {
+	case back >= 0:
+		front = (back + 2) % 4
+	case d != input.None:
+		front = int(d - input.Up)
+	default:
+		front = 0
+	}
+	return front, back
+}
+
+// turned returns (u, v), a point in a cell of size b drawn facing up, turned
+// to face the side s, clockwise from up.
+func turned(u, v, b float32, s int) (float32, float32) {
+	for range s {
+		u, v = b-v, u
+	}
+	return u, v
+}
+
+// drawHead draws the flat head on dst at (x, y), a square of color c with
+// two eyes looking to the side front.
+func (g *Game) drawHead(dst *ebiten.Image, x, y float32, front int, c color.Color) {
+	b := float32(g.box - 1)
+	vector.DrawFilledRect(dst, x, y, b, b, c, true)
+
+	eye, pupil := max(b/6, 1), max(b/12, 0.5)
+	for _, u := range []float32{b * 0.3, b * 0.7} {
+		ex, ey := turned(u, b*0.35, b, front)
+		px, py := turned(u, b*0.35-eye/2, b, front)
+		vector.DrawFilledCircle(dst, x+ex, y+ey, eye, eyeColor, true)
+		vector.DrawFilledCircle(dst, x+px, y+py, pupil, pupilColor, true)
+	}
+}
+
+// tailIndices are the two triangles of the tail, its corners in order.
+var tailIndices = []uint16{0, 1, 2, 0, 2, 3}
+
+// drawTail draws the flat tail on dst at (x, y) in color c, the full width
+// on the side front, joined to the body, tapering to a third of it on the
+// far one.
+func (g *Game) drawTail(dst *ebiten.Image, x, y float32, front int, c color.Color) {
+	b := float32(g.box - 1)
+	r, gr, bl, a := c.RGBA()
+	for i, pt := range [...][2]float32{{0, 0}, {b, 0}, {b * 2 / 3, b}, {b / 3, b}} {
+		u, v := turned(pt[0], pt[1], b, front)
+		g.tail[i] = ebiten.Vertex{
+			DstX: x + u, DstY: y + v,
+			SrcX: 1, SrcY: 1,
+			ColorR: float32(r) / 0xffff,
+			ColorG: float32(gr) / 0xffff,
+			ColorB: float32(bl) / 0xffff,
+			ColorA: float32(a) / 0xffff,
+		}
+	}
+	g.tailOp.AntiAlias = true
+	dst.DrawTriangles(g.tail[:], tailIndices, whiteImage, &g.tailOp)
+}
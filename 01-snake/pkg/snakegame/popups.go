@@ -0,0 +1,86 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+const (
+	// popupLife is how long a popup floats before it's gone, about 30
+	// frames.
+	popupLife = 500 * time.Millisecond
+	// popupRise is how high a popup floats in pixels.
+	popupRise = 12
+)
+
+// popupGain and popupLoss color the points won and lost.
+var (
+	popupGain = color.RGBA{255, 255, 255, 255}
+	popupLoss = color.RGBA{230, 60, 80, 255}
+)
+
+// popup is the points scored eating, floating up from where the food was
+// and fading out.
+type popup struct {
+	text string
+	at   snake.Point
+	loss bool
+	// age is in ticks
+	age int
+}
+
+// addPopup shows the points scored at p.
+func (g *Game) addPopup(p snake.Point, points int) {
+	if points == 0 {
+		return
+	}
+	g.popups = append(g.popups, popup{text: fmt.Sprintf("%+d", points), at: p, loss: points < 0})
+}
+
+// tickPopups ages the popups by a tick, dropping those gone.
+func (g *Game) tickPopups() {
+	kept := g.popups[:0]
+	for _, p := range g.popups {
+		if p.age++; p.age < g.ticks(popupLife) {
+			kept = append(kept, p)
+		}
+	}
+	g.popups = kept
+}
+
+// drawPopups draws the popups over the board.
+func (g *Game) drawPopups() {
+	life := float64(g.ticks(popupLife))
+	for _, p := range g.popups {
+		t := float64(p.age) / life
+		w, h := text.Measure(p.text, g.hudFace, 0)
+		x := 5 + float64(p.at.X*g.box+g.box/2) - w/2
+		y := 5 + float64(p.at.Y*g.box) - h/2 - t*popupRise
+
+		op := g.textOptions(x, y)
+		c := popupGain
+		if p.loss {
+			c = popupLoss
+		}
+		op.ColorScale.ScaleWithColor(c)
+		op.ColorScale.ScaleAlpha(float32(1 - t))
+		text.Draw(g.offscreen, p.text, g.hudFace, op)
+	}
+}
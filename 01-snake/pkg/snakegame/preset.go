@@ -0,0 +1,99 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+)
+
+// Preset bundles display and input defaults for a kind of device.
+type Preset struct {
+	Name string
+
+	Fullscreen bool
+	// WindowWidth and WindowHeight are the window size, fitted to the
+	// monitor if zero.
+	WindowWidth  int
+	WindowHeight int
+
+	// HUDScale multiplies the size of the HUD text.
+	HUDScale float64
+	// Gamepad shows gamepad prompts until another device is used.
+	Gamepad bool
+	// Autosave keeps the running game in a file so it survives the device
+	// being suspended and the game killed meanwhile.
+	Autosave bool
+}
+
+var (
+	DesktopPreset = Preset{
+		Name:     "desktop",
+		HUDScale: 1,
+	}
+
+	// DeckPreset targets the Steam Deck and similar handhelds.
+	DeckPreset = Preset{
+		Name:         "deck",
+		Fullscreen:   true,
+		WindowWidth:  1280,
+		WindowHeight: 800,
+		HUDScale:     1.5,
+		Gamepad:      true,
+		Autosave:     true,
+	}
+)
+
+// PresetByName looks a preset up by name, an empty name detects it from
+// the environment.
+func PresetByName(name string) (Preset, error) {
+	switch strings.ToLower(name) {
+	case "":
+		return DetectPreset(), nil
+	case DesktopPreset.Name:
+		return DesktopPreset, nil
+	case DeckPreset.Name:
+		return DeckPreset, nil
+	}
+	return Preset{}, fmt.Errorf("unknown preset %q, expected desktop or deck", name)
+}
+
+// DetectPreset picks the deck preset when running on a Steam Deck (Steam
+// sets SteamDeck=1 there), the desktop one otherwise.
+func DetectPreset() Preset {
+	if os.Getenv("SteamDeck") == "1" {
+		return DeckPreset
+	}
+	return DesktopPreset
+}
+
+// ApplyPreset applies the in-game part of p, the window is up to the caller.
+// With autosave on, a previously saved game is restored.
+func (g *Game) ApplyPreset(p Preset) {
+	g.hudFace = &text.GoTextFace{
+		Source: mplusFaceSource,
+		Size:   16 * p.HUDScale,
+	}
+
+	if p.Gamepad {
+		g.lastDevice = xbox
+	}
+
+	g.autosave = p.Autosave
+	if g.autosave {
+		g.restore()
+	}
+}
@@ -0,0 +1,245 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/replay"
+	"jhartman.pl/gamedev/pkg/settings"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// replaysDir keeps the runs recorded, next to the settings, one .replay file
+// per run named after when it ended.
+const replaysDir = "replays"
+
+// recording is the run being recorded. The recorder starts on the first
+// step, once the rules are set, and stops when the run ends or can't be
+// played back from its seed anymore.
+type recording struct {
+	// on is set while the core played from seed can be recorded
+	on    bool
+	seed  uint64
+	rules replay.Rules
+	rec   *replay.Recorder
+	// last is the last run recorded
+	last *replay.Replay
+}
+
+// playback is a recorded run played back, see PlayReplay.
+type playback struct {
+	r     *replay.Replay
+	turns []replay.Turn
+	step  int
+}
+
+// startRecording records the runs of the core just started from seed.
+func (g *Game) startRecording(seed uint64) {
+	g.recording = recording{on: true, seed: seed, last: g.recording.last}
+}
+
+// stopRecording drops the run being recorded, e.g. once the core was changed
+// behind the simulation's back.
+func (g *Game) stopRecording() {
+	g.recording = recording{last: g.recording.last}
+	g.ghost = nil
+}
+
+// recorder returns the recorder of the run, started on its first step, nil
+// when it isn't recorded. The levels of a campaign and the games against
+// others aren't.
+func (g *Game) recorder() *replay.Recorder {
+	r := &g.recording
+	if !r.on || g.playback != nil || g.campaign.levels != nil || g.versus != nil {
+		return nil
+	}
+	if r.rec == nil {
+		r.rules = replay.RulesOf(g.core)
+		r.rec = replay.NewRulesRecorder(r.seed, r.rules)
+		g.loadGhost(&r.rules)
+	}
+	return r.rec
+}
+
+// recordTurn records the direction the snake was just turned to.
+func (g *Game) recordTurn() {
+	if rec := g.recorder(); rec != nil {
+		rec.Turn(input.DirOf(g.core.Direction.X, g.core.Direction.Y))
+	}
+}
+
+// recordStep records the step just made, unless the rules changed midway,
+// e.g. the border while paused, which the replay can't tell.
+func (g *Game) recordStep() {
+	rec := g.recorder()
+	if rec == nil {
+		return
+	}
+	if !g.recording.rules.Same(g.core) || rec.Steps() >= replay.MaxSteps {
+		g.stopRecording()
+		return
+	}
+	rec.Step(g.core)
+	g.stepGhost()
+}
+
+// saveReplay writes the run just over to the replays directory, keeping it
+// as the last one for the leaderboard.
+func (g *Game) saveReplay() {
+	rec := g.recorder()
+	g.stopRecording()
+	if rec == nil || rec.Steps() == 0 {
+		return
+	}
+	r := rec.Replay()
+	g.recording.last = r
+
+	if err := writeReplay(time.Now().Format("2006-01-02-150405"), r); err != nil {
+		log.Printf("replay: %v", err)
+	}
+	g.keepBest(r)
+}
+
+// replayPath returns where the replay called name is kept.
+func replayPath(name string) (string, error) {
+	dir, err := settings.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, replaysDir, name+".replay"), nil
+}
+
+// writeReplay writes r to the replays directory as name.
+func writeReplay(name string, r *replay.Replay) error {
+	data, err := r.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	path, err := replayPath(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// lastReplay returns the encoded replay of the run just over, if the score
+// server can verify it: played under the default rules up to the crash or
+// the win.
+func (g *Game) lastReplay() []byte {
+	r := g.recording.last
+	if r == nil || !r.Rules.Default() || (g.core.State != snake.CRASHED && g.core.State != snake.WON) {
+		return nil
+	}
+	data, err := r.MarshalBinary()
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// ReadReplay reads a run saved in the replays directory, or anywhere else.
+func ReadReplay(name string) (*replay.Replay, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	var r replay.Replay
+	if err := r.UnmarshalBinary(data); err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return &r, nil
+}
+
+// PlayReplay plays r back on the board and under the rules it was recorded
+// with, the keyboard only pausing it. The game over screen shows once it's
+// over, the next run being played as usual.
+func (g *Game) PlayReplay(r *replay.Replay) {
+	g.mazes = mazes{}
+	g.stopCampaign()
+	if w, h := r.Rules.Board(); w != g.width || h != g.height {
+		g.width, g.height = w, h
+		g.resize()
+	}
+	g.SetBorder(r.Rules.Border)
+	g.SetWinLength(r.Rules.Target)
+	g.SetLives(r.Rules.Lives)
+	g.SetCombos(r.Rules.Combos)
+	g.SetGrowth(r.Rules.Growth)
+	g.SetChase(r.Rules.Chase)
+	g.SetArena(r.Rules.Arena)
+	g.playLayout(r.Rules.Walls, r.Rules.Portals, r.Rules.Obstacles, r.Rules.Tiles)
+
+	// the same game again, whatever the layout started it with
+	g.core = r.Rules.New(r.Seed)
+	g.daily = 0
+	g.stopRecording()
+	g.playback = &playback{r: r, turns: r.Turns}
+}
+
+// playbackTurn turns the snake as recorded before the next step.
+func (g *Game) playbackTurn() {
+	p := g.playback
+	for len(p.turns) > 0 && p.turns[0].Step == p.step {
+		g.core.Direction.X, g.core.Direction.Y = p.turns[0].Dir.Delta()
+		p.turns = p.turns[1:]
+	}
+}
+
+// playbackStep checks the step just played back against the recorded one,
+// ending the playback after the last or at the first not matching.
+func (g *Game) playbackStep() error {
+	p := g.playback
+	if len(p.r.Hashes) > 0 && replay.StepHash(g.core) != p.r.Hashes[p.step] {
+		g.notify("The replay went out of sync")
+		return g.endRun()
+	}
+	p.step++
+	if p.step >= p.r.Steps && g.core.State != snake.CRASHED && g.core.State != snake.WON {
+		// out of time, or cut short
+		return g.endRun()
+	}
+	return nil
+}
+
+// endPlayback shows the game over screen once the replay is over, without
+// recording anything.
+func (g *Game) endPlayback() {
+	g.playback = nil
+	g.run = run{}
+	g.turns.clear()
+	g.gameOver = true
+	g.gameOverTimer = g.ticks(gameOverDelay)
+	g.runSummary, g.shareCode = "", ""
+	g.finalScore = fmt.Sprintf("Replay over, score %d", g.core.Score)
+}
+
+// nextRun starts the run after the game over screen on the same board and
+// under the same rules, from a new seed so it can be recorded. A campaign
+// plays its level on.
+func (g *Game) nextRun() {
+	if g.campaign.levels != nil {
+		g.core.Restart()
+		g.restartClock()
+		return
+	}
+	g.playLayout(g.core.Walls, g.core.Portals, g.core.Obstacles, g.core.Tiles)
+}
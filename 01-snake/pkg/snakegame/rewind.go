@@ -0,0 +1,178 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+const (
+	// rewindTicks is how far back the rewind goes, 5 seconds at 60 TPS.
+	rewindTicks = 300
+	// rewindUses are the rewinds a run takes.
+	rewindUses = 3
+	// rewindOffer is how long the rewind is offered after the crash, the
+	// game being over once it's gone.
+	rewindOffer = 2 * time.Second
+)
+
+// rewindSnapshot is the game right before a step.
+type rewindSnapshot struct {
+	core snake.Game
+	// tick it was taken on, see rewind.tick
+	tick     int
+	timeLeft int
+	// eaten is the food eaten, see speedrun
+	eaten int
+}
+
+// rewind keeps the game of the last rewindTicks ticks for the player to go
+// back to after a crash, see SetRewind.
+type rewind struct {
+	on bool
+	// snapshots is a ring of n taken before the steps, the last at
+	// (first+n-1) % len. They keep their memory from a run to the next.
+	snapshots []rewindSnapshot
+	first, n  int
+	// tick counts the ticks of the run
+	tick int
+	// used are the rewinds used in the run
+	used int
+	// offer is the ticks left offering the rewind after a crash, rewinding
+	// is set while going back
+	offer     int
+	rewinding bool
+}
+
+// SetRewind lets the player hold Backspace after a crash to go back up to
+// 5 seconds, rewindUses times a run. The snake starts again where it's let
+// go, after a countdown. A run rewound isn't recorded.
+func (g *Game) SetRewind(on bool) {
+	g.rewind.on = on
+	g.resetRewind()
+}
+
+// resetRewind forgets the snapshots and the rewinds used, for a new run.
+func (g *Game) resetRewind() {
+	r := &g.rewind
+	r.first, r.n, r.tick, r.used, r.offer, r.rewinding = 0, 0, 0, 0, 0, false
+}
+
+// canRewind reports whether the rewind is there to be used: playing alone,
+// not a campaign whose quotas would count the food twice, with rewinds left.
+func (g *Game) canRewind() bool {
+	r := &g.rewind
+	return r.on && r.used < rewindUses && g.versus == nil && g.playback == nil &&
+		g.campaign.levels == nil && g.controller == nil && !g.hardcore
+}
+
+// snapshot keeps the game as it is before the step about to be made.
+func (g *Game) snapshot() {
+	r := &g.rewind
+	if !g.canRewind() {
+		return
+	}
+	if r.snapshots == nil {
+		// a step per tick at most
+		r.snapshots = make([]rewindSnapshot, rewindTicks)
+	}
+
+	// too old to go back to
+	for r.n > 0 && r.tick-r.snapshots[r.first].tick >= rewindTicks {
+		r.first = (r.first + 1) % len(r.snapshots)
+		r.n--
+	}
+	if r.n == len(r.snapshots) {
+		r.first = (r.first + 1) % len(r.snapshots)
+		r.n--
+	}
+
+	s := &r.snapshots[(r.first+r.n)%len(r.snapshots)]
+	g.core.CopyTo(&s.core)
+	s.tick, s.timeLeft, s.eaten = r.tick, g.timeLeft, g.speedrun.eaten
+	r.n++
+}
+
+// offerRewind offers the rewind after the crash that just happened,
+// reporting whether it's on offer rather than the game being over.
+func (g *Game) offerRewind() bool {
+	r := &g.rewind
+	if !g.canRewind() || r.n == 0 {
+		return false
+	}
+	r.offer = g.ticks(rewindOffer)
+	g.turns.clear()
+	return true
+}
+
+// updateRewind handles a tick of the offer and of the rewind: holding the
+// key goes back a step per tick, letting it go starts the countdown.
+func (g *Game) updateRewind() error {
+	r := &g.rewind
+	held := g.keymap.pressed(actionRewind)
+
+	if !r.rewinding {
+		if !held {
+			if r.offer--; r.offer <= 0 {
+				return g.endRun()
+			}
+			return nil
+		}
+		r.rewinding, r.offer = true, 0
+		r.used++
+		// the replay can't go back in time
+		g.stopRecording()
+	}
+
+	if held && r.n > 0 {
+		r.n--
+		s := &r.snapshots[(r.first+r.n)%len(r.snapshots)]
+		s.core.CopyTo(g.core)
+		g.timeLeft = s.timeLeft
+		g.rewindSpeedrun(s.eaten)
+		r.tick = s.tick
+		g.stepAcc = 0
+		g.forgetCells()
+		g.popups = g.popups[:0]
+		g.particles.Clear()
+		return nil
+	}
+
+	// let go, or as far back as it goes
+	r.rewinding = false
+	g.countdown = g.ticks(respawnDelay)
+	g.turns.clear()
+	return nil
+}
+
+// drawRewind tells how to rewind while it's offered, and that it's going
+// back meanwhile.
+func (g *Game) drawRewind() {
+	r := &g.rewind
+	var msg string
+	switch {
+	case r.rewinding:
+		msg = "◀◀"
+	case r.offer > 0:
+		msg = fmt.Sprintf("Hold %s to rewind (%d left)", g.keymap.label(actionRewind), rewindUses-r.used)
+	default:
+		return
+	}
+	w, h := text.Measure(msg, g.hudFace, 0)
+	text.Draw(g.offscreen, msg, g.hudFace, g.textOptions((g.screenWidth-w)/2, (g.screenHeight-h)/2))
+}
@@ -0,0 +1,67 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import "jhartman.pl/gamedev/pkg/snake"
+
+// smooth slides the snake from cell to cell rather than jumping, see
+// SetSmooth.
+type smooth struct {
+	on bool
+	// from are the cells of the segments before the last step, head first
+	from []snake.Point
+}
+
+// SetSmooth slides the snake between the cells as it moves: each segment is
+// drawn on the way from where it was before the last step to where it is,
+// as far as the next step is near. The snake is drawn a step behind, the
+// rules still playing on the cells.
+func (g *Game) SetSmooth(on bool) {
+	g.smooth = smooth{on: on, from: g.smooth.from[:0]}
+	g.dirty = true
+}
+
+// keepCells keeps where the segments are before a step.
+func (g *Game) keepCells() {
+	s := &g.smooth
+	s.from = s.from[:0]
+	if !s.on {
+		return
+	}
+	for _, p := range g.core.Snake.All() {
+		s.from = append(s.from, p)
+	}
+}
+
+// forgetCells drops the cells kept, the snake having moved otherwise than
+// by a step.
+func (g *Game) forgetCells() {
+	g.smooth.from = g.smooth.from[:0]
+}
+
+// segmentPos returns where segment i, on cell v, is drawn: the top left
+// corner of the cell, or on the way there from the one it was on. It jumps
+// through the portals and the wrapping edge.
+func (g *Game) segmentPos(i int, v snake.Point) (x, y float32) {
+	x, y = float32(5+v.X*g.box), float32(5+v.Y*g.box)
+	s := &g.smooth
+	if !s.on || i >= len(s.from) {
+		return x, y
+	}
+	from := s.from[i]
+	if abs(from.X-v.X)+abs(from.Y-v.Y) != 1 {
+		return x, y
+	}
+	left := float32(g.box) * (1 - min(max(g.stepAcc, 0), 1))
+	return x + float32(from.X-v.X)*left, y + float32(from.Y-v.Y)*left
+}
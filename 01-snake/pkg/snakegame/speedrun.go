@@ -0,0 +1,148 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+	"image/color"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// splitEvery is the food eaten between two splits of the speedrun timer.
+const splitEvery = 10
+
+// splitColor is the color of the last split, under the timer.
+var splitColor = color.Gray{160}
+
+// speedrun times the run on the clock rather than by counting ticks, so a
+// slow frame doesn't slow it down, see SetSpeedrun.
+type speedrun struct {
+	on bool
+	// elapsed is the time the run has been going, pauses and menus
+	// excluded
+	elapsed time.Duration
+	// eaten is the food eaten in the run, splits the elapsed time at every
+	// splitEvery of them
+	eaten  int
+	splits []time.Duration
+
+	// the timer and the last split as drawn, formatted again only when
+	// they change
+	text, splitText string
+	shown           time.Duration
+}
+
+// SetSpeedrun shows a timer of the run in the top right corner, split every
+// 10 foods eaten. The final time and the splits go to the statistics.
+func (g *Game) SetSpeedrun(on bool) {
+	g.speedrun.on = on
+	g.resetSpeedrun()
+}
+
+// resetSpeedrun starts the timer over, for a new run.
+func (g *Game) resetSpeedrun() {
+	s := &g.speedrun
+	s.elapsed, s.eaten = 0, 0
+	s.splits = s.splits[:0]
+	s.text, s.splitText = "", ""
+}
+
+// tickSpeedrun adds the time since the last update, dt as read from the
+// monotonic clock, while the run goes on.
+func (g *Game) tickSpeedrun(dt time.Duration) {
+	if !g.speedrun.on || g.core.State == snake.WON || dt <= 0 || dt > suspendGap {
+		return
+	}
+	g.speedrun.elapsed += dt
+}
+
+// splitSpeedrun counts the food just eaten, splitting the time at every
+// splitEvery of them.
+func (g *Game) splitSpeedrun() {
+	s := &g.speedrun
+	if !s.on {
+		return
+	}
+	if s.eaten++; s.eaten%splitEvery != 0 {
+		return
+	}
+	s.splits = append(s.splits, s.elapsed)
+	s.splitText = fmt.Sprintf("%d: %s", s.eaten, formatSplit(s.elapsed))
+}
+
+// rewindSpeedrun takes the splits back to the food eaten at the time gone
+// back to, the clock running on.
+func (g *Game) rewindSpeedrun(eaten int) {
+	s := &g.speedrun
+	s.eaten = eaten
+	if n := eaten / splitEvery; n < len(s.splits) {
+		s.splits = s.splits[:n]
+		s.splitText = ""
+	}
+}
+
+// speedrunSeconds returns the final time and the splits of the run in
+// seconds, for the statistics, zero without the timer.
+func (g *Game) speedrunSeconds() (float64, []float64) {
+	s := &g.speedrun
+	if !s.on {
+		return 0, nil
+	}
+	splits := make([]float64, len(s.splits))
+	for i, d := range s.splits {
+		splits[i] = d.Seconds()
+	}
+	return s.elapsed.Seconds(), splits
+}
+
+// formatSplit formats a time as minutes, seconds and hundredths.
+func formatSplit(d time.Duration) string {
+	cs := int(d / (10 * time.Millisecond))
+	return fmt.Sprintf("%d:%02d.%02d", cs/6000, cs/100%60, cs%100)
+}
+
+// drawSpeedrun draws the timer in the top right corner, below what the
+// controller and the clock of a time attack show there, the last split
+// under it.
+func (g *Game) drawSpeedrun() {
+	s := &g.speedrun
+	if !s.on || g.mode == ZenMode {
+		return
+	}
+	if shown := s.elapsed.Truncate(10 * time.Millisecond); s.text == "" || shown != s.shown {
+		s.text = formatSplit(shown)
+		s.shown = shown
+	}
+
+	line := g.hudFace.Size * 1.25
+	y := 3.0
+	if _, ok := g.controller.(hudder); ok {
+		y += line
+	}
+	if g.mode == TimeAttackMode {
+		y += line
+	}
+	w, _ := text.Measure(s.text, g.hudFace, 0)
+	text.Draw(g.offscreen, s.text, g.hudFace, g.textOptions(g.screenWidth-5-w, y))
+
+	if s.splitText != "" {
+		w, _ := text.Measure(s.splitText, g.hudFace, 0)
+		op := g.textOptions(g.screenWidth-5-w, y+line)
+		op.ColorScale.ScaleWithColor(splitColor)
+		text.Draw(g.offscreen, s.splitText, g.hudFace, op)
+	}
+}
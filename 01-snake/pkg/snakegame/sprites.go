@@ -0,0 +1,186 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+	"image"
+	_ "image/png"
+	"os"
+
+	"github.com/hajimehoshi/ebiten/v2"
+	"jhartman.pl/gamedev/pkg/assets"
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// The built-in skins, see SetSkin.
+const (
+	// FlatSkin draws plain squares, the default.
+	FlatSkin = "flat"
+	// SpritesSkin draws the tiles of the bundled sprites.png.
+	SpritesSkin = "sprites"
+)
+
+// sides of a cell, as bits of the joins of a body piece
+const (
+	sideUp = 1 << iota
+	sideRight
+	sideDown
+	sideLeft
+)
+
+// sheetTiles is the number of tiles across and down a sprite sheet.
+const sheetTiles = 4
+
+// sprites are the tiles of a sprite sheet, sprites.png or one of the same
+// layout. The directions are indexed clockwise from up, like input.Dirs.
+type sprites struct {
+	// name is the skin they're of
+	name string
+	// size is the size of the tiles, scaled to the cells
+	size int
+	food *ebiten.Image
+	// head facing each direction
+	head [4]*ebiten.Image
+	// tail joined to the body on each side
+	tail [4]*ebiten.Image
+	// body joined on two sides, by the bits of the sides
+	body [16]*ebiten.Image
+}
+
+// loadSkin loads the sprite sheet of the skin name, the bundled one or a
+// PNG file.
+func loadSkin(name string) (*sprites, error) {
+	if name == SpritesSkin {
+		img, err := assets.Image("sprites.png")
+		if err != nil {
+			return nil, err
+		}
+		return loadSheet(name, img)
+	}
+
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("skin: %w", err)
+	}
+	defer f.Close()
+	src, _, err := image.Decode(f)
+	if err != nil {
+		return nil, fmt.Errorf("skin %s: %w", name, err)
+	}
+	s, err := loadSheet(name, ebiten.NewImageFromImage(src))
+	if err != nil {
+		return nil, fmt.Errorf("skin %s: %w", name, err)
+	}
+	return s, nil
+}
+
+// loadSheet cuts a sprite sheet laid out like sprites.png: 4 by 4 square
+// tiles of any size, in rows the head facing up, right, down and left, the
+// tail joined to the body on the same sides, the vertical and horizontal
+// body, the food and an unused tile, then the corners joining up and right,
+// right and down, down and left, left and up.
+func loadSheet(name string, img *ebiten.Image) (*sprites, error) {
+	b := img.Bounds()
+	size := b.Dx() / sheetTiles
+	if size == 0 || b.Dx() != size*sheetTiles || b.Dy() != size*sheetTiles {
+		return nil, fmt.Errorf("sheet of %dx%d pixels, expected %d by %d square tiles", b.Dx(), b.Dy(), sheetTiles, sheetTiles)
+	}
+	t := assets.Tiles(img, size)
+
+	s := &sprites{name: name, size: size, food: t[10]}
+	copy(s.head[:], t[0:4])
+	copy(s.tail[:], t[4:8])
+	s.body[sideUp|sideDown] = t[8]
+	s.body[sideLeft|sideRight] = t[9]
+	s.body[sideUp|sideRight] = t[12]
+	s.body[sideRight|sideDown] = t[13]
+	s.body[sideDown|sideLeft] = t[14]
+	s.body[sideLeft|sideUp] = t[15]
+	return s, nil
+}
+
+// SetSkin sets how the snake and the food are drawn: FlatSkin, plain
+// squares, SpritesSkin, the bundled sprites, or else the name of a PNG
+// sprite sheet laid out like them, see loadSheet. The pieces of the snake
+// are picked by where the segments next to them are. "" is the flat skin.
+func (g *Game) SetSkin(name string) error {
+	if name == "" || name == FlatSkin {
+		g.useSprites = false
+		g.dirty = true
+		return nil
+	}
+	if g.sprites == nil || g.sprites.name != name {
+		s, err := loadSkin(name)
+		if err != nil {
+			return err
+		}
+		g.sprites = s
+	}
+	g.useSprites = true
+	g.dirty = true
+	return nil
+}
+
+// SetSprites draws the snake and the food with the bundled sprites instead
+// of plain squares, see SetSkin.
+func (g *Game) SetSprites(on bool) error {
+	if !on {
+		return g.SetSkin(FlatSkin)
+	}
+	return g.SetSkin(SpritesSkin)
+}
+
+// side returns the index, clockwise from up, of the side of a that b is
+// next to, -1 if they aren't neighbours. Cells at opposite edges of g's
+// board are neighbours through them, as when the board wraps.
+func side(g *snake.Game, a, b snake.Point) int {
+	dx, dy := b.X-a.X, b.Y-a.Y
+	switch dx {
+	case g.Width:
+		dx = -1
+	case -g.Width:
+		dx = 1
+	}
+	switch dy {
+	case g.Height:
+		dy = -1
+	case -g.Height:
+		dy = 1
+	}
+
+	d := input.DirOf(dx, dy)
+	if d == input.None {
+		return -1
+	}
+	return int(d - input.Up)
+}
+
+// segmentSprite picks the piece for segment i of g's snake, by where its
+// neighbours are.
+func (s *sprites) segmentSprite(g *snake.Game, i int) *ebiten.Image {
+	front, back := segmentSides(g, i)
+	switch {
+	case i == 0:
+		return s.head[front]
+	case front < 0:
+		// on top of the previous segment, after a crash
+		return nil
+	case i == g.Snake.Len()-1:
+		return s.tail[front]
+	case back < 0 || front == back:
+		return nil
+	}
+	return s.body[1<<front|1<<back]
+}
@@ -0,0 +1,128 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"image/color"
+	"slices"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"jhartman.pl/gamedev/pkg/input"
+)
+
+// modeSummaries tell the modes apart on the start screen.
+var modeSummaries = [...]string{
+	ClassicMode:    "Solid border, steady pace",
+	EndlessMode:    "Wrapping border, faster and faster",
+	TimeAttackMode: "2 minutes, bonus food adds time",
+	ZenMode:        "No crashes, no score, just the snake",
+	DailyMode:      "Today's board, the same for everyone",
+	FogMode:        "Only what's near the head is seen",
+}
+
+// startScreen picks the mode before the first run.
+type startScreen struct {
+	open     bool
+	selected int
+}
+
+// ShowStartScreen opens the screen picking the mode of the runs, the
+// current one selected. The run waits for the pick; a run restored from the
+// autosave goes on instead, without it.
+func (g *Game) ShowStartScreen() {
+	if g.paused {
+		return
+	}
+	g.start = startScreen{open: true, selected: max(slices.Index(GameModes, g.mode), 0)}
+}
+
+// updateStart handles the start screen while it is open: up and down move
+// through the modes, however they're steered, confirming picks one.
+func (g *Game) updateStart(confirmed bool) {
+	s := &g.start
+	g.handleKeyboard()
+	for d, ok := g.turns.pop(); ok; d, ok = g.turns.pop() {
+		g.dirty = true
+		switch d {
+		case input.Up:
+			s.selected = max(s.selected-1, 0)
+		case input.Down:
+			s.selected = min(s.selected+1, len(GameModes)-1)
+		}
+	}
+
+	if g.keymap.justPressed(actionScores) {
+		g.openScores(-1)
+		return
+	}
+	if g.keymap.justPressed(actionMirror) {
+		g.SetMirror(!g.mirror)
+	}
+	if confirmed || g.keymap.justPressed(actionSelect) || g.keymap.justPressed(actionResume) {
+		s.open = false
+		g.SetMode(GameModes[s.selected])
+	}
+}
+
+// drawStart draws the modes over the dimmed board, the selected one
+// highlighted and summed up under them.
+func (g *Game) drawStart() {
+	vector.DrawFilledRect(g.offscreen, 0, 0, float32(g.screenWidth), float32(g.screenHeight), color.RGBA{0, 0, 0, 230}, false)
+
+	const title = "Snake"
+	tw, th := text.Measure(title, mplusBigFace, 0)
+	text.Draw(g.offscreen, title, mplusBigFace, g.textOptions((g.screenWidth-tw)/2, 6))
+
+	line := g.hudFace.Size * 1.25
+	y := 6 + th + 4
+	for i, m := range GameModes {
+		c := color.Color(color.Gray{160})
+		if i == g.start.selected {
+			c = color.White
+			op := g.textOptions(40, y)
+			op.ColorScale.ScaleWithColor(c)
+			text.Draw(g.offscreen, ">", g.hudFace, op)
+		}
+		op := g.textOptions(52, y)
+		op.ColorScale.ScaleWithColor(c)
+		text.Draw(g.offscreen, m.String(), g.hudFace, op)
+		y += line
+	}
+
+	// the summary of the selected one only, so all the modes fit
+	summary := modeSummaries[GameModes[g.start.selected]]
+	op := g.textOptions(52, y+4)
+	op.ColorScale.ScaleWithColor(color.Gray{120})
+	text.Draw(g.offscreen, summary, g.hudFace, op)
+	y += line + 8
+
+	msg := g.prompt("start")
+	if g.lastDevice == keyboard {
+		msg += ", " + g.keymap.label(actionScores) + " for high scores"
+
+		mirror := "off"
+		if g.mirror {
+			mirror = "on"
+		}
+		mirror = g.keymap.label(actionMirror) + " mirrored controls: " + mirror
+		w, _ := text.Measure(mirror, g.hudFace, 0)
+		op := g.textOptions((g.screenWidth-w)/2, y+4)
+		op.ColorScale.ScaleWithColor(color.Gray{160})
+		text.Draw(g.offscreen, mirror, g.hudFace, op)
+		y += line
+	}
+	w, _ := text.Measure(msg, g.hudFace, 0)
+	text.Draw(g.offscreen, msg, g.hudFace, g.textOptions((g.screenWidth-w)/2, y+4))
+}
@@ -0,0 +1,107 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/settings"
+	"jhartman.pl/gamedev/pkg/snake"
+	"jhartman.pl/gamedev/pkg/stats"
+)
+
+// run tracks the game being played for the statistics.
+type run struct {
+	start time.Time
+	ticks int
+	steps int
+	// wallHit is set once the snake hit a wall, any life
+	wallHit bool
+}
+
+func loadStats() *stats.Stats {
+	s, err := stats.Load()
+	if err != nil {
+		log.Printf("stats: %v", err)
+	}
+	return s
+}
+
+// statsTick counts the time played, called every unpaused tick.
+func (g *Game) statsTick() {
+	if g.core.State != snake.RUNNING {
+		return
+	}
+
+	if g.run.ticks == 0 {
+		g.run.start = g.clock.Now()
+	}
+	g.run.ticks++
+}
+
+// recordGame adds the game that just crashed, or was won, to the
+// statistics, returning its place in the table. The runs of a controller
+// don't go in the tables, as they don't go on the global boards.
+func (g *Game) recordGame() (place int, err error) {
+	timer, splits := g.speedrunSeconds()
+	game := stats.Game{
+		Start:    g.run.start,
+		Seconds:  float64(g.run.ticks) / float64(g.clock.TPS()),
+		Score:    g.core.Score,
+		Length:   g.core.Snake.Len(),
+		Steps:    g.run.steps,
+		Won:      g.core.State == snake.WON,
+		Time:     timer,
+		Splits:   splits,
+		Hardcore: g.hardcore,
+	}
+	place = -1
+	if g.controller == nil {
+		place = g.stats.Add(game)
+	} else {
+		g.stats.Record(game)
+	}
+	g.run = run{}
+
+	if err := g.stats.Save(); err != nil {
+		return place, fmt.Errorf("saving the statistics: %w", err)
+	}
+	return place, nil
+}
+
+// formatPlayed formats seconds played as minutes and seconds.
+func formatPlayed(seconds int) string {
+	return fmt.Sprintf("%d:%02d", seconds/60, seconds%60)
+}
+
+// exportStats writes the statistics to a new directory next to the
+// settings.
+func (g *Game) exportStats() {
+	dir, err := settings.Dir()
+	if err == nil {
+		dir = filepath.Join(dir, "exports", time.Now().Format("2006-01-02-150405"))
+		_, err = g.stats.Export(dir)
+	}
+
+	if err != nil {
+		log.Printf("stats: %v", err)
+		g.notify("Export failed")
+		return
+	}
+
+	log.Printf("stats: exported to %s", dir)
+	g.notify("Statistics exported")
+}
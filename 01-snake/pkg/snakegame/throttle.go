@@ -0,0 +1,87 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2"
+)
+
+const (
+	// time Draw may take, half a 60 Hz frame leaves room for the rest
+	drawBudget = 8 * time.Millisecond
+	// most frames skipped in a row
+	maxSkip = 3
+	// drawn frames between two decisions to skip more or less
+	throttleWindow = 60
+)
+
+// throttle skips drawing frames when Draw is consistently over budget, as
+// happens with wasm on low end machines. ebiten keeps calling Update at the
+// TPS, running several in a row when it's behind, so the simulation and the
+// input stay correct, only the animation gets choppier.
+type throttle struct {
+	// moving average of the Draw time
+	avg time.Duration
+	// frames skipped after each drawn one
+	skip    int
+	skipped int
+	drawn   int
+}
+
+// skipFrame tells whether the coming Draw should be skipped.
+func (t *throttle) skipFrame() bool {
+	if t.skipped < t.skip {
+		t.skipped++
+		return true
+	}
+	t.skipped = 0
+	return false
+}
+
+// record accounts a Draw that took d.
+func (t *throttle) record(d time.Duration) {
+	if t.avg == 0 {
+		t.avg = d
+	}
+	t.avg = (t.avg*9 + d) / 10
+
+	t.drawn++
+	if t.drawn < throttleWindow {
+		return
+	}
+	t.drawn = 0
+
+	switch {
+	case t.avg > drawBudget && t.skip < maxSkip:
+		t.skip++
+	case t.avg < drawBudget/2 && t.skip > 0:
+		// well under budget, not just under, or it would flip every window
+		t.skip--
+	default:
+		return
+	}
+
+	// skipped frames keep showing the last drawn one
+	ebiten.SetScreenClearedEveryFrame(t.skip == 0)
+}
+
+func (t *throttle) String() string {
+	s := fmt.Sprintf("draw %.1fms", float64(t.avg.Microseconds())/1000)
+	if t.skip > 0 {
+		s += fmt.Sprintf(", drawing 1 of %d frames", t.skip+1)
+	}
+	return s
+}
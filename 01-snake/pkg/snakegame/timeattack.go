@@ -0,0 +1,106 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/hajimehoshi/ebiten/v2/text/v2"
+
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+const (
+	// timeAttackLength is the time on the clock at the start of a time
+	// attack.
+	timeAttackLength = 2 * time.Minute
+	// bonusTime is added to the clock per point of the bonus foods: the
+	// food worth more than the plain one and the timed food.
+	bonusTime = time.Second
+	// clockWarning is the time left from which the clock turns red.
+	clockWarning = 10 * time.Second
+)
+
+// resetClock puts the time of a time attack back on the clock.
+func (g *Game) resetClock() {
+	g.timeLeft = g.ticks(timeAttackLength)
+}
+
+// tickClock counts a tick of a time attack down while the snake runs,
+// ending the run once the time is out.
+func (g *Game) tickClock() error {
+	if g.core.State != snake.RUNNING {
+		return nil
+	}
+	g.timeLeft--
+	if g.timeLeft > 0 {
+		return nil
+	}
+	g.outOfTime = true
+	return g.endRun()
+}
+
+// restartClock sets the clock for the next run once the game over screen is
+// dismissed. A run out of time starts over, the snake still being on the
+// board.
+func (g *Game) restartClock() {
+	if g.outOfTime {
+		g.playLayout(g.core.Walls, g.core.Portals, g.core.Obstacles, g.core.Tiles)
+	}
+	g.resetClock()
+}
+
+// addBonusTime adds time to the clock for the bonus food eaten in the last
+// step, food and timed being where the food and the timed food were before
+// it.
+func (g *Game) addBonusTime(food snake.Point, kind snake.FoodKind, timed *snake.Point) {
+	head := g.core.Snake.Head()
+	points := 0
+	if head == food && kind.Value() > snake.NormalFood.Value() {
+		points += kind.Value()
+	}
+	if timed != nil && head == *timed && g.core.Timed == nil {
+		points += snake.TimedValue
+	}
+	if points == 0 {
+		return
+	}
+	g.timeLeft += g.ticks(time.Duration(points) * bonusTime)
+	g.notify(fmt.Sprintf("+%d seconds", points))
+}
+
+// drawClock draws the time left of a time attack in the top right corner,
+// below what the controller shows there.
+func (g *Game) drawClock() {
+	if g.mode != TimeAttackMode {
+		return
+	}
+	tps := g.clock.TPS()
+	seconds := (max(g.timeLeft, 0) + tps - 1) / tps
+	if g.clockText == "" || seconds != g.clockShown {
+		g.clockText = formatPlayed(seconds)
+		g.clockShown = seconds
+	}
+
+	y := 3.0
+	if _, ok := g.controller.(hudder); ok {
+		y += g.hudFace.Size * 1.25
+	}
+	w, _ := text.Measure(g.clockText, g.hudFace, 0)
+	op := g.textOptions(g.screenWidth-5-w, y)
+	if g.timeLeft < g.ticks(clockWarning) {
+		op.ColorScale.ScaleWithColor(lifeColor)
+	}
+	text.Draw(g.offscreen, g.clockText, g.hudFace, op)
+}
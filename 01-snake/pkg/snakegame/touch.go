@@ -0,0 +1,90 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"github.com/hajimehoshi/ebiten/v2"
+	"github.com/hajimehoshi/ebiten/v2/inpututil"
+
+	"jhartman.pl/gamedev/pkg/input"
+)
+
+// minimal distance (in screen pixels) a finger has to travel to count as a swipe
+const swipeThreshold = 12
+
+type touchState struct {
+	ids     []ebiten.TouchID
+	id      ebiten.TouchID
+	active  bool
+	swiped  bool
+	originX int
+	originY int
+}
+
+// handleTouch turns swipes into direction changes. Only the first finger is
+// tracked and a single touch results in at most one turn. It reports whether
+// the touch was released without swiping, i.e. it was a tap.
+func (g *Game) handleTouch() bool {
+	t := &g.touch
+
+	if !t.active {
+		t.ids = inpututil.AppendJustPressedTouchIDs(t.ids[:0])
+		if len(t.ids) > 0 {
+			g.lastDevice = touchscreen
+
+			t.id = t.ids[0]
+			t.active = true
+			t.swiped = false
+			t.originX, t.originY = ebiten.TouchPosition(t.id)
+		}
+		return false
+	}
+
+	if inpututil.IsTouchJustReleased(t.id) {
+		t.active = false
+		return !t.swiped
+	}
+
+	if t.swiped || g.paused {
+		return false
+	}
+
+	x, y := ebiten.TouchPosition(t.id)
+	dx, dy := x-t.originX, y-t.originY
+
+	switch {
+	case abs(dx) < swipeThreshold && abs(dy) < swipeThreshold:
+		return false
+	case abs(dx) > abs(dy):
+		g.turn(input.DirOf(sign(dx), 0))
+	default:
+		g.turn(input.DirOf(0, sign(dy)))
+	}
+	t.swiped = true
+
+	return false
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func sign(v int) int {
+	if v < 0 {
+		return -1
+	}
+	return 1
+}
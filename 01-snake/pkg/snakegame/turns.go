@@ -0,0 +1,92 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// maxTurns is how many turns can wait for the snake to move, presses beyond
+// are dropped
+const maxTurns = 3
+
+// turnQueue holds the turns asked for and not made yet, oldest first. The
+// snake makes one per step, so pressing up then left within a step turns up
+// then left rather than just left.
+type turnQueue struct {
+	dirs [maxTurns]input.Dir
+	n    int
+}
+
+func (q *turnQueue) push(d input.Dir) bool {
+	if q.n == len(q.dirs) {
+		return false
+	}
+	q.dirs[q.n] = d
+	q.n++
+	return true
+}
+
+func (q *turnQueue) pop() (input.Dir, bool) {
+	if q.n == 0 {
+		return input.None, false
+	}
+	d := q.dirs[0]
+	copy(q.dirs[:], q.dirs[1:q.n])
+	q.n--
+	return d, true
+}
+
+// last returns the newest turn, None if there is none.
+func (q *turnQueue) last() input.Dir {
+	if q.n == 0 {
+		return input.None
+	}
+	return q.dirs[q.n-1]
+}
+
+func (q *turnQueue) clear() {
+	q.n = 0
+}
+
+// turn queues a turn to d from the player, see queueTurn, the other way
+// while the controls are mirrored.
+func (g *Game) turn(d input.Dir) {
+	if g.mirrored() {
+		d = d.Opposite()
+	}
+	if queueTurn(&g.turns, g.core.Direction, g.core.Snake.Len(), d) {
+		g.latency.turned()
+	}
+}
+
+// queueTurn queues a turn to d for a snake of length segments heading dir,
+// unless it will already be heading that way or it would reverse into its
+// neck: heading right, pressing left is ignored rather than crashing, up
+// then left is a U-turn over two steps.
+func queueTurn(q *turnQueue, dir snake.Point, length int, d input.Dir) bool {
+	heading := q.last()
+	if heading == input.None {
+		heading = input.DirOf(dir.X, dir.Y)
+	}
+	if d == heading {
+		return false
+	}
+	if hx, hy := heading.Delta(); length > 1 {
+		if x, y := d.Delta(); x == -hx && y == -hy {
+			return false
+		}
+	}
+	return q.push(d)
+}
@@ -0,0 +1,316 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"fmt"
+	"image/color"
+
+	"github.com/hajimehoshi/ebiten/v2/vector"
+
+	"jhartman.pl/gamedev/pkg/input"
+	"jhartman.pl/gamedev/pkg/snake"
+)
+
+// versusPlayers is the number of snakes in the two player mode
+const versusPlayers = 2
+
+// the battle royale, see PlayRoyale: up to RoyaleRivals snakes of the
+// computer against the player, on a board of cells half the size
+const (
+	MinRoyaleRivals = 3
+	MaxRoyaleRivals = 6
+
+	royaleWidth  = 2*snake.BoardWidth + 1
+	royaleHeight = 2*snake.BoardHeight + 1
+)
+
+// playerColors tell the snakes apart, player one's first.
+var playerColors = [MaxRoyaleRivals + 1]color.RGBA{
+	{90, 200, 90, 255},
+	{90, 150, 255, 255},
+	{230, 200, 60, 255},
+	{200, 90, 220, 255},
+	{60, 210, 210, 255},
+	{240, 140, 60, 255},
+	{170, 170, 170, 255},
+}
+
+// pelletColor is for the food left by the crashed snakes of a battle royale.
+var pelletColor = color.RGBA{200, 60, 60, 255}
+
+// MatchController steers a snake of a match, e.g. a bot.Rival.
+type MatchController interface {
+	// Observe returns the direction to turn player i to before the next
+	// step, m must not be modified.
+	Observe(m *snake.Match, i int) input.Dir
+}
+
+// versus is the two player mode, see PlayVersus and PlayRival: matches of
+// snakes on the same board, player one steering with the arrows and player
+// two with the letters, or the computer. It's also the battle royale, see
+// PlayRoyale.
+type versus struct {
+	match *snake.Match
+	// players in a match, 2 but in the battle royale
+	players int
+	// royale is set for the battle royale
+	royale bool
+	// box is the size of a cell on the screen
+	box int
+
+	// turns of each player waiting for the next steps
+	turns [versusPlayers]turnQueue
+	// rival steers the players but the first, nil for a second player at
+	// the keyboard
+	rival MatchController
+	// dirs are the rival's turns for the next step
+	dirs  [MaxRoyaleRivals + 1]input.Dir
+	names [versusPlayers]string
+
+	// the scores shown in the top corners, formatted as they change: the
+	// players' but in the battle royale, where the second is the snakes left
+	scores     [versusPlayers]label
+	scoreTexts [versusPlayers]string
+	shown      [versusPlayers]int
+}
+
+// PlayVersus switches to the two player mode: two snakes race for the food
+// on the same board, player one steering with the arrows and player two with
+// the letter keys, WASD on a QWERTY keyboard. The round ends when either
+// crashes, the other winning. Levels, bots and the autosave are left out,
+// the run saved is kept for the next time.
+func (g *Game) PlayVersus() {
+	g.versus = &versus{players: versusPlayers, box: g.box, names: [...]string{"P1", "P2"}}
+	g.autosave = false
+	g.newMatch()
+}
+
+// PlayRival is the two player mode against the computer: c steers the second
+// snake and the player the first, with either set of keys.
+func (g *Game) PlayRival(c MatchController) {
+	g.versus = &versus{players: versusPlayers, box: g.box, rival: c, names: [...]string{"You", "CPU"}}
+	g.autosave = false
+	g.newMatch()
+}
+
+// PlayRoyale switches to the battle royale: the player and rivals snakes
+// steered by c, MinRoyaleRivals to MaxRoyaleRivals, on a board four times
+// as large. A crashed snake turns into pellets the others can eat, and the
+// round ends once the player's snake crashes or is the last one left.
+func (g *Game) PlayRoyale(rivals int, c MatchController) {
+	g.versus = &versus{
+		players: rivals + 1,
+		royale:  true,
+		box:     g.royaleBox(),
+		rival:   c,
+		names:   [...]string{"You", "Left"},
+	}
+	g.autosave = false
+	g.newMatch()
+}
+
+// royaleBox is the size of the cells of the battle royale, as large as they
+// can be for its board to fit on the screen: half the size of the cells of
+// the classic board, with -cell as well.
+func (g *Game) royaleBox() int {
+	return max(min((g.width+1)*g.box/(royaleWidth+1), (g.height+1)*g.box/(royaleHeight+1)), 1)
+}
+
+// newMatch starts the next round of the two player mode.
+func (g *Game) newMatch() {
+	v := g.versus
+	if v.royale {
+		v.match = snake.NewMatchSize(g.rng.Uint64(), v.players, royaleWidth, royaleHeight)
+		v.match.LastStanding = true
+	} else {
+		v.match = snake.NewMatch(g.rng.Uint64(), v.players)
+	}
+	v.match.Border = g.border
+	for i := range v.turns {
+		v.turns[i].clear()
+	}
+	v.formatScores()
+	g.stepAcc = 0
+}
+
+// formatScores formats the scores that changed, so drawing them doesn't
+// allocate.
+func (v *versus) formatScores() {
+	scores := [versusPlayers]int{v.match.Players[0].Score, v.match.Players[1].Score}
+	if v.royale {
+		scores[1] = v.match.Alive()
+	}
+	for i, n := range scores {
+		if v.scoreTexts[i] == "" || v.shown[i] != n {
+			v.scoreTexts[i] = fmt.Sprintf("%s: %d", v.names[i], n)
+			v.shown[i] = n
+		}
+	}
+}
+
+// over reports whether the round is over, in the battle royale as soon as
+// the player is out.
+func (v *versus) over() bool {
+	return v.match.Over || (v.royale && v.match.Players[0].Crashed)
+}
+
+// result tells who won the round that just ended.
+func (v *versus) result() string {
+	one, two := v.match.Players[0], v.match.Players[1]
+	if v.royale {
+		if !one.Crashed {
+			return fmt.Sprintf("Last one standing, %d points", one.Score)
+		}
+		// the ones crashing in the same step share the place
+		return fmt.Sprintf("%s of %d, %d points", ordinal(v.match.Alive()+1), v.players, one.Score)
+	}
+	switch {
+	case one.Crashed && two.Crashed:
+		return fmt.Sprintf("Draw, %d to %d", one.Score, two.Score)
+	case v.rival != nil && two.Crashed:
+		return fmt.Sprintf("You win, %d to %d", one.Score, two.Score)
+	case v.rival != nil:
+		return fmt.Sprintf("The computer wins, %d to %d", two.Score, one.Score)
+	case two.Crashed:
+		return fmt.Sprintf("Player 1 wins, %d to %d", one.Score, two.Score)
+	}
+	return fmt.Sprintf("Player 2 wins, %d to %d", two.Score, one.Score)
+}
+
+// handleVersusKeyboard queues the turns of both players: the first key
+// bound to a direction is player one's, the second player two's unless the
+// computer plays it.
+func (g *Game) handleVersusKeyboard() {
+	v := g.versus
+	for _, d := range input.Dirs {
+		i := g.keymap.justPressedIndex(steerActions[d]) - 1
+		if i < 0 || i >= versusPlayers {
+			continue
+		}
+		if v.rival != nil {
+			i = 0
+		}
+		p := v.match.Players[i]
+		queueTurn(&v.turns[i], p.Direction, p.Snake.Len(), d)
+	}
+}
+
+// updateVersus moves the snakes of the two player mode, once the game over
+// screen of the last round is dismissed.
+func (g *Game) updateVersus() error {
+	v := g.versus
+	if v.over() {
+		g.newMatch()
+	}
+
+	g.handleVersusKeyboard()
+
+	g.stepAcc += g.perTick(g.speed)
+	g.pulse += g.perTick(pulsesPerSecond)
+	g.pulse -= float32(int(g.pulse))
+	if g.stepAcc < 1 {
+		return nil
+	}
+	g.stepAcc = min(g.stepAcc-1, 1)
+
+	for i := range v.turns {
+		if d, ok := v.turns[i].pop(); ok {
+			v.match.Players[i].Turn(d.Delta())
+		}
+	}
+	if v.rival != nil {
+		// all see the match before any turns
+		for i := 1; i < v.players; i++ {
+			v.dirs[i] = input.None
+			if !v.match.Players[i].Crashed {
+				v.dirs[i] = v.rival.Observe(v.match, i)
+			}
+		}
+		for i := 1; i < v.players; i++ {
+			if d := v.dirs[i]; d != input.None {
+				v.match.Players[i].Turn(d.Delta())
+			}
+		}
+	}
+	v.match.Step()
+	v.formatScores()
+
+	if v.over() {
+		g.gameOver = true
+		g.gameOverTimer = g.ticks(gameOverDelay)
+		g.finalScore = v.result()
+	}
+	return nil
+}
+
+// drawVersus draws the snakes and the food of the two player mode, the
+// scores in the top corners.
+func (g *Game) drawVersus() {
+	v := g.versus
+	box := v.box
+	for _, p := range v.match.Pellets {
+		vector.DrawFilledRect(g.offscreen, float32(5+p.X*box+1), float32(5+p.Y*box+1), float32(box-2), float32(box-2), pelletColor, true)
+	}
+	for i, p := range v.match.Players {
+		c := playerColors[i]
+		for j, s := range p.Snake.Backward() {
+			sc := c
+			if j == 0 {
+				// the head, red once crashed
+				sc = lighten(c, g.pulse)
+				if p.Crashed {
+					sc = color.RGBA{255, 60, 60, 255}
+				}
+			}
+			vector.DrawFilledRect(g.offscreen, float32(5+s.X*box), float32(5+s.Y*box), float32(box-1), float32(box-1), sc, true)
+		}
+	}
+
+	f := v.match.Food
+	vector.DrawFilledRect(g.offscreen, float32(5+f.X*box), float32(5+f.Y*box), float32(box-1), float32(box-1), foodColors[snake.NormalFood], true)
+
+	for i := range v.scores {
+		l := &v.scores[i]
+		l.set(v.scoreTexts[i], g.hudFace)
+		x := 5 + 10.0
+		if i == 1 {
+			x = g.screenWidth - 5 - l.w
+		}
+		if !v.royale || i == 0 {
+			vector.DrawFilledRect(g.offscreen, float32(x-10), 7, 7, 7, playerColors[i], true)
+		}
+		l.drawAt(g.offscreen, x, 3)
+	}
+}
+
+// ordinal spells out place n, 1st to 7th.
+func ordinal(n int) string {
+	switch n {
+	case 1:
+		return "1st"
+	case 2:
+		return "2nd"
+	case 3:
+		return "3rd"
+	}
+	return fmt.Sprintf("%dth", n)
+}
+
+// lighten blends c towards white by t, 0 to 1.
+func lighten(c color.RGBA, t float32) color.RGBA {
+	mix := func(v uint8) uint8 {
+		return v + uint8(float32(255-v)*t)
+	}
+	return color.RGBA{mix(c.R), mix(c.G), mix(c.B), c.A}
+}
@@ -0,0 +1,60 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snakegame
+
+import (
+	"image/color"
+	"math"
+	"time"
+)
+
+const (
+	// zenBeat is how long the snake waits in front of what it ran into in
+	// the zen mode.
+	zenBeat = 500 * time.Millisecond
+	// zenCycle is how long the palette of the zen mode takes to go round
+	// the colors.
+	zenCycle = 20 * time.Second
+)
+
+// zenColor returns the color of the i-th segment in the zen mode: the body
+// is a soft gradient, its hues slowly shifting.
+func (g *Game) zenColor(i int) color.Color {
+	cycle := float64(g.ticks(zenCycle))
+	hue := math.Mod(float64(g.frame)/cycle+float64(i)*0.015, 1)
+	return hsv(hue, 0.45, 0.9)
+}
+
+// hsv converts a color from hue, saturation and value, all 0 to 1.
+func hsv(h, s, v float64) color.Color {
+	h *= 6
+	c := v * s
+	x := c * (1 - math.Abs(math.Mod(h, 2)-1))
+	var r, g, b float64
+	switch int(h) % 6 {
+	case 0:
+		r, g, b = c, x, 0
+	case 1:
+		r, g, b = x, c, 0
+	case 2:
+		r, g, b = 0, c, x
+	case 3:
+		r, g, b = 0, x, c
+	case 4:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	m := v - c
+	return color.RGBA{uint8((r + m) * 255), uint8((g + m) * 255), uint8((b + m) * 255), 255}
+}
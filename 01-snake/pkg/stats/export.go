@@ -0,0 +1,137 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package stats
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+// Export writes the statistics to dir: everything to stats.json, and the
+// lifetime totals, the history and the high score tables to CSV files. It
+// returns the names of the files written.
+func (s *Stats) Export(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+
+	var written []string
+	write := func(name string, f func(*os.File) error) error {
+		path := filepath.Join(dir, name)
+
+		out, err := os.Create(path)
+		if err != nil {
+			return err
+		}
+		if err := f(out); err != nil {
+			out.Close()
+			return err
+		}
+		if err := out.Close(); err != nil {
+			return err
+		}
+
+		written = append(written, path)
+		return nil
+	}
+
+	err := write("stats.json", func(f *os.File) error {
+		enc := json.NewEncoder(f)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	})
+	if err != nil {
+		return written, err
+	}
+
+	err = write("lifetime.csv", func(f *os.File) error {
+		l := s.Lifetime
+		return writeCSV(f,
+			[]string{"games", "score", "best", "wins", "steps", "seconds", "first", "last"},
+			[][]string{{
+				strconv.Itoa(l.Games), strconv.Itoa(l.Score), strconv.Itoa(l.Best), strconv.Itoa(l.Wins),
+				strconv.Itoa(l.Steps), formatSeconds(l.Seconds), formatTime(l.First), formatTime(l.Last),
+			}})
+	})
+	if err != nil {
+		return written, err
+	}
+
+	err = write("games.csv", func(f *os.File) error {
+		return writeCSV(f, gameHeader, gameRecords(s.History, false))
+	})
+	if err != nil {
+		return written, err
+	}
+
+	err = write("top.csv", func(f *os.File) error {
+		return writeCSV(f, append(append([]string{"rank"}, gameHeader...), "initials"), gameRecords(s.Top, true))
+	})
+	if err != nil {
+		return written, err
+	}
+
+	err = write("hardcore.csv", func(f *os.File) error {
+		return writeCSV(f, append(append([]string{"rank"}, gameHeader...), "initials"), gameRecords(s.Hardcore, true))
+	})
+	return written, err
+}
+
+var gameHeader = []string{"start", "seconds", "score", "length", "steps", "won", "time"}
+
+func gameRecords(games []Game, ranked bool) [][]string {
+	records := make([][]string, len(games))
+	for i, g := range games {
+		r := []string{
+			formatTime(g.Start), formatSeconds(g.Seconds),
+			strconv.Itoa(g.Score), strconv.Itoa(g.Length), strconv.Itoa(g.Steps),
+			strconv.FormatBool(g.Won), formatTimer(g.Time),
+		}
+		if ranked {
+			r = append(append([]string{strconv.Itoa(i + 1)}, r...), g.Initials)
+		}
+		records[i] = r
+	}
+	return records
+}
+
+func writeCSV(f *os.File, header []string, records [][]string) error {
+	w := csv.NewWriter(f)
+	w.Write(header)
+	w.WriteAll(records)
+	return w.Error()
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func formatSeconds(s float64) string {
+	return strconv.FormatFloat(s, 'f', 1, 64)
+}
+
+// formatTimer formats the time of the speedrun timer to the millisecond,
+// empty when it wasn't on.
+func formatTimer(s float64) string {
+	if s == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(s, 'f', 3, 64)
+}
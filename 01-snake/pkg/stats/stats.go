@@ -0,0 +1,185 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package stats keeps the player's statistics: lifetime totals, the history
+// of the recent games and the local high score tables. They are stored as
+// JSON next to the settings and can be exported to CSV and JSON for
+// analysis in other tools.
+package stats
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/settings"
+)
+
+const (
+	fileName = "snake-stats.json"
+	// games kept in the history, the lifetime totals include all of them
+	maxHistory = 1000
+	// entries of the high score table
+	topSize = 10
+)
+
+// Game is a finished game.
+type Game struct {
+	Start   time.Time `json:"start"`
+	Seconds float64   `json:"seconds"` // played, pauses excluded
+	Score   int       `json:"score"`
+	Length  int       `json:"length"`
+	Steps   int       `json:"steps"`
+	// Won is set for a run won filling the board or reaching the target
+	// length.
+	Won bool `json:"won,omitempty"`
+	// Time is the run timed by the speedrun timer, in seconds, Splits the
+	// time at every 10 foods eaten, both unset without it.
+	Time   float64   `json:"time,omitempty"`
+	Splits []float64 `json:"splits,omitempty"`
+	// Initials are the player's, entered for a game making the high score
+	// table.
+	Initials string `json:"initials,omitempty"`
+	// Hardcore is set for a run played in the hardcore, on a single life.
+	Hardcore bool `json:"hardcore,omitempty"`
+}
+
+// Lifetime are the totals over all games ever played.
+type Lifetime struct {
+	Games   int       `json:"games"`
+	Score   int       `json:"score"`
+	Best    int       `json:"best"`
+	Wins    int       `json:"wins"`
+	Steps   int       `json:"steps"`
+	Seconds float64   `json:"seconds"`
+	First   time.Time `json:"first"`
+	Last    time.Time `json:"last"`
+}
+
+// Stats are the player's statistics.
+type Stats struct {
+	Lifetime Lifetime `json:"lifetime"`
+	// History has the most recent games, oldest first.
+	History []Game `json:"history"`
+	// Top are the best games, best first.
+	Top []Game `json:"top"`
+	// Hardcore are the hardcore games that beat the best one before them,
+	// best first. They're not in Top.
+	Hardcore []Game `json:"hardcore,omitempty"`
+}
+
+// Path returns the location of the statistics file.
+func Path() (string, error) {
+	dir, err := settings.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, fileName), nil
+}
+
+// Load reads the statistics, which are empty if there are none yet.
+func Load() (*Stats, error) {
+	s := &Stats{}
+
+	name, err := Path()
+	if err != nil {
+		return s, err
+	}
+
+	data, err := settings.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return s, err
+	}
+
+	if err := json.Unmarshal(data, s); err != nil {
+		return &Stats{}, err
+	}
+	// edited by hand, the table may be out of order or too long
+	slices.SortStableFunc(s.Top, func(a, b Game) int { return b.Score - a.Score })
+	s.Top = s.Top[:min(len(s.Top), topSize)]
+	slices.SortStableFunc(s.Hardcore, func(a, b Game) int { return b.Score - a.Score })
+	s.Hardcore = s.Hardcore[:min(len(s.Hardcore), topSize)]
+	return s, nil
+}
+
+// Save writes the statistics atomically.
+func (s *Stats) Save() error {
+	name, err := Path()
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	return settings.WriteFile(name, data)
+}
+
+// Record counts a finished game in Lifetime and History only, leaving the
+// tables to the player's own games, e.g. for one a bot played.
+func (s *Stats) Record(g Game) {
+	l := &s.Lifetime
+	l.Games++
+	l.Score += g.Score
+	l.Best = max(l.Best, g.Score)
+	if g.Won {
+		l.Wins++
+	}
+	l.Steps += g.Steps
+	l.Seconds += g.Seconds
+	if l.First.IsZero() {
+		l.First = g.Start
+	}
+	l.Last = g.Start
+
+	s.History = append(s.History, g)
+	if len(s.History) > maxHistory {
+		s.History = slices.Delete(s.History, 0, len(s.History)-maxHistory)
+	}
+}
+
+// Add records a finished game, returning its place in Top from 0, or -1 if
+// it didn't make the table. A hardcore game goes to Hardcore instead, first
+// if it beats the best one there, else not at all.
+func (s *Stats) Add(g Game) int {
+	s.Record(g)
+
+	if g.Hardcore {
+		if len(s.Hardcore) > 0 && g.Score <= s.Hardcore[0].Score {
+			return -1
+		}
+		s.Hardcore = slices.Insert(s.Hardcore, 0, g)
+		s.Hardcore = s.Hardcore[:min(len(s.Hardcore), topSize)]
+		return 0
+	}
+
+	// ties keep the earlier game first
+	i, _ := slices.BinarySearchFunc(s.Top, g.Score, func(e Game, score int) int {
+		if e.Score >= score {
+			return -1
+		}
+		return 1
+	})
+	if i >= topSize {
+		return -1
+	}
+	s.Top = slices.Insert(s.Top, i, g)
+	s.Top = s.Top[:min(len(s.Top), topSize)]
+	return i
+}
@@ -0,0 +1,301 @@
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package twitch lets a Twitch channel's chat steer the snake ("chat plays").
+// Direction commands typed in chat are read anonymously over IRC and turned
+// into one direction per movement step, either by majority vote or by
+// queueing them in order.
+package twitch
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"jhartman.pl/gamedev/pkg/input"
+)
+
+const (
+	ircAddr = "irc.chat.twitch.tv:6667"
+
+	dialTimeout       = 10 * time.Second
+	reconnectInterval = 10 * time.Second
+
+	// the queue is bounded so a spamming chat can't run far ahead of the game
+	maxQueue = 16
+	// how many queued commands to show on screen
+	hudQueue = 8
+)
+
+// Mode tells how chat commands are turned into directions.
+type Mode int
+
+const (
+	// Vote picks the most voted direction since the last step, every
+	// chatter having a single (their latest) vote.
+	Vote Mode = iota
+	// Queue applies the commands one per step in the order they came in.
+	Queue
+)
+
+// ParseMode parses "vote" or "queue".
+func ParseMode(s string) (Mode, error) {
+	switch s {
+	case "vote":
+		return Vote, nil
+	case "queue":
+		return Queue, nil
+	}
+	return Vote, fmt.Errorf("twitch: unknown mode %q, expected vote or queue", s)
+}
+
+type vote struct {
+	user string
+	dir  input.Dir
+}
+
+// Plays is an input.Controller fed by a Twitch channel's chat.
+type Plays struct {
+	channel string
+	mode    Mode
+
+	mu        sync.Mutex
+	connected bool
+	votes     []vote // in the order they were cast
+	queue     []input.Dir
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// New joins the chat of channel and starts collecting commands. Connection
+// problems are retried in the background.
+func New(channel string, mode Mode) *Plays {
+	p := &Plays{
+		channel: strings.ToLower(strings.TrimPrefix(channel, "#")),
+		mode:    mode,
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// Close leaves the chat.
+func (p *Plays) Close() {
+	p.closeOnce.Do(func() {
+		close(p.stop)
+	})
+	<-p.done
+}
+
+// Next implements input.Controller.
+func (p *Plays) Next() input.Dir {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.mode == Queue {
+		if len(p.queue) == 0 {
+			return input.None
+		}
+		d := p.queue[0]
+		p.queue = p.queue[1:]
+		return d
+	}
+
+	counts, first := p.tally()
+	p.votes = p.votes[:0]
+
+	// ties go to the direction that was voted for first
+	best := input.None
+	for _, d := range input.Dirs {
+		if counts[d] == 0 {
+			continue
+		}
+		if best == input.None || counts[d] > counts[best] || (counts[d] == counts[best] && first[d] < first[best]) {
+			best = d
+		}
+	}
+	return best
+}
+
+// tally counts the votes per direction, also returning the index of the
+// first vote for each of them. Must be called with mu held.
+func (p *Plays) tally() (counts, first [5]int) {
+	for i, v := range p.votes {
+		if counts[v.dir] == 0 {
+			first[v.dir] = i
+		}
+		counts[v.dir]++
+	}
+	return counts, first
+}
+
+// HUD describes the running vote (or the queue) to be shown on screen.
+func (p *Plays) HUD() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.connected {
+		return "chat: connecting..."
+	}
+
+	var b strings.Builder
+	if p.mode == Queue {
+		b.WriteString("chat: ")
+		for i, d := range p.queue {
+			if i == hudQueue {
+				b.WriteString("…")
+				break
+			}
+			b.WriteString(d.Arrow())
+		}
+		return b.String()
+	}
+
+	counts, _ := p.tally()
+	for i, d := range input.Dirs {
+		if i > 0 {
+			b.WriteString(" ")
+		}
+		fmt.Fprintf(&b, "%s%d", d.Arrow(), counts[d])
+	}
+	return b.String()
+}
+
+// command handles a chat message, ignoring anything that doesn't start with
+// a direction.
+func (p *Plays) command(user, msg string) {
+	fields := strings.Fields(msg)
+	if len(fields) == 0 {
+		return
+	}
+	d, ok := input.ParseDir(fields[0])
+	if !ok {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.mode == Queue {
+		if len(p.queue) < maxQueue {
+			p.queue = append(p.queue, d)
+		}
+		return
+	}
+
+	for i, v := range p.votes {
+		if v.user == user {
+			p.votes = append(p.votes[:i], p.votes[i+1:]...)
+			break
+		}
+	}
+	p.votes = append(p.votes, vote{user, d})
+}
+
+func (p *Plays) setConnected(connected bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.connected = connected
+}
+
+func (p *Plays) run() {
+	defer close(p.done)
+
+	for {
+		p.session()
+		p.setConnected(false)
+
+		t := time.NewTimer(reconnectInterval)
+		select {
+		case <-t.C:
+		case <-p.stop:
+			t.Stop()
+			return
+		}
+	}
+}
+
+// session reads the chat until the connection drops or Plays is closed.
+func (p *Plays) session() error {
+	d := net.Dialer{Timeout: dialTimeout}
+	conn, err := d.Dial("tcp", ircAddr)
+	if err != nil {
+		return err
+	}
+
+	// closing the connection unblocks the reader below
+	finished := make(chan struct{})
+	defer close(finished)
+	go func() {
+		select {
+		case <-p.stop:
+		case <-finished:
+		}
+		conn.Close()
+	}()
+
+	// justinfan<number> is Twitch's anonymous, read-only login
+	_, err = fmt.Fprintf(conn, "NICK justinfan%d\r\nJOIN #%s\r\n", 10000+rand.IntN(90000), p.channel)
+	if err != nil {
+		return err
+	}
+
+	s := bufio.NewScanner(conn)
+	for s.Scan() {
+		line := s.Text()
+
+		if rest, ok := strings.CutPrefix(line, "PING"); ok {
+			if _, err := fmt.Fprintf(conn, "PONG%s\r\n", rest); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if isJoined(line) {
+			p.setConnected(true)
+		}
+		if user, msg, ok := parsePrivmsg(line); ok {
+			p.command(user, msg)
+		}
+	}
+
+	return s.Err()
+}
+
+// isJoined reports whether line is the end of the channel's names list,
+// which the server sends once the channel has been joined.
+func isJoined(line string) bool {
+	_, command, ok := strings.Cut(line, " ")
+	return ok && strings.HasPrefix(command, "366 ")
+}
+
+// parsePrivmsg extracts the sender and text of a chat message line such as
+//
+//	:nick!nick@nick.tmi.twitch.tv PRIVMSG #channel :message
+func parsePrivmsg(line string) (user, msg string, ok bool) {
+	prefix, rest, ok := strings.Cut(line, " PRIVMSG ")
+	if !ok || !strings.HasPrefix(prefix, ":") {
+		return "", "", false
+	}
+
+	user, _, _ = strings.Cut(prefix[1:], "!")
+	_, msg, ok = strings.Cut(rest, " :")
+	return user, msg, ok
+}